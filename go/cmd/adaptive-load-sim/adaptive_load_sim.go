@@ -3,11 +3,11 @@ package main
 import (
 	"context"
 	"fmt"
+	utilconfig "github.com/blackducksoftware/cerebros/go/pkg/util/config"
 	resty2 "github.com/go-resty/resty/v2"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
 	"golang.org/x/time/rate"
 	"math/rand"
 	"net/http"
@@ -41,22 +41,11 @@ func (config *Config) GetLogLevel() (log.Level, error) {
 
 // GetConfig ...
 func GetConfig(configPath string) (*Config, error) {
-	var config *Config
-
-	// avoid viper serialization problems caused by '.'s in keys
-	v := viper.NewWithOptions(viper.KeyDelimiter("*"))
-
-	v.SetConfigFile(configPath)
-	err := v.ReadInConfig()
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to ReadInConfig")
-	}
-
-	err = v.Unmarshal(&config)
+	config := &Config{}
+	err := utilconfig.Load(configPath, config, &utilconfig.Options{EnvPrefix: "ADAPTIVE_LOAD_SIM"})
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to unmarshal config")
+		return nil, err
 	}
-
 	return config, nil
 }
 
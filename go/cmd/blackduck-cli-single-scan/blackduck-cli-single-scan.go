@@ -21,10 +21,8 @@ under the License.
 package main
 
 import (
-	"encoding/json"
 	s "github.com/blackducksoftware/cerebros/go/pkg/synopsys-scancli"
-	"github.com/pkg/errors"
-	"github.com/spf13/viper"
+	utilconfig "github.com/blackducksoftware/cerebros/go/pkg/util/config"
 	"os"
 
 	log "github.com/sirupsen/logrus"
@@ -33,23 +31,16 @@ import (
 type Config struct {
 	Blackduck   *s.BlackduckConfig
 	ImageFacade *s.ImageFacadeConfig
+	Workspace   *s.WorkspaceConfig
 	Scan        *s.ScanConfig
 }
 
 func GetConfig(configPath string) (*Config, error) {
-	var config *Config
-
-	viper.SetConfigFile(configPath)
-	err := viper.ReadInConfig()
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to ReadInConfig")
-	}
-
-	err = viper.Unmarshal(&config)
+	config := &Config{}
+	err := utilconfig.Load(configPath, config, &utilconfig.Options{EnvPrefix: "BLACKDUCK_CLI_SINGLE_SCAN"})
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to unmarshal config")
+		return nil, err
 	}
-
 	return config, nil
 }
 
@@ -64,13 +55,14 @@ func main() {
 	config, err := GetConfig(configPath)
 	doOrDie(err)
 
-	configBytes, err := json.MarshalIndent(config, "", "  ")
-	doOrDie(err)
-	log.Infof("got config: \n%s\n", string(configBytes))
+	log.Infof("got config: \n%s\n", utilconfig.Redact(config))
 
-	scanner, err := s.NewScannerFromConfig(config.Blackduck, nil, config.ImageFacade)
+	scanner, err := s.NewScannerFromConfig(config.Blackduck, nil, config.ImageFacade, config.Workspace)
 	doOrDie(err)
 
 	err = scanner.Scan(config.Scan)
+	if closeErr := scanner.Close(); closeErr != nil {
+		log.Errorf("unable to clean up workspaces: %s", closeErr.Error())
+	}
 	doOrDie(err)
 }
@@ -5,9 +5,9 @@ import (
 	"fmt"
 	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
 	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api-load/stress_testing"
+	utilconfig "github.com/blackducksoftware/cerebros/go/pkg/util/config"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
 	"os"
 	"time"
 )
@@ -28,10 +28,18 @@ type Config struct {
 	ServiceAccountName     string
 	ServiceAccountPassword string
 
+	// Organization is the name or id of the organization to work in; it can be
+	// left out if there's only one
+	Organization string
 	ProjectLimit int
 	RoleName     string
 
 	Verify bool
+	// Cleanup deletes the service account and its role assignments instead of creating them
+	Cleanup bool
+
+	// RateLimit defaults to api.DefaultRateLimitConfig
+	RateLimit *api.RateLimitConfig
 }
 
 // GetLogLevel ...
@@ -41,19 +49,11 @@ func (config *Config) GetLogLevel() (log.Level, error) {
 
 // GetConfig ...
 func GetConfig(configPath string) (*Config, error) {
-	var config *Config
-
-	viper.SetConfigFile(configPath)
-	err := viper.ReadInConfig()
+	config := &Config{}
+	err := utilconfig.Load(configPath, config, &utilconfig.Options{EnvPrefix: "CREATE_ROLE_ASSIGNMENTS"})
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to ReadInConfig at %s", configPath)
+		return nil, err
 	}
-
-	err = viper.Unmarshal(&config)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to unmarshal config at %s", configPath)
-	}
-
 	return config, nil
 }
 
@@ -75,6 +75,11 @@ func main() {
 	roleName := config.RoleName
 
 	client := api.NewClient(url, email, password)
+	client.UseDefaultResilience()
+	client.RateLimit = config.RateLimit
+	if client.RateLimit == nil {
+		client.RateLimit = api.DefaultRateLimitConfig()
+	}
 	doOrDie(client.Authenticate())
 
 	if config.Verify {
@@ -84,12 +89,16 @@ func main() {
 		return
 	}
 
+	if config.Cleanup {
+		doOrDie(cleanup(client, serviceAccountEmail))
+		return
+	}
+
 	// 1. prelim: get an orgId
-	log.Infof("getting organizations")
-	orgs, err := client.GetOrganizations()
+	log.Infof("selecting organization")
+	org, err := client.SelectOrganization(config.Organization)
 	doOrDie(err)
-	orgId := orgs.Data[0].Id
-	log.Infof("found orgid %s from orgs meta %+v", orgId, orgs.Meta)
+	orgId := org.Id
 
 	// 2. prelim: get the 'Project Administrator' roleId
 	roles, err := client.GetRoles()
@@ -158,3 +167,41 @@ func main() {
 		}
 	}
 }
+
+func cleanup(client *api.Client, serviceAccountEmail string) error {
+	users, err := client.GetUserByEmail(serviceAccountEmail)
+	if err != nil {
+		return err
+	}
+	if len(users.Data) == 0 {
+		log.Infof("service account %s not found, nothing to clean up", serviceAccountEmail)
+		return nil
+	}
+
+	// deleting shifts the remaining assignments down, so always read the first page
+	deleted := 0
+	for {
+		ras, err := client.GetRoleAssignmentsForUser(serviceAccountEmail, 0, 100, true)
+		if err != nil {
+			return err
+		}
+		if len(ras.Data) == 0 {
+			break
+		}
+		for _, ra := range ras.Data {
+			if err := client.DeleteRoleAssignment(ra.Id); err != nil {
+				return errors.WithMessagef(err, "unable to delete role assignment %s after deleting %d", ra.Id, deleted)
+			}
+			deleted++
+		}
+		log.Infof("deleted %d role assignments for %s", deleted, serviceAccountEmail)
+	}
+
+	for _, user := range users.Data {
+		if err := client.DeleteUser(user.Id); err != nil {
+			return err
+		}
+		log.Infof("deleted service account %s with id %s", serviceAccountEmail, user.Id)
+	}
+	return nil
+}
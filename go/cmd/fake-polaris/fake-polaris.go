@@ -0,0 +1,98 @@
+/*
+Copyright (C) 2018 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api/fake"
+	utilconfig "github.com/blackducksoftware/cerebros/go/pkg/util/config"
+	log "github.com/sirupsen/logrus"
+)
+
+type Config struct {
+	LogLevel string
+	Port     int
+
+	Email    string
+	Password string
+
+	Projects         int
+	IssuesPerProject int
+
+	LatencyMilliseconds int
+	ErrorRate           float64
+}
+
+// GetLogLevel ...
+func (config *Config) GetLogLevel() (log.Level, error) {
+	return log.ParseLevel(config.LogLevel)
+}
+
+// GetConfig ...
+func GetConfig(configPath string) (*Config, error) {
+	config := &Config{}
+	err := utilconfig.Load(configPath, config, &utilconfig.Options{EnvPrefix: "FAKE_POLARIS"})
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func doOrDie(err error) {
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+}
+
+func main() {
+	config, err := GetConfig(os.Args[1])
+	doOrDie(err)
+
+	logLevel, err := config.GetLogLevel()
+	doOrDie(err)
+	log.SetLevel(logLevel)
+
+	fakeConfig := fake.DefaultConfig()
+	fakeConfig.Email = config.Email
+	fakeConfig.Password = config.Password
+	fakeConfig.Latency = time.Duration(config.LatencyMilliseconds) * time.Millisecond
+	fakeConfig.ErrorRate = config.ErrorRate
+
+	server := fake.NewUnstartedServer(fakeConfig)
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", config.Port))
+	doOrDie(err)
+	server.Listener.Close()
+	server.Listener = listener
+	server.Seed(config.Projects, config.IssuesPerProject)
+	server.Start()
+	log.Infof("fake polaris for %s serving at %s with %d projects", config.Email, server.URL, config.Projects)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	<-stop
+	server.Close()
+}
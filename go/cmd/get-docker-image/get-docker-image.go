@@ -22,9 +22,8 @@ package main
 
 import (
 	"github.com/blackducksoftware/cerebros/go/pkg/blackduck/docker"
-	"github.com/pkg/errors"
+	utilconfig "github.com/blackducksoftware/cerebros/go/pkg/util/config"
 	log "github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
 	"os"
 	"time"
 )
@@ -74,18 +73,10 @@ type Config struct {
 
 // GetConfig ...
 func GetConfig(configPath string) (*Config, error) {
-	var config *Config
-
-	viper.SetConfigFile(configPath)
-	err := viper.ReadInConfig()
+	config := &Config{}
+	err := utilconfig.Load(configPath, config, &utilconfig.Options{EnvPrefix: "GET_DOCKER_IMAGE"})
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to ReadInConfig at %s", configPath)
+		return nil, err
 	}
-
-	err = viper.Unmarshal(&config)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to unmarshal config at %s", configPath)
-	}
-
 	return config, nil
 }
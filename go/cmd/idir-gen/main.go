@@ -21,8 +21,10 @@ under the License.
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/blackducksoftware/cerebros/go/pkg/blobstore"
 	"github.com/blackducksoftware/cerebros/go/pkg/polaris"
 	"github.com/blackducksoftware/cerebros/go/pkg/util"
 	"github.com/streadway/amqp"
@@ -36,6 +38,7 @@ import (
 
 const queueName = "idir-gen"
 
+// IdirGenJob buckets are blob store urls such as gs://bucket, s3://bucket or file:///dir; bare names mean GCS buckets
 type IdirGenJob struct {
 	FromBucket     string `json:"fromBucket"`
 	FromBucketPath string `json:"fromBucketPath"`
@@ -50,6 +53,11 @@ func main() {
 	polarisURL := getEnv("POLARIS_URL", "https://onprem-dev.dev.polaris.synopsys.com")
 	polarisToken := getEnv("POLARIS_TOKEN", "")
 
+	// only used by gs:// buckets; see blobstore.NewBlobStore for other backends' configuration
+	if err := os.Setenv("GOOGLE_APPLICATION_CREDENTIALS", serviceAccountPath); err != nil {
+		panic(err)
+	}
+
 	env := os.Getenv("CA_PATH")
 	if len(env) > 0 {
 		input, err := ioutil.ReadFile(env)
@@ -120,7 +128,7 @@ func main() {
 			continue
 		}
 		fmt.Printf("Starting IdirGenJob %s/%s\n", job.FromBucket, job.FromBucketPath)
-		if err := process(job, serviceAccountPath, jb); err != nil {
+		if err := process(job, jb); err != nil {
 			log.Print(err)
 			d.Ack(false)
 			continue
@@ -130,9 +138,11 @@ func main() {
 	}
 }
 
-func process(job IdirGenJob, serviceAccountPath string, jb *polaris.Scanner) error {
+func process(job IdirGenJob, jb *polaris.Scanner) error {
+	ctx := context.Background()
+
 	// Download and extract
-	tmpDir, err := downloadAndExtractToTmpDir(job, serviceAccountPath)
+	tmpDir, err := downloadAndExtractToTmpDir(ctx, job)
 	if err != nil {
 		return err
 	}
@@ -150,7 +160,12 @@ func process(job IdirGenJob, serviceAccountPath string, jb *polaris.Scanner) err
 	}
 
 	//Upload
-	if err := util.CopyToGSBucket(job.ToBucket, serviceAccountPath, job.ToBucketPath, pathToIdirZip); err != nil {
+	store, err := blobstore.NewBlobStore(ctx, blobstore.BucketURL(job.ToBucket))
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	if err := blobstore.UploadFile(ctx, store, job.ToBucketPath, pathToIdirZip); err != nil {
 		return err
 	}
 
@@ -170,23 +185,29 @@ func getEnv(name string, defaultValue string) string {
 	return defaultValue
 }
 
-func downloadAndExtractToTmpDir(job IdirGenJob, svcPath string) (string, error) {
+func downloadAndExtractToTmpDir(ctx context.Context, job IdirGenJob) (string, error) {
 	tmpDir, err := ioutil.TempDir("/tmp", "scan")
 	if err != nil {
 		return "", err
 	}
 
-	fmt.Printf("Downloading from GS Bucket\n")
+	fmt.Printf("Downloading from bucket %s\n", job.FromBucket)
 
-	// Download the zip archive
-	tmpFile := path.Join(tmpDir, "master.zip")
-	if err := util.CopyFromGSBucket(job.FromBucket, svcPath, job.FromBucketPath, tmpFile); err != nil {
+	// Download the archive: zip, tar, tar.gz or tar.zst
+	tmpFile := path.Join(tmpDir, "master.archive")
+	store, err := blobstore.NewBlobStore(ctx, blobstore.BucketURL(job.FromBucket))
+	if err != nil {
+		return "", err
+	}
+	defer store.Close()
+	if err := blobstore.DownloadFile(ctx, store, job.FromBucketPath, tmpFile); err != nil {
 		return "", err
 	}
 
-	// Unzip then remove
-	if _, err := util.Unzip(tmpFile, tmpDir); err != nil {
+	// Extract then remove
+	if _, err := util.ExtractArchive(tmpFile, tmpDir, nil); err != nil {
 		return "", err
 	}
+	os.Remove(tmpFile)
 	return tmpDir, nil
 }
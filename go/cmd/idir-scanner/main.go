@@ -21,7 +21,9 @@ under the License.
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"github.com/blackducksoftware/cerebros/go/pkg/blobstore"
 	"github.com/blackducksoftware/cerebros/go/pkg/polaris"
 	"github.com/blackducksoftware/cerebros/go/pkg/util"
 	log "github.com/sirupsen/logrus"
@@ -33,6 +35,7 @@ import (
 )
 
 type IdirScanJob struct {
+	// FromBucket is a blob store url such as gs://bucket, s3://bucket or file:///dir; a bare name means a GCS bucket
 	FromBucket     string `json:"fromBucket"`
 	FromBucketPath string `json:"fromBucketPath"`
 }
@@ -45,6 +48,11 @@ func main() {
 	polarisURL := getEnv("POLARIS_URL", "https://onprem-dev.dev.polaris.synopsys.com")
 	polarisToken := getEnv("POLARIS_TOKEN", "")
 
+	// only used by gs:// buckets; see blobstore.NewBlobStore for other backends' configuration
+	if err := os.Setenv("GOOGLE_APPLICATION_CREDENTIALS", serviceAccountPath); err != nil {
+		panic(err)
+	}
+
 	env := os.Getenv("CA_PATH")
 	if len(env) > 0 {
 		input, err := ioutil.ReadFile(env)
@@ -114,7 +122,7 @@ func main() {
 			continue
 		}
 		log.Infof("Starting IdirScanJob  %s/%s", job.FromBucket, job.FromBucketPath)
-		if err := process(job, serviceAccountPath, jb); err != nil {
+		if err := process(job, jb); err != nil {
 			log.Errorf("unable to process job: %s", err)
 			d.Ack(false)
 			continue
@@ -125,7 +133,7 @@ func main() {
 	}
 }
 
-func process(job IdirScanJob, serviceAccountPath string, jb *polaris.Scanner) error {
+func process(job IdirScanJob, jb *polaris.Scanner) error {
 	tmpDir, err := ioutil.TempDir("/tmp", "scan")
 	if err != nil {
 		return err
@@ -134,14 +142,20 @@ func process(job IdirScanJob, serviceAccountPath string, jb *polaris.Scanner) er
 
 	idirPath := path.Join(tmpDir, "idir")
 
-	// Download the zip archive
-	tmpFile := path.Join(tmpDir, "idir.zip")
-	if err := util.CopyFromGSBucket(job.FromBucket, serviceAccountPath, job.FromBucketPath, tmpFile); err != nil {
+	// Download the archive: zip, tar, tar.gz or tar.zst
+	tmpFile := path.Join(tmpDir, "idir.archive")
+	ctx := context.Background()
+	store, err := blobstore.NewBlobStore(ctx, blobstore.BucketURL(job.FromBucket))
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	if err := blobstore.DownloadFile(ctx, store, job.FromBucketPath, tmpFile); err != nil {
 		return err
 	}
 
-	// Unzip then remove
-	if _, err := util.Unzip(tmpFile, tmpDir); err != nil {
+	// Extract then remove
+	if _, err := util.ExtractArchive(tmpFile, tmpDir, nil); err != nil {
 		return err
 	}
 	os.Remove(tmpFile)
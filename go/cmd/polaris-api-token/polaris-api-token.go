@@ -22,9 +22,8 @@ package main
 
 import (
 	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
-	"github.com/pkg/errors"
+	utilconfig "github.com/blackducksoftware/cerebros/go/pkg/util/config"
 	log "github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
 	"os"
 )
 
@@ -34,6 +33,8 @@ type Config struct {
 	URL       string
 	TokenName string
 	LogLevel  string
+	// RateLimit defaults to api.DefaultRateLimitConfig
+	RateLimit *api.RateLimitConfig
 }
 
 // GetLogLevel ...
@@ -43,19 +44,11 @@ func (config *Config) GetLogLevel() (log.Level, error) {
 
 // GetConfig ...
 func GetConfig(configPath string) (*Config, error) {
-	var config *Config
-
-	viper.SetConfigFile(configPath)
-	err := viper.ReadInConfig()
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to ReadInConfig at %s", configPath)
-	}
-
-	err = viper.Unmarshal(&config)
+	config := &Config{}
+	err := utilconfig.Load(configPath, config, &utilconfig.Options{EnvPrefix: "POLARIS_API_TOKEN"})
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to unmarshal config at %s", configPath)
+		return nil, err
 	}
-
 	return config, nil
 }
 
@@ -73,6 +66,11 @@ func main() {
 	log.SetLevel(logLevel)
 
 	pc := api.NewClient(config.URL, config.Email, config.Password)
+	pc.UseDefaultResilience()
+	pc.RateLimit = config.RateLimit
+	if pc.RateLimit == nil {
+		pc.RateLimit = api.DefaultRateLimitConfig()
+	}
 
 	err = pc.Authenticate()
 	if err != nil {
@@ -22,9 +22,8 @@ package main
 
 import (
 	synopsys_scancli "github.com/blackducksoftware/cerebros/go/pkg/synopsys-scancli"
-	"github.com/pkg/errors"
+	utilconfig "github.com/blackducksoftware/cerebros/go/pkg/util/config"
 	log "github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
 	"os"
 )
 
@@ -35,19 +34,11 @@ type Config struct {
 
 // GetConfig ...
 func GetConfig(configPath string) (*Config, error) {
-	var config *Config
-
-	viper.SetConfigFile(configPath)
-	err := viper.ReadInConfig()
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to ReadInConfig at %s", configPath)
-	}
-
-	err = viper.Unmarshal(&config)
+	config := &Config{}
+	err := utilconfig.Load(configPath, config, &utilconfig.Options{EnvPrefix: "POLARIS_CLI_SINGLE_SCAN"})
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to unmarshal config at %s", configPath)
+		return nil, err
 	}
-
 	return config, nil
 }
 
@@ -62,8 +53,11 @@ func main() {
 
 	config, err := GetConfig(os.Args[1])
 	doOrDie(err)
-	scanner, err := synopsys_scancli.NewScannerFromConfig(nil, config.Polaris, nil)
+	scanner, err := synopsys_scancli.NewScannerFromConfig(nil, config.Polaris, nil, nil)
 	doOrDie(err)
 	err = scanner.Scan(config.Scan)
+	if closeErr := scanner.Close(); closeErr != nil {
+		log.Errorf("unable to clean up workspaces: %s", closeErr.Error())
+	}
 	doOrDie(err)
 }
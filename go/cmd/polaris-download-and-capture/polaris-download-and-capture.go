@@ -24,9 +24,8 @@ import (
 	"fmt"
 	"github.com/blackducksoftware/cerebros/go/pkg/polaris"
 	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
-	"github.com/pkg/errors"
+	utilconfig "github.com/blackducksoftware/cerebros/go/pkg/util/config"
 	log "github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
 	"os"
 )
 
@@ -34,6 +33,8 @@ type Config struct {
 	PolarisEmail    string
 	PolarisURL      string
 	PolarisPassword string
+	// PolarisAccessToken, if set, replaces PolarisEmail and PolarisPassword and is handed to the CLI
+	PolarisAccessToken string `config:"secret"`
 
 	LogLevel string
 
@@ -50,19 +51,11 @@ func (config *Config) GetLogLevel() (log.Level, error) {
 
 // GetConfig ...
 func GetConfig(configPath string) (*Config, error) {
-	var config *Config
-
-	viper.SetConfigFile(configPath)
-	err := viper.ReadInConfig()
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to ReadInConfig at %s", configPath)
-	}
-
-	err = viper.Unmarshal(&config)
+	config := &Config{}
+	err := utilconfig.Load(configPath, config, &utilconfig.Options{EnvPrefix: "POLARIS_DOWNLOAD_AND_CAPTURE"})
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to unmarshal config at %s", configPath)
+		return nil, err
 	}
-
 	return config, nil
 }
 
@@ -79,7 +72,8 @@ func main() {
 	}
 	log.SetLevel(logLevel)
 
-	polarisClient := api.NewClient(config.PolarisURL, config.PolarisEmail, config.PolarisPassword)
+	polarisClient := api.NewClientFromCredentials(config.PolarisURL, config.PolarisEmail, config.PolarisPassword, config.PolarisAccessToken)
+	polarisClient.UseDefaultResilience()
 
 	err = polarisClient.Authenticate()
 	if err != nil {
@@ -105,13 +99,17 @@ func main() {
 	log.Infof("successfully downloaded polaris-cli to %s", unzipPath)
 
 	cliPath := fmt.Sprintf("%s/bin", unzipPath)
-	authResp, err := polarisClient.GetAccessToken(config.TokenName)
-	if err != nil {
-		panic(err)
+	accessToken := config.PolarisAccessToken
+	if accessToken == "" {
+		authResp, err := polarisClient.GetAccessToken(config.TokenName)
+		if err != nil {
+			panic(err)
+		}
+		accessToken = authResp.Data.Attributes.AccessToken
+		log.Infof("successfully got access token %s", accessToken)
 	}
-	log.Infof("successfully got access token %s", authResp.Data.Attributes.AccessToken)
 
-	scanner, err := polaris.NewScanner(cliPath, config.PolarisURL, authResp.Data.Attributes.AccessToken, "")
+	scanner, err := polaris.NewScanner(cliPath, config.PolarisURL, accessToken, "")
 	if err != nil {
 		panic(err)
 	}
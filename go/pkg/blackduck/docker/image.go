@@ -62,3 +62,8 @@ func (i *image) getURL() string {
 func (i *image) inspectURL() string {
 	return fmt.Sprintf("http://localhost/v1.24/images/%s/json", i.urlEncodedName())
 }
+
+// TarFilePath is where PullImageTo saves pullSpec's tarball in directory.
+func TarFilePath(directory string, pullSpec string) string {
+	return newImage(pullSpec).tarFilePath(directory)
+}
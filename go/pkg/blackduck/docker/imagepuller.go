@@ -65,6 +65,11 @@ func NewImagePuller(directory string, registries []RegistryAuth) *ImagePuller {
 // It does this by accessing the host's docker daemon, locally, over the docker
 // socket.  This gives us a window into any images that are local.
 func (ip *ImagePuller) PullImage(pullSpec string) (*PullResult, error) {
+	return ip.PullImageTo(pullSpec, ip.directory)
+}
+
+// PullImageTo is PullImage, but saves the tarball in directory instead of the puller's directory.
+func (ip *ImagePuller) PullImageTo(pullSpec string, directory string) (*PullResult, error) {
 	start := time.Now()
 
 	img := image{PullSpec: pullSpec}
@@ -74,16 +79,16 @@ func (ip *ImagePuller) PullImage(pullSpec string) (*PullResult, error) {
 	}
 	log.Infof("Processing image: %s", img.PullSpec)
 
-	err = ip.SaveImageToTar(img)
+	err = ip.SaveImageToTar(img, directory)
 	if err != nil {
 		return nil, errors.WithMessagef(err, "unable to save image %s to tar file", img.PullSpec)
 	}
 
 	recordDockerTotalDuration(time.Now().Sub(start))
 
-	log.Infof("Ready to scan image %s at path %s", img.PullSpec, img.tarFilePath(ip.directory))
+	log.Infof("Ready to scan image %s at path %s", img.PullSpec, img.tarFilePath(directory))
 
-	return ip.inspectImage(img)
+	return ip.inspectImage(img, directory)
 }
 
 // CreateImageInLocalDocker could also be implemented using curl:
@@ -158,7 +163,7 @@ type DockerInspectResponse struct {
 	// TODO lots of other stuff
 }
 
-func (ip *ImagePuller) inspectImage(img image) (*PullResult, error) {
+func (ip *ImagePuller) inspectImage(img image, directory string) (*PullResult, error) {
 	//start := time.Now()
 	imageURL := img.inspectURL()
 	log.Infof("Attempting to inspect %s ......", imageURL)
@@ -229,7 +234,7 @@ func (ip *ImagePuller) inspectImage(img image) (*PullResult, error) {
 	}
 
 	pr := &PullResult{
-		Path: img.tarFilePath(ip.directory),
+		Path: img.tarFilePath(directory),
 		Repo: repo,
 		Tag:  tag,
 		Sha:  digest,
@@ -239,7 +244,7 @@ func (ip *ImagePuller) inspectImage(img image) (*PullResult, error) {
 
 // SaveImageToTar -- part of what it does is to issue an http request similar to the following:
 //   curl --unix-socket /var/run/docker.sock -X GET http://localhost/images/openshift%2Forigin-docker-registry%3Av3.6.1/get
-func (ip *ImagePuller) SaveImageToTar(img image) error {
+func (ip *ImagePuller) SaveImageToTar(img image, directory string) error {
 	start := time.Now()
 	url := img.getURL()
 	log.Infof("Making docker GET image request: %s", url)
@@ -259,7 +264,7 @@ func (ip *ImagePuller) SaveImageToTar(img image) error {
 	defer func() {
 		body.Close()
 	}()
-	tarFilePath := img.tarFilePath(ip.directory)
+	tarFilePath := img.tarFilePath(directory)
 	log.Infof("Starting to write file contents to tar file %s", tarFilePath)
 
 	f, err := os.OpenFile(tarFilePath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0777)
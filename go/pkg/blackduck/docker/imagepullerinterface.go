@@ -30,4 +30,5 @@ type PullResult struct {
 
 type ImagePullerInterface interface {
 	PullImage(pullSpec string) (*PullResult, error)
+	PullImageTo(pullSpec string, directory string) (*PullResult, error)
 }
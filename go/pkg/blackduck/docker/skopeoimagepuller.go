@@ -49,18 +49,23 @@ func NewSkopeoImagePuller(directory string, registries []RegistryAuth) *SkopeoIm
 }
 
 func (ip *SkopeoImagePuller) PullImage(pullSpec string) (*PullResult, error) {
+	return ip.PullImageTo(pullSpec, ip.directory)
+}
+
+// PullImageTo is PullImage, but saves the tarball in directory instead of the puller's directory.
+func (ip *SkopeoImagePuller) PullImageTo(pullSpec string, directory string) (*PullResult, error) {
 	start := time.Now()
 	img := image{PullSpec: pullSpec}
-	log.Infof("Processing image: %s in %s", img.PullSpec, img.tarFilePath(ip.directory))
+	log.Infof("Processing image: %s in %s", img.PullSpec, img.tarFilePath(directory))
 
-	err := ip.SaveImageToTar(img)
+	err := ip.SaveImageToTar(img, directory)
 	if err != nil {
 		return nil, errors.WithMessagef(err, "unable to save image %s to tar file", img.PullSpec)
 	}
 
 	recordDockerTotalDuration(time.Now().Sub(start))
 
-	log.Infof("Ready to scan image %s at path %s", img.PullSpec, img.tarFilePath(ip.directory))
+	log.Infof("Ready to scan image %s at path %s", img.PullSpec, img.tarFilePath(directory))
 	inspect, err := ip.inspect(img.PullSpec)
 	if err != nil {
 		return nil, err
@@ -71,7 +76,7 @@ func (ip *SkopeoImagePuller) PullImage(pullSpec string) (*PullResult, error) {
 		tag = inspect.RepoTags[0]
 	}
 	pr := &PullResult{
-		Path: img.tarFilePath(ip.directory),
+		Path: img.tarFilePath(directory),
 		Repo: inspect.Name,
 		Tag:  tag,
 		Sha:  inspect.Digest, // TODO probably have to peel off the sha256: prefix ?
@@ -151,7 +156,7 @@ func (ip *SkopeoImagePuller) inspect(pullSpec string) (*SkopeoInspectResponse, e
 //	return err
 //}
 
-func (ip *SkopeoImagePuller) SaveImageToTar(img image) error {
+func (ip *SkopeoImagePuller) SaveImageToTar(img image, directory string) error {
 	start := time.Now()
 	dockerPullSpec := img.PullSpec
 	log.Infof("Attempting to create %s ......", dockerPullSpec)
@@ -162,7 +167,7 @@ func (ip *SkopeoImagePuller) SaveImageToTar(img image) error {
 		headerValue = fmt.Sprintf("--src-creds=%s", authHeader)
 	}
 
-	tarFilePath := img.tarFilePath(ip.directory)
+	tarFilePath := img.tarFilePath(directory)
 
 	var cmd *exec.Cmd
 	if len(headerValue) > 0 {
@@ -194,7 +199,7 @@ func (ip *SkopeoImagePuller) SaveImageToTar(img image) error {
 
 	recordDockerGetDuration(time.Now().Sub(start))
 
-	err = ip.recordTarFileSize(img)
+	err = ip.recordTarFileSize(img, directory)
 
 	return err
 }
@@ -219,16 +224,16 @@ func (ip *SkopeoImagePuller) needAuthHeader(img image) string {
 }
 
 // recordTarFileSize will record the TAR file size
-func (ip *SkopeoImagePuller) recordTarFileSize(img image) error {
+func (ip *SkopeoImagePuller) recordTarFileSize(img image, directory string) error {
 	// What's the right way to get the size of the file?
 	//  1. resp.ContentLength
 	//  2. check the size of the file after it's written
 	// fileSizeInMBs := int(resp.ContentLength / (1024 * 1024))
-	stats, err := os.Stat(img.tarFilePath(ip.directory))
+	stats, err := os.Stat(img.tarFilePath(directory))
 
 	if err != nil {
 		recordDockerError(skopeoGetStage, "unable to get tar file stats", img, err)
-		return errors.Wrapf(err, "unable to get tar file stats from %s", img.tarFilePath(ip.directory))
+		return errors.Wrapf(err, "unable to get tar file stats from %s", img.tarFilePath(directory))
 	}
 
 	fileSizeInMBs := int(stats.Size() / (1024 * 1024))
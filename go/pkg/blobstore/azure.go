@@ -0,0 +1,98 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package blobstore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/pkg/errors"
+)
+
+// AzureStore is a BlobStore backed by an Azure Blob storage container.
+type AzureStore struct {
+	container azblob.ContainerURL
+}
+
+// NewAzureStore creates a store for container in the given storage account, authenticating with
+// a shared account key.
+func NewAzureStore(accountName string, accountKey string, container string) (*AzureStore, error) {
+	credential, err := azblob.NewSharedKeyCredential(accountName, accountKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid azure credentials for account %s", accountName)
+	}
+	u, err := url.Parse(fmt.Sprintf("https://%s.blob.core.windows.net/%s", accountName, container))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to build azure container url")
+	}
+	pipeline := azblob.NewPipeline(credential, azblob.PipelineOptions{})
+	return &AzureStore{container: azblob.NewContainerURL(*u, pipeline)}, nil
+}
+
+// NewAzureStoreFromEnv reads AZURE_STORAGE_ACCOUNT and AZURE_STORAGE_KEY to configure a store for container.
+func NewAzureStoreFromEnv(container string) (*AzureStore, error) {
+	return NewAzureStore(os.Getenv("AZURE_STORAGE_ACCOUNT"), os.Getenv("AZURE_STORAGE_KEY"), container)
+}
+
+func (a *AzureStore) Upload(ctx context.Context, key string, r io.Reader) error {
+	_, err := azblob.UploadStreamToBlockBlob(ctx, r, a.container.NewBlockBlobURL(key), azblob.UploadStreamToBlockBlobOptions{
+		BufferSize: 4 * 1024 * 1024,
+		MaxBuffers: 4,
+	})
+	return errors.Wrapf(err, "unable to write azure blob %s", key)
+}
+
+func (a *AzureStore) Download(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := a.container.NewBlobURL(key).Download(ctx, 0, azblob.CountToEnd, azblob.BlobAccessConditions{}, false)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read azure blob %s", key)
+	}
+	return resp.Body(azblob.RetryReaderOptions{MaxRetryRequests: 3}), nil
+}
+
+func (a *AzureStore) List(ctx context.Context, prefix string) ([]string, error) {
+	keys := []string{}
+	for marker := (azblob.Marker{}); marker.NotDone(); {
+		resp, err := a.container.ListBlobsFlatSegment(ctx, marker, azblob.ListBlobsSegmentOptions{Prefix: prefix})
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to list azure blobs with prefix %s", prefix)
+		}
+		for _, blob := range resp.Segment.BlobItems {
+			keys = append(keys, blob.Name)
+		}
+		marker = resp.NextMarker
+	}
+	return keys, nil
+}
+
+func (a *AzureStore) Delete(ctx context.Context, key string) error {
+	_, err := a.container.NewBlobURL(key).Delete(ctx, azblob.DeleteSnapshotsOptionInclude, azblob.BlobAccessConditions{})
+	return errors.Wrapf(err, "unable to delete azure blob %s", key)
+}
+
+// Close does nothing: azblob pipelines have nothing to close.
+func (a *AzureStore) Close() error {
+	return nil
+}
@@ -0,0 +1,167 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package blobstore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// BlobStore is a flat namespace of objects, addressed by slash-separated keys, in some storage
+// backend: a GCS or S3 bucket, an Azure Blob container, or a local directory.
+type BlobStore interface {
+	// Upload streams the contents of r into the object at key, replacing it if it already exists.
+	Upload(ctx context.Context, key string, r io.Reader) error
+	// Download opens the object at key for reading.  The caller must close it.
+	Download(ctx context.Context, key string) (io.ReadCloser, error)
+	// List returns the keys of all objects whose keys start with prefix.
+	List(ctx context.Context, prefix string) ([]string, error)
+	// Delete removes the object at key.
+	Delete(ctx context.Context, key string) error
+	// Close releases the store's connections.  The store can't be used afterwards.
+	Close() error
+}
+
+// Scheme identifies a BlobStore backend in a store URL.
+type Scheme string
+
+// The schemes of the supported backends.
+const (
+	SchemeGCS   Scheme = "gs"
+	SchemeS3    Scheme = "s3"
+	SchemeAzure Scheme = "azblob"
+	SchemeFile  Scheme = "file"
+)
+
+// NewBlobStore creates a BlobStore from a URL whose scheme selects the backend:
+//
+//	gs://bucket            -- Google Cloud Storage, credentials from GOOGLE_APPLICATION_CREDENTIALS
+//	s3://bucket            -- S3 or an S3-compatible service such as MinIO, see NewS3Store
+//	azblob://container     -- Azure Blob storage, see NewAzureStore
+//	file:///some/directory -- the local filesystem
+//
+// Any path after the bucket or container is used as a key prefix.
+func NewBlobStore(ctx context.Context, storeURL string) (BlobStore, error) {
+	u, err := url.Parse(storeURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to parse blob store url %s", storeURL)
+	}
+	prefix := strings.TrimPrefix(u.Path, "/")
+	var store BlobStore
+	switch Scheme(u.Scheme) {
+	case SchemeGCS:
+		store, err = NewGCSStore(ctx, u.Host)
+	case SchemeS3:
+		store, err = NewS3StoreFromEnv(u.Host)
+	case SchemeAzure:
+		store, err = NewAzureStoreFromEnv(u.Host)
+	case SchemeFile:
+		return NewLocalStore(u.Path)
+	default:
+		return nil, errors.Errorf("unsupported blob store scheme '%s' in %s", u.Scheme, storeURL)
+	}
+	if err != nil {
+		return nil, errors.WithMessagef(err, "unable to create blob store for %s", storeURL)
+	}
+	if prefix != "" {
+		store = &prefixedStore{store: store, prefix: strings.TrimSuffix(prefix, "/") + "/"}
+	}
+	log.Debugf("created %s blob store for %s", u.Scheme, storeURL)
+	return store, nil
+}
+
+// BucketURL turns a bare bucket name into a gs:// url, for backwards compatibility with
+// configuration written before other backends existed.  URLs are returned unchanged.
+func BucketURL(bucket string) string {
+	if strings.Contains(bucket, "://") {
+		return bucket
+	}
+	return fmt.Sprintf("%s://%s", SchemeGCS, bucket)
+}
+
+// UploadFile copies the local file at localPath to key.
+func UploadFile(ctx context.Context, store BlobStore, key string, localPath string) error {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return errors.Wrapf(err, "unable to open %s", localPath)
+	}
+	defer f.Close()
+	return errors.WithMessagef(store.Upload(ctx, key, f), "unable to upload %s to %s", localPath, key)
+}
+
+// DownloadFile copies the object at key to the local file at localPath.
+func DownloadFile(ctx context.Context, store BlobStore, key string, localPath string) error {
+	r, err := store.Download(ctx, key)
+	if err != nil {
+		return errors.WithMessagef(err, "unable to download %s", key)
+	}
+	defer r.Close()
+
+	f, err := os.Create(localPath)
+	if err != nil {
+		return errors.Wrapf(err, "unable to create %s", localPath)
+	}
+	if _, err = io.Copy(f, r); err != nil {
+		f.Close()
+		return errors.Wrapf(err, "unable to download %s to %s", key, localPath)
+	}
+	return errors.Wrapf(f.Close(), "unable to close %s", localPath)
+}
+
+// prefixedStore scopes every key of an underlying store under a fixed prefix.
+type prefixedStore struct {
+	store  BlobStore
+	prefix string
+}
+
+func (p *prefixedStore) Upload(ctx context.Context, key string, r io.Reader) error {
+	return p.store.Upload(ctx, p.prefix+key, r)
+}
+
+func (p *prefixedStore) Download(ctx context.Context, key string) (io.ReadCloser, error) {
+	return p.store.Download(ctx, p.prefix+key)
+}
+
+func (p *prefixedStore) List(ctx context.Context, prefix string) ([]string, error) {
+	keys, err := p.store.List(ctx, p.prefix+prefix)
+	if err != nil {
+		return nil, err
+	}
+	for i, key := range keys {
+		keys[i] = strings.TrimPrefix(key, p.prefix)
+	}
+	return keys, nil
+}
+
+func (p *prefixedStore) Delete(ctx context.Context, key string) error {
+	return p.store.Delete(ctx, p.prefix+key)
+}
+
+func (p *prefixedStore) Close() error {
+	return p.store.Close()
+}
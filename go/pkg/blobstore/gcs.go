@@ -0,0 +1,82 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package blobstore
+
+import (
+	"context"
+	"io"
+
+	"cloud.google.com/go/storage"
+	"github.com/pkg/errors"
+	"google.golang.org/api/iterator"
+)
+
+// GCSStore is a BlobStore backed by a Google Cloud Storage bucket.
+type GCSStore struct {
+	client *storage.Client
+	bucket *storage.BucketHandle
+}
+
+// NewGCSStore creates a store for bucket using application default credentials, which are
+// usually pointed at a service account file with GOOGLE_APPLICATION_CREDENTIALS.
+func NewGCSStore(ctx context.Context, bucket string) (*GCSStore, error) {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to create gcs client")
+	}
+	return &GCSStore{client: client, bucket: client.Bucket(bucket)}, nil
+}
+
+func (g *GCSStore) Upload(ctx context.Context, key string, r io.Reader) error {
+	wc := g.bucket.Object(key).NewWriter(ctx)
+	if _, err := io.Copy(wc, r); err != nil {
+		wc.Close()
+		return errors.Wrapf(err, "unable to write gcs object %s", key)
+	}
+	return errors.Wrapf(wc.Close(), "unable to write gcs object %s", key)
+}
+
+func (g *GCSStore) Download(ctx context.Context, key string) (io.ReadCloser, error) {
+	rc, err := g.bucket.Object(key).NewReader(ctx)
+	return rc, errors.Wrapf(err, "unable to read gcs object %s", key)
+}
+
+func (g *GCSStore) List(ctx context.Context, prefix string) ([]string, error) {
+	keys := []string{}
+	it := g.bucket.Objects(ctx, &storage.Query{Prefix: prefix})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			return keys, nil
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "unable to list gcs objects with prefix %s", prefix)
+		}
+		keys = append(keys, attrs.Name)
+	}
+}
+
+func (g *GCSStore) Delete(ctx context.Context, key string) error {
+	return errors.Wrapf(g.bucket.Object(key).Delete(ctx), "unable to delete gcs object %s", key)
+}
+
+func (g *GCSStore) Close() error {
+	return errors.Wrapf(g.client.Close(), "unable to close gcs client")
+}
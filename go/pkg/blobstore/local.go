@@ -0,0 +1,127 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package blobstore
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// LocalStore is a BlobStore backed by a directory on the local filesystem; keys map to paths
+// relative to that directory.
+type LocalStore struct {
+	root string
+}
+
+// NewLocalStore creates a store rooted at dir, creating dir if necessary.
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrapf(err, "unable to create local blob store dir %s", dir)
+	}
+	return &LocalStore{root: dir}, nil
+}
+
+func (l *LocalStore) path(key string) (string, error) {
+	path := filepath.Join(l.root, filepath.FromSlash(key))
+	if !strings.HasPrefix(path, filepath.Clean(l.root)+string(os.PathSeparator)) {
+		return "", errors.Errorf("invalid key %s", key)
+	}
+	return path, nil
+}
+
+func (l *LocalStore) Upload(ctx context.Context, key string, r io.Reader) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrapf(err, "unable to create dir for %s", path)
+	}
+	// write to a temp file first, so that readers never see a partially written object
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".upload-")
+	if err != nil {
+		return errors.Wrapf(err, "unable to create temp file for %s", path)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return errors.Wrapf(err, "unable to write %s", path)
+	}
+	if err = tmp.Close(); err != nil {
+		return errors.Wrapf(err, "unable to write %s", path)
+	}
+	return errors.Wrapf(os.Rename(tmp.Name(), path), "unable to write %s", path)
+}
+
+func (l *LocalStore) Download(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	return f, errors.Wrapf(err, "unable to open %s", path)
+}
+
+func (l *LocalStore) List(ctx context.Context, prefix string) ([]string, error) {
+	keys := []string{}
+	err := filepath.Walk(l.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".upload-") {
+			return nil
+		}
+		rel, err := filepath.Rel(l.root, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to list %s", l.root)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (l *LocalStore) Delete(ctx context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	return errors.Wrapf(os.Remove(path), "unable to delete %s", path)
+}
+
+// Close does nothing: a local store holds nothing open between calls.
+func (l *LocalStore) Close() error {
+	return nil
+}
@@ -0,0 +1,98 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package blobstore
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func RunLocalStoreTests() {
+	Describe("LocalStore", func() {
+		var dir string
+		ctx := context.Background()
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "blobstore-test")
+			Expect(err).To(Succeed())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("should upload, list, download and delete", func() {
+			store, err := NewBlobStore(ctx, "file://"+dir)
+			Expect(err).To(Succeed())
+
+			Expect(store.Upload(ctx, "a/one.txt", strings.NewReader("one"))).To(Succeed())
+			Expect(store.Upload(ctx, "a/two.txt", strings.NewReader("two"))).To(Succeed())
+			Expect(store.Upload(ctx, "b/three.txt", strings.NewReader("three"))).To(Succeed())
+
+			keys, err := store.List(ctx, "a/")
+			Expect(err).To(Succeed())
+			Expect(keys).To(Equal([]string{"a/one.txt", "a/two.txt"}))
+
+			local := filepath.Join(dir, "downloaded")
+			Expect(DownloadFile(ctx, store, "b/three.txt", local)).To(Succeed())
+			contents, err := ioutil.ReadFile(local)
+			Expect(err).To(Succeed())
+			Expect(string(contents)).To(Equal("three"))
+
+			Expect(store.Delete(ctx, "a/one.txt")).To(Succeed())
+			keys, err = store.List(ctx, "a/")
+			Expect(err).To(Succeed())
+			Expect(keys).To(Equal([]string{"a/two.txt"}))
+			Expect(store.Close()).To(Succeed())
+		})
+
+		It("should scope keys under a prefix", func() {
+			local, err := NewLocalStore(dir)
+			Expect(err).To(Succeed())
+			store := &prefixedStore{store: local, prefix: "jobs/"}
+			Expect(store.Upload(ctx, "one.txt", strings.NewReader("one"))).To(Succeed())
+			Expect(filepath.Join(dir, "jobs", "one.txt")).To(BeAnExistingFile())
+			keys, err := store.List(ctx, "")
+			Expect(err).To(Succeed())
+			Expect(keys).To(Equal([]string{"one.txt"}))
+			Expect(store.Close()).To(Succeed())
+		})
+
+		It("should reject keys outside the root", func() {
+			store, err := NewLocalStore(dir)
+			Expect(err).To(Succeed())
+			Expect(store.Upload(ctx, "../escape.txt", strings.NewReader("nope"))).NotTo(Succeed())
+		})
+
+		It("should map bare bucket names to gcs", func() {
+			Expect(BucketURL("my-bucket")).To(Equal("gs://my-bucket"))
+			Expect(BucketURL("s3://my-bucket")).To(Equal("s3://my-bucket"))
+		})
+	})
+}
@@ -0,0 +1,110 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package blobstore
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/minio/minio-go/v6"
+	"github.com/pkg/errors"
+)
+
+// S3Config describes how to reach an S3 or S3-compatible (MinIO, Ceph, ...) endpoint.
+type S3Config struct {
+	Endpoint        string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	Insecure        bool
+}
+
+// S3Store is a BlobStore backed by an S3 bucket.
+type S3Store struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3Store creates a store for bucket on the endpoint described by config.
+func NewS3Store(config *S3Config, bucket string) (*S3Store, error) {
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = "s3.amazonaws.com"
+	}
+	client, err := minio.NewWithRegion(endpoint, config.AccessKeyID, config.SecretAccessKey, !config.Insecure, config.Region)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to create s3 client for %s", endpoint)
+	}
+	return &S3Store{client: client, bucket: bucket}, nil
+}
+
+// NewS3StoreFromEnv reads S3_ENDPOINT, S3_REGION, S3_INSECURE, AWS_ACCESS_KEY_ID and
+// AWS_SECRET_ACCESS_KEY to configure a store for bucket.
+func NewS3StoreFromEnv(bucket string) (*S3Store, error) {
+	return NewS3Store(&S3Config{
+		Endpoint:        os.Getenv("S3_ENDPOINT"),
+		Region:          os.Getenv("S3_REGION"),
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		Insecure:        os.Getenv("S3_INSECURE") == "true",
+	}, bucket)
+}
+
+func (s *S3Store) Upload(ctx context.Context, key string, r io.Reader) error {
+	_, err := s.client.PutObjectWithContext(ctx, s.bucket, key, r, -1, minio.PutObjectOptions{})
+	return errors.Wrapf(err, "unable to write s3 object %s", key)
+}
+
+func (s *S3Store) Download(ctx context.Context, key string) (io.ReadCloser, error) {
+	obj, err := s.client.GetObjectWithContext(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read s3 object %s", key)
+	}
+	// GetObject is lazy: stat it so that a missing object shows up here rather than on first read
+	if _, err = obj.Stat(); err != nil {
+		obj.Close()
+		return nil, errors.Wrapf(err, "unable to read s3 object %s", key)
+	}
+	return obj, nil
+}
+
+func (s *S3Store) List(ctx context.Context, prefix string) ([]string, error) {
+	done := make(chan struct{})
+	defer close(done)
+	keys := []string{}
+	for obj := range s.client.ListObjectsV2(s.bucket, prefix, true, done) {
+		if obj.Err != nil {
+			return nil, errors.Wrapf(obj.Err, "unable to list s3 objects with prefix %s", prefix)
+		}
+		keys = append(keys, obj.Key)
+	}
+	return keys, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	return errors.Wrapf(s.client.RemoveObject(s.bucket, key), "unable to delete s3 object %s", key)
+}
+
+// Close does nothing: minio clients have nothing to close.
+func (s *S3Store) Close() error {
+	return nil
+}
@@ -22,11 +22,10 @@ package kube
 
 import (
 	"fmt"
-	"github.com/pkg/errors"
+	utilconfig "github.com/blackducksoftware/cerebros/go/pkg/util/config"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
 	"net/http"
 	"strings"
 	"time"
@@ -46,19 +45,11 @@ func (config *Config) GetLogLevel() (log.Level, error) {
 
 // GetConfig ...
 func GetConfig(configPath string) (*Config, error) {
-	var config *Config
-
-	viper.SetConfigFile(configPath)
-	err := viper.ReadInConfig()
+	config := &Config{}
+	err := utilconfig.Load(configPath, config, &utilconfig.Options{EnvPrefix: "KUBE_METRICS"})
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to ReadInConfig at %s", configPath)
+		return nil, err
 	}
-
-	err = viper.Unmarshal(&config)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to unmarshal config at %s", configPath)
-	}
-
 	return config, nil
 }
 
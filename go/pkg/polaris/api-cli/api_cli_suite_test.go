@@ -0,0 +1,16 @@
+package api_cli
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestApiCli(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunOutputTests()
+	RunUsersTests()
+	RunRoleAssignmentsTests()
+	RunSpecs(t, "api-cli suite")
+}
@@ -1,7 +1,7 @@
 package api_cli
 
 import (
-	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
+	"context"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -21,7 +21,7 @@ func SetupAuthCommand() *cobra.Command {
 		Short: "Auth commands",
 		Long:  "use commands for auth server",
 		Run: func(cmd *cobra.Command, as []string) {
-			runAuth(args)
+			runAuth(cmd.Context(), args)
 		},
 	}
 
@@ -39,22 +39,22 @@ func SetupAuthCommand() *cobra.Command {
 	return command
 }
 
-func runAuth(args *AuthArgs) {
+func runAuth(ctx context.Context, args *AuthArgs) {
 	log.Infof("auth args: %+v", args)
 
-	client := api.NewClient(args.PolarisURL, args.Email, args.Password)
+	client := newClient(args.PolarisURL, args.Email, args.Password)
 
-	DoOrDie(client.Authenticate())
+	DoOrDie(client.AuthenticateContext(ctx))
 
 	switch args.Type {
 	case "orgs", "organizations":
-		printJson(client.GetOrganizations())
+		printJson(client.GetOrganizationsContext(ctx))
 	case "roles":
-		printJson(client.GetRoles())
+		printJson(client.GetRolesContext(ctx))
 	case "users":
-		printJson(client.GetUsers(0, 10))
+		printJson(client.GetUsersContext(ctx, 0, 10))
 	case "groups":
-		printJson(client.GetGroups())
+		printJson(client.GetGroupsContext(ctx))
 	default:
 		panic(errors.Errorf("invalid type: %s", args.Type))
 	}
@@ -1,9 +1,9 @@
 package api_cli
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -23,7 +23,7 @@ func SetupCosCommand() *cobra.Command {
 		Short: "cos commands",
 		Long:  "use commands for common object server",
 		Run: func(cmd *cobra.Command, as []string) {
-			runCos(args)
+			runCos(cmd.Context(), args)
 		},
 	}
 
@@ -41,18 +41,18 @@ func SetupCosCommand() *cobra.Command {
 	return command
 }
 
-func runCos(args *CosArgs) {
+func runCos(ctx context.Context, args *CosArgs) {
 	log.Infof("cos args: %+v", args)
 
-	client := api.NewClient(args.PolarisURL, args.Email, args.Password)
+	client := newClient(args.PolarisURL, args.Email, args.Password)
 
-	DoOrDie(client.Authenticate())
+	DoOrDie(client.AuthenticateContext(ctx))
 
 	switch args.Type {
 	case "projects":
-		printJson(client.GetProjects(10))
+		printJson(client.GetProjectsContext(ctx, 10))
 	case "tools":
-		printJson(client.GetTools(10))
+		printJson(client.GetToolsContext(ctx, 10))
 	default:
 		panic(errors.Errorf("invalid type: %s", args.Type))
 	}
@@ -1,7 +1,6 @@
 package api_cli
 
 import (
-	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -12,7 +11,7 @@ type ExampleArgs struct {
 }
 
 func runExample(args *ExampleArgs) {
-	client := api.NewClient(args.PolarisURL, args.Email, args.Password)
+	client := newClient(args.PolarisURL, args.Email, args.Password)
 
 	log.SetLevel(log.DebugLevel)
 
@@ -31,10 +30,9 @@ func runExample(args *ExampleArgs) {
 	DoOrDie(err)
 	log.Infof("role assignments for %s: \n\n%+v\n\n", projId, ras)
 
-	orgs, err := client.GetOrganizations()
+	org, err := client.SelectOrganization("")
 	DoOrDie(err)
-	log.Infof("orgs: %d", len(orgs.Data))
-	orgId := orgs.Data[0].Id
+	orgId := org.Id
 
 	//params := map[string]interface{}{
 	//	"filter[entitlements][object][eq]": fmt.Sprintf("urn:x-swip:organizations:%s", orgId),
@@ -0,0 +1,103 @@
+package api_cli
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/export"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func SetupExportCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "export",
+		Short: "export Polaris data",
+		Long:  "export Polaris data into formats other tools can consume",
+		Args:  cobra.ExactArgs(0),
+	}
+
+	command.AddCommand(SetupExportIssuesCommand())
+
+	return command
+}
+
+type ExportIssuesArgs struct {
+	PolarisURL   string
+	Email        string
+	Password     string
+	ProjectId    string
+	BranchId     string
+	RunId        string
+	OutputFormat string
+	OutputFile   string
+	PageSize     int
+}
+
+func SetupExportIssuesCommand() *cobra.Command {
+	args := &ExportIssuesArgs{}
+
+	command := &cobra.Command{
+		Use:   "issues",
+		Short: "export the issues of a branch or run",
+		Long:  "export the issues of a branch or run as SARIF 2.1.0, CSV or JSON Lines",
+		Args:  cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, as []string) {
+			DoOrDie(RunExportIssues(cmd.Context(), args))
+		},
+	}
+
+	command.Flags().StringVar(&args.PolarisURL, "polaris-url", "", "polaris URL")
+	command.MarkFlagRequired("polaris-url")
+
+	command.Flags().StringVar(&args.Email, "email", "", "email")
+	command.MarkFlagRequired("email")
+
+	command.Flags().StringVar(&args.Password, "password", "", "password")
+	command.MarkFlagRequired("password")
+
+	command.Flags().StringVar(&args.ProjectId, "project-id", "", "id of the project to export issues from")
+	command.MarkFlagRequired("project-id")
+
+	command.Flags().StringVar(&args.BranchId, "branch-id", "", "id of the branch to export issues from; exactly one of --branch-id and --run-id is required")
+	command.Flags().StringVar(&args.RunId, "run-id", "", "id of the run to export issues from; exactly one of --branch-id and --run-id is required")
+	command.Flags().StringVar(&args.OutputFormat, "output-format", export.FormatSARIF, outputFormatUsage(export.FormatSARIF, export.FormatCSV, export.FormatJSONLines))
+	command.Flags().StringVar(&args.OutputFile, "output-file", "-", "file to write to; - for stdout")
+	command.Flags().IntVar(&args.PageSize, "page-size", 100, "number of issues to fetch per request")
+
+	return command
+}
+
+func RunExportIssues(ctx context.Context, args *ExportIssuesArgs) error {
+	if (args.BranchId == "") == (args.RunId == "") {
+		return errors.Errorf("exactly one of --branch-id and --run-id is required")
+	}
+
+	var out io.Writer = os.Stdout
+	if args.OutputFile != "-" {
+		file, err := os.Create(args.OutputFile)
+		if err != nil {
+			return errors.Wrapf(err, "unable to create %s", args.OutputFile)
+		}
+		defer file.Close()
+		out = file
+	}
+	writer, err := export.NewIssueWriter(args.OutputFormat, out)
+	if err != nil {
+		return err
+	}
+
+	client := newClient(args.PolarisURL, args.Email, args.Password)
+	if err := client.AuthenticateContext(ctx); err != nil {
+		return err
+	}
+
+	count, err := export.WriteIssues(ctx, client.ResolvedV1IssuesPaginator(args.ProjectId, args.BranchId, args.RunId, args.PageSize), writer)
+	if err != nil {
+		return err
+	}
+	log.Infof("exported %d issues as %s", count, args.OutputFormat)
+	return nil
+}
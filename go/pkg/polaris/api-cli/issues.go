@@ -0,0 +1,161 @@
+package api_cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func SetupIssuesCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "issues",
+		Short: "issues functionality",
+		Long:  "issues-related functionality",
+		Args:  cobra.ExactArgs(0),
+	}
+
+	command.AddCommand(SetupIssuesDiffCommand())
+
+	return command
+}
+
+type IssuesDiffArgs struct {
+	PolarisURL    string
+	Email         string
+	Password      string
+	ProjectId     string
+	BaseProjectId string
+	BaseBranchId  string
+	BaseRunId     string
+	HeadBranchId  string
+	HeadRunId     string
+	MatchBy       string
+	OutputFormat  string
+	FailOnNew     bool
+}
+
+func SetupIssuesDiffCommand() *cobra.Command {
+	args := &IssuesDiffArgs{}
+
+	command := &cobra.Command{
+		Use:   "diff",
+		Short: "compare the issues of two runs or branches",
+		Long:  "report the issues that are new, fixed and unchanged in a head run or branch compared to a base one, with counts per severity",
+		Args:  cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, as []string) {
+			DoOrDie(RunIssuesDiff(cmd.Context(), args))
+		},
+	}
+
+	command.Flags().StringVar(&args.PolarisURL, "polaris-url", "", "polaris URL")
+	command.MarkFlagRequired("polaris-url")
+
+	command.Flags().StringVar(&args.Email, "email", "", "email")
+	command.MarkFlagRequired("email")
+
+	command.Flags().StringVar(&args.Password, "password", "", "password")
+	command.MarkFlagRequired("password")
+
+	command.Flags().StringVar(&args.ProjectId, "project-id", "", "id of the project the head issues are in")
+	command.MarkFlagRequired("project-id")
+
+	command.Flags().StringVar(&args.BaseProjectId, "base-project-id", "", "id of the project the base issues are in, if it's not --project-id; use with --match-by finding-key")
+	command.Flags().StringVar(&args.BaseBranchId, "base-branch-id", "", "id of the base branch; exactly one of --base-branch-id and --base-run-id is required")
+	command.Flags().StringVar(&args.BaseRunId, "base-run-id", "", "id of the base run; exactly one of --base-branch-id and --base-run-id is required")
+	command.Flags().StringVar(&args.HeadBranchId, "head-branch-id", "", "id of the head branch; exactly one of --head-branch-id and --head-run-id is required")
+	command.Flags().StringVar(&args.HeadRunId, "head-run-id", "", "id of the head run; exactly one of --head-branch-id and --head-run-id is required")
+	command.Flags().StringVar(&args.MatchBy, "match-by", api.MatchByIssueKey, "how to match issues; one of [issue-key, finding-key]")
+	command.Flags().StringVar(&args.OutputFormat, "output-format", OutputTable, outputFormatUsage(outputFormats...))
+	command.Flags().BoolVar(&args.FailOnNew, "fail-on-new", false, "exit with status 1 if there are new issues")
+
+	return command
+}
+
+// IssuesDiffReport is the JSON and YAML output of issues diff
+type IssuesDiffReport struct {
+	Counts    []*api.SeverityCounts
+	New       []*api.ResolvedV1Issue
+	Fixed     []*api.ResolvedV1Issue
+	Unchanged int
+}
+
+func RunIssuesDiff(ctx context.Context, args *IssuesDiffArgs) error {
+	if (args.BaseBranchId == "") == (args.BaseRunId == "") {
+		return errors.Errorf("exactly one of --base-branch-id and --base-run-id is required")
+	}
+	if (args.HeadBranchId == "") == (args.HeadRunId == "") {
+		return errors.Errorf("exactly one of --head-branch-id and --head-run-id is required")
+	}
+	if err := checkOutputFormat(args.OutputFormat, outputFormats...); err != nil {
+		return err
+	}
+	baseProjectId := args.BaseProjectId
+	if baseProjectId == "" {
+		baseProjectId = args.ProjectId
+	}
+	if baseProjectId != args.ProjectId && args.MatchBy != api.MatchByFindingKey {
+		return errors.Errorf("--base-project-id requires --match-by %s: issue keys aren't shared between projects", api.MatchByFindingKey)
+	}
+
+	client := newClient(args.PolarisURL, args.Email, args.Password)
+	if err := client.AuthenticateContext(ctx); err != nil {
+		return err
+	}
+
+	base := &api.IssueSelection{ProjectId: baseProjectId, BranchId: args.BaseBranchId, RunId: args.BaseRunId}
+	head := &api.IssueSelection{ProjectId: args.ProjectId, BranchId: args.HeadBranchId, RunId: args.HeadRunId}
+	diff, err := client.DiffV1IssuesContext(ctx, base, head, args.MatchBy)
+	if err != nil {
+		return err
+	}
+	log.Infof("%d new, %d fixed and %d unchanged issues", len(diff.New), len(diff.Fixed), len(diff.Unchanged))
+
+	if args.OutputFormat == OutputTable {
+		printIssuesDiff(diff)
+	} else {
+		err = writeResult(os.Stdout, args.OutputFormat, &IssuesDiffReport{
+			Counts:    diff.CountsBySeverity(),
+			New:       diff.New,
+			Fixed:     diff.Fixed,
+			Unchanged: len(diff.Unchanged),
+		}, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	if args.FailOnNew && len(diff.New) > 0 {
+		log.Errorf("found %d new issues", len(diff.New))
+		os.Exit(1)
+	}
+	return nil
+}
+
+func printIssuesDiff(diff *api.IssueDiff) {
+	table := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(table, "SEVERITY\tNEW\tFIXED\tUNCHANGED\n")
+	for _, counts := range diff.CountsBySeverity() {
+		fmt.Fprintf(table, "%s\t%d\t%d\t%d\n", counts.Severity, counts.New, counts.Fixed, counts.Unchanged)
+	}
+	fmt.Fprintf(table, "total\t%d\t%d\t%d\n", len(diff.New), len(diff.Fixed), len(diff.Unchanged))
+	table.Flush()
+
+	for _, section := range []struct {
+		title  string
+		issues []*api.ResolvedV1Issue
+	}{{"new", diff.New}, {"fixed", diff.Fixed}} {
+		if len(section.issues) == 0 {
+			continue
+		}
+		fmt.Printf("\n%s issues:\n", section.title)
+		for _, issue := range section.issues {
+			fmt.Printf("  %s\t%s\t%s\t%s\n", issue.IssueKey, issue.Severity, issue.IssueType, issue.Path)
+		}
+	}
+}
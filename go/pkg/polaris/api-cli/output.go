@@ -0,0 +1,192 @@
+package api_cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats of the commands that print results; every command picks its
+// format with --output-format.
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+)
+
+var outputFormats = []string{OutputTable, OutputJSON, OutputYAML}
+
+func outputFormatUsage(formats ...string) string {
+	return fmt.Sprintf("output format; one of [%s]", strings.Join(formats, ", "))
+}
+
+func checkOutputFormat(format string, formats ...string) error {
+	for _, allowed := range formats {
+		if format == allowed {
+			return nil
+		}
+	}
+	return errors.Errorf("invalid output format %s: expected one of [%s]", format, strings.Join(formats, ", "))
+}
+
+// ConnectionArgs are the flags shared by every command of a command tree:
+// how to reach Polaris and how to print results.
+type ConnectionArgs struct {
+	PolarisURL   string
+	Email        string
+	Password     string
+	OutputFormat string
+}
+
+func addConnectionFlags(command *cobra.Command, args *ConnectionArgs) {
+	command.PersistentFlags().StringVar(&args.PolarisURL, "polaris-url", "", "polaris URL")
+	command.MarkPersistentFlagRequired("polaris-url")
+
+	command.PersistentFlags().StringVar(&args.Email, "email", "", "email")
+	command.MarkPersistentFlagRequired("email")
+
+	command.PersistentFlags().StringVar(&args.Password, "password", "", "password")
+	command.MarkPersistentFlagRequired("password")
+
+	command.PersistentFlags().StringVar(&args.OutputFormat, "output-format", OutputTable, outputFormatUsage(outputFormats...))
+}
+
+func (args *ConnectionArgs) connect(cmd *cobra.Command) (*api.Client, error) {
+	if err := checkOutputFormat(args.OutputFormat, outputFormats...); err != nil {
+		return nil, err
+	}
+	client := newClient(args.PolarisURL, args.Email, args.Password)
+	return client, client.AuthenticateContext(cmd.Context())
+}
+
+// table is how a result is printed in the table output format
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+func (t *table) add(row ...string) {
+	t.rows = append(t.rows, row)
+}
+
+// printResult prints value as JSON or YAML, or else prints t
+func (args *ConnectionArgs) printResult(value interface{}, t *table) error {
+	return writeResult(os.Stdout, args.OutputFormat, value, t)
+}
+
+func writeResult(out io.Writer, format string, value interface{}, t *table) error {
+	switch format {
+	case OutputJSON:
+		bytes, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return errors.Wrapf(err, "unable to marshal JSON")
+		}
+		_, err = fmt.Fprintf(out, "%s\n", bytes)
+		return err
+	case OutputYAML:
+		bytes, err := jsonToYAML(value)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(bytes)
+		return err
+	default:
+		writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintf(writer, "%s\n", strings.Join(t.header, "\t"))
+		for _, row := range t.rows {
+			fmt.Fprintf(writer, "%s\n", strings.Join(row, "\t"))
+		}
+		return writer.Flush()
+	}
+}
+
+// jsonToYAML renders value as YAML with the same field names as its JSON,
+// and in the same order
+func jsonToYAML(value interface{}) ([]byte, error) {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to marshal JSON")
+	}
+	node := &yaml.Node{}
+	if err := yaml.Unmarshal(bytes, node); err != nil {
+		return nil, errors.Wrapf(err, "unable to convert JSON to YAML")
+	}
+	// JSON parses as flow style YAML; switch to block style
+	var clearStyle func(node *yaml.Node)
+	clearStyle = func(node *yaml.Node) {
+		node.Style = 0
+		for _, child := range node.Content {
+			clearStyle(child)
+		}
+	}
+	clearStyle(node)
+	bytes, err = yaml.Marshal(node)
+	return bytes, errors.Wrapf(err, "unable to marshal YAML")
+}
+
+// readCSV reads a CSV file with a header row, returning a map of column name
+// to value for each row.  The columns in required must be present.
+func readCSV(path string, required ...string) ([]map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to open %s", path)
+	}
+	defer file.Close()
+	reader := csv.NewReader(file)
+	// rows may leave off trailing optional columns
+	reader.FieldsPerRecord = -1
+	lines, err := reader.ReadAll()
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read CSV from %s", path)
+	}
+	if len(lines) == 0 {
+		return nil, errors.Errorf("%s has no header row", path)
+	}
+	header := lines[0]
+	for _, column := range required {
+		found := false
+		for _, name := range header {
+			found = found || strings.TrimSpace(name) == column
+		}
+		if !found {
+			return nil, errors.Errorf("%s has no %s column", path, column)
+		}
+	}
+	rows := []map[string]string{}
+	for _, line := range lines[1:] {
+		row := map[string]string{}
+		for i, name := range header {
+			if i < len(line) {
+				row[strings.TrimSpace(name)] = strings.TrimSpace(line[i])
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// bulk runs do for each row, carrying on past failures, and reports how many failed
+func bulk(rows []map[string]string, describe string, do func(row map[string]string) error) error {
+	failed := 0
+	for i, row := range rows {
+		if err := do(row); err != nil {
+			failed++
+			log.Errorf("row %d: unable to %s: %s", i+2, describe, err)
+		}
+	}
+	log.Infof("%s: %d succeeded, %d failed", describe, len(rows)-failed, failed)
+	if failed > 0 {
+		return errors.Errorf("%d of %d rows failed", failed, len(rows))
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package api_cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+// writeCSV writes contents to a file in a new temporary directory, which the caller removes
+func writeCSV(contents string) string {
+	dir, err := ioutil.TempDir("", "api-cli-csv-")
+	Expect(err).To(BeNil())
+	path := filepath.Join(dir, "rows.csv")
+	Expect(ioutil.WriteFile(path, []byte(contents), 0644)).To(Succeed())
+	return path
+}
+
+type outputExample struct {
+	Name  string   `json:"name"`
+	Count int      `json:"count"`
+	Tags  []string `json:"tags"`
+}
+
+func RunOutputTests() {
+	Describe("readCSV", func() {
+		It("should map each row by its trimmed column names", func() {
+			path := writeCSV("email, name,password\na@example.com, A ,\nb@example.com,B\n")
+			defer os.RemoveAll(filepath.Dir(path))
+			rows, err := readCSV(path, "email", "name")
+			Expect(err).To(BeNil())
+			Expect(rows).To(Equal([]map[string]string{
+				{"email": "a@example.com", "name": "A", "password": ""},
+				{"email": "b@example.com", "name": "B"},
+			}))
+		})
+
+		It("should require the given columns", func() {
+			path := writeCSV("email\na@example.com\n")
+			defer os.RemoveAll(filepath.Dir(path))
+			_, err := readCSV(path, "email", "name")
+			Expect(err).To(MatchError(ContainSubstring("has no name column")))
+		})
+
+		It("should reject a file without a header row", func() {
+			path := writeCSV("")
+			defer os.RemoveAll(filepath.Dir(path))
+			_, err := readCSV(path, "email")
+			Expect(err).To(MatchError(ContainSubstring("has no header row")))
+		})
+	})
+
+	Describe("bulk", func() {
+		It("should carry on past failed rows and count them", func() {
+			rows := []map[string]string{{"name": "a"}, {"name": "b"}, {"name": "c"}}
+			done := []string{}
+			err := bulk(rows, "do things", func(row map[string]string) error {
+				if row["name"] == "b" {
+					return errors.New("b is bad")
+				}
+				done = append(done, row["name"])
+				return nil
+			})
+			Expect(err).To(MatchError("1 of 3 rows failed"))
+			Expect(done).To(Equal([]string{"a", "c"}))
+		})
+
+		It("should succeed when every row does", func() {
+			Expect(bulk([]map[string]string{{}}, "do nothing", func(row map[string]string) error { return nil })).To(Succeed())
+		})
+	})
+
+	Describe("writeResult", func() {
+		value := []*outputExample{{Name: "first", Count: 1, Tags: []string{"x", "y"}}}
+		t := &table{header: []string{"NAME", "COUNT"}}
+		t.add("first", "1")
+		t.add("a-longer-name", "22")
+
+		It("should align tables", func() {
+			out := &bytes.Buffer{}
+			Expect(writeResult(out, OutputTable, value, t)).To(Succeed())
+			Expect(out.String()).To(Equal("NAME           COUNT\nfirst          1\na-longer-name  22\n"))
+		})
+
+		It("should write JSON", func() {
+			out := &bytes.Buffer{}
+			Expect(writeResult(out, OutputJSON, value, t)).To(Succeed())
+			Expect(out.String()).To(MatchJSON(`[{"name": "first", "count": 1, "tags": ["x", "y"]}]`))
+		})
+
+		It("should write block style YAML with the JSON field names in order", func() {
+			out := &bytes.Buffer{}
+			Expect(writeResult(out, OutputYAML, value, t)).To(Succeed())
+			Expect(out.String()).To(Equal("- name: first\n  count: 1\n  tags:\n    - x\n    - y\n"))
+		})
+	})
+}
@@ -0,0 +1,291 @@
+package api_cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func SetupRoleAssignmentsCommand() *cobra.Command {
+	conn := &ConnectionArgs{}
+	command := &cobra.Command{
+		Use:   "role-assignments",
+		Short: "manage role assignments",
+		Long:  "list, get, create and delete the role assignments that give users and groups roles on projects",
+		Args:  cobra.ExactArgs(0),
+	}
+	addConnectionFlags(command, conn)
+
+	command.AddCommand(SetupRoleAssignmentsListCommand(conn))
+	command.AddCommand(SetupRoleAssignmentsGetCommand(conn))
+	command.AddCommand(SetupRoleAssignmentsCreateCommand(conn))
+	command.AddCommand(SetupRoleAssignmentsDeleteCommand(conn))
+	command.AddCommand(SetupRoleAssignmentsBulkCommand(conn))
+
+	return command
+}
+
+type RoleAssignmentsListArgs struct {
+	ProjectId string
+	User      string
+	PageSize  int
+}
+
+func SetupRoleAssignmentsListCommand(conn *ConnectionArgs) *cobra.Command {
+	args := &RoleAssignmentsListArgs{}
+	command := &cobra.Command{
+		Use:   "list",
+		Short: "list role assignments, optionally only those of a project or user",
+		Args:  cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, as []string) {
+			client, err := conn.connect(cmd)
+			DoOrDie(err)
+			ras, err := listRoleAssignments(cmd.Context(), client, args)
+			DoOrDie(err)
+			t, err := roleAssignmentsTable(cmd.Context(), client, ras...)
+			DoOrDie(err)
+			DoOrDie(conn.printResult(ras, t))
+		},
+	}
+	command.Flags().StringVar(&args.ProjectId, "project-id", "", "only list the role assignments on this project")
+	command.Flags().StringVar(&args.User, "user", "", "only list the role assignments of this user, by id or email")
+	command.Flags().IntVar(&args.PageSize, "page-size", 100, "number of role assignments to fetch per request")
+	return command
+}
+
+func listRoleAssignments(ctx context.Context, client *api.Client, args *RoleAssignmentsListArgs) ([]*api.RoleAssignment, error) {
+	var ras []*api.RoleAssignment
+	switch {
+	case args.User != "":
+		user, err := findUser(ctx, client, args.User)
+		if err != nil {
+			return nil, err
+		}
+		// the user filter needs to know whether it's a service account
+		response, err := client.GetRoleAssignmentsForUserContext(ctx, user.Attributes.Email, 0, 1000, user.Attributes.Automated)
+		if err != nil {
+			return nil, err
+		}
+		ras = response.Data
+	case args.ProjectId != "":
+		response, err := client.GetRoleAssignmentsForProjectContext(ctx, args.ProjectId)
+		if err != nil {
+			return nil, err
+		}
+		ras = response.Data
+	default:
+		items, err := client.RoleAssignmentsPaginator(args.PageSize).All(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			ras = append(ras, item.(*api.RoleAssignment))
+		}
+	}
+	if args.User == "" || args.ProjectId == "" {
+		return ras, nil
+	}
+	onProject := []*api.RoleAssignment{}
+	for _, ra := range ras {
+		if ra.Attributes.Object == projectURN(args.ProjectId) {
+			onProject = append(onProject, ra)
+		}
+	}
+	return onProject, nil
+}
+
+func SetupRoleAssignmentsGetCommand(conn *ConnectionArgs) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get ID",
+		Short: "get a role assignment",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, as []string) {
+			client, err := conn.connect(cmd)
+			DoOrDie(err)
+			ra, err := client.GetRoleAssignmentContext(cmd.Context(), as[0])
+			DoOrDie(err)
+			t, err := roleAssignmentsTable(cmd.Context(), client, ra.Data)
+			DoOrDie(err)
+			DoOrDie(conn.printResult(ra.Data, t))
+		},
+	}
+}
+
+type RoleAssignmentsCreateArgs struct {
+	User      string
+	GroupId   string
+	Role      string
+	ProjectId string
+}
+
+func SetupRoleAssignmentsCreateCommand(conn *ConnectionArgs) *cobra.Command {
+	args := &RoleAssignmentsCreateArgs{}
+	command := &cobra.Command{
+		Use:   "create",
+		Short: "give a user or group a role on a project",
+		Args:  cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, as []string) {
+			client, err := conn.connect(cmd)
+			DoOrDie(err)
+			ra, err := createRoleAssignment(cmd.Context(), client, args)
+			DoOrDie(err)
+			t, err := roleAssignmentsTable(cmd.Context(), client, ra)
+			DoOrDie(err)
+			DoOrDie(conn.printResult(ra, t))
+		},
+	}
+	command.Flags().StringVar(&args.User, "user", "", "id or email of the user to give the role to; exactly one of --user and --group-id is required")
+	command.Flags().StringVar(&args.GroupId, "group-id", "", "id of the group to give the role to; exactly one of --user and --group-id is required")
+	command.Flags().StringVar(&args.Role, "role", "", "name or id of the role")
+	command.MarkFlagRequired("role")
+	command.Flags().StringVar(&args.ProjectId, "project-id", "", "id of the project")
+	command.MarkFlagRequired("project-id")
+	return command
+}
+
+func createRoleAssignment(ctx context.Context, client *api.Client, args *RoleAssignmentsCreateArgs) (*api.RoleAssignment, error) {
+	if (args.User == "") == (args.GroupId == "") {
+		return nil, errors.Errorf("exactly one of a user and a group is required")
+	}
+	roles, index, err := findRole(ctx, client, args.Role)
+	if err != nil {
+		return nil, err
+	}
+	roleId := roles.Data[index].Id
+	var body string
+	if args.GroupId != "" {
+		body, err = client.CreateGroupRoleAssignmentContext(ctx, args.GroupId, roleId, args.ProjectId, "")
+	} else {
+		var user *api.User
+		user, err = findUser(ctx, client, args.User)
+		if err != nil {
+			return nil, err
+		}
+		body, err = client.CreateRoleAssignmentContext(ctx, user.Id, roleId, args.ProjectId, "")
+	}
+	if err != nil {
+		return nil, errors.WithMessagef(err, "unable to create role assignment")
+	}
+	created := struct{ Data *api.RoleAssignment }{}
+	if err := json.Unmarshal([]byte(body), &created); err != nil {
+		return nil, errors.Wrapf(err, "unable to decode created role assignment")
+	}
+	return created.Data, nil
+}
+
+func SetupRoleAssignmentsDeleteCommand(conn *ConnectionArgs) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete ID",
+		Short: "delete a role assignment",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, as []string) {
+			client, err := conn.connect(cmd)
+			DoOrDie(err)
+			DoOrDie(client.DeleteRoleAssignmentContext(cmd.Context(), as[0]))
+			log.Infof("deleted role assignment %s", as[0])
+		},
+	}
+}
+
+func SetupRoleAssignmentsBulkCommand(conn *ConnectionArgs) *cobra.Command {
+	path := ""
+	del := false
+	command := &cobra.Command{
+		Use:   "bulk",
+		Short: "create or delete the role assignments listed in a CSV file",
+		Long: "create the role assignments listed in a CSV file, or delete them with --delete.  The file needs a header row " +
+			"with user, role and project-id columns; users are given by id or email, and roles by name or id.",
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, as []string) {
+			client, err := conn.connect(cmd)
+			DoOrDie(err)
+			ctx := cmd.Context()
+			rows, err := readCSV(path, "user", "role", "project-id")
+			DoOrDie(err)
+			if del {
+				DoOrDie(bulk(rows, "delete role assignment", func(row map[string]string) error {
+					return deleteMatchingRoleAssignments(ctx, client, row["user"], row["role"], row["project-id"])
+				}))
+				return
+			}
+			created := []*api.RoleAssignment{}
+			err = bulk(rows, "create role assignment", func(row map[string]string) error {
+				ra, err := createRoleAssignment(ctx, client, &RoleAssignmentsCreateArgs{User: row["user"], Role: row["role"], ProjectId: row["project-id"]})
+				if err == nil {
+					created = append(created, ra)
+				}
+				return err
+			})
+			t, tableErr := roleAssignmentsTable(ctx, client, created...)
+			DoOrDie(tableErr)
+			DoOrDie(conn.printResult(created, t))
+			DoOrDie(err)
+		},
+	}
+	command.Flags().StringVar(&path, "file", "", "CSV file of role assignments")
+	command.MarkFlagRequired("file")
+	command.Flags().BoolVar(&del, "delete", false, "delete the role assignments instead of creating them")
+	return command
+}
+
+// deleteMatchingRoleAssignments deletes the user's assignments of the role on the project
+func deleteMatchingRoleAssignments(ctx context.Context, client *api.Client, userIdOrEmail string, role string, projectId string) error {
+	user, err := findUser(ctx, client, userIdOrEmail)
+	if err != nil {
+		return err
+	}
+	roles, index, err := findRole(ctx, client, role)
+	if err != nil {
+		return err
+	}
+	ras, err := listRoleAssignments(ctx, client, &RoleAssignmentsListArgs{User: user.Id, ProjectId: projectId})
+	if err != nil {
+		return err
+	}
+	deleted := 0
+	for _, ra := range ras {
+		if ra.Relationships["role"].Data.Id != roles.Data[index].Id {
+			continue
+		}
+		if err := client.DeleteRoleAssignmentContext(ctx, ra.Id); err != nil {
+			return err
+		}
+		deleted++
+	}
+	if deleted == 0 {
+		return errors.Errorf("%s has no %s role on project %s", userIdOrEmail, role, projectId)
+	}
+	return nil
+}
+
+func projectURN(projectId string) string {
+	return fmt.Sprintf("urn:x-swip:projects:%s", projectId)
+}
+
+func roleAssignmentsTable(ctx context.Context, client *api.Client, ras ...*api.RoleAssignment) (*table, error) {
+	t := &table{header: []string{"ID", "ROLE", "SUBJECT", "OBJECT", "EXPIRES BY"}}
+	if len(ras) == 0 {
+		return t, nil
+	}
+	roles, err := client.GetRolesContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := roleNames(roles)
+	for _, ra := range ras {
+		subject := ""
+		if user := ra.Relationships["user"].Data.Id; user != "" {
+			subject = "user:" + user
+		} else if group := ra.Relationships["group"].Data.Id; group != "" {
+			subject = "group:" + group
+		}
+		t.add(ra.Id, names[ra.Relationships["role"].Data.Id], subject, strings.TrimPrefix(ra.Attributes.Object, "urn:x-swip:"), ra.Attributes.ExpiresBy)
+	}
+	return t, nil
+}
@@ -0,0 +1,76 @@
+package api_cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api/fake"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func RunRoleAssignmentsTests() {
+	Describe("role assignments", func() {
+		var server *fake.Server
+		var client *api.Client
+		var project *fake.Project
+		ctx := context.Background()
+
+		BeforeEach(func() {
+			server = fake.NewServer(fake.DefaultConfig())
+			client = connectToFake(server)
+			project = server.AddProject("cerebros")
+			for _, email := range []string{"a@example.com", "b@example.com"} {
+				_, err := createUser(ctx, client, &UsersCreateArgs{Email: email, Name: email})
+				Expect(err).To(BeNil())
+			}
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		bulkFromCSV := func(contents string, del bool) error {
+			path := writeCSV(contents)
+			defer os.RemoveAll(filepath.Dir(path))
+			rows, err := readCSV(path, "user", "role", "project-id")
+			Expect(err).To(BeNil())
+			if del {
+				return bulk(rows, "delete role assignment", func(row map[string]string) error {
+					return deleteMatchingRoleAssignments(ctx, client, row["user"], row["role"], row["project-id"])
+				})
+			}
+			return bulk(rows, "create role assignment", func(row map[string]string) error {
+				_, err := createRoleAssignment(ctx, client, &RoleAssignmentsCreateArgs{User: row["user"], Role: row["role"], ProjectId: row["project-id"]})
+				return err
+			})
+		}
+
+		It("should create and delete role assignments from a CSV file, carrying on past a failing row", func() {
+			Expect(bulkFromCSV("user,role,project-id\n"+
+				"a@example.com,Contributor,"+project.Id+"\n"+
+				"b@example.com,No Such Role,"+project.Id+"\n"+
+				"b@example.com,contributor,"+project.Id+"\n", false)).To(MatchError("1 of 3 rows failed"))
+			ras, err := listRoleAssignments(ctx, client, &RoleAssignmentsListArgs{ProjectId: project.Id})
+			Expect(err).To(BeNil())
+			Expect(len(ras)).To(Equal(2))
+
+			t, err := roleAssignmentsTable(ctx, client, ras...)
+			Expect(err).To(BeNil())
+			for _, row := range t.rows {
+				Expect(row[1]).To(Equal("Contributor"))
+				Expect(row[3]).To(Equal("projects:" + project.Id))
+			}
+
+			Expect(bulkFromCSV("user,role,project-id\n"+
+				"a@example.com,Contributor,"+project.Id+"\n"+
+				"a@example.com,Contributor,"+project.Id+"\n", true)).To(MatchError("1 of 2 rows failed"))
+			ras, err = listRoleAssignments(ctx, client, &RoleAssignmentsListArgs{ProjectId: project.Id})
+			Expect(err).To(BeNil())
+			Expect(len(ras)).To(Equal(1))
+			Expect(ras[0].Relationships["user"].Data.Id).ToNot(BeEmpty())
+		})
+	})
+}
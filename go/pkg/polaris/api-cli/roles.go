@@ -0,0 +1,82 @@
+package api_cli
+
+import (
+	"context"
+	"strings"
+
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func SetupRolesCommand() *cobra.Command {
+	conn := &ConnectionArgs{}
+	command := &cobra.Command{
+		Use:   "roles",
+		Short: "show roles",
+		Long:  "list and get roles.  Polaris has a fixed set of roles, so they can't be created or deleted; use role-assignments to grant them.",
+		Args:  cobra.ExactArgs(0),
+	}
+	addConnectionFlags(command, conn)
+
+	command.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "list roles",
+		Args:  cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, as []string) {
+			client, err := conn.connect(cmd)
+			DoOrDie(err)
+			roles, err := client.GetRolesContext(cmd.Context())
+			DoOrDie(err)
+			DoOrDie(conn.printResult(roles.Data, rolesTable(roles, -1)))
+		},
+	})
+	command.AddCommand(&cobra.Command{
+		Use:   "get NAME_OR_ID",
+		Short: "get a role by name or id",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, as []string) {
+			client, err := conn.connect(cmd)
+			DoOrDie(err)
+			roles, index, err := findRole(cmd.Context(), client, as[0])
+			DoOrDie(err)
+			DoOrDie(conn.printResult(roles.Data[index], rolesTable(roles, index)))
+		},
+	})
+
+	return command
+}
+
+// findRole returns all the roles, and the index of the one whose id or name, ignoring case, is nameOrId
+func findRole(ctx context.Context, client *api.Client, nameOrId string) (*api.GetRolesResponse, int, error) {
+	roles, err := client.GetRolesContext(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i, role := range roles.Data {
+		if role.Id == nameOrId || strings.EqualFold(role.Attributes.RoleName, nameOrId) {
+			return roles, i, nil
+		}
+	}
+	return nil, 0, errors.Errorf("no role %s", nameOrId)
+}
+
+// roleNames maps role ids to names
+func roleNames(roles *api.GetRolesResponse) map[string]string {
+	names := map[string]string{}
+	for _, role := range roles.Data {
+		names[role.Id] = role.Attributes.RoleName
+	}
+	return names
+}
+
+// rolesTable tabulates the role at index, or every role if index is negative
+func rolesTable(roles *api.GetRolesResponse, index int) *table {
+	t := &table{header: []string{"ID", "NAME", "ORGANIZATION PERMISSIONS", "PROJECT PERMISSIONS"}}
+	for i, role := range roles.Data {
+		if index < 0 || i == index {
+			t.add(role.Id, role.Attributes.RoleName, strings.Join(role.Attributes.Permissions.Organization, ","), strings.Join(role.Attributes.Permissions.Project, ","))
+		}
+	}
+	return t
+}
@@ -1,23 +1,62 @@
 package api_cli
 
 import (
+	"context"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
 func Run() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-signals:
+			log.Warnf("interrupted, cancelling outstanding requests")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
 	rootCmd := SetupRootCommand()
-	if err := errors.Wrapf(rootCmd.Execute(), "run root command"); err != nil {
+	if err := errors.Wrapf(rootCmd.ExecuteContext(ctx), "run root command"); err != nil {
 		log.Fatalf("unable to run root command: %+v", err)
 		os.Exit(1)
 	}
 }
 
 type RootFlags struct {
-	LogLevel string
+	LogLevel          string
+	RequestTimeout    time.Duration
+	RequestsPerSecond float64
+	MaxInFlight       int
+	Organization      string
+}
+
+// requestTimeout, rateLimit and organization are set from RootFlags before any subcommand runs
+var requestTimeout = api.DefaultRequestTimeout
+var rateLimit = api.DefaultRateLimitConfig()
+var organization = ""
+
+func newClient(url string, email string, password string) *api.Client {
+	client := api.NewClient(url, email, password)
+	client.UseDefaultResilience()
+	client.RequestTimeout = requestTimeout
+	client.RateLimit = rateLimit
+	if organization != "" {
+		_, err := client.SelectOrganization(organization)
+		DoOrDie(err)
+	}
+	return client
 }
 
 func SetupRootCommand() *cobra.Command {
@@ -27,16 +66,34 @@ func SetupRootCommand() *cobra.Command {
 		Short: "polaris API client",
 		Long:  "polaris API client",
 		PersistentPreRunE: func(cmd *cobra.Command, as []string) error {
+			requestTimeout = args.RequestTimeout
+			rateLimit = &api.RateLimitConfig{Global: api.RateLimit{
+				RequestsPerSecond: args.RequestsPerSecond,
+				Burst:             int(args.RequestsPerSecond),
+				MaxInFlight:       args.MaxInFlight,
+			}}
+			organization = args.Organization
 			return SetUpLogger(args.LogLevel)
 		},
 	}
 
 	rootCmd.PersistentFlags().StringVarP(&args.LogLevel, "verbosity", "v", "info", "log level; one of [info, debug, trace, warn, error, fatal, panic]")
+	rootCmd.PersistentFlags().DurationVar(&args.RequestTimeout, "request-timeout", api.DefaultRequestTimeout, "timeout for each Polaris API request; 0 for none")
+	defaultLimit := api.DefaultRateLimitConfig().Global
+	rootCmd.PersistentFlags().Float64Var(&args.RequestsPerSecond, "max-requests-per-second", defaultLimit.RequestsPerSecond, "limit on Polaris API requests per second; 0 for none")
+	rootCmd.PersistentFlags().IntVar(&args.MaxInFlight, "max-in-flight", defaultLimit.MaxInFlight, "limit on concurrent Polaris API requests; 0 for none")
+	rootCmd.PersistentFlags().StringVar(&args.Organization, "organization", "", "name or id of the organization to work in; may be left out if there's only one")
 
 	rootCmd.AddCommand(SetupScanCommand())
 	rootCmd.AddCommand(SetupToolsCommand())
 	rootCmd.AddCommand(SetupCosCommand())
 	rootCmd.AddCommand(SetupAuthCommand())
+	rootCmd.AddCommand(SetupExportCommand())
+	rootCmd.AddCommand(SetupIssuesCommand())
+	rootCmd.AddCommand(SetupUsersCommand())
+	rootCmd.AddCommand(SetupRolesCommand())
+	rootCmd.AddCommand(SetupRoleAssignmentsCommand())
+	rootCmd.AddCommand(SetupTokensCommand())
 	//rootCmd.AddCommand(setupExampleCommand())
 
 	return rootCmd
@@ -6,6 +6,7 @@ import (
 	"os/user"
 	"path"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -87,8 +88,11 @@ func RunScan(args *ScanArgs) {
 			GitRepo: &synopsys_scancli.GitRepo{Repo: args.GithubRepo},
 		},
 	}
-	scanner, err := synopsys_scancli.NewScannerFromConfig(nil, polarisConfig, nil)
+	scanner, err := synopsys_scancli.NewScannerFromConfig(nil, polarisConfig, nil, nil)
 	DoOrDie(err)
 	err = scanner.Scan(scanConfig)
+	if closeErr := scanner.Close(); closeErr != nil {
+		log.Errorf("unable to clean up workspaces: %s", closeErr.Error())
+	}
 	DoOrDie(err)
 }
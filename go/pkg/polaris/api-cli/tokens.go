@@ -0,0 +1,201 @@
+package api_cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func SetupTokensCommand() *cobra.Command {
+	conn := &ConnectionArgs{}
+	command := &cobra.Command{
+		Use:   "tokens",
+		Short: "manage API tokens",
+		Long:  "list, get, create, revoke and delete the API tokens of the user logged in with --email",
+		Args:  cobra.ExactArgs(0),
+	}
+	addConnectionFlags(command, conn)
+
+	command.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "list API tokens",
+		Args:  cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, as []string) {
+			client, err := conn.connect(cmd)
+			DoOrDie(err)
+			tokens, err := listTokens(cmd.Context(), client)
+			DoOrDie(err)
+			DoOrDie(conn.printResult(tokens, tokensTable(tokens...)))
+		},
+	})
+	command.AddCommand(&cobra.Command{
+		Use:   "get ID_OR_NAME",
+		Short: "get an API token by id or name",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, as []string) {
+			client, err := conn.connect(cmd)
+			DoOrDie(err)
+			token, err := findToken(cmd.Context(), client, as[0])
+			DoOrDie(err)
+			DoOrDie(conn.printResult(token, tokensTable(token)))
+		},
+	})
+	command.AddCommand(&cobra.Command{
+		Use:   "create NAME",
+		Short: "create an API token, printing its secret value",
+		Long:  "create an API token, printing its secret value.  Polaris doesn't show the value again, so keep it somewhere safe.",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, as []string) {
+			client, err := conn.connect(cmd)
+			DoOrDie(err)
+			token, err := createToken(cmd.Context(), client, as[0])
+			DoOrDie(err)
+			DoOrDie(conn.printResult(token, createdTokensTable(token)))
+		},
+	})
+	command.AddCommand(SetupTokensDeleteCommand(conn))
+	command.AddCommand(SetupTokensBulkCommand(conn))
+
+	return command
+}
+
+func SetupTokensDeleteCommand(conn *ConnectionArgs) *cobra.Command {
+	revoke := false
+	command := &cobra.Command{
+		Use:   "delete ID_OR_NAME",
+		Short: "delete or revoke an API token by id or name",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, as []string) {
+			client, err := conn.connect(cmd)
+			DoOrDie(err)
+			DoOrDie(deleteToken(cmd.Context(), client, as[0], revoke))
+		},
+	}
+	command.Flags().BoolVar(&revoke, "revoke", false, "only revoke the token, keeping its record")
+	return command
+}
+
+func SetupTokensBulkCommand(conn *ConnectionArgs) *cobra.Command {
+	path := ""
+	del := false
+	revoke := false
+	command := &cobra.Command{
+		Use:   "bulk",
+		Short: "create or delete the API tokens listed in a CSV file",
+		Long: "create the API tokens listed in a CSV file, printing their secret values, or delete them with --delete.  " +
+			"The file needs a header row with a name column.",
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, as []string) {
+			client, err := conn.connect(cmd)
+			DoOrDie(err)
+			ctx := cmd.Context()
+			rows, err := readCSV(path, "name")
+			DoOrDie(err)
+			if del || revoke {
+				DoOrDie(bulk(rows, "delete token", func(row map[string]string) error {
+					return deleteToken(ctx, client, row["name"], revoke)
+				}))
+				return
+			}
+			created := []*createdToken{}
+			err = bulk(rows, "create token", func(row map[string]string) error {
+				token, err := createToken(ctx, client, row["name"])
+				if err == nil {
+					created = append(created, token)
+				}
+				return err
+			})
+			DoOrDie(conn.printResult(created, createdTokensTable(created...)))
+			DoOrDie(err)
+		},
+	}
+	command.Flags().StringVar(&path, "file", "", "CSV file of tokens")
+	command.MarkFlagRequired("file")
+	command.Flags().BoolVar(&del, "delete", false, "delete the tokens, matched by name, instead of creating them")
+	command.Flags().BoolVar(&revoke, "revoke", false, "revoke the tokens, matched by name, instead of creating them")
+	return command
+}
+
+// createdToken is the only time a token's secret value is available
+type createdToken struct {
+	Name        string `json:"name"`
+	AccessToken string `json:"access-token"`
+}
+
+func createToken(ctx context.Context, client *api.Client, name string) (*createdToken, error) {
+	created, err := client.GetAccessTokenContext(ctx, name)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "unable to create token %s", name)
+	}
+	return &createdToken{Name: name, AccessToken: created.Data.Attributes.AccessToken}, nil
+}
+
+func deleteToken(ctx context.Context, client *api.Client, idOrName string, revoke bool) error {
+	token, err := findToken(ctx, client, idOrName)
+	if err != nil {
+		return err
+	}
+	if revoke {
+		if err := client.RevokeAccessTokenContext(ctx, token.Id); err != nil {
+			return err
+		}
+		log.Infof("revoked token %s (%s)", token.Attributes.Name, token.Id)
+		return nil
+	}
+	if err := client.DeleteAccessTokenContext(ctx, token.Id); err != nil {
+		return err
+	}
+	log.Infof("deleted token %s (%s)", token.Attributes.Name, token.Id)
+	return nil
+}
+
+func listTokens(ctx context.Context, client *api.Client) ([]*api.AccessToken, error) {
+	items, err := client.AccessTokensPaginator(100).All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make([]*api.AccessToken, len(items))
+	for i, item := range items {
+		tokens[i] = item.(*api.AccessToken)
+	}
+	return tokens, nil
+}
+
+// findToken looks a token up by id or, failing that, by name
+func findToken(ctx context.Context, client *api.Client, idOrName string) (*api.AccessToken, error) {
+	tokens, err := listTokens(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	for _, token := range tokens {
+		if token.Id == idOrName {
+			return token, nil
+		}
+	}
+	for _, token := range tokens {
+		if token.Attributes.Name == idOrName {
+			return token, nil
+		}
+	}
+	return nil, errors.Errorf("no token %s", idOrName)
+}
+
+func tokensTable(tokens ...*api.AccessToken) *table {
+	t := &table{header: []string{"ID", "NAME", "CREATED", "REVOKED"}}
+	for _, token := range tokens {
+		t.add(token.Id, token.Attributes.Name, token.Attributes.DateCreated, fmt.Sprintf("%t", token.Attributes.Revoked))
+	}
+	return t
+}
+
+func createdTokensTable(tokens ...*createdToken) *table {
+	t := &table{header: []string{"NAME", "ACCESS TOKEN"}}
+	for _, token := range tokens {
+		t.add(token.Name, token.AccessToken)
+	}
+	return t
+}
@@ -1,10 +1,10 @@
 package api_cli
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
-	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
 	"github.com/go-resty/resty/v2"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -41,7 +41,7 @@ func SetupToolsDebugCommand() *cobra.Command {
 		Long:  "check tools state",
 		Args:  cobra.ExactArgs(0),
 		Run: func(cmd *cobra.Command, as []string) {
-			RunToolsDebug(args)
+			RunToolsDebug(cmd.Context(), args)
 		},
 	}
 
@@ -56,19 +56,22 @@ func SetupToolsDebugCommand() *cobra.Command {
 	return command
 }
 
-func RunToolsDebug(args *ToolsDebugArgs) {
-	polarisClient := api.NewClient(args.PolarisURL, args.Email, args.Password)
+func RunToolsDebug(ctx context.Context, args *ToolsDebugArgs) {
+	polarisClient := newClient(args.PolarisURL, args.Email, args.Password)
 
-	err := polarisClient.Authenticate()
+	err := polarisClient.AuthenticateContext(ctx)
 	DoOrDie(err)
 
-	tools, err := polarisClient.GetTools(25)
+	tools, err := polarisClient.GetToolsContext(ctx, 25)
 	DoOrDie(err)
 	fmt.Printf("GET to api/common/v0/tools: %+v\n", tools)
 
-	toolIds, err := polarisClient.QueryV0DiscoveryFilterKeysIssuetoolidValues()
+	toolIds, err := polarisClient.QueryV0DiscoveryFilterKeysIssuetoolidValuesContext(ctx)
 	DoOrDie(err)
-	fmt.Printf("GET to api/query/v0/discovery/filter-keys/issue.tool.id/values: %s\n", toolIds)
+	fmt.Printf("GET to api/query/v0/discovery/filter-keys/issue.tool.id/values: %d values\n", len(toolIds.Data))
+	for _, toolId := range toolIds.Data {
+		fmt.Printf("  %s: %s\n", toolId.Attributes.Value, toolId.Attributes.DisplayValue)
+	}
 }
 
 type PostToolsGetCurlCommandArgs struct {
@@ -88,7 +91,7 @@ func SetupPostToolsGetCurlCommandCommand() *cobra.Command {
 		Long:  "this should fix an issue with local scans not working",
 		Args:  cobra.ExactArgs(0),
 		Run: func(cmd *cobra.Command, as []string) {
-			RunPostToolsGetCurlCommand(args)
+			RunPostToolsGetCurlCommand(cmd.Context(), args)
 		},
 	}
 
@@ -122,14 +125,14 @@ const CurlTemplate = `curl --fail --verbose --location --request POST '%s/api/co
     }'
 `
 
-func RunPostToolsGetCurlCommand(args *PostToolsGetCurlCommandArgs) {
-	polarisClient := api.NewClient(args.PolarisURL, args.Email, args.Password)
+func RunPostToolsGetCurlCommand(ctx context.Context, args *PostToolsGetCurlCommandArgs) {
+	polarisClient := newClient(args.PolarisURL, args.Email, args.Password)
 
-	err := polarisClient.Authenticate()
+	err := polarisClient.AuthenticateContext(ctx)
 	DoOrDie(err)
 
 	if args.UseAccessToken {
-		token, err := polarisClient.GetAccessToken(args.TokenName)
+		token, err := polarisClient.GetAccessTokenContext(ctx, args.TokenName)
 		DoOrDie(err)
 
 		tokenCommand := fmt.Sprintf(CurlTemplate, args.PolarisURL, token.Data.Attributes.AccessToken)
@@ -156,7 +159,7 @@ func SetupPostToolsCommand() *cobra.Command {
 		Long:  "this should fix an issue with local scans not working",
 		Args:  cobra.ExactArgs(0),
 		Run: func(cmd *cobra.Command, as []string) {
-			RunPostTools(args)
+			RunPostTools(cmd.Context(), args)
 		},
 	}
 
@@ -173,10 +176,10 @@ func SetupPostToolsCommand() *cobra.Command {
 	return command
 }
 
-func RunPostTools(args *PostToolsArgs) {
-	polarisClient := api.NewClient(args.PolarisURL, args.Email, args.Password)
+func RunPostTools(ctx context.Context, args *PostToolsArgs) {
+	polarisClient := newClient(args.PolarisURL, args.Email, args.Password)
 
-	err := polarisClient.Authenticate()
+	err := polarisClient.AuthenticateContext(ctx)
 	DoOrDie(err)
 
 	if args.Certfile != "" {
@@ -200,7 +203,7 @@ func RunPostTools(args *PostToolsArgs) {
 		polarisClient.RestyClient.SetRedirectPolicy(resty.FlexibleRedirectPolicy(200000))
 	}
 
-	out, err := polarisClient.PostTools()
+	out, err := polarisClient.PostToolsContext(ctx)
 	log.Infof("post tools response: %s", out)
 	DoOrDie(err)
 }
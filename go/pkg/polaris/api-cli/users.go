@@ -0,0 +1,224 @@
+package api_cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func SetupUsersCommand() *cobra.Command {
+	conn := &ConnectionArgs{}
+	command := &cobra.Command{
+		Use:   "users",
+		Short: "manage users",
+		Long:  "list, get, create and delete users and service accounts",
+		Args:  cobra.ExactArgs(0),
+	}
+	addConnectionFlags(command, conn)
+
+	command.AddCommand(SetupUsersListCommand(conn))
+	command.AddCommand(SetupUsersGetCommand(conn))
+	command.AddCommand(SetupUsersCreateCommand(conn))
+	command.AddCommand(SetupUsersDeleteCommand(conn))
+	command.AddCommand(SetupUsersBulkCommand(conn))
+
+	return command
+}
+
+func SetupUsersListCommand(conn *ConnectionArgs) *cobra.Command {
+	pageSize := 0
+	command := &cobra.Command{
+		Use:   "list",
+		Short: "list users",
+		Args:  cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, as []string) {
+			client, err := conn.connect(cmd)
+			DoOrDie(err)
+			items, err := client.UsersPaginator(pageSize).All(cmd.Context())
+			DoOrDie(err)
+			users := make([]*api.User, len(items))
+			for i, item := range items {
+				users[i] = item.(*api.User)
+			}
+			DoOrDie(conn.printResult(users, usersTable(users...)))
+		},
+	}
+	command.Flags().IntVar(&pageSize, "page-size", 100, "number of users to fetch per request")
+	return command
+}
+
+func SetupUsersGetCommand(conn *ConnectionArgs) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get ID_OR_EMAIL",
+		Short: "get a user by id or email",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, as []string) {
+			client, err := conn.connect(cmd)
+			DoOrDie(err)
+			user, err := findUser(cmd.Context(), client, as[0])
+			DoOrDie(err)
+			DoOrDie(conn.printResult(user, usersTable(user)))
+		},
+	}
+}
+
+type UsersCreateArgs struct {
+	Email    string
+	Name     string
+	Password string
+}
+
+func SetupUsersCreateCommand(conn *ConnectionArgs) *cobra.Command {
+	args := &UsersCreateArgs{}
+	command := &cobra.Command{
+		Use:   "create",
+		Short: "create a user, or a service account if a password is given",
+		Args:  cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, as []string) {
+			client, err := conn.connect(cmd)
+			DoOrDie(err)
+			user, err := createUser(cmd.Context(), client, args)
+			DoOrDie(err)
+			DoOrDie(conn.printResult(user, usersTable(user)))
+		},
+	}
+	command.Flags().StringVar(&args.Email, "user-email", "", "email of the new user")
+	command.MarkFlagRequired("user-email")
+	command.Flags().StringVar(&args.Name, "user-name", "", "name of the new user")
+	command.MarkFlagRequired("user-name")
+	command.Flags().StringVar(&args.Password, "user-password", "", "password of the new service account; leave out to create a regular user")
+	return command
+}
+
+func SetupUsersDeleteCommand(conn *ConnectionArgs) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete ID_OR_EMAIL",
+		Short: "delete a user by id or email, along with their role assignments",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, as []string) {
+			client, err := conn.connect(cmd)
+			DoOrDie(err)
+			user, err := findUser(cmd.Context(), client, as[0])
+			DoOrDie(err)
+			DoOrDie(deleteUser(cmd.Context(), client, user))
+			log.Infof("deleted user %s (%s)", user.Attributes.Email, user.Id)
+		},
+	}
+}
+
+func SetupUsersBulkCommand(conn *ConnectionArgs) *cobra.Command {
+	path := ""
+	del := false
+	command := &cobra.Command{
+		Use:   "bulk",
+		Short: "create or delete the users listed in a CSV file",
+		Long: "create the users listed in a CSV file, or delete them and their role assignments with --delete.  The file needs a header row with an email " +
+			"column, and a name column to create users; rows with a password are created as service accounts.",
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, as []string) {
+			client, err := conn.connect(cmd)
+			DoOrDie(err)
+			ctx := cmd.Context()
+			if del {
+				rows, err := readCSV(path, "email")
+				DoOrDie(err)
+				DoOrDie(bulk(rows, "delete user", func(row map[string]string) error {
+					user, err := findUser(ctx, client, row["email"])
+					if err != nil {
+						return err
+					}
+					return deleteUser(ctx, client, user)
+				}))
+				return
+			}
+			rows, err := readCSV(path, "email", "name")
+			DoOrDie(err)
+			created := []*api.User{}
+			err = bulk(rows, "create user", func(row map[string]string) error {
+				user, err := createUser(ctx, client, &UsersCreateArgs{Email: row["email"], Name: row["name"], Password: row["password"]})
+				if err == nil {
+					created = append(created, user)
+				}
+				return err
+			})
+			DoOrDie(conn.printResult(created, usersTable(created...)))
+			DoOrDie(err)
+		},
+	}
+	command.Flags().StringVar(&path, "file", "", "CSV file of users")
+	command.MarkFlagRequired("file")
+	command.Flags().BoolVar(&del, "delete", false, "delete the users, matched by email, instead of creating them")
+	return command
+}
+
+// deleteUser deletes the user's role assignments, and then the user
+func deleteUser(ctx context.Context, client *api.Client, user *api.User) error {
+	ras := []*api.RoleAssignment{}
+	for offset, limit := 0, 100; ; offset += limit {
+		response, err := client.GetRoleAssignmentsForUserContext(ctx, user.Attributes.Email, offset, limit, user.Attributes.Automated)
+		if err != nil {
+			return errors.WithMessagef(err, "unable to find the role assignments of %s", user.Attributes.Email)
+		}
+		ras = append(ras, response.Data...)
+		if len(response.Data) < limit {
+			break
+		}
+	}
+	for _, ra := range ras {
+		if ra.Relationships["user"].Data.Id != user.Id {
+			continue
+		}
+		if err := client.DeleteRoleAssignmentContext(ctx, ra.Id); err != nil {
+			return errors.WithMessagef(err, "unable to delete role assignment %s of %s", ra.Id, user.Attributes.Email)
+		}
+		log.Debugf("deleted role assignment %s of %s", ra.Id, user.Attributes.Email)
+	}
+	return client.DeleteUserContext(ctx, user.Id)
+}
+
+func createUser(ctx context.Context, client *api.Client, args *UsersCreateArgs) (*api.User, error) {
+	var created *api.CreateUserResponse
+	var err error
+	if args.Password != "" {
+		created, err = client.CreateServiceAccountContext(ctx, args.Email, args.Name, "", args.Password)
+	} else {
+		created, err = client.CreateUserContext(ctx, args.Email, args.Name, "")
+	}
+	if err != nil {
+		return nil, errors.WithMessagef(err, "unable to create user %s", args.Email)
+	}
+	return &created.Data, nil
+}
+
+// findUser looks a user up by email if idOrEmail looks like one, and by id otherwise
+func findUser(ctx context.Context, client *api.Client, idOrEmail string) (*api.User, error) {
+	if !strings.Contains(idOrEmail, "@") {
+		user, err := client.GetUserContext(ctx, idOrEmail)
+		if err != nil {
+			return nil, err
+		}
+		return user.Data, nil
+	}
+	users, err := client.GetUserByEmailContext(ctx, idOrEmail)
+	if err != nil {
+		return nil, err
+	}
+	if len(users.Data) == 0 {
+		return nil, errors.Errorf("no user with email %s", idOrEmail)
+	}
+	return users.Data[0], nil
+}
+
+func usersTable(users ...*api.User) *table {
+	t := &table{header: []string{"ID", "EMAIL", "NAME", "USERNAME", "ENABLED", "SERVICE ACCOUNT", "OWNER"}}
+	for _, user := range users {
+		t.add(user.Id, user.Attributes.Email, user.Attributes.Name, user.Attributes.Username,
+			fmt.Sprintf("%t", user.Attributes.Enabled), fmt.Sprintf("%t", user.Attributes.Automated), fmt.Sprintf("%t", user.Attributes.Owner))
+	}
+	return t
+}
@@ -0,0 +1,123 @@
+package api_cli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api/fake"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// connectToFake logs in to server as its owner, the way every command does
+func connectToFake(server *fake.Server) *api.Client {
+	client := newClient(server.URL, server.Config.Email, server.Config.Password)
+	Expect(client.AuthenticateContext(context.Background())).To(Succeed())
+	return client
+}
+
+func RunUsersTests() {
+	Describe("users", func() {
+		var server *fake.Server
+		var client *api.Client
+		ctx := context.Background()
+
+		BeforeEach(func() {
+			server = fake.NewServer(fake.DefaultConfig())
+			client = connectToFake(server)
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should create users from a CSV file, carrying on past a failing row", func() {
+			path := writeCSV("email,name,password\n" +
+				"a@example.com,A,\n" +
+				server.Config.Email + ",Duplicate,\n" +
+				"bot@example.com,Bot,secret\n")
+			defer os.RemoveAll(filepath.Dir(path))
+			rows, err := readCSV(path, "email", "name")
+			Expect(err).To(BeNil())
+
+			created := []*api.User{}
+			err = bulk(rows, "create user", func(row map[string]string) error {
+				user, err := createUser(ctx, client, &UsersCreateArgs{Email: row["email"], Name: row["name"], Password: row["password"]})
+				if err == nil {
+					created = append(created, user)
+				}
+				return err
+			})
+			Expect(err).To(MatchError("1 of 3 rows failed"))
+			Expect(len(created)).To(Equal(2))
+
+			user, err := findUser(ctx, client, "a@example.com")
+			Expect(err).To(BeNil())
+			Expect(user.Attributes.Automated).To(BeFalse())
+			bot, err := findUser(ctx, client, "bot@example.com")
+			Expect(err).To(BeNil())
+			Expect(bot.Attributes.Automated).To(BeTrue())
+			Expect(api.NewClient(server.URL, "bot@example.com", "secret").Authenticate()).To(Succeed())
+		})
+
+		It("should delete users from a CSV file, carrying on past a failing row", func() {
+			for _, email := range []string{"a@example.com", "b@example.com"} {
+				_, err := createUser(ctx, client, &UsersCreateArgs{Email: email, Name: email})
+				Expect(err).To(BeNil())
+			}
+			path := writeCSV("email\na@example.com\nnobody@example.com\nb@example.com\n")
+			defer os.RemoveAll(filepath.Dir(path))
+			rows, err := readCSV(path, "email")
+			Expect(err).To(BeNil())
+
+			err = bulk(rows, "delete user", func(row map[string]string) error {
+				user, err := findUser(ctx, client, row["email"])
+				if err != nil {
+					return err
+				}
+				return deleteUser(ctx, client, user)
+			})
+			Expect(err).To(MatchError("1 of 3 rows failed"))
+			for _, email := range []string{"a@example.com", "b@example.com"} {
+				_, err = findUser(ctx, client, email)
+				Expect(err).To(MatchError(fmt.Sprintf("no user with email %s", email)))
+			}
+		})
+
+		It("should delete a user's role assignments along with them, and leave other users' alone", func() {
+			project := server.AddProject("cerebros")
+			doomed, err := createUser(ctx, client, &UsersCreateArgs{Email: "doomed@example.com", Name: "Doomed", Password: "secret"})
+			Expect(err).To(BeNil())
+			survivor, err := createUser(ctx, client, &UsersCreateArgs{Email: "survivor@example.com", Name: "Survivor"})
+			Expect(err).To(BeNil())
+			doomedRa, err := createRoleAssignment(ctx, client, &RoleAssignmentsCreateArgs{User: doomed.Id, Role: "contributor", ProjectId: project.Id})
+			Expect(err).To(BeNil())
+			survivorRa, err := createRoleAssignment(ctx, client, &RoleAssignmentsCreateArgs{User: survivor.Attributes.Email, Role: "Contributor", ProjectId: project.Id})
+			Expect(err).To(BeNil())
+
+			Expect(deleteUser(ctx, client, doomed)).To(Succeed())
+
+			Expect(server.RequestCount(http.MethodDelete, "/api/auth/role-assignments/"+doomedRa.Id)).To(Equal(1))
+			Expect(server.RequestCount(http.MethodDelete, "/api/auth/role-assignments/"+survivorRa.Id)).To(Equal(0))
+			Expect(server.RequestCount(http.MethodDelete, "/api/auth/users/"+doomed.Id)).To(Equal(1))
+			_, err = findUser(ctx, client, doomed.Id)
+			Expect(err).ToNot(BeNil())
+			ras, err := listRoleAssignments(ctx, client, &RoleAssignmentsListArgs{PageSize: 10})
+			Expect(err).To(BeNil())
+			Expect(len(ras)).To(Equal(1))
+			Expect(ras[0].Id).To(Equal(survivorRa.Id))
+		})
+
+		It("should print users as a table", func() {
+			user, err := findUser(ctx, client, server.Config.Email)
+			Expect(err).To(BeNil())
+			t := usersTable(user)
+			Expect(t.header).To(Equal([]string{"ID", "EMAIL", "NAME", "USERNAME", "ENABLED", "SERVICE ACCOUNT", "OWNER"}))
+			Expect(t.rows).To(Equal([][]string{{server.OwnerId, server.Config.Email, "Owner", "owner", "true", "false", "true"}}))
+		})
+	})
+}
@@ -21,9 +21,12 @@ under the License.
 package api_load
 
 import (
-	"github.com/pkg/errors"
+	"time"
+
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
+	utilconfig "github.com/blackducksoftware/cerebros/go/pkg/util/config"
+	"github.com/blackducksoftware/cerebros/go/pkg/util/tracing"
 	log "github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
 )
 
 type LoadGeneratorConfig struct {
@@ -31,13 +34,39 @@ type LoadGeneratorConfig struct {
 }
 
 type DataSeederConfig struct {
+	// Organization is the name or id of the organization to seed; it can be
+	// left out if there's only one
+	Organization  string
 	Concurrency   int
 	UsersToCreate int
+	// Cleanup deletes the seeded users and role assignments on shutdown
+	Cleanup bool
+}
+
+// ResponseCacheConfig turns on api.Client's response cache.  Leave it out to
+// send every request to Polaris.
+type ResponseCacheConfig struct {
+	MaxEntries        int
+	DefaultTTLSeconds int
+	// PathTTLSeconds is keyed by path template, e.g. "api/auth/roles"
+	PathTTLSeconds map[string]int
+}
+
+func (rcc *ResponseCacheConfig) CacheConfig() *api.CacheConfig {
+	config := &api.CacheConfig{
+		MaxEntries: rcc.MaxEntries,
+		DefaultTTL: time.Duration(rcc.DefaultTTLSeconds) * time.Second,
+		PathTTLs:   map[string]time.Duration{},
+	}
+	for path, seconds := range rcc.PathTTLSeconds {
+		config.PathTTLs[path] = time.Duration(seconds) * time.Second
+	}
+	return config
 }
 
 type Config struct {
-	PolarisURL      string
-	PolarisEmail    string
+	PolarisURL      string `config:"required"`
+	PolarisEmail    string `config:"required"`
 	PolarisPassword string
 
 	LogLevel string
@@ -46,6 +75,12 @@ type Config struct {
 
 	LoadGenerator *LoadGeneratorConfig
 	DataSeeder    *DataSeederConfig
+	ResponseCache *ResponseCacheConfig
+	// Tracing, if set, exports a span per API call
+	Tracing *tracing.Config
+	// RetryAndCircuitBreak turns on the client's default retries and circuit
+	// breakers.  They're off by default so that every failure is measured.
+	RetryAndCircuitBreak bool
 }
 
 // GetLogLevel ...
@@ -55,18 +90,10 @@ func (config *Config) GetLogLevel() (log.Level, error) {
 
 // GetConfig ...
 func GetConfig(configPath string) (*Config, error) {
-	var config *Config
-
-	viper.SetConfigFile(configPath)
-	err := viper.ReadInConfig()
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to ReadInConfig at %s", configPath)
-	}
-
-	err = viper.Unmarshal(&config)
+	config := &Config{}
+	err := utilconfig.Load(configPath, config, &utilconfig.Options{EnvPrefix: "POLARIS_API_LOAD"})
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to unmarshal config at %s", configPath)
+		return nil, err
 	}
-
 	return config, nil
 }
@@ -21,11 +21,14 @@ under the License.
 package api_load
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -48,10 +51,18 @@ type DataSeeder struct {
 	Roles           map[string]string
 	Organizations   map[string]string
 	RoleAssignments map[string]*RoleAssignment
+
+	// the goroutines started by CreateRoleAssignments
+	workers *sync.WaitGroup
+
+	// what we created, so that Cleanup can remove it
+	createdMux             *sync.Mutex
+	createdUserIds         []string
+	createdRoleAssignments []string
 }
 
 func NewDataSeeder(client *api.Client, usersToCreate int, concurrency int) (*DataSeeder, error) {
-	ds := &DataSeeder{Client: client, UsersToCreate: usersToCreate, Concurrency: concurrency}
+	ds := &DataSeeder{Client: client, UsersToCreate: usersToCreate, Concurrency: concurrency, workers: &sync.WaitGroup{}, createdMux: &sync.Mutex{}}
 	err := ds.setupData()
 	return ds, errors.WithMessagef(err, "unable to set up DataSeeder")
 }
@@ -179,13 +190,15 @@ func (ds *DataSeeder) CreateRoleAssignments(stop <-chan struct{}) error {
 		}
 	}
 
+	ds.workers.Add(1 + 2*ds.Concurrency)
 	go func() {
+		defer ds.workers.Done()
 		jobId := 0
 		for {
 			var userId string
 			select {
 			case <-stop:
-				break
+				return
 			case userId = <-didCreateUser:
 			}
 			for projectId, _ := range ds.Projects {
@@ -203,9 +216,11 @@ func (ds *DataSeeder) CreateRoleAssignments(stop <-chan struct{}) error {
 
 	for workerId := 0; workerId < ds.Concurrency; workerId++ {
 		go func(workerId int) {
+			defer ds.workers.Done()
 			ds.createUsersHelper(workerId, userJobs, didCreateUser, stop)
 		}(workerId)
 		go func(workerId int) {
+			defer ds.workers.Done()
 			ds.createRoleAssignmentsHelper(workerId, roleAssignmentJobs, stop)
 		}(workerId)
 	}
@@ -213,6 +228,23 @@ func (ds *DataSeeder) CreateRoleAssignments(stop <-chan struct{}) error {
 	return nil
 }
 
+// Wait blocks until the goroutines started by CreateRoleAssignments have returned, which they do
+// once stop is closed and any request they're in the middle of has finished.
+func (ds *DataSeeder) Wait() {
+	ds.workers.Wait()
+}
+
+// stopped checks stop without blocking, so that a worker with a job ready doesn't start it after
+// being stopped
+func stopped(stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
+}
+
 type CreateUserJob struct {
 	JobId int
 	Email string
@@ -225,9 +257,12 @@ func (ds *DataSeeder) createUsersHelper(workerId int, jobs <-chan *CreateUserJob
 		var job *CreateUserJob
 		select {
 		case <-stop:
-			break
+			return
 		case job = <-jobs:
 		}
+		if stopped(stop) {
+			return
+		}
 
 		userId, ok := ds.UserNameToId[job.Name]
 		// user doesn't exist already?  let's create them
@@ -236,6 +271,9 @@ func (ds *DataSeeder) createUsersHelper(workerId int, jobs <-chan *CreateUserJob
 			recordEvent("create_user", err)
 			log.Debugf("worker %d, job %d, create user %s, success? %t", workerId, job.JobId, job.Email, err == nil)
 			if err == nil {
+				ds.createdMux.Lock()
+				ds.createdUserIds = append(ds.createdUserIds, user.Data.Id)
+				ds.createdMux.Unlock()
 				didCreateUser <- user.Data.Id
 			} else {
 				log.Errorf("unable to create user: %s", err)
@@ -253,22 +291,69 @@ func (ds *DataSeeder) createRoleAssignmentsHelper(workerId int, jobs <-chan *Cre
 		var job *CreateRoleAssignmentJob
 		select {
 		case <-stop:
-			break
+			return nil
 		case job = <-jobs:
 		}
+		if stopped(stop) {
+			return nil
+		}
 
-		_, err := ds.Client.CreateRoleAssignment(job.UserId, job.RoleId, job.ProjectId, job.OrgId)
+		body, err := ds.Client.CreateRoleAssignment(job.UserId, job.RoleId, job.ProjectId, job.OrgId)
 		recordEvent("create_role_assignment", err)
 		if err != nil {
 			log.Errorf("worker %d: job %d, unable to create role assignment: data %+v, error %s", workerId, job.JobId, job, err)
 		} else {
 			log.Infof("worker %d: job %d, created role assignment: data %+v", workerId, job.JobId, job)
+			created := struct {
+				Data api.RoleAssignment
+			}{}
+			if err := json.Unmarshal([]byte(body), &created); err != nil || created.Data.Id == "" {
+				log.Errorf("worker %d: job %d, unable to find id of created role assignment, it won't be cleaned up: %s", workerId, job.JobId, body)
+			} else {
+				ds.createdMux.Lock()
+				ds.createdRoleAssignments = append(ds.createdRoleAssignments, created.Data.Id)
+				ds.createdMux.Unlock()
+			}
 		}
 
 		time.Sleep(500 * time.Millisecond)
 	}
 }
 
+// Cleanup deletes the role assignments and users created by CreateRoleAssignments.
+// Close CreateRoleAssignments' stop channel first: Cleanup waits for its workers to
+// return, so that nothing they create in the meantime is left behind.
+func (ds *DataSeeder) Cleanup(ctx context.Context) error {
+	ds.Wait()
+	ds.createdMux.Lock()
+	roleAssignmentIds, userIds := ds.createdRoleAssignments, ds.createdUserIds
+	ds.createdRoleAssignments, ds.createdUserIds = nil, nil
+	ds.createdMux.Unlock()
+
+	log.Infof("cleaning up %d role assignments and %d users", len(roleAssignmentIds), len(userIds))
+	failures := 0
+	for _, id := range roleAssignmentIds {
+		err := ds.Client.DeleteRoleAssignmentContext(ctx, id)
+		recordEvent("delete_role_assignment", err)
+		if err != nil {
+			log.Errorf("unable to delete role assignment %s: %s", id, err)
+			failures++
+		}
+	}
+	for _, id := range userIds {
+		err := ds.Client.DeleteUserContext(ctx, id)
+		recordEvent("delete_user", err)
+		if err != nil {
+			log.Errorf("unable to delete user %s: %s", id, err)
+			failures++
+		}
+	}
+	if failures > 0 {
+		return errors.New(fmt.Sprintf("unable to delete %d out of %d seeded resources", failures, len(roleAssignmentIds)+len(userIds)))
+	}
+	return nil
+}
+
 func (ds *DataSeeder) getRole() (string, string, error) {
 	if len(ds.Roles) == 0 {
 		return "", "", errors.New(fmt.Sprintf("unable to create role assignments: no roles found"))
@@ -280,15 +365,17 @@ func (ds *DataSeeder) getRole() (string, string, error) {
 	return id, name, nil
 }
 
+// getOrg returns the client's organization, choosing the only one if none was selected
 func (ds *DataSeeder) getOrg() (string, string, error) {
-	if len(ds.Organizations) == 0 {
-		return "", "", errors.New(fmt.Sprintf("unable to create role assignments: no orgs found"))
-	}
-	var id, name string
-	for id, name = range ds.Organizations {
-		break
+	id := ds.Client.OrganizationId()
+	if id == "" {
+		org, err := ds.Client.SelectOrganization("")
+		if err != nil {
+			return "", "", errors.WithMessagef(err, "unable to create role assignments")
+		}
+		id = org.Id
 	}
-	return id, name, nil
+	return id, ds.Organizations[id], nil
 }
 
 func (ds *DataSeeder) oldCreateRoleAssignmentsDeprecated() error {
@@ -0,0 +1,99 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api_load
+
+import (
+	"context"
+	"time"
+
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api/fake"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func RunDataSeederTests() {
+	Describe("DataSeeder", func() {
+		It("should create role assignments and clean them up", func() {
+			server := fake.NewServer(fake.DefaultConfig())
+			defer server.Close()
+			server.AddProject("cerebros")
+			server.AddProject("perceptor")
+			client := api.NewClient(server.URL, server.Config.Email, server.Config.Password)
+
+			ds, err := NewDataSeeder(client, 2, 2)
+			Expect(err).To(BeNil())
+			Expect(ds.Projects).To(HaveLen(2))
+			Expect(ds.Users).To(HaveLen(1))
+
+			stop := make(chan struct{})
+			Expect(ds.CreateRoleAssignments(stop)).To(Succeed())
+			// one role assignment per user per project
+			Eventually(func() int {
+				ds.createdMux.Lock()
+				defer ds.createdMux.Unlock()
+				return len(ds.createdRoleAssignments)
+			}, 10*time.Second, 50*time.Millisecond).Should(Equal(4))
+			close(stop)
+
+			roleAssignments, err := client.GetRoleAssignments(0, 100)
+			Expect(err).To(BeNil())
+			Expect(roleAssignments.Data).To(HaveLen(4))
+			ds.Wait()
+			ds.createdMux.Lock()
+			Expect(ds.createdUserIds).To(HaveLen(2))
+			ds.createdMux.Unlock()
+
+			Expect(ds.Cleanup(context.Background())).To(Succeed())
+			roleAssignments, err = client.GetRoleAssignments(0, 100)
+			Expect(err).To(BeNil())
+			Expect(roleAssignments.Data).To(BeEmpty())
+			users, err := client.GetUsers(0, 100)
+			Expect(err).To(BeNil())
+			Expect(users.Data).To(HaveLen(1))
+		})
+
+		It("should clean up what its workers create after being stopped", func() {
+			server := fake.NewServer(fake.DefaultConfig())
+			defer server.Close()
+			server.AddProject("cerebros")
+			client := api.NewClient(server.URL, server.Config.Email, server.Config.Password)
+
+			ds, err := NewDataSeeder(client, 5, 5)
+			Expect(err).To(BeNil())
+			stop := make(chan struct{})
+			Expect(ds.CreateRoleAssignments(stop)).To(Succeed())
+			// stop while the first users are being created
+			Eventually(func() int {
+				return server.RequestCount("POST", "/api/auth/users")
+			}, 10*time.Second, time.Millisecond).ShouldNot(BeZero())
+			close(stop)
+
+			Expect(ds.Cleanup(context.Background())).To(Succeed())
+			roleAssignments, err := client.GetRoleAssignments(0, 100)
+			Expect(err).To(BeNil())
+			Expect(roleAssignments.Data).To(BeEmpty())
+			users, err := client.GetUsers(0, 100)
+			Expect(err).To(BeNil())
+			Expect(users.Data).To(HaveLen(1))
+		})
+	})
+}
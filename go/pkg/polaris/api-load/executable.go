@@ -21,13 +21,18 @@ under the License.
 package api_load
 
 import (
+	"context"
 	"fmt"
 	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
+	utilconfig "github.com/blackducksoftware/cerebros/go/pkg/util/config"
+	"github.com/blackducksoftware/cerebros/go/pkg/util/tracing"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
@@ -42,14 +47,24 @@ func Run() {
 	config, err := GetConfig(configPath)
 	doOrDie(err)
 
-	log.Infof("config: \n%+v\n%+v\n%+v\n", config, config.DataSeeder, config.LoadGenerator)
-
-	log.Infof("config: %+v", config)
+	log.Infof("config: \n%s\n", utilconfig.Redact(config))
 
 	logLevel, err := config.GetLogLevel()
 	doOrDie(err)
 	log.SetLevel(logLevel)
 
+	if config.Tracing != nil {
+		shutdownTracing, err := tracing.Setup(config.Tracing)
+		doOrDie(err)
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := shutdownTracing(ctx); err != nil {
+				log.Errorf("%+v", err)
+			}
+		}()
+	}
+
 	// Prometheus and http setup
 	prometheus.Unregister(prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
 	prometheus.Unregister(prometheus.NewGoCollector())
@@ -63,12 +78,24 @@ func Run() {
 	}()
 
 	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		log.Infof("received %s, stopping", sig)
+		close(stop)
+	}()
 
 	client := api.NewClient(config.PolarisURL, config.PolarisEmail, config.PolarisPassword)
+	if config.ResponseCache != nil {
+		client.Cache = api.NewResponseCache(config.ResponseCache.CacheConfig())
+	}
+	if config.RetryAndCircuitBreak {
+		client.UseDefaultResilience()
+	}
 	err = client.Authenticate()
 	doOrDie(err)
 	log.Infof("successfully authenticated")
-	startRegularReauthentication(client, stop)
 
 	if config.LoadGenerator != nil {
 		loadGenerator := NewLoadGenerator(client, config.LoadGenerator.WorkerRequests, stop)
@@ -76,36 +103,23 @@ func Run() {
 		loadGenerator.StartGeneratingLoad()
 	}
 
+	var dataSeeder *DataSeeder
 	if config.DataSeeder != nil {
 		log.Infof("instantiating data seeder")
-		dataSeeder, err := NewDataSeeder(client, config.DataSeeder.UsersToCreate, config.DataSeeder.Concurrency)
+		_, err = client.SelectOrganization(config.DataSeeder.Organization)
+		doOrDie(err)
+		dataSeeder, err = NewDataSeeder(client, config.DataSeeder.UsersToCreate, config.DataSeeder.Concurrency)
 		doOrDie(err)
 		log.Infof("starting data seeder: create role assignments")
 		dataSeeder.CreateRoleAssignments(stop)
 	}
 
 	<-stop
-}
 
-func startRegularReauthentication(client *api.Client, stop <-chan struct{}) {
-	go func() {
-	ForLoop:
-		for {
-			select {
-			case <-stop:
-				break ForLoop
-			case <-time.After(15 * time.Minute):
-			}
-			log.Infof("attempting to authenticate into polaris")
-			err := client.Authenticate()
-			recordEvent("authenticate", err)
-			if err != nil {
-				log.Errorf("unable to re-authenticate with polaris: %s", err)
-			} else {
-				log.Infof("successfully re-authenticated into polaris")
-			}
-			log.Infof("waiting 15 minutes to re-authenticate into polaris")
-		}
-		log.Infof("exiting authentication goroutine")
-	}()
+	if dataSeeder != nil && config.DataSeeder.Cleanup {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+		doOrDie(dataSeeder.Cleanup(ctx))
+		log.Infof("finished cleaning up seeded data")
+	}
 }
@@ -95,7 +95,7 @@ func (loadGen *LoadGenerator) StartGeneratingLoad() {
 				break
 			case "Taxonomies", "taxonomies":
 				f := func() error {
-					taxonomies, err := loadGen.Client.GetTaxonomies(nil, 10)
+					taxonomies, err := loadGen.Client.GetTaxonomies(10)
 					log.Debugf("got %d projects, total of %d", len(taxonomies.Data), taxonomies.Meta.Total)
 					return err
 				}
@@ -106,6 +106,8 @@ func (loadGen *LoadGenerator) StartGeneratingLoad() {
 				// which could stomp on what the other worker types are doing.
 				// So: one client per login worker
 				client := api.NewClient(loadGen.Client.URL, loadGen.Client.Email, loadGen.Client.Password)
+				client.Retry = loadGen.Client.Retry
+				client.CircuitBreaker = loadGen.Client.CircuitBreaker
 				f := func() error {
 					err := client.Authenticate()
 					log.Debugf("login worker authenticate result: %+v", err)
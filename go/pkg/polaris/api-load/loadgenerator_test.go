@@ -0,0 +1,71 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api_load
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api/fake"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func events(eventType string, isError bool) float64 {
+	isErrorLabel := "false"
+	if isError {
+		isErrorLabel = "true"
+	}
+	return testutil.ToFloat64(eventCounter.With(prometheus.Labels{"type": eventType, "iserror": isErrorLabel}))
+}
+
+func RunLoadGeneratorTests() {
+	Describe("LoadGenerator", func() {
+		It("should send each worker type's requests until stopped", func() {
+			server := fake.NewServer(fake.DefaultConfig())
+			defer server.Close()
+			server.Seed(2, 3)
+			client := api.NewClient(server.URL, server.Config.Email, server.Config.Password)
+
+			workerTypes := []string{"jobs", "projects", "roleassignments", "taxonomies", "login"}
+			workerRequests := map[string]int{}
+			failures := map[string]float64{}
+			for _, workerType := range workerTypes {
+				workerRequests[workerType] = 1
+				failures[workerType] = events(workerType, true)
+			}
+			stop := make(chan struct{})
+			NewLoadGenerator(client, workerRequests, stop).StartGeneratingLoad()
+			time.Sleep(200 * time.Millisecond)
+			close(stop)
+
+			for _, workerType := range workerTypes {
+				Expect(events(workerType, false)).To(BeNumerically(">", 0), workerType)
+				Expect(events(workerType, true)).To(Equal(failures[workerType]), workerType)
+			}
+			Expect(server.RequestCount(http.MethodGet, "/api/jobs/jobs")).To(BeNumerically(">", 0))
+			Expect(server.RequestCount(http.MethodPost, "/api/auth/authenticate")).To(BeNumerically(">", 1))
+		})
+	})
+}
@@ -1,6 +1,7 @@
 package stress_testing
 
 import (
+	"context"
 	"fmt"
 	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
 	"github.com/pkg/errors"
@@ -10,41 +11,37 @@ import (
 )
 
 type PostRoleAssignmentsSource struct {
-	Name            string
-	client          *api.Client
-	userIds         []string
-	userPage        int
-	userPageSize    int
-	projectIds      []string
-	projectPage     int
-	projectPageSize int
-	orgId           string
-	roleId          string
-	mux             *sync.Mutex
+	Name     string
+	client   *api.Client
+	users    *pageCycler
+	projects *pageCycler
+	orgId    string
+	roleId   string
+	mux      *sync.Mutex
 }
 
 func NewPostRoleAssignmentsSource(name string, client *api.Client) (*PostRoleAssignmentsSource, error) {
+	pageSize := 10
 	pras := &PostRoleAssignmentsSource{
-		Name:            name,
-		client:          client,
-		userIds:         []string{},
-		userPage:        0,
-		userPageSize:    10,
-		projectIds:      []string{},
-		projectPage:     0,
-		projectPageSize: 10,
-		mux:             &sync.Mutex{},
+		Name:   name,
+		client: client,
+		users: newPageCycler(fmt.Sprintf("%sUsers", name), func() *api.Paginator {
+			return client.UsersPaginator(pageSize)
+		}),
+		projects: newPageCycler(fmt.Sprintf("%sProject", name), func() *api.Paginator {
+			return client.VinylV0ProjectsPaginator(pageSize)
+		}),
+		mux: &sync.Mutex{},
 	}
 
-	orgs, err := client.GetOrganizations()
-	if err != nil {
-		return nil, errors.WithMessagef(err, "unable to get organizations for PostRoleAssignmentsSource")
-	}
-	log.Infof("number of orgs: %d", len(orgs.Data))
-	if len(orgs.Data) == 0 {
-		return nil, errors.WithMessagef(err, "no organizations found for PostRoleAssignmentsSource")
+	pras.orgId = client.OrganizationId()
+	if pras.orgId == "" {
+		org, err := client.SelectOrganization("")
+		if err != nil {
+			return nil, errors.WithMessagef(err, "unable to choose an organization for PostRoleAssignmentsSource")
+		}
+		pras.orgId = org.Id
 	}
-	pras.orgId = orgs.Data[0].Id
 
 	roles, err := client.GetRoles()
 	if err != nil {
@@ -59,96 +56,19 @@ func NewPostRoleAssignmentsSource(name string, client *api.Client) (*PostRoleAss
 	return pras, nil
 }
 
-func (pras *PostRoleAssignmentsSource) getNextProjectPage() int {
-	page := pras.projectPage
-	recordEventGauge(fmt.Sprintf("%sProjectPage", pras.Name), page)
-	pras.projectPage++
-	return page
-}
-
-func (pras *PostRoleAssignmentsSource) resetProjectPage() {
-	recordEvent(fmt.Sprintf("%sResetProjectPage", pras.Name), nil)
-	pras.projectPage = 0
-}
-
 func (pras *PostRoleAssignmentsSource) getProjectId() string {
 	pras.mux.Lock()
 	defer pras.mux.Unlock()
-
-	if len(pras.projectIds) == 0 {
-		page := pras.getNextProjectPage()
-		offset := page * pras.projectPageSize
-		var projects *api.GetVinylV0ProjectsResponse
-		var err error
-		for {
-			projects, err = pras.client.GetVinylV0Projects(offset, pras.projectPageSize)
-			if err == nil {
-				break
-			}
-			log.Errorf("unable to get vinyl projects: %+v", err)
-			time.Sleep(500 * time.Millisecond)
-		}
-		recordEventGauge(fmt.Sprintf("%sProjectTotal", pras.Name), projects.Meta.Total)
-		recordEventGauge(fmt.Sprintf("%sProjectLimit", pras.Name), projects.Meta.Limit)
-		recordEventGauge(fmt.Sprintf("%sProjectOffset", pras.Name), projects.Meta.Offset)
-		if (offset + pras.projectPageSize) >= projects.Meta.Total {
-			pras.resetProjectPage()
-		}
-		for _, proj := range projects.Data {
-			pras.projectIds = append(pras.projectIds, proj.Id)
-		}
-	}
-	first, rest := pras.projectIds[0], pras.projectIds[1:]
-	pras.projectIds = rest
-	return first
-}
-
-func (pras *PostRoleAssignmentsSource) getNextUserPage() int {
-	page := pras.userPage
-	recordEventGauge(fmt.Sprintf("%sUserPage", pras.Name), page)
-	pras.userPage++
-	return page
-}
-
-func (pras *PostRoleAssignmentsSource) resetUserPage() {
-	recordEvent(fmt.Sprintf("%sResetUserPage", pras.Name), nil)
-	pras.userPage = 0
+	return pras.projects.next().(*api.VinylV0Project).Id
 }
 
 func (pras *PostRoleAssignmentsSource) getUserId() string {
 	pras.mux.Lock()
 	defer pras.mux.Unlock()
-
-	if len(pras.userIds) == 0 {
-		page := pras.getNextUserPage()
-		offset := page * pras.userPageSize
-		var users *api.GetUsersResponse
-		var err error
-		for {
-			users, err = pras.client.GetUsers(offset, pras.userPageSize)
-			if err == nil {
-				break
-			}
-			log.Errorf("unable to get users: %+v", err)
-			time.Sleep(500 * time.Millisecond)
-		}
-		log.Infof("got users: %+v", users.Meta)
-		recordEventGauge(fmt.Sprintf("%sUsersTotal", pras.Name), users.Meta.Total)
-		recordEventGauge(fmt.Sprintf("%sUsersLimit", pras.Name), users.Meta.Limit)
-		recordEventGauge(fmt.Sprintf("%sUsersOffset", pras.Name), users.Meta.Offset)
-		if (offset + pras.userPageSize) >= users.Meta.Total {
-			pras.resetUserPage()
-		}
-		for _, user := range users.Data {
-			pras.userIds = append(pras.userIds, user.Id)
-		}
-	}
-	first, rest := pras.userIds[0], pras.userIds[1:]
-	pras.userIds = rest
-	return first
+	return pras.users.next().(*api.User).Id
 }
 
-func (pras *PostRoleAssignmentsSource) RunJob() (string, error) {
+func (pras *PostRoleAssignmentsSource) RunJob(ctx context.Context) (string, error) {
 	start := time.Now()
 	projectId := pras.getProjectId()
 	recordDuration(fmt.Sprintf("%sGetProjectId", pras.Name), time.Since(start))
@@ -159,7 +79,7 @@ func (pras *PostRoleAssignmentsSource) RunJob() (string, error) {
 	recordDuration(fmt.Sprintf("%sGetUserId", pras.Name), time.Since(userStart))
 	log.Infof("%s got user id: %s", pras.Name, projectId)
 
-	ras, err := pras.client.CreateRoleAssignment(userId, pras.roleId, projectId, pras.orgId)
+	ras, err := pras.client.CreateRoleAssignmentContext(ctx, userId, pras.roleId, projectId, pras.orgId)
 	if err != nil {
 		log.Errorf("unable to create role assignment: %s, %s, %s, %s, %s, %+v", userId, pras.roleId, projectId, pras.orgId, ras, err)
 	} else {
@@ -201,11 +121,11 @@ func (raps *RoleAssignmentsPagerSource) resetPage() {
 	raps.Page = 0
 }
 
-func (raps *RoleAssignmentsPagerSource) RunJob() (string, error) {
+func (raps *RoleAssignmentsPagerSource) RunJob(ctx context.Context) (string, error) {
 	page := raps.getPage()
 	recordEventGauge(fmt.Sprintf("%sPage", raps.Name), page)
 	offset := page * raps.PageSize
-	ras, err := raps.client.GetRoleAssignments(offset, raps.PageSize)
+	ras, err := raps.client.GetRoleAssignmentsContext(ctx, offset, raps.PageSize)
 	if err == nil {
 		recordEventGauge(fmt.Sprintf("%sTotal", raps.Name), ras.Meta.Total)
 		if offset >= ras.Meta.Total {
@@ -251,14 +171,14 @@ func (raps *RoleAssignmentsSingleProjectSource) getNextIndex() (int, bool) {
 	return i, true
 }
 
-func (raps *RoleAssignmentsSingleProjectSource) RunJob() (string, error) {
+func (raps *RoleAssignmentsSingleProjectSource) RunJob(ctx context.Context) (string, error) {
 	index, ok := raps.getNextIndex()
 	if !ok {
 		return "getRoleAssignmentsSingleProject -- no project available", errors.New(fmt.Sprintf("no project available"))
 	}
 	project := raps.projects.GetProject(index)
 	recordEventGauge("roleAssignmentsProjectIndex", index)
-	_, err := raps.client.GetRoleAssignmentsForProject(project.Id)
+	_, err := raps.client.GetRoleAssignmentsForProjectContext(ctx, project.Id)
 	return "getRoleAssignmentsSingleProject", err
 }
 
@@ -284,15 +204,11 @@ func NewAuthLoadGenerator(projects *ProjectFetcher, url string, email string, pa
 	entitlementsClient := api.NewClient(url, email, password)
 	err := entitlementsClient.Authenticate()
 	doOrDie(err)
-	Reauthenticator(entitlementsClient, alg.stopChan)
-	orgs, err := entitlementsClient.GetOrganizations()
+	org, err := entitlementsClient.SelectOrganization(config.Organization)
 	doOrDie(err)
-	log.Infof("found %d orgs", len(orgs.Data))
-	// just crash if there's 0 orgs
-	org := orgs.Data[0]
 
-	entitlementsJob := func() (string, error) {
-		entitlements, err := entitlementsClient.GetEntitlementsForOrganization(org.Id)
+	entitlementsJob := func(ctx context.Context) (string, error) {
+		entitlements, err := entitlementsClient.GetEntitlementsForOrganizationContext(ctx, org.Id)
 		log.Infof("found %d entitlements", len(entitlements.Data))
 		return "entitlements", err
 	}
@@ -302,9 +218,9 @@ func NewAuthLoadGenerator(projects *ProjectFetcher, url string, email string, pa
 		config.Entitlements.WorkersCount,
 		config.Entitlements.Rate.MustRateLimiter("entitlements"))
 
-	loginJob := func() (string, error) {
+	loginJob := func(ctx context.Context) (string, error) {
 		client := api.NewClient(url, email, password)
-		err := client.Authenticate()
+		err := client.AuthenticateContext(ctx)
 		return "login", err
 	}
 	alg.loginsLoadManager = NewLoadManager(
@@ -314,9 +230,9 @@ func NewAuthLoadGenerator(projects *ProjectFetcher, url string, email string, pa
 		config.Login.Rate.MustRateLimiter("logins"))
 
 	roleAssignmentsClient := api.NewClient(url, email, password)
+	roleAssignmentsClient.SetOrganizationId(org.Id)
 	err = roleAssignmentsClient.Authenticate()
 	doOrDie(err)
-	Reauthenticator(roleAssignmentsClient, alg.stopChan)
 	rap := config.RoleAssignmentsPager
 	for name, conf := range rap {
 		debugName := fmt.Sprintf("role-assignments-pager-%s", name)
@@ -22,15 +22,17 @@ package stress_testing
 
 import (
 	"fmt"
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
+	utilconfig "github.com/blackducksoftware/cerebros/go/pkg/util/config"
+	"github.com/blackducksoftware/cerebros/go/pkg/util/tracing"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
 	"time"
 )
 
 type Config struct {
-	PolarisURL      string
-	PolarisEmail    string
+	PolarisURL      string `config:"required"`
+	PolarisEmail    string `config:"required"`
 	PolarisPassword string
 
 	LogLevel string
@@ -41,6 +43,35 @@ type Config struct {
 		Issue *IssueServerConfig
 		Auth  *AuthConfig
 	}
+
+	// ResponseCache, if set, caches the project fetcher's and issue load's GETs
+	ResponseCache *ResponseCacheConfig
+	// Tracing, if set, exports a span per API call
+	Tracing *tracing.Config
+	// RetryAndCircuitBreak turns on the client's default retries and circuit
+	// breakers.  They're off by default so that every failure is measured.
+	RetryAndCircuitBreak bool
+}
+
+// ResponseCacheConfig turns on api.Client's response cache.  Leave it out to
+// send every request to Polaris.
+type ResponseCacheConfig struct {
+	MaxEntries        int
+	DefaultTTLSeconds int
+	// PathTTLSeconds is keyed by path template, e.g. "api/auth/roles"
+	PathTTLSeconds map[string]int
+}
+
+func (rcc *ResponseCacheConfig) CacheConfig() *api.CacheConfig {
+	config := &api.CacheConfig{
+		MaxEntries: rcc.MaxEntries,
+		DefaultTTL: time.Duration(rcc.DefaultTTLSeconds) * time.Second,
+		PathTTLs:   map[string]time.Duration{},
+	}
+	for path, seconds := range rcc.PathTTLSeconds {
+		config.PathTTLs[path] = time.Duration(seconds) * time.Second
+	}
+	return config
 }
 
 type RateConfig struct {
@@ -104,6 +135,9 @@ type RoleAssignmentsPager struct {
 }
 
 type AuthConfig struct {
+	// Organization is the name or id of the organization to work in; it can be
+	// left out if there's only one
+	Organization                 string
 	PreRunLogins                 int
 	Entitlements                 *LoadConfig
 	Login                        *LoadConfig
@@ -117,11 +151,24 @@ type RollupCountsPager struct {
 	PageSize   int
 }
 
+type V1RollupCountsPager struct {
+	LoadConfig *LoadConfig
+	PageSize   int
+	// GroupBy facets such as "[issue][severity]"
+	GroupBy []string
+}
+
 type IssueServerConfig struct {
 	FetchProjectsCount int
 
 	Issues       *LoadConfig
 	RollupCounts *RollupCountsPager
+
+	// optional
+	V1RollupCounts *V1RollupCountsPager
+	StatusCounts   *LoadConfig
+	FilterValues   *LoadConfig
+	TriageHistory  *LoadConfig
 }
 
 // GetLogLevel ...
@@ -131,18 +178,10 @@ func (config *Config) GetLogLevel() (log.Level, error) {
 
 // GetConfig ...
 func GetConfig(configPath string) (*Config, error) {
-	var config *Config
-
-	viper.SetConfigFile(configPath)
-	err := viper.ReadInConfig()
+	config := &Config{}
+	err := utilconfig.Load(configPath, config, &utilconfig.Options{EnvPrefix: "STRESS_TESTING"})
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to ReadInConfig at %s", configPath)
+		return nil, err
 	}
-
-	err = viper.Unmarshal(&config)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to unmarshal config at %s", configPath)
-	}
-
 	return config, nil
 }
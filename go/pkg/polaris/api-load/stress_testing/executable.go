@@ -3,6 +3,7 @@ package stress_testing
 import (
 	"fmt"
 	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
+	"github.com/blackducksoftware/cerebros/go/pkg/util/tracing"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
@@ -23,6 +24,13 @@ func Run(configPath string) {
 	doOrDie(err)
 	log.SetLevel(logLevel)
 
+	if config.Tracing != nil {
+		// this runs until it's killed, so spans are flushed by the batcher
+		// rather than on shutdown
+		_, err = tracing.Setup(config.Tracing)
+		doOrDie(err)
+	}
+
 	islg, auth, err := RunLoadGenerator(config)
 	doOrDie(err)
 
@@ -43,6 +51,12 @@ func Run(configPath string) {
 
 func RunLoadGenerator(config *Config) (*IssueServerLoadGenerator, *AuthLoadGenerator, error) {
 	apiClient := api.NewClient(config.PolarisURL, config.PolarisEmail, config.PolarisPassword)
+	if config.ResponseCache != nil {
+		apiClient.Cache = api.NewResponseCache(config.ResponseCache.CacheConfig())
+	}
+	if config.RetryAndCircuitBreak {
+		apiClient.UseDefaultResilience()
+	}
 
 	if err := apiClient.Authenticate(); err != nil {
 		return nil, nil, err
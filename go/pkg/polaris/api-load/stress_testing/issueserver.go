@@ -21,6 +21,8 @@ under the License.
 package stress_testing
 
 import (
+	"context"
+	"fmt"
 	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -65,16 +67,113 @@ func (rcs *RollupCountsSource) getNextIndex() (int, bool) {
 	return i, true
 }
 
-func (rcs *RollupCountsSource) RunJob() (string, error) {
+func (rcs *RollupCountsSource) RunJob(ctx context.Context) (string, error) {
 	index, ok := rcs.getNextIndex()
 	if !ok {
 		return "getV0RollUpCounts -- no project available", errors.New("no projects")
 	}
 	project := rcs.Projects.GetMainBranchProject(index)
-	_, err := rcs.client.GetV0RollUpCounts(project.ProjectId, project.MainBranchId, rcs.Limit)
+	_, err := rcs.client.GetV0RollUpCountsContext(ctx, project.ProjectId, project.MainBranchId, rcs.Limit)
 	return "getV0RollUpCounts", err
 }
 
+// ProjectQuerySource runs a query against each main-branch project in turn.
+type ProjectQuerySource struct {
+	Name     string
+	Projects *ProjectFetcher
+	Index    int
+	query    func(ctx context.Context, project *MainBranchProject) error
+	mux      *sync.Mutex
+}
+
+func NewProjectQuerySource(name string, projects *ProjectFetcher, query func(ctx context.Context, project *MainBranchProject) error) *ProjectQuerySource {
+	return &ProjectQuerySource{
+		Name:     name,
+		Projects: projects,
+		Index:    0,
+		query:    query,
+		mux:      &sync.Mutex{},
+	}
+}
+
+func (pqs *ProjectQuerySource) getNextProject() (*MainBranchProject, bool) {
+	pqs.mux.Lock()
+	defer pqs.mux.Unlock()
+
+	projectsCount := pqs.Projects.MainBranchProjectsLength()
+	if projectsCount == 0 {
+		return nil, false
+	}
+	if pqs.Index >= projectsCount {
+		pqs.Index = 0
+	}
+	recordEventGauge(fmt.Sprintf("%sIndex", pqs.Name), pqs.Index)
+	project := pqs.Projects.GetMainBranchProject(pqs.Index)
+	pqs.Index++
+	return project, true
+}
+
+func (pqs *ProjectQuerySource) RunJob(ctx context.Context) (string, error) {
+	project, ok := pqs.getNextProject()
+	if !ok {
+		return fmt.Sprintf("%s -- no project available", pqs.Name), errors.New("no projects")
+	}
+	return pqs.Name, pqs.query(ctx, project)
+}
+
+func NewV1RollupCountsSource(client *api.Client, projects *ProjectFetcher, groupBy []string, limit int) *ProjectQuerySource {
+	return NewProjectQuerySource("getV1RollUpCounts", projects, func(ctx context.Context, project *MainBranchProject) error {
+		_, err := client.GetV1RollUpCountsContext(ctx, &api.RollUpCountsQuery{
+			ProjectId: project.ProjectId,
+			BranchId:  project.MainBranchId,
+			GroupBy:   groupBy,
+			Limit:     limit,
+		})
+		return err
+	})
+}
+
+func NewStatusCountsSource(client *api.Client, projects *ProjectFetcher) *ProjectQuerySource {
+	return NewProjectQuerySource("getV1StatusCounts", projects, func(ctx context.Context, project *MainBranchProject) error {
+		_, err := client.GetV1StatusCountsContext(ctx, &api.RollUpCountsQuery{
+			ProjectId: project.ProjectId,
+			BranchId:  project.MainBranchId,
+		})
+		return err
+	})
+}
+
+// NewTriageHistorySource looks up the triage history of the first issue of each project.
+func NewTriageHistorySource(client *api.Client, projects *ProjectFetcher) *ProjectQuerySource {
+	return NewProjectQuerySource("getTriageHistory", projects, func(ctx context.Context, project *MainBranchProject) error {
+		issues, err := client.GetV1IssuesContext(ctx, project.ProjectId, project.MainBranchId, "", 0, 1)
+		if err != nil {
+			return err
+		}
+		if len(issues.Data) == 0 {
+			recordEvent("triage history: project without issues", nil)
+			return nil
+		}
+		_, err = client.GetTriageHistoryContext(ctx, project.ProjectId, issues.Data[0].Attributes.IssueKey, 0, 25)
+		return err
+	})
+}
+
+// NewFilterValuesSource cycles through the values of every discovery filter key.
+func NewFilterValuesSource(client *api.Client, limit int) *FuncJobSource {
+	filterKeys := []string{api.FilterKeyIssueTool, api.FilterKeyIssueType, api.FilterKeyIssueSeverity, api.FilterKeyIssueStatus, api.FilterKeyPath}
+	index := 0
+	mux := &sync.Mutex{}
+	return &FuncJobSource{function: func(ctx context.Context) (string, error) {
+		mux.Lock()
+		filterKey := filterKeys[index%len(filterKeys)]
+		index++
+		mux.Unlock()
+		_, err := client.GetV0DiscoveryFilterValuesContext(ctx, filterKey, limit)
+		return "getV0DiscoveryFilterValues", err
+	}}
+}
+
 type issueJob struct {
 	ProjectId string
 	BranchId  string
@@ -137,14 +236,14 @@ func (is *IssuesSource) getIssuePageJob() *issuePageJob {
 	}
 }
 
-func (is *IssuesSource) RunJob() (string, error) {
+func (is *IssuesSource) RunJob(ctx context.Context) (string, error) {
 	if job := is.getIssueJob(); job != nil {
-		_, err := is.client.GetV1Issue(job.ProjectId, job.BranchId, job.IssueId)
+		_, err := is.client.GetV1IssueContext(ctx, job.ProjectId, job.BranchId, job.IssueId)
 		return "getV1Issue (single)", err
 	} else if pageJob := is.getIssuePageJob(); pageJob != nil {
 		offset := 0
 		pageSize := 60
-		v1Issues, err := is.client.GetV1Issues(pageJob.ProjectId, pageJob.BranchId, "", offset, pageSize)
+		v1Issues, err := is.client.GetV1IssuesContext(ctx, pageJob.ProjectId, pageJob.BranchId, "", offset, pageSize)
 		if err == nil {
 			go func() {
 				for _, issueResponse := range v1Issues.Data {
@@ -169,7 +268,9 @@ type IssueServerLoadGenerator struct {
 	Config                  *IssueServerConfig
 	issuesLoadManager       *LoadManager
 	rollupCountsLoadManager *LoadManager
-	stopChan                chan struct{}
+	// the optional query load managers, started only if configured
+	queryLoadManagers []*LoadManager
+	stopChan          chan struct{}
 }
 
 func NewIssueServerLoadGenerator(polarisClient *api.Client, projects *ProjectFetcher, config *IssueServerConfig) *IssueServerLoadGenerator {
@@ -189,10 +290,25 @@ func NewIssueServerLoadGenerator(polarisClient *api.Client, projects *ProjectFet
 			config.RollupCounts.LoadConfig.WorkersCount,
 			config.RollupCounts.LoadConfig.Rate.MustRateLimiter("rollupcounts")),
 	}
-	Reauthenticator(polarisClient, c.stopChan)
+	if rc := config.V1RollupCounts; rc != nil {
+		c.addQueryLoadManager("v1rollupcounts", NewV1RollupCountsSource(polarisClient, projects, rc.GroupBy, rc.PageSize), rc.LoadConfig)
+	}
+	if config.StatusCounts != nil {
+		c.addQueryLoadManager("statuscounts", NewStatusCountsSource(polarisClient, projects), config.StatusCounts)
+	}
+	if config.FilterValues != nil {
+		c.addQueryLoadManager("filtervalues", NewFilterValuesSource(polarisClient, 50), config.FilterValues)
+	}
+	if config.TriageHistory != nil {
+		c.addQueryLoadManager("triagehistory", NewTriageHistorySource(polarisClient, projects), config.TriageHistory)
+	}
 	return c
 }
 
+func (c *IssueServerLoadGenerator) addQueryLoadManager(name string, source JobSource, config *LoadConfig) {
+	c.queryLoadManagers = append(c.queryLoadManagers, NewLoadManager(name, source, config.WorkersCount, config.Rate.MustRateLimiter(name)))
+}
+
 func (c *IssueServerLoadGenerator) Stop() {
 	close(c.stopChan)
 	if c.issuesLoadManager != nil {
@@ -201,4 +317,7 @@ func (c *IssueServerLoadGenerator) Stop() {
 	if c.rollupCountsLoadManager != nil {
 		c.rollupCountsLoadManager.stop()
 	}
+	for _, lm := range c.queryLoadManagers {
+		lm.stop()
+	}
 }
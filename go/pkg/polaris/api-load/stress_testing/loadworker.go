@@ -1,22 +1,23 @@
 package stress_testing
 
 import (
+	"context"
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"sync"
 )
 
 type JobSource interface {
-	// RunJob must be synchronous and reentrant
-	RunJob() (string, error)
+	// RunJob must be synchronous and reentrant; ctx is cancelled when the LoadManager is stopped
+	RunJob(ctx context.Context) (string, error)
 }
 
 type FuncJobSource struct {
-	function func() (string, error)
+	function func(ctx context.Context) (string, error)
 }
 
-func (fjs *FuncJobSource) RunJob() (string, error) {
-	return fjs.function()
+func (fjs *FuncJobSource) RunJob(ctx context.Context) (string, error) {
+	return fjs.function(ctx)
 }
 
 type LoadManager struct {
@@ -26,9 +27,12 @@ type LoadManager struct {
 	limiter   *RateLimiter
 	mux       *sync.Mutex
 	stopChan  chan struct{}
+	ctx       context.Context
+	cancel    context.CancelFunc
 }
 
 func NewLoadManager(name string, js JobSource, workerCount int, rateLimiter *RateLimiter) *LoadManager {
+	ctx, cancel := context.WithCancel(context.Background())
 	lm := &LoadManager{
 		Name:      name,
 		jobSource: js,
@@ -36,6 +40,8 @@ func NewLoadManager(name string, js JobSource, workerCount int, rateLimiter *Rat
 		limiter:   rateLimiter,
 		mux:       &sync.Mutex{},
 		stopChan:  make(chan struct{}),
+		ctx:       ctx,
+		cancel:    cancel,
 	}
 	lm.setWorkerCount(workerCount)
 	return lm
@@ -46,6 +52,7 @@ func (lm *LoadManager) stop() {
 		worker.stop()
 	}
 	close(lm.stopChan)
+	lm.cancel()
 }
 
 func (lm *LoadManager) setWorkerCount(count int) {
@@ -59,7 +66,7 @@ func (lm *LoadManager) setWorkerCount(count int) {
 
 func (lm *LoadManager) runJob() {
 	lm.limiter.Wait()
-	lm.limiter.Finish(lm.jobSource.RunJob())
+	lm.limiter.Finish(lm.jobSource.RunJob(lm.ctx))
 }
 
 type LoadWorker struct {
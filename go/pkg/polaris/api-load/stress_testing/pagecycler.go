@@ -0,0 +1,74 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package stress_testing
+
+import (
+	"context"
+	"fmt"
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+// pageCycler hands out the items of a paginated collection forever, starting
+// over from the beginning whenever it runs off the end.  It is not safe for
+// concurrent use.
+type pageCycler struct {
+	name         string
+	newPaginator func() *api.Paginator
+	iterator     *api.PageIterator
+	yielded      bool
+}
+
+func newPageCycler(name string, newPaginator func() *api.Paginator) *pageCycler {
+	return &pageCycler{name: name, newPaginator: newPaginator}
+}
+
+func (pc *pageCycler) next() interface{} {
+	for {
+		if pc.iterator == nil {
+			paginator := pc.newPaginator()
+			paginator.OnPage = func(page *api.Page) {
+				recordEventGauge(fmt.Sprintf("%sTotal", pc.name), page.Meta.Total)
+				recordEventGauge(fmt.Sprintf("%sLimit", pc.name), page.Meta.Limit)
+				recordEventGauge(fmt.Sprintf("%sOffset", pc.name), page.Meta.Offset)
+			}
+			pc.iterator = paginator.Iterate(context.Background())
+			pc.yielded = false
+		}
+		if pc.iterator.Next() {
+			pc.yielded = true
+			return pc.iterator.Item()
+		}
+		err := pc.iterator.Err()
+		pc.iterator.Close()
+		pc.iterator = nil
+		if err != nil {
+			log.Errorf("unable to get %s: %+v", pc.name, err)
+		} else {
+			recordEvent(fmt.Sprintf("%sReset", pc.name), nil)
+		}
+		// don't hammer the server on errors or an empty collection
+		if err != nil || !pc.yielded {
+			time.Sleep(500 * time.Millisecond)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package stress_testing
 
 import (
+	"context"
 	"fmt"
 	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
 	"github.com/pkg/errors"
@@ -97,64 +98,51 @@ func (pf *ProjectFetcher) Stop() {
 }
 
 func (pf *ProjectFetcher) Start() {
-	initialOffset := pf.StartIndex
-	limit := pf.Limit
 	pageSize := 10
-	workerId := 0
-	fetched := 0
-	pageStart := initialOffset / pageSize
-	log.Infof("starting project fetches: %d", pf.Limit)
-	go func() {
-	ForLoop:
-		for page := pageStart; fetched < limit; {
-			select {
-			case <-pf.stopChan:
-				break ForLoop
-			default:
-			}
-			recordEventGauge("projectPage", page)
-			offset := page * pageSize
-			log.Infof("projects worker %d: getting projects with offset %d, pagesize %d", workerId, offset, pageSize)
-			vinyl, err := pf.client.GetVinylV0Projects(offset, pageSize)
-			if err != nil {
-				// TODO if there's an error, this will just spin and spin and spin ...
-				log.Errorf("projects worker %d: unable to get vinyl projects: %+v", workerId, err)
-				time.Sleep(1 * time.Second)
-				continue
-			}
-			log.Infof("projects worker %d: vinyl meta: %+v", workerId, vinyl.Meta)
-
-			go func() {
-				pf.mux.Lock()
+	paginator := pf.client.VinylV0ProjectsPaginator(pageSize)
+	// start on a page boundary, as the page-at-a-time fetcher used to
+	paginator.StartOffset = (pf.StartIndex / pageSize) * pageSize
+	paginator.MaxItems = pf.Limit
+	paginator.Prefetch = 2
+	paginator.OnPage = func(page *api.Page) {
+		recordEventGauge("projectPage", page.Meta.Offset/pageSize)
+		log.Infof("projects: vinyl meta: %+v", page.Meta)
+	}
 
-				defer pf.mux.Unlock()
-				for _, project := range vinyl.Data {
-					//start := time.Now()
-					log.Debugf("writing project %s", project.Id)
-					pf.projects = append(pf.projects, project)
-					//recordProjectWriteDuration(time.Since(start))
-					if branch, ok := project.Relationships["main-branch"]; ok && branch.Data != nil {
-						recordEvent("found main-branch", nil)
-						pf.mainBranchProjects = append(pf.mainBranchProjects, &MainBranchProject{
-							ProjectId:    project.Id,
-							MainBranchId: project.Relationships["main-branch"].Data.Id,
-						})
-					} else {
-						recordEvent("missing main-branch", nil)
-					}
-				}
-			}()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-pf.stopChan:
+		case <-ctx.Done():
+		}
+		cancel()
+	}()
 
-			fetched += vinyl.Meta.Limit
-			page++
-			// wrap around // TODO should this send back to 0 instead?
-			if offset >= vinyl.Meta.Total {
-				break
-			}
-			// got back to where we started?  alright, we're done
-			if page == pageStart {
-				break
+	log.Infof("starting project fetches: %d", pf.Limit)
+	go func() {
+		defer cancel()
+		fetched := 0
+		it := paginator.Iterate(ctx)
+		defer it.Close()
+		for it.Next() {
+			project := it.Item().(*api.VinylV0Project)
+			log.Debugf("writing project %s", project.Id)
+			pf.mux.Lock()
+			pf.projects = append(pf.projects, project)
+			if branch, ok := project.Relationships["main-branch"]; ok && branch.Data.Id != "" {
+				recordEvent("found main-branch", nil)
+				pf.mainBranchProjects = append(pf.mainBranchProjects, &MainBranchProject{
+					ProjectId:    project.Id,
+					MainBranchId: branch.Data.Id,
+				})
+			} else {
+				recordEvent("missing main-branch", nil)
 			}
+			pf.mux.Unlock()
+			fetched++
+		}
+		if err := it.Err(); err != nil && err != context.Canceled {
+			log.Errorf("unable to get vinyl projects: %+v", err)
 		}
 
 		log.Infof("finishing project fetches, got %d, expected %d", fetched, pf.Limit)
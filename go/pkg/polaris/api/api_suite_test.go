@@ -30,6 +30,21 @@ import (
 
 func TestKube(t *testing.T) {
 	RegisterFailHandler(Fail)
+	RunClientTests()
 	RunMetricsTests()
+	RunPaginationTests()
+	RunResilienceTests()
+	RunTokenTests()
+	RunAuthenticatorTests()
+	RunAuthTests()
+	RunJsonApiTests()
+	RunIssueTests()
+	RunIssueDiffTests()
+	RunJobsTests()
+	RunReplayTests()
+	RunCacheTests()
+	RunTracingTests()
+	RunRateLimitTests()
+	RunTaxonomyTests()
 	RunSpecs(t, "kube")
 }
@@ -21,58 +21,66 @@ under the License.
 package api
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"github.com/go-resty/resty/v2"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
-	"time"
 )
 
+// Authenticate obtains a new session token using the client's Authenticator.
 func (client *Client) Authenticate() error {
+	return client.AuthenticateContext(context.Background())
+}
+
+func (client *Client) AuthenticateContext(ctx context.Context) error {
+	if client.Authenticator == nil {
+		return errors.New("no authenticator configured")
+	}
+	token, err := client.Authenticator.Authenticate(ctx, client)
+	if err != nil {
+		return err
+	}
+	client.setToken(token)
+	return nil
+}
+
+// postAuthenticate exchanges form credentials for a session token at api/auth/authenticate.
+func (client *Client) postAuthenticate(ctx context.Context, bodyParams map[string]string) (string, error) {
 	path := "api/auth/authenticate"
 	url := fmt.Sprintf("%s/%s", client.URL, path)
 	log.Debugf("issuing POST request to %s", url)
-	bodyParams := map[string]string{
-		"email":    client.Email,
-		"password": client.Password,
-	}
-	request := client.RestyClient.R().
-		SetHeader("Content-Type", "application/x-www-form-urlencoded").
-		SetHeader("Accept", "application/json").
-		SetFormData(bodyParams)
-
-	client.authMux.Lock()
-	defer client.authMux.Unlock()
-	start := time.Now()
-	resp, err := request.Post(url)
-	duration := time.Now().Sub(start)
-
-	recordEvent("POST_"+path, err)
-
+	resp, err := client.sendWithRetry(ctx, resty.MethodPost, url, path, func() *resty.Request {
+		return client.RestyClient.R().
+			SetHeader("Content-Type", "application/x-www-form-urlencoded").
+			SetHeader("Accept", "application/json").
+			SetFormData(bodyParams)
+	})
 	if err != nil {
-		return errors.Wrapf(err, "unable to POST to %s", url)
+		return "", errors.Wrapf(err, "unable to POST to %s", url)
 	}
 
 	statusCode := resp.StatusCode()
-	recordResponseTime("POST", path, duration, statusCode)
-	recordResponseStatusCode("POST", path, statusCode)
-
 	if statusCode < 200 || statusCode > 299 {
-		return errors.New(fmt.Sprintf("bad response code: %d, %s", statusCode, resp.String()))
+		return "", errors.New(fmt.Sprintf("bad response code: %d, %s", statusCode, resp.String()))
 	}
 
 	log.Tracef("resp from %s: %+v, %s", url, resp, resp.String())
-	var accessToken string
 	for _, cookie := range resp.Cookies() {
 		log.Tracef("cookie: %s, \n%s\n\n", cookie.Name, cookie.Value)
 		if cookie.Name == "access_token" {
-			accessToken = cookie.Value
+			return cookie.Value, nil
 		}
 	}
-	if accessToken == "" {
-		return errors.New(fmt.Sprintf("got status code %d, but did not find cookie access_token in response", statusCode))
+	// access token logins may only return the token in the body
+	body := struct {
+		JWT string `json:"jwt"`
+	}{}
+	if err := json.Unmarshal(resp.Body(), &body); err == nil && body.JWT != "" {
+		return body.JWT, nil
 	}
-	client.AuthToken = accessToken
-	return nil
+	return "", errors.New(fmt.Sprintf("got status code %d, but did not find cookie access_token or jwt in response", statusCode))
 }
 
 type CreateAccessTokenResponse struct {
@@ -84,6 +92,10 @@ type CreateAccessTokenResponse struct {
 }
 
 func (client *Client) GetAccessToken(tokenName string) (*CreateAccessTokenResponse, error) {
+	return client.GetAccessTokenContext(context.Background(), tokenName)
+}
+
+func (client *Client) GetAccessTokenContext(ctx context.Context, tokenName string) (*CreateAccessTokenResponse, error) {
 	bodyParams := map[string]interface{}{
 		"data": map[string]interface{}{
 			"attributes": map[string]interface{}{
@@ -96,94 +108,273 @@ func (client *Client) GetAccessToken(tokenName string) (*CreateAccessTokenRespon
 		},
 	}
 	result := &CreateAccessTokenResponse{}
-	_, err := client.PostJson(bodyParams, result, "api/auth/apitokens")
+	_, err := client.PostJsonContext(ctx, bodyParams, result, "api/auth/apitokens")
 	return result, err
 }
 
-type GetEntitlementsForProjectResponse struct {
-	Data []struct {
-		Type       string
-		Id         string
-		Attributes struct {
-			Allowed []string
+type AccessToken struct {
+	Type       string
+	Id         string
+	Attributes struct {
+		Name        string
+		DateCreated string `json:"date-created"`
+		Revoked     bool
+	}
+	Relationships map[string]Relationship
+}
+
+type GetAccessTokensResponse struct {
+	Data  []*AccessToken
+	Meta  PageMeta
+	Links PageLinks
+}
+
+// GetAccessTokens lists the API tokens of the authenticated user.  The secret
+// token values are only ever returned when a token is created.
+func (client *Client) GetAccessTokens(offset int, limit int) (*GetAccessTokensResponse, error) {
+	return client.GetAccessTokensContext(context.Background(), offset, limit)
+}
+
+func (client *Client) GetAccessTokensContext(ctx context.Context, offset int, limit int) (*GetAccessTokensResponse, error) {
+	result := &GetAccessTokensResponse{}
+	params := map[string]interface{}{
+		"page[limit]":  fmt.Sprintf("%d", limit),
+		"page[offset]": fmt.Sprintf("%d", offset),
+	}
+	_, err := client.GetJsonContext(ctx, params, result, "api/auth/apitokens")
+	return result, err
+}
+
+// AccessTokensPaginator walks the API tokens of the authenticated user; items are *AccessToken.
+func (client *Client) AccessTokensPaginator(pageSize int) *Paginator {
+	return NewPaginator(func(ctx context.Context, offset int, limit int) (*Page, error) {
+		tokens, err := client.GetAccessTokensContext(ctx, offset, limit)
+		if err != nil {
+			return nil, err
 		}
+		items := boxItems(len(tokens.Data), func(i int) interface{} { return tokens.Data[i] })
+		return &Page{Items: items, Meta: tokens.Meta, Links: tokens.Links}, nil
+	}, pageSize)
+}
+
+// RevokeAccessToken stops a token from being usable for login, but keeps its record.
+func (client *Client) RevokeAccessToken(tokenId string) error {
+	return client.RevokeAccessTokenContext(context.Background(), tokenId)
+}
+
+func (client *Client) RevokeAccessTokenContext(ctx context.Context, tokenId string) error {
+	bodyParams := map[string]interface{}{
+		"data": map[string]interface{}{
+			"attributes": map[string]interface{}{
+				"revoked": true,
+			},
+			"id":   tokenId,
+			"type": "apitokens",
+		},
 	}
-	Meta struct {
-		Limit  int
-		Offset int
-		Total  int
+	_, err := client.PatchJsonContext(ctx, bodyParams, nil, "api/auth/apitokens/%s", tokenId)
+	return err
+}
+
+func (client *Client) DeleteAccessToken(tokenId string) error {
+	return client.DeleteAccessTokenContext(context.Background(), tokenId)
+}
+
+func (client *Client) DeleteAccessTokenContext(ctx context.Context, tokenId string) error {
+	_, err := client.DeleteJsonContext(ctx, nil, "api/auth/apitokens/%s", tokenId)
+	return err
+}
+
+// Entitlement is the set of permissions a user has on an object.  Entitlements
+// are computed by Polaris from role assignments, both direct and through
+// groups, so they're changed by creating or deleting role assignments.
+type Entitlement struct {
+	Type       string
+	Id         string
+	Attributes struct {
+		Allowed []string
+		Object  string
 	}
+	Relationships map[string]Relationship
+}
+
+type GetEntitlementsForProjectResponse struct {
+	Data     []*Entitlement
+	Included Included
+	Meta     PageMeta
+	Links    PageLinks
 }
 
 func (client *Client) GetEntitlementsForProject(projectID string) (*GetEntitlementsForProjectResponse, error) {
+	return client.GetEntitlementsForProjectContext(context.Background(), projectID)
+}
+
+func (client *Client) GetEntitlementsForProjectContext(ctx context.Context, projectID string) (*GetEntitlementsForProjectResponse, error) {
 	params := map[string]interface{}{
 		"filter[entitlements][object][eq]": fmt.Sprintf("urn:x-swip:projects:%s", projectID),
 	}
 	result := &GetEntitlementsForProjectResponse{}
-	_, err := client.GetJson(params, result, "api/auth/entitlements")
+	_, err := client.GetJsonContext(ctx, params, result, "api/auth/entitlements")
 	return result, err
 }
 
 type GetEntitlementsForOrganizationResponse struct {
-	Data []struct {
-		Type       string
-		Id         string
-		Attributes struct {
-			Allowed []string
-		}
-		// TODO relationships
-		Links struct {
-			Self struct {
-				HRef string
-			}
-		}
-	}
-	// TODO included
+	Data     []*Entitlement
+	Included Included
+	Meta     PageMeta
+	Links    PageLinks
 }
 
+// GetEntitlementsForOrganization lists the organization's entitlements; an empty
+// orgId means the client's organization.
 func (client *Client) GetEntitlementsForOrganization(orgId string) (*GetEntitlementsForOrganizationResponse, error) {
+	return client.GetEntitlementsForOrganizationContext(context.Background(), orgId)
+}
+
+func (client *Client) GetEntitlementsForOrganizationContext(ctx context.Context, orgId string) (*GetEntitlementsForOrganizationResponse, error) {
+	orgId, err := client.organizationFor(ctx, orgId)
+	if err != nil {
+		return nil, err
+	}
 	params := map[string]interface{}{
-		"filter[entitlements][object][eq]": fmt.Sprintf("urn:x-swip:organizations:%s", orgId),
+		"filter[entitlements][object][eq]": organizationURN(orgId),
 	}
 	result := &GetEntitlementsForOrganizationResponse{}
-	_, err := client.GetJson(params, result, "api/auth/entitlements")
+	_, err = client.GetJsonContext(ctx, params, result, "api/auth/entitlements")
 	return result, err
 }
 
-type GetGroupsResponse struct {
-	Data []struct {
-		Type       string
-		Id         string
-		Attributes struct {
-			GroupName   string
-			DateCreated string `json:"date-created"`
-		}
-		// TODO relationships
-	}
-	Meta struct {
-		Limit  int
-		Offset int
-		Total  int
+type Group struct {
+	Type       string
+	Id         string
+	Attributes struct {
+		GroupName   string
+		DateCreated string `json:"date-created"`
 	}
+	Relationships map[string]Relationship
+}
+
+type GetGroupsResponse struct {
+	Data  []*Group
+	Meta  PageMeta
+	Links PageLinks
+}
+
+type GroupResponse struct {
+	Data *Group
 }
 
 func (client *Client) GetGroups() (*GetGroupsResponse, error) {
+	return client.GetGroupsContext(context.Background())
+}
+
+func (client *Client) GetGroupsContext(ctx context.Context) (*GetGroupsResponse, error) {
 	result := &GetGroupsResponse{}
-	_, err := client.GetJson(map[string]interface{}{}, result, "api/auth/groups")
+	_, err := client.GetJsonContext(ctx, map[string]interface{}{}, result, "api/auth/groups")
 	return result, err
 }
 
+// CreateGroup creates a group in the organization; an empty orgId means the client's organization.
+func (client *Client) CreateGroup(name string, orgId string) (*GroupResponse, error) {
+	return client.CreateGroupContext(context.Background(), name, orgId)
+}
+
+func (client *Client) CreateGroupContext(ctx context.Context, name string, orgId string) (*GroupResponse, error) {
+	orgId, err := client.organizationFor(ctx, orgId)
+	if err != nil {
+		return nil, err
+	}
+	bodyParams := map[string]interface{}{
+		"data": map[string]interface{}{
+			"attributes": map[string]interface{}{
+				"groupname": name,
+			},
+			"relationships": map[string]interface{}{
+				"organization": identifierData("organizations", orgId),
+			},
+			"type": "groups",
+		},
+	}
+	result := &GroupResponse{}
+	_, err = client.PostJsonContext(ctx, bodyParams, result, "api/auth/groups")
+	return result, err
+}
+
+func (client *Client) RenameGroup(groupId string, name string) (*GroupResponse, error) {
+	return client.RenameGroupContext(context.Background(), groupId, name)
+}
+
+func (client *Client) RenameGroupContext(ctx context.Context, groupId string, name string) (*GroupResponse, error) {
+	bodyParams := map[string]interface{}{
+		"data": map[string]interface{}{
+			"attributes": map[string]interface{}{
+				"groupname": name,
+			},
+			"id":   groupId,
+			"type": "groups",
+		},
+	}
+	result := &GroupResponse{}
+	_, err := client.PatchJsonContext(ctx, bodyParams, result, "api/auth/groups/%s", groupId)
+	return result, err
+}
+
+func (client *Client) DeleteGroup(groupId string) error {
+	return client.DeleteGroupContext(context.Background(), groupId)
+}
+
+func (client *Client) DeleteGroupContext(ctx context.Context, groupId string) error {
+	_, err := client.DeleteJsonContext(ctx, nil, "api/auth/groups/%s", groupId)
+	return err
+}
+
+func groupMembersBody(userIds []string) map[string]interface{} {
+	data := []map[string]interface{}{}
+	for _, userId := range userIds {
+		data = append(data, map[string]interface{}{
+			"type": "users",
+			"id":   userId,
+		})
+	}
+	return map[string]interface{}{"data": data}
+}
+
+func (client *Client) AddGroupMembers(groupId string, userIds []string) error {
+	return client.AddGroupMembersContext(context.Background(), groupId, userIds)
+}
+
+func (client *Client) AddGroupMembersContext(ctx context.Context, groupId string, userIds []string) error {
+	_, err := client.PostJsonContext(ctx, groupMembersBody(userIds), nil, "api/auth/groups/%s/relationships/users", groupId)
+	return err
+}
+
+func (client *Client) RemoveGroupMembers(groupId string, userIds []string) error {
+	return client.RemoveGroupMembersContext(context.Background(), groupId, userIds)
+}
+
+func (client *Client) RemoveGroupMembersContext(ctx context.Context, groupId string, userIds []string) error {
+	_, err := client.DeleteJsonContext(ctx, groupMembersBody(userIds), "api/auth/groups/%s/relationships/users", groupId)
+	return err
+}
+
 func (client *Client) GetRoleAssignmentsForProject(projectId string) (*GetRoleAssignmentsResponse, error) {
+	return client.GetRoleAssignmentsForProjectContext(context.Background(), projectId)
+}
+
+func (client *Client) GetRoleAssignmentsForProjectContext(ctx context.Context, projectId string) (*GetRoleAssignmentsResponse, error) {
 	result := &GetRoleAssignmentsResponse{}
-	params := map[string]interface{}{
-		"filter[role-assignments][object][$eq]": fmt.Sprintf("urn:x-swip:projects:%s", projectId),
-		"include[role-assignments][]":           []string{"role", "user", "group"},
-	}
-	_, err := client.GetJson(params, result, "api/auth/role-assignments")
+	query := NewQuery().
+		Set("filter[role-assignments][object][$eq]", fmt.Sprintf("urn:x-swip:projects:%s", projectId)).
+		Include("role-assignments", "role", "user", "group")
+	_, err := client.GetJsonContext(ctx, query, result, "api/auth/role-assignments")
 	return result, err
 }
 
 func (client *Client) GetRoleAssignmentsForUser(email string, offset int, limit int, isServiceAccount bool) (*GetRoleAssignmentsResponse, error) {
+	return client.GetRoleAssignmentsForUserContext(context.Background(), email, offset, limit, isServiceAccount)
+}
+
+func (client *Client) GetRoleAssignmentsForUserContext(ctx context.Context, email string, offset int, limit int, isServiceAccount bool) (*GetRoleAssignmentsResponse, error) {
 	result := &GetRoleAssignmentsResponse{}
 	params := map[string]interface{}{
 		"filter[role-assignments][user][email][$eq]": email,
@@ -192,78 +383,139 @@ func (client *Client) GetRoleAssignmentsForUser(email string, offset int, limit
 		"page[limit]":                                fmt.Sprintf("%d", limit),
 		"page[offset]":                               fmt.Sprintf("%d", offset),
 	}
-	_, err := client.GetJson(params, result, "api/auth/role-assignments")
+	_, err := client.GetJsonContext(ctx, params, result, "api/auth/role-assignments")
 	return result, err
 }
 
-type GetRoleAssignmentsResponse struct {
-	Data []struct {
-		Type       string
-		Id         string
-		Attributes struct {
-			ExpiresBy string `json:"expires-by"`
-			Object    string
-		}
-		Relationships map[string]struct {
-			Links struct {
-				Self    string
-				Related string
-			}
-			Data struct {
-				Type string
-				Id   string
-			}
-		}
-	}
-	Meta struct {
-		Limit  int
-		Offset int
-		Total  int
+type RoleAssignment struct {
+	Type       string
+	Id         string
+	Attributes struct {
+		ExpiresBy string `json:"expires-by"`
+		Object    string
 	}
+	Relationships map[string]Relationship
+}
+
+type GetRoleAssignmentsResponse struct {
+	Data     []*RoleAssignment
+	Included Included
+	Meta     PageMeta
+	Links    PageLinks
 }
 
 func (client *Client) GetRoleAssignments(offset int, limit int) (*GetRoleAssignmentsResponse, error) {
+	return client.GetRoleAssignmentsContext(context.Background(), offset, limit)
+}
+
+func (client *Client) GetRoleAssignmentsContext(ctx context.Context, offset int, limit int) (*GetRoleAssignmentsResponse, error) {
 	result := &GetRoleAssignmentsResponse{}
 	params := map[string]interface{}{
 		"page[limit]":  fmt.Sprintf("%d", limit),
 		"page[offset]": fmt.Sprintf("%d", offset),
 	}
-	_, err := client.GetJson(params, result, "api/auth/role-assignments")
+	_, err := client.GetJsonContext(ctx, params, result, "api/auth/role-assignments")
 	return result, err
 }
 
-type GetRolesResponse struct {
-	Data []struct {
-		Type       string
-		Id         string
-		Attributes struct {
-			RoleName    string
-			Permissions struct {
-				Organization []string
-				Project      []string
-			}
-		}
+type GetRoleAssignmentResponse struct {
+	Data     *RoleAssignment
+	Included Included
+}
+
+// GetRoleAssignment fetches a role assignment along with its role and user or group.
+func (client *Client) GetRoleAssignment(roleAssignmentId string) (*GetRoleAssignmentResponse, error) {
+	return client.GetRoleAssignmentContext(context.Background(), roleAssignmentId)
+}
+
+func (client *Client) GetRoleAssignmentContext(ctx context.Context, roleAssignmentId string) (*GetRoleAssignmentResponse, error) {
+	result := &GetRoleAssignmentResponse{}
+	query := NewQuery().Include("role-assignments", "role", "user", "group")
+	_, err := client.GetJsonContext(ctx, query, result, "api/auth/role-assignments/%s", roleAssignmentId)
+	if err == nil && result.Data == nil {
+		return result, errors.Errorf("no data in response for role assignment %s", roleAssignmentId)
 	}
-	Meta struct {
-		Limit  int
-		Offset int
-		Total  int
+	return result, err
+}
+
+// RoleAssignmentsPaginator walks all role assignments; items are *RoleAssignment.
+func (client *Client) RoleAssignmentsPaginator(pageSize int) *Paginator {
+	return NewPaginator(func(ctx context.Context, offset int, limit int) (*Page, error) {
+		ras, err := client.GetRoleAssignmentsContext(ctx, offset, limit)
+		if err != nil {
+			return nil, err
+		}
+		items := boxItems(len(ras.Data), func(i int) interface{} { return ras.Data[i] })
+		return &Page{Items: items, Meta: ras.Meta, Links: ras.Links}, nil
+	}, pageSize)
+}
+
+// The roles Polaris has.  They're fixed: they can be assigned, but not created or changed.
+const (
+	RoleObserver      = "Observer"
+	RoleContributor   = "Contributor"
+	RoleAdministrator = "Administrator"
+)
+
+// Role is a set of permissions, such as "users.read" on the organization or "projects.write" on
+// a project, that role assignments grant.
+type Role struct {
+	Type       string
+	Id         string
+	Attributes struct {
+		RoleName    string
+		Permissions RolePermissions
 	}
 }
 
-// TODO encode this important data in types:
-// Roles: Observer, Administrator, Contributor
-// Permissions:
-//    "ORGANIZATION" : [ "administer", "users.read", "users.readPrivate", "users.write", "groups.read", "groups.write", "projects.create" ],
-//    "PROJECT" : [ "administer", "projects.read", "projects.write" ]
+// RolePermissions lists what a role allows on the organization, and on the projects it's
+// assigned for.
+type RolePermissions struct {
+	Organization []string
+	Project      []string
+}
+
+type GetRolesResponse struct {
+	Data  []*Role
+	Meta  PageMeta
+	Links PageLinks
+}
 
 func (client *Client) GetRoles() (*GetRolesResponse, error) {
+	return client.GetRolesContext(context.Background())
+}
+
+func (client *Client) GetRolesContext(ctx context.Context) (*GetRolesResponse, error) {
 	result := &GetRolesResponse{}
-	_, err := client.GetJson(map[string]interface{}{}, result, "api/auth/roles")
+	_, err := client.GetJsonContext(ctx, map[string]interface{}{}, result, "api/auth/roles")
 	return result, err
 }
 
+// CreateRoleAssignment gives the user the role on the project; an empty orgId
+// means the client's organization.
 func (client *Client) CreateRoleAssignment(userId string, roleId string, projectId string, orgId string) (string, error) {
+	return client.CreateRoleAssignmentContext(context.Background(), userId, roleId, projectId, orgId)
+}
+
+func (client *Client) CreateRoleAssignmentContext(ctx context.Context, userId string, roleId string, projectId string, orgId string) (string, error) {
+	return client.createRoleAssignment(ctx, "user", identifierData("users", userId), roleId, projectId, orgId)
+}
+
+// CreateGroupRoleAssignment gives every member of the group the role on the project.
+func (client *Client) CreateGroupRoleAssignment(groupId string, roleId string, projectId string, orgId string) (string, error) {
+	return client.CreateGroupRoleAssignmentContext(context.Background(), groupId, roleId, projectId, orgId)
+}
+
+func (client *Client) CreateGroupRoleAssignmentContext(ctx context.Context, groupId string, roleId string, projectId string, orgId string) (string, error) {
+	return client.createRoleAssignment(ctx, "group", identifierData("groups", groupId), roleId, projectId, orgId)
+}
+
+// createRoleAssignment assigns the role to subject, which goes in the "user" or "group" relationship
+func (client *Client) createRoleAssignment(ctx context.Context, relationship string, subject map[string]interface{}, roleId string, projectId string, orgId string) (string, error) {
+	orgId, err := client.organizationFor(ctx, orgId)
+	if err != nil {
+		return "", err
+	}
 	objectId := fmt.Sprintf("urn:x-swip:projects:%s", projectId)
 	bodyParams := map[string]interface{}{
 		"data": map[string]interface{}{
@@ -272,68 +524,121 @@ func (client *Client) CreateRoleAssignment(userId string, roleId string, project
 				"object": objectId,
 			},
 			"relationships": map[string]interface{}{
-				//"group": map[string]interface{}{
-				//	"data": map[string]interface{}{
-				//		"type": "groups",
-				//		"id": groupId,
-				//	},
-				//},
-				"organization": map[string]interface{}{
-					"data": map[string]interface{}{
-						"type": "organizations",
-						"id":   orgId,
-					},
-				},
-				"role": map[string]interface{}{
-					"data": map[string]interface{}{
-						"type": "roles",
-						"id":   roleId,
-					},
-				},
-				"user": map[string]interface{}{
-					"data": map[string]interface{}{
-						"type": "users",
-						"id":   userId,
-					},
-				},
+				"organization": identifierData("organizations", orgId),
+				"role":         identifierData("roles", roleId),
+				relationship:   subject,
 			},
 			"type": "role-assignments",
 		},
 	}
-	return client.PostJson(bodyParams, nil, "api/auth/role-assignments")
+	return client.PostJsonContext(ctx, bodyParams, nil, "api/auth/role-assignments")
 }
 
-type GetUsersResponse struct {
-	Data []struct {
-		Type       string
-		Id         string
-		Attributes struct {
-			Owner    bool
-			Name     string
-			Email    string
-			Username string
-		}
-		// TODO relationships
+// UpdateRoleAssignment changes the role and expiry of an existing assignment.
+// An empty expiresBy, in the same format Polaris returns, means it never expires.
+func (client *Client) UpdateRoleAssignment(roleAssignmentId string, roleId string, expiresBy string) error {
+	return client.UpdateRoleAssignmentContext(context.Background(), roleAssignmentId, roleId, expiresBy)
+}
+
+func (client *Client) UpdateRoleAssignmentContext(ctx context.Context, roleAssignmentId string, roleId string, expiresBy string) error {
+	var expiry interface{}
+	if expiresBy != "" {
+		expiry = expiresBy
 	}
-	Meta struct {
-		Limit  int
-		Offset int
-		Total  int
+	bodyParams := map[string]interface{}{
+		"data": map[string]interface{}{
+			"attributes": map[string]interface{}{
+				"expires-by": expiry,
+			},
+			"relationships": map[string]interface{}{
+				"role": identifierData("roles", roleId),
+			},
+			"id":   roleAssignmentId,
+			"type": "role-assignments",
+		},
+	}
+	_, err := client.PatchJsonContext(ctx, bodyParams, nil, "api/auth/role-assignments/%s", roleAssignmentId)
+	return err
+}
+
+func (client *Client) DeleteRoleAssignment(roleAssignmentId string) error {
+	return client.DeleteRoleAssignmentContext(context.Background(), roleAssignmentId)
+}
+
+func (client *Client) DeleteRoleAssignmentContext(ctx context.Context, roleAssignmentId string) error {
+	_, err := client.DeleteJsonContext(ctx, nil, "api/auth/role-assignments/%s", roleAssignmentId)
+	return err
+}
+
+type User struct {
+	Type       string
+	Id         string
+	Attributes struct {
+		Owner     bool
+		Name      string
+		Email     string
+		Username  string
+		Enabled   bool
+		Automated bool
 	}
+	Relationships map[string]Relationship
+}
+
+type GetUsersResponse struct {
+	Data  []*User
+	Meta  PageMeta
+	Links PageLinks
 }
 
 func (client *Client) GetUsers(offset int, limit int) (*GetUsersResponse, error) {
+	return client.GetUsersContext(context.Background(), offset, limit)
+}
+
+func (client *Client) GetUsersContext(ctx context.Context, offset int, limit int) (*GetUsersResponse, error) {
 	result := &GetUsersResponse{}
 	params := map[string]interface{}{
 		// "filter[users][automated][$eq]": "true",
 		"page[limit]":  fmt.Sprintf("%d", limit),
 		"page[offset]": fmt.Sprintf("%d", offset),
 	}
-	_, err := client.GetJson(params, result, "api/auth/users")
+	_, err := client.GetJsonContext(ctx, params, result, "api/auth/users")
+	return result, err
+}
+
+// UsersPaginator walks all users; items are *User.
+func (client *Client) UsersPaginator(pageSize int) *Paginator {
+	return NewPaginator(func(ctx context.Context, offset int, limit int) (*Page, error) {
+		users, err := client.GetUsersContext(ctx, offset, limit)
+		if err != nil {
+			return nil, err
+		}
+		items := boxItems(len(users.Data), func(i int) interface{} { return users.Data[i] })
+		return &Page{Items: items, Meta: users.Meta, Links: users.Links}, nil
+	}, pageSize)
+}
+
+type GetUserResponse struct {
+	Data *User
+}
+
+func (client *Client) GetUser(userId string) (*GetUserResponse, error) {
+	return client.GetUserContext(context.Background(), userId)
+}
+
+func (client *Client) GetUserContext(ctx context.Context, userId string) (*GetUserResponse, error) {
+	result := &GetUserResponse{}
+	_, err := client.GetJsonContext(ctx, NewQuery(), result, "api/auth/users/%s", userId)
+	if err == nil && result.Data == nil {
+		return result, errors.Errorf("no data in response for user %s", userId)
+	}
 	return result, err
 }
 
 func (client *Client) GetUserByEmail(email string) (*GetUsersResponse, error) {
+	return client.GetUserByEmailContext(context.Background(), email)
+}
+
+func (client *Client) GetUserByEmailContext(ctx context.Context, email string) (*GetUsersResponse, error) {
 	result := &GetUsersResponse{}
 	params := map[string]interface{}{
 		// "filter[users][automated][$eq]": "true",
@@ -341,23 +646,24 @@ func (client *Client) GetUserByEmail(email string) (*GetUsersResponse, error) {
 		"page[limit]":               "100",
 		"page[offset]":              "0",
 	}
-	_, err := client.GetJson(params, result, "api/auth/users")
+	_, err := client.GetJsonContext(ctx, params, result, "api/auth/users")
 	return result, err
 }
 
 type CreateUserResponse struct {
-	Data struct {
-		Type       string
-		Id         string
-		Attributes struct {
-			Name     string
-			Email    string
-			Username string
-		}
-	}
+	Data User
 }
 
+// CreateUser adds a user to the organization; an empty orgId means the client's organization.
 func (client *Client) CreateUser(email string, name string, orgId string) (*CreateUserResponse, error) {
+	return client.CreateUserContext(context.Background(), email, name, orgId)
+}
+
+func (client *Client) CreateUserContext(ctx context.Context, email string, name string, orgId string) (*CreateUserResponse, error) {
+	orgId, err := client.organizationFor(ctx, orgId)
+	if err != nil {
+		return nil, err
+	}
 	bodyParams := map[string]interface{}{
 		"data": map[string]interface{}{
 			"attributes": map[string]interface{}{
@@ -382,11 +688,19 @@ func (client *Client) CreateUser(email string, name string, orgId string) (*Crea
 		},
 	}
 	result := &CreateUserResponse{}
-	_, err := client.PostJson(bodyParams, result, "api/auth/users")
+	_, err = client.PostJsonContext(ctx, bodyParams, result, "api/auth/users")
 	return result, err
 }
 
 func (client *Client) CreateServiceAccount(email string, name string, orgId string, password string) (*CreateUserResponse, error) {
+	return client.CreateServiceAccountContext(context.Background(), email, name, orgId, password)
+}
+
+func (client *Client) CreateServiceAccountContext(ctx context.Context, email string, name string, orgId string, password string) (*CreateUserResponse, error) {
+	orgId, err := client.organizationFor(ctx, orgId)
+	if err != nil {
+		return nil, err
+	}
 	bodyParams := map[string]interface{}{
 		"data": map[string]interface{}{
 			"attributes": map[string]interface{}{
@@ -419,28 +733,73 @@ func (client *Client) CreateServiceAccount(email string, name string, orgId stri
 		},
 	}
 	result := &CreateUserResponse{}
-	_, err := client.PostJson(bodyParams, result, "api/auth/users")
+	_, err = client.PostJsonContext(ctx, bodyParams, result, "api/auth/users")
 	return result, err
 }
 
-type GetOrganizationsResponse struct {
-	Data []struct {
-		Type       string
-		Id         string
-		Attributes struct {
-			OrganizationName string
-			Description      string
-		}
+// UserUpdate holds the user attributes to change; nil fields are left alone.
+type UserUpdate struct {
+	Name    *string
+	Email   *string
+	Enabled *bool
+}
+
+func (client *Client) UpdateUser(userId string, update *UserUpdate) (*CreateUserResponse, error) {
+	return client.UpdateUserContext(context.Background(), userId, update)
+}
+
+func (client *Client) UpdateUserContext(ctx context.Context, userId string, update *UserUpdate) (*CreateUserResponse, error) {
+	if update == nil {
+		return nil, errors.Errorf("unable to update user %s: no update given", userId)
+	}
+	attributes := map[string]interface{}{}
+	if update.Name != nil {
+		attributes["name"] = *update.Name
+	}
+	if update.Email != nil {
+		attributes["email"] = *update.Email
 	}
-	Meta struct {
-		Limit  int
-		Offset int
-		Total  int
+	if update.Enabled != nil {
+		attributes["enabled"] = *update.Enabled
 	}
+	if len(attributes) == 0 {
+		return nil, errors.Errorf("unable to update user %s: the update doesn't change anything", userId)
+	}
+	return client.patchUser(ctx, userId, attributes)
+}
+
+func (client *Client) UpdateServiceAccountPassword(userId string, password string) error {
+	return client.UpdateServiceAccountPasswordContext(context.Background(), userId, password)
+}
+
+func (client *Client) UpdateServiceAccountPasswordContext(ctx context.Context, userId string, password string) error {
+	_, err := client.patchUser(ctx, userId, map[string]interface{}{
+		"password-login": map[string]string{
+			"password": password,
+		},
+	})
+	return err
 }
 
-func (client *Client) GetOrganizations() (*GetOrganizationsResponse, error) {
-	result := &GetOrganizationsResponse{}
-	_, err := client.GetJson(map[string]interface{}{}, result, "api/auth/organizations")
+func (client *Client) patchUser(ctx context.Context, userId string, attributes map[string]interface{}) (*CreateUserResponse, error) {
+	bodyParams := map[string]interface{}{
+		"data": map[string]interface{}{
+			"attributes": attributes,
+			"id":         userId,
+			"type":       "users",
+		},
+	}
+	result := &CreateUserResponse{}
+	_, err := client.PatchJsonContext(ctx, bodyParams, result, "api/auth/users/%s", userId)
 	return result, err
 }
+
+// DeleteUser deletes a user or a service account.
+func (client *Client) DeleteUser(userId string) error {
+	return client.DeleteUserContext(context.Background(), userId)
+}
+
+func (client *Client) DeleteUserContext(ctx context.Context, userId string) error {
+	_, err := client.DeleteJsonContext(ctx, nil, "api/auth/users/%s", userId)
+	return err
+}
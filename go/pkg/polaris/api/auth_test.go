@@ -0,0 +1,145 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func RunAuthTests() {
+	Describe("Auth resource management", func() {
+		var server *testServer
+		var client *Client
+
+		BeforeEach(func() {
+			server = newTestServer(serveBody(`{"data":{"type":"users","id":"user-1","attributes":{"name":"renamed","enabled":false}}}`))
+			client = NewBearerTokenClient(server.URL, "token")
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should only send the user attributes being changed", func() {
+			enabled := false
+			user, err := client.UpdateUser("user-1", &UserUpdate{Enabled: &enabled})
+			Expect(err).To(BeNil())
+			Expect(user.Data.Attributes.Name).To(Equal("renamed"))
+
+			request := server.last()
+			Expect(request.Method).To(Equal(http.MethodPatch))
+			Expect(request.Path).To(Equal("/api/auth/users/user-1"))
+			data := request.Body["data"].(map[string]interface{})
+			Expect(data["id"]).To(Equal("user-1"))
+			Expect(data["attributes"]).To(Equal(map[string]interface{}{"enabled": false}))
+		})
+
+		It("should refuse updates that don't change anything", func() {
+			_, err := client.UpdateUser("user-1", nil)
+			Expect(err).ToNot(BeNil())
+			_, err = client.UpdateUser("user-1", &UserUpdate{})
+			Expect(err).ToNot(BeNil())
+			Expect(server.count("", "")).To(Equal(0))
+		})
+
+		It("should delete resources by id", func() {
+			Expect(client.DeleteUser("user-1")).To(Succeed())
+			Expect(client.DeleteRoleAssignment("ra-1")).To(Succeed())
+			Expect(client.DeleteGroup("group-1")).To(Succeed())
+			Expect(client.DeleteAccessToken("token-1")).To(Succeed())
+
+			paths := []string{}
+			for _, request := range server.requests {
+				Expect(request.Method).To(Equal(http.MethodDelete))
+				Expect(request.Body).To(BeNil())
+				paths = append(paths, request.Path)
+			}
+			Expect(paths).To(Equal([]string{
+				"/api/auth/users/user-1",
+				"/api/auth/role-assignments/ra-1",
+				"/api/auth/groups/group-1",
+				"/api/auth/apitokens/token-1",
+			}))
+		})
+
+		It("should revoke access tokens without deleting them", func() {
+			Expect(client.RevokeAccessToken("token-1")).To(Succeed())
+			request := server.last()
+			Expect(request.Method).To(Equal(http.MethodPatch))
+			Expect(request.Path).To(Equal("/api/auth/apitokens/token-1"))
+			Expect(request.Body["data"].(map[string]interface{})["attributes"]).To(Equal(map[string]interface{}{"revoked": true}))
+		})
+
+		It("should manage group membership through the relationship endpoint", func() {
+			Expect(client.AddGroupMembers("group-1", []string{"user-1", "user-2"})).To(Succeed())
+			Expect(server.last().Method).To(Equal(http.MethodPost))
+			Expect(server.last().Path).To(Equal("/api/auth/groups/group-1/relationships/users"))
+
+			Expect(client.RemoveGroupMembers("group-1", []string{"user-2"})).To(Succeed())
+			request := server.last()
+			Expect(request.Method).To(Equal(http.MethodDelete))
+			Expect(request.Path).To(Equal("/api/auth/groups/group-1/relationships/users"))
+			Expect(request.Body["data"]).To(Equal([]interface{}{
+				map[string]interface{}{"type": "users", "id": "user-2"},
+			}))
+		})
+
+		It("should assign roles to groups", func() {
+			_, err := client.CreateGroupRoleAssignment("group-1", "role-1", "project-1", "org-1")
+			Expect(err).To(BeNil())
+			relationships := server.last().Body["data"].(map[string]interface{})["relationships"].(map[string]interface{})
+			Expect(relationships).To(HaveKey("group"))
+			Expect(relationships).ToNot(HaveKey("user"))
+			Expect(relationships["role"]).To(Equal(map[string]interface{}{
+				"data": map[string]interface{}{"type": "roles", "id": "role-1"},
+			}))
+		})
+
+		It("should surface failed deletes", func() {
+			server.Close()
+			Expect(client.DeleteUser("user-1")).ToNot(Succeed())
+		})
+
+		It("should decode to-one and to-many relationships", func() {
+			response := &GetEntitlementsForOrganizationResponse{}
+			err := json.Unmarshal([]byte(`{
+				"data": [{"type": "entitlements", "id": "e-1", "attributes": {"allowed": ["administer"], "object": "urn:x-swip:organizations:org-1"},
+					"relationships": {
+						"user": {"links": {"related": "/api/auth/users/user-1"}, "data": {"type": "users", "id": "user-1"}},
+						"roles": {"data": [{"type": "roles", "id": "role-1"}, {"type": "roles", "id": "role-2"}]},
+						"group": {"data": null}}}],
+				"included": [{"type": "users", "id": "user-1", "attributes": {"name": "someone"}}],
+				"meta": {"offset": 0, "limit": 25, "total": 1}}`), response)
+			Expect(err).To(BeNil())
+			relationships := response.Data[0].Relationships
+			Expect(relationships["user"].Data.Id).To(Equal("user-1"))
+			Expect(relationships["user"].Links.Related).To(Equal("/api/auth/users/user-1"))
+			Expect(relationships["roles"].Data.IsMany).To(BeTrue())
+			Expect(relationships["roles"].Data.Many).To(HaveLen(2))
+			Expect(relationships["group"].Data.Id).To(Equal(""))
+			Expect(response.Included[0].Attributes["name"]).To(Equal("someone"))
+		})
+	})
+}
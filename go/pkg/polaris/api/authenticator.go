@@ -0,0 +1,93 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// Authenticator is a strategy for obtaining the bearer token a Client sends.
+type Authenticator interface {
+	// Authenticate returns a new session token
+	Authenticate(ctx context.Context, client *Client) (string, error)
+	// CanRefresh reports whether Authenticate can be called again to replace an expired token
+	CanRefresh() bool
+}
+
+// PasswordAuthenticator logs in with email and password.
+type PasswordAuthenticator struct {
+	Email    string
+	Password string
+}
+
+func (pa *PasswordAuthenticator) Authenticate(ctx context.Context, client *Client) (string, error) {
+	token, err := client.postAuthenticate(ctx, map[string]string{
+		"email":    pa.Email,
+		"password": pa.Password,
+	})
+	if err != nil {
+		return "", errors.WithMessagef(err, "unable to authenticate as %s", pa.Email)
+	}
+	return token, nil
+}
+
+func (pa *PasswordAuthenticator) CanRefresh() bool {
+	return pa.Email != "" && pa.Password != ""
+}
+
+// AccessTokenAuthenticator exchanges a long-lived Polaris access token, such as
+// one created by GetAccessToken, for a session token.
+type AccessTokenAuthenticator struct {
+	AccessToken string
+}
+
+func (ata *AccessTokenAuthenticator) Authenticate(ctx context.Context, client *Client) (string, error) {
+	token, err := client.postAuthenticate(ctx, map[string]string{
+		"accesstoken": ata.AccessToken,
+	})
+	if err != nil {
+		return "", errors.WithMessagef(err, "unable to authenticate with access token")
+	}
+	return token, nil
+}
+
+func (ata *AccessTokenAuthenticator) CanRefresh() bool {
+	return ata.AccessToken != ""
+}
+
+// BearerTokenAuthenticator uses a pre-issued session token as is.  It can't be
+// refreshed, so the client stops working once the token expires.
+type BearerTokenAuthenticator struct {
+	Token string
+}
+
+func (bta *BearerTokenAuthenticator) Authenticate(ctx context.Context, client *Client) (string, error) {
+	if bta.Token == "" {
+		return "", errors.New("empty bearer token")
+	}
+	return bta.Token, nil
+}
+
+func (bta *BearerTokenAuthenticator) CanRefresh() bool {
+	return false
+}
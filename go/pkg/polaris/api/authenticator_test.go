@@ -0,0 +1,72 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api
+
+import (
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func RunAuthenticatorTests() {
+	Describe("Authenticators", func() {
+		It("should exchange an access token for a session", func() {
+			server, issuer := newAuthServer(time.Hour)
+			defer server.Close()
+			client := NewAccessTokenClient(server.URL, "long-lived-token")
+
+			_, err := client.GetUsers(0, 10)
+			Expect(err).To(BeNil())
+			Expect(server.count(http.MethodPost, "/api/auth/authenticate")).To(Equal(1))
+
+			issuer.expireTokens()
+			_, err = client.GetUsers(0, 10)
+			Expect(err).To(BeNil())
+			Expect(server.count(http.MethodPost, "/api/auth/authenticate")).To(Equal(2))
+		})
+
+		It("should prefer an access token over a password", func() {
+			client := NewClientFromCredentials("http://polaris", "user@example.com", "password", "long-lived-token")
+			Expect(client.Authenticator).To(Equal(&AccessTokenAuthenticator{AccessToken: "long-lived-token"}))
+
+			client = NewClientFromCredentials("http://polaris", "user@example.com", "password", "")
+			Expect(client.Authenticator).To(Equal(&PasswordAuthenticator{Email: "user@example.com", Password: "password"}))
+		})
+
+		It("should use a bearer token without logging in", func() {
+			server, issuer := newAuthServer(time.Hour)
+			defer server.Close()
+			session := NewClient(server.URL, "user@example.com", "password")
+			Expect(session.Authenticate()).To(Succeed())
+
+			client := NewBearerTokenClient(server.URL, session.AuthToken)
+			_, err := client.GetUsers(0, 10)
+			Expect(err).To(BeNil())
+
+			issuer.expireTokens()
+			_, err = client.GetUsers(0, 10)
+			Expect(err).ToNot(BeNil())
+			Expect(server.count(http.MethodPost, "/api/auth/authenticate")).To(Equal(1))
+		})
+	})
+}
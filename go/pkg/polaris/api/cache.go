@@ -0,0 +1,197 @@
+/*
+Copyright (C) 2018 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache results recorded in the response cache metrics.
+const (
+	cacheHit         = "hit"
+	cacheMiss        = "miss"
+	cacheRevalidated = "revalidated"
+	cacheBypass      = "bypass"
+)
+
+// CacheConfig configures a ResponseCache.
+type CacheConfig struct {
+	// MaxEntries bounds the cache; the least recently used response is evicted first
+	MaxEntries int
+	// DefaultTTL is how long responses without a Cache-Control max-age stay
+	// fresh.  With 0 they're still kept if they have an ETag, and revalidated on every use.
+	DefaultTTL time.Duration
+	// PathTTLs override the server's Cache-Control by path template, such as
+	// "api/auth/roles"; only no-store is still honored.
+	PathTTLs map[string]time.Duration
+}
+
+func DefaultCacheConfig() *CacheConfig {
+	return &CacheConfig{
+		MaxEntries: 1000,
+		PathTTLs: map[string]time.Duration{
+			"api/auth/roles":             10 * time.Minute,
+			"api/taxonomy/v0/taxonomies": 10 * time.Minute,
+		},
+	}
+}
+
+type cacheEntry struct {
+	key     string
+	path    string
+	body    []byte
+	etag    string
+	expires time.Time
+}
+
+// ResponseCache is an LRU cache of GET responses, shared by the requests of a
+// single Client.  Don't share one between clients logged in as different
+// users: entries aren't keyed by user.
+type ResponseCache struct {
+	config  CacheConfig
+	mux     *sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+func NewResponseCache(config *CacheConfig) *ResponseCache {
+	return &ResponseCache{
+		config:  *config,
+		mux:     &sync.Mutex{},
+		entries: map[string]*list.Element{},
+		lru:     list.New(),
+	}
+}
+
+func (rc *ResponseCache) Len() int {
+	rc.mux.Lock()
+	defer rc.mux.Unlock()
+	return rc.lru.Len()
+}
+
+// Purge empties the cache.
+func (rc *ResponseCache) Purge() {
+	rc.mux.Lock()
+	defer rc.mux.Unlock()
+	rc.entries = map[string]*list.Element{}
+	rc.lru.Init()
+}
+
+func (rc *ResponseCache) get(key string) *cacheEntry {
+	rc.mux.Lock()
+	defer rc.mux.Unlock()
+	element, ok := rc.entries[key]
+	if !ok {
+		return nil
+	}
+	rc.lru.MoveToFront(element)
+	// hand out a copy, so the caller can read it without the lock
+	entry := *element.Value.(*cacheEntry)
+	return &entry
+}
+
+func (rc *ResponseCache) put(entry *cacheEntry) {
+	rc.mux.Lock()
+	defer rc.mux.Unlock()
+	if element, ok := rc.entries[entry.key]; ok {
+		element.Value = entry
+		rc.lru.MoveToFront(element)
+		return
+	}
+	rc.entries[entry.key] = rc.lru.PushFront(entry)
+	for rc.config.MaxEntries > 0 && rc.lru.Len() > rc.config.MaxEntries {
+		oldest := rc.lru.Back()
+		rc.lru.Remove(oldest)
+		delete(rc.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// invalidate drops the cached responses for paths under pathPrefix
+func (rc *ResponseCache) invalidate(pathPrefix string) {
+	rc.mux.Lock()
+	defer rc.mux.Unlock()
+	for key, element := range rc.entries {
+		if strings.HasPrefix(element.Value.(*cacheEntry).path, pathPrefix) {
+			rc.lru.Remove(element)
+			delete(rc.entries, key)
+		}
+	}
+}
+
+// ttl decides how long a response stays fresh, and whether it may be stored at all
+func (rc *ResponseCache) ttl(pathTemplate string, header http.Header) (time.Duration, bool) {
+	var maxAge *time.Duration
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case directive == "no-store":
+			return 0, false
+		case directive == "no-cache":
+			zero := time.Duration(0)
+			maxAge = &zero
+		case strings.HasPrefix(directive, "max-age="):
+			if seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil && maxAge == nil {
+				age := time.Duration(seconds) * time.Second
+				maxAge = &age
+			}
+		}
+	}
+	if override, ok := rc.config.PathTTLs[pathTemplate]; ok {
+		return override, true
+	}
+	if maxAge != nil {
+		return *maxAge, true
+	}
+	return rc.config.DefaultTTL, true
+}
+
+func cacheKey(acceptHeader string, url string, queryParams url.Values) string {
+	return acceptHeader + " " + url + "?" + queryParams.Encode()
+}
+
+// collectionPath is the part of a path template before any arguments, so that
+// a write to api/auth/users/%s invalidates cached reads of api/auth/users
+func collectionPath(pathTemplate string) string {
+	if index := strings.Index(pathTemplate, "%"); index >= 0 {
+		pathTemplate = pathTemplate[:index]
+	}
+	return strings.TrimSuffix(pathTemplate, "/")
+}
+
+type bypassCacheKey struct{}
+
+// WithoutCache makes requests using ctx skip the response cache: they neither
+// read from it nor fill it.
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassCacheKey{}, true)
+}
+
+func cacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassCacheKey{}).(bool)
+	return bypass
+}
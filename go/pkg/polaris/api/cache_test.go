@@ -0,0 +1,161 @@
+/*
+Copyright (C) 2018 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// serveCacheable answers every GET with the same body and the given headers,
+// honoring If-None-Match against its ETag
+func serveCacheable(headers map[string]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			return
+		}
+		for name, value := range headers {
+			w.Header().Set(name, value)
+		}
+		if etag := headers["ETag"]; etag != "" && r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		fmt.Fprint(w, `{"data":[{"type":"roles","id":"role-1","attributes":{"rolename":"Observer"}}],"meta":{"total":1}}`)
+	}
+}
+
+// conditionalRequests counts the requests that were sent with If-None-Match
+func conditionalRequests(server *testServer) int {
+	server.mux.Lock()
+	defer server.mux.Unlock()
+	count := 0
+	for _, request := range server.requests {
+		if request.Header.Get("If-None-Match") != "" {
+			count++
+		}
+	}
+	return count
+}
+
+func cachingClient(url string, config *CacheConfig) *Client {
+	client := NewBearerTokenClient(url, "token")
+	client.Cache = NewResponseCache(config)
+	return client
+}
+
+func RunCacheTests() {
+	Describe("Response cache", func() {
+		It("should revalidate with the ETag", func() {
+			server := newTestServer(serveCacheable(map[string]string{"ETag": `"v1"`, "Cache-Control": "no-cache"}))
+			defer server.Close()
+			client := cachingClient(server.URL, &CacheConfig{MaxEntries: 10})
+
+			for i := 0; i < 3; i++ {
+				roles, err := client.GetRoles()
+				Expect(err).To(BeNil())
+				Expect(roles.Data[0].Attributes.RoleName).To(Equal("Observer"))
+			}
+			Expect(server.count(http.MethodGet, "/api/auth/roles")).To(Equal(3))
+			Expect(conditionalRequests(server)).To(Equal(2))
+		})
+
+		It("should serve fresh responses without asking", func() {
+			server := newTestServer(serveCacheable(map[string]string{"Cache-Control": "private, max-age=60"}))
+			defer server.Close()
+			client := cachingClient(server.URL, &CacheConfig{MaxEntries: 10})
+
+			for i := 0; i < 3; i++ {
+				_, err := client.GetRoles()
+				Expect(err).To(BeNil())
+			}
+			Expect(server.count(http.MethodGet, "/api/auth/roles")).To(Equal(1))
+
+			_, err := client.GetRolesContext(WithoutCache(context.Background()))
+			Expect(err).To(BeNil())
+			Expect(server.count(http.MethodGet, "/api/auth/roles")).To(Equal(2))
+		})
+
+		It("should let path TTLs override everything but no-store", func() {
+			server := newTestServer(serveCacheable(map[string]string{"Cache-Control": "no-cache"}))
+			defer server.Close()
+			client := cachingClient(server.URL, &CacheConfig{MaxEntries: 10, PathTTLs: map[string]time.Duration{"api/auth/roles": time.Minute}})
+
+			for i := 0; i < 2; i++ {
+				_, err := client.GetRoles()
+				Expect(err).To(BeNil())
+				_, err = client.GetOrganizations()
+				Expect(err).To(BeNil())
+			}
+			Expect(server.count(http.MethodGet, "/api/auth/roles")).To(Equal(1))
+			Expect(server.count(http.MethodGet, "/api/auth/organizations")).To(Equal(2))
+
+			noStore := newTestServer(serveCacheable(map[string]string{"Cache-Control": "no-store", "ETag": `"v1"`}))
+			defer noStore.Close()
+			client = cachingClient(noStore.URL, &CacheConfig{MaxEntries: 10, PathTTLs: map[string]time.Duration{"api/auth/roles": time.Minute}})
+			for i := 0; i < 2; i++ {
+				_, err := client.GetRoles()
+				Expect(err).To(BeNil())
+			}
+			Expect(noStore.count(http.MethodGet, "/api/auth/roles")).To(Equal(2))
+			Expect(client.Cache.Len()).To(Equal(0))
+		})
+
+		It("should evict the least recently used response", func() {
+			server := newTestServer(serveCacheable(map[string]string{"Cache-Control": "max-age=60"}))
+			defer server.Close()
+			client := cachingClient(server.URL, &CacheConfig{MaxEntries: 2})
+
+			for _, offset := range []int{0, 1, 0, 2, 0, 1} {
+				_, err := client.GetUsers(offset, 10)
+				Expect(err).To(BeNil())
+			}
+			// offset 1 was evicted by offset 2, offset 0 was kept by being used
+			Expect(server.count(http.MethodGet, "/api/auth/users")).To(Equal(4))
+			Expect(client.Cache.Len()).To(Equal(2))
+		})
+
+		It("should drop cached reads of a collection after writing to it", func() {
+			server := newTestServer(serveCacheable(map[string]string{"Cache-Control": "max-age=60"}))
+			defer server.Close()
+			client := cachingClient(server.URL, &CacheConfig{MaxEntries: 10})
+
+			_, err := client.GetRoleAssignments(0, 10)
+			Expect(err).To(BeNil())
+			_, err = client.GetRoles()
+			Expect(err).To(BeNil())
+			Expect(client.DeleteRoleAssignment("ra-1")).To(Succeed())
+
+			_, err = client.GetRoleAssignments(0, 10)
+			Expect(err).To(BeNil())
+			_, err = client.GetRoles()
+			Expect(err).To(BeNil())
+			Expect(server.count(http.MethodGet, "/api/auth/role-assignments")).To(Equal(2))
+			Expect(server.count(http.MethodGet, "/api/auth/roles")).To(Equal(1))
+		})
+	})
+}
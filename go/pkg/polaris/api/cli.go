@@ -24,12 +24,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/blackducksoftware/cerebros/go/pkg/util"
-	"github.com/go-resty/resty/v2"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"io/ioutil"
 	"path"
-	"time"
 )
 
 type OSType string
@@ -119,13 +117,11 @@ func (client *Client) DownloadCli(dir string, osType OSType) (string, error) {
 	filename := fmt.Sprintf("polaris-cli-%s", osType.PlatformType())
 	downloadPath := path.Join(dir, filename) + ".zip"
 	log.Infof("fetching polaris cli at %s to file %s", url, downloadPath)
-	restyClient := resty.New().SetTimeout(5 * time.Minute)
 
 	// download
-	resp, err := restyClient.R().SetOutput(downloadPath).Get(url)
-	log.Debugf("response from resty: %s", resp.String())
+	err := client.Downloader.Download(url, downloadPath, "")
 	if err != nil {
-		return "", errors.Wrapf(err, "http GET request to %s failed", url)
+		return "", errors.WithMessagef(err, "unable to download polaris cli")
 	}
 
 	// unzip
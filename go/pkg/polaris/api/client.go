@@ -21,124 +21,322 @@ under the License.
 package api
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"github.com/blackducksoftware/cerebros/go/pkg/util"
 	"github.com/go-resty/resty/v2"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	"net/http"
 	"sync"
 	"time"
 )
 
+// DefaultRequestTimeout keeps a hung Polaris endpoint from blocking callers forever.
+const DefaultRequestTimeout = 2 * time.Minute
+
 type Client struct {
 	URL         string
 	Email       string
 	Password    string
 	AuthToken   string
 	RestyClient *resty.Client
-	// authMux needs to be used whenever AuthToken is touched
-	authMux *sync.RWMutex
+	// Authenticator obtains AuthToken; NewClient uses a PasswordAuthenticator for Email and Password
+	Authenticator Authenticator
+	// Downloader is used for large binary downloads such as the Polaris CLI
+	Downloader *util.Downloader
+	// RefreshBefore is how long before the token expires that it gets refreshed
+	RefreshBefore time.Duration
+	// RequestTimeout bounds each HTTP attempt, on top of any deadline on the caller's context; 0 means no limit
+	RequestTimeout time.Duration
+	// Retry controls retries of failed requests; nil, the default, disables them
+	Retry *RetryPolicy
+	// CircuitBreaker configures the per-endpoint circuit breakers; nil, the default, disables them
+	CircuitBreaker *CircuitBreakerConfig
+	// Cache holds GET responses for reuse and revalidation; nil, the default, disables caching
+	Cache *ResponseCache
+	// RateLimit throttles requests, overall and per path template; nil, the default, doesn't
+	RateLimit   *RateLimitConfig
+	breakersMux *sync.Mutex
+	breakers    map[string]*circuitBreaker
+	// orgMux guards organizationId, the organization calls act in by default
+	orgMux         *sync.RWMutex
+	organizationId string
+	// taxonomyMux guards taxonomyTrees, the trees LoadTaxonomyTree has built by organization
+	taxonomyMux   *sync.Mutex
+	taxonomyTrees map[string]*TaxonomyTree
+	// limitersMux guards globalLimiter and limiters, which are created on first use
+	limitersMux   *sync.Mutex
+	globalLimiter *limiter
+	limiters      map[string]*limiter
+	// authMux needs to be used whenever AuthToken or tokenExpiry is touched
+	authMux     *sync.RWMutex
+	tokenExpiry time.Time
+	// refreshMux guards refreshCall, which de-duplicates concurrent token refreshes
+	refreshMux  *sync.Mutex
+	refreshCall *authCall
 }
 
 func NewClient(url string, email string, password string) *Client {
+	client := NewClientWithAuthenticator(url, &PasswordAuthenticator{Email: email, Password: password})
+	client.Email = email
+	client.Password = password
+	return client
+}
+
+// NewClientFromCredentials prefers accessToken if it's set, falling back to email and password.
+func NewClientFromCredentials(url string, email string, password string, accessToken string) *Client {
+	if accessToken != "" {
+		return NewAccessTokenClient(url, accessToken)
+	}
+	return NewClient(url, email, password)
+}
+
+// NewAccessTokenClient creates a client that logs in with a Polaris access token instead of a password.
+func NewAccessTokenClient(url string, accessToken string) *Client {
+	return NewClientWithAuthenticator(url, &AccessTokenAuthenticator{AccessToken: accessToken})
+}
+
+// NewBearerTokenClient creates a client that uses an already issued session token.
+func NewBearerTokenClient(url string, token string) *Client {
+	client := NewClientWithAuthenticator(url, &BearerTokenAuthenticator{Token: token})
+	client.setToken(token)
+	return client
+}
+
+func NewClientWithAuthenticator(url string, authenticator Authenticator) *Client {
 	return &Client{
-		URL:         url,
-		Email:       email,
-		Password:    password,
-		AuthToken:   "",
-		RestyClient: resty.New(),
-		authMux:     &sync.RWMutex{},
+		URL:            url,
+		AuthToken:      "",
+		RestyClient:    resty.New(),
+		Authenticator:  authenticator,
+		Downloader:     util.NewDownloader(""),
+		RefreshBefore:  DefaultRefreshBefore,
+		RequestTimeout: DefaultRequestTimeout,
+		breakersMux:    &sync.Mutex{},
+		breakers:       map[string]*circuitBreaker{},
+		orgMux:         &sync.RWMutex{},
+		taxonomyMux:    &sync.Mutex{},
+		taxonomyTrees:  map[string]*TaxonomyTree{},
+		limitersMux:    &sync.Mutex{},
+		limiters:       map[string]*limiter{},
+		authMux:        &sync.RWMutex{},
+		refreshMux:     &sync.Mutex{},
+	}
+}
+
+// execute issues the request built by newRequest, refreshing the token first
+// if it's about to expire and retrying once with a new token on a 401.  Other
+// failures are retried by sendWithRetry.
+func (client *Client) execute(ctx context.Context, method string, url string, pathTemplate string, newRequest func(token string) *resty.Request) (*resty.Response, error) {
+	token := client.freshToken(ctx)
+	resp, err := client.sendWithRetry(ctx, method, url, pathTemplate, func() *resty.Request { return newRequest(token) })
+	if err != nil || resp.StatusCode() != http.StatusUnauthorized || !client.canRefresh() {
+		return resp, err
+	}
+	log.Infof("got 401 from %s %s, refreshing token and retrying", method, url)
+	if err := client.refreshToken(ctx, token); err != nil {
+		log.Errorf("unable to refresh polaris token after 401: %+v", err)
+		return resp, nil
 	}
+	token, _ = client.currentToken()
+	return client.sendWithRetry(ctx, method, url, pathTemplate, func() *resty.Request { return newRequest(token) })
 }
 
-func (client *Client) getJsonWithHeader(acceptHeader string, params map[string]interface{}, result interface{}, pathTemplate string, pathArgs []interface{}) (string, error) {
+func (client *Client) send(ctx context.Context, method string, url string, pathTemplate string, request *resty.Request) (*resty.Response, error) {
+	// waiting for the rate limiter doesn't count against RequestTimeout
+	release, err := client.throttle(ctx, method, pathTemplate)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if client.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, client.RequestTimeout)
+		defer cancel()
+	}
+	traceAttempt(ctx, request)
+	start := time.Now()
+	resp, err := request.SetContext(ctx).Execute(method, url)
+	duration := time.Now().Sub(start)
+
+	recordEvent(method+"_"+pathTemplate, err)
+
+	if err != nil {
+		traceResponse(ctx, 0, err)
+		return nil, err
+	}
+
+	code := resp.StatusCode()
+	traceResponse(ctx, code, nil)
+	recordResponseTime(method, pathTemplate, duration, code)
+	recordResponseStatusCode(method, pathTemplate, code)
+	return resp, nil
+}
+
+func (client *Client) getJsonWithHeader(ctx context.Context, acceptHeader string, params map[string]interface{}, result interface{}, pathTemplate string, pathArgs []interface{}) (body string, err error) {
 	path := fmt.Sprintf(pathTemplate, pathArgs...)
 	url := fmt.Sprintf("%s/%s", client.URL, path)
+	ctx, span := startSpan(ctx, resty.MethodGet, pathTemplate, url)
+	defer func() { endSpan(span, err) }()
 	log.Debugf("issuing GET request to %s, params %+v", url, params)
 
-	client.authMux.RLock()
-	defer client.authMux.RUnlock()
-	request := client.RestyClient.R().
-		SetHeader("Accept", acceptHeader).
-		SetHeader("Authorization", fmt.Sprintf("Bearer %s", client.AuthToken))
-
+	// repeated values, like include[project][], are sent as repeated parameters
+	queryParams := map[string][]string{}
 	for k, v := range params {
 		switch t := v.(type) {
 		case string:
-			request = request.SetQueryParam(k, t)
+			queryParams[k] = []string{t}
 		case []string:
-			for _, v := range t {
-				request = request.SetQueryParam(k, v)
-			}
+			queryParams[k] = t
 		default:
 			return "", errors.New(fmt.Sprintf("expected string or []string for params value, found %T", v))
 		}
 	}
 
-	if result != nil {
-		request = request.SetResult(result)
+	cache := client.Cache
+	if cache != nil && cacheBypassed(ctx) {
+		recordCacheResult(resty.MethodGet, pathTemplate, cacheBypass)
+		span.SetAttributes(attributeCacheResult.String(cacheBypass))
+		cache = nil
+	}
+	var key string
+	var cached *cacheEntry
+	if cache != nil {
+		key = cacheKey(acceptHeader, url, queryParams)
+		cached = cache.get(key)
+		if cached != nil && time.Now().Before(cached.expires) {
+			recordCacheResult(resty.MethodGet, pathTemplate, cacheHit)
+			span.SetAttributes(attributeCacheResult.String(cacheHit))
+			return cachedBody(cached, result)
+		}
 	}
 
-	start := time.Now()
-	resp, err := request.Get(url)
-	duration := time.Now().Sub(start)
-
-	recordEvent("GET_"+pathTemplate, err)
-
+	resp, err := client.execute(ctx, resty.MethodGet, url, pathTemplate, func(token string) *resty.Request {
+		request := client.RestyClient.R().
+			SetHeader("Accept", acceptHeader).
+			SetHeader("Authorization", fmt.Sprintf("Bearer %s", token)).
+			SetQueryParamsFromValues(queryParams)
+		if cached != nil && cached.etag != "" {
+			request = request.SetHeader("If-None-Match", cached.etag)
+		}
+		if result != nil {
+			request = request.SetResult(result)
+		}
+		return request
+	})
 	if err != nil {
 		return "", errors.Wrapf(err, "unable to GET %s", url)
 	}
 
 	body, code := resp.String(), resp.StatusCode()
-	recordResponseTime("GET", pathTemplate, duration, code)
-	recordResponseStatusCode("GET", pathTemplate, code)
-
+	if code == http.StatusNotModified && cached != nil {
+		recordCacheResult(resty.MethodGet, pathTemplate, cacheRevalidated)
+		span.SetAttributes(attributeCacheResult.String(cacheRevalidated))
+		if ttl, ok := cache.ttl(pathTemplate, resp.Header()); ok {
+			cached.expires = time.Now().Add(ttl)
+			cache.put(cached)
+		}
+		return cachedBody(cached, result)
+	}
 	if code < 200 || code > 299 {
 		return body, errors.New(fmt.Sprintf("bad status code to url GET %s: %d, response %s", url, code, body))
 	}
+	if cache != nil {
+		recordCacheResult(resty.MethodGet, pathTemplate, cacheMiss)
+		span.SetAttributes(attributeCacheResult.String(cacheMiss))
+		etag := resp.Header().Get("ETag")
+		if ttl, ok := cache.ttl(pathTemplate, resp.Header()); ok && (ttl > 0 || etag != "") {
+			cache.put(&cacheEntry{key: key, path: path, body: resp.Body(), etag: etag, expires: time.Now().Add(ttl)})
+		}
+	}
 	return body, nil
 }
 
+// cachedBody serves a response from the cache as if it had just been fetched
+func cachedBody(entry *cacheEntry, result interface{}) (string, error) {
+	if result != nil {
+		if err := json.Unmarshal(entry.body, result); err != nil {
+			return "", errors.Wrapf(err, "unable to unmarshal cached response")
+		}
+	}
+	return string(entry.body), nil
+}
+
 func (client *Client) GetJson(params map[string]interface{}, result interface{}, pathTemplate string, pathArgs ...interface{}) (string, error) {
-	return client.getJsonWithHeader("application/vnd.api+json", params, result, pathTemplate, pathArgs)
+	return client.GetJsonContext(context.Background(), params, result, pathTemplate, pathArgs...)
+}
+
+func (client *Client) GetJsonContext(ctx context.Context, params map[string]interface{}, result interface{}, pathTemplate string, pathArgs ...interface{}) (string, error) {
+	return client.getJsonWithHeader(ctx, "application/vnd.api+json", params, result, pathTemplate, pathArgs)
 }
 
 func (client *Client) GetRawJson(params map[string]interface{}, result interface{}, pathTemplate string, pathArgs ...interface{}) (string, error) {
-	return client.getJsonWithHeader("application/json", params, result, pathTemplate, pathArgs)
+	return client.GetRawJsonContext(context.Background(), params, result, pathTemplate, pathArgs...)
+}
+
+func (client *Client) GetRawJsonContext(ctx context.Context, params map[string]interface{}, result interface{}, pathTemplate string, pathArgs ...interface{}) (string, error) {
+	return client.getJsonWithHeader(ctx, "application/json", params, result, pathTemplate, pathArgs)
 }
 
 func (client *Client) PostJson(bodyParams map[string]interface{}, result interface{}, pathTemplate string, pathArgs ...interface{}) (string, error) {
-	path := fmt.Sprintf(pathTemplate, pathArgs...)
-	url := fmt.Sprintf("%s/%s", client.URL, path)
+	return client.PostJsonContext(context.Background(), bodyParams, result, pathTemplate, pathArgs...)
+}
 
-	log.Debugf("issuing POST request to %s", url)
-	client.authMux.RLock()
-	defer client.authMux.RUnlock()
-	request := client.RestyClient.R().
-		SetHeader("Content-Type", "application/vnd.api+json").
-		SetHeader("Accept", "application/vnd.api+json").
-		SetHeader("Authorization", fmt.Sprintf("Bearer %s", client.AuthToken)).
-		SetBody(bodyParams)
+func (client *Client) PostJsonContext(ctx context.Context, bodyParams map[string]interface{}, result interface{}, pathTemplate string, pathArgs ...interface{}) (string, error) {
+	return client.sendJson(ctx, resty.MethodPost, bodyParams, result, pathTemplate, pathArgs)
+}
 
-	if result != nil {
-		request = request.SetResult(result)
-	}
+func (client *Client) PatchJson(bodyParams map[string]interface{}, result interface{}, pathTemplate string, pathArgs ...interface{}) (string, error) {
+	return client.PatchJsonContext(context.Background(), bodyParams, result, pathTemplate, pathArgs...)
+}
 
-	start := time.Now()
-	resp, err := request.Post(url)
-	duration := time.Now().Sub(start)
+func (client *Client) PatchJsonContext(ctx context.Context, bodyParams map[string]interface{}, result interface{}, pathTemplate string, pathArgs ...interface{}) (string, error) {
+	return client.sendJson(ctx, resty.MethodPatch, bodyParams, result, pathTemplate, pathArgs)
+}
 
-	recordEvent("POST_"+pathTemplate, err)
+// DeleteJson issues a DELETE; bodyParams may be nil, but JSON:API relationship removals need one.
+func (client *Client) DeleteJson(bodyParams map[string]interface{}, pathTemplate string, pathArgs ...interface{}) (string, error) {
+	return client.DeleteJsonContext(context.Background(), bodyParams, pathTemplate, pathArgs...)
+}
 
+func (client *Client) DeleteJsonContext(ctx context.Context, bodyParams map[string]interface{}, pathTemplate string, pathArgs ...interface{}) (string, error) {
+	return client.sendJson(ctx, resty.MethodDelete, bodyParams, nil, pathTemplate, pathArgs)
+}
+
+func (client *Client) sendJson(ctx context.Context, method string, bodyParams map[string]interface{}, result interface{}, pathTemplate string, pathArgs []interface{}) (body string, err error) {
+	path := fmt.Sprintf(pathTemplate, pathArgs...)
+	url := fmt.Sprintf("%s/%s", client.URL, path)
+	ctx, span := startSpan(ctx, method, pathTemplate, url)
+	defer func() { endSpan(span, err) }()
+
+	log.Debugf("issuing %s request to %s", method, url)
+	resp, err := client.execute(ctx, method, url, pathTemplate, func(token string) *resty.Request {
+		request := client.RestyClient.R().
+			SetHeader("Content-Type", "application/vnd.api+json").
+			SetHeader("Accept", "application/vnd.api+json").
+			SetHeader("Authorization", fmt.Sprintf("Bearer %s", token))
+		if bodyParams != nil {
+			request = request.SetBody(bodyParams)
+		}
+		if result != nil {
+			request = request.SetResult(result)
+		}
+		return request
+	})
+	if client.Cache != nil {
+		// even a failed write may have gone through.  Derived data, like
+		// entitlements, isn't invalidated and lives out its TTL.
+		client.Cache.invalidate(collectionPath(pathTemplate))
+	}
 	if err != nil {
-		return "", errors.Wrapf(err, "unable to POST to url %s", url)
+		return "", errors.Wrapf(err, "unable to %s to url %s", method, url)
 	}
 
 	body, statusCode := resp.String(), resp.StatusCode()
-	recordResponseStatusCode("POST", pathTemplate, statusCode)
-	recordResponseTime("POST", pathTemplate, duration, statusCode)
-
 	if statusCode < 200 || statusCode > 299 {
-		return body, errors.New(fmt.Sprintf("bad response status code to POST %s: %d, body %s", url, resp.StatusCode(), body))
+		return body, errors.New(fmt.Sprintf("bad response status code to %s %s: %d, body %s", method, url, resp.StatusCode(), body))
 	}
 	//log.Debugf("tokens response: %d", resp.StatusCode())
 	return body, nil
@@ -0,0 +1,81 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func RunClientTests() {
+	Describe("Client", func() {
+		var server *testServer
+		var release chan struct{}
+
+		BeforeEach(func() {
+			release = make(chan struct{})
+			server = newTestServer(func(w http.ResponseWriter, r *http.Request) {
+				// hang until the test is over or the client gives up
+				select {
+				case <-release:
+				case <-r.Context().Done():
+				}
+			})
+		})
+
+		AfterEach(func() {
+			close(release)
+			server.Close()
+		})
+
+		It("should give up on a hung endpoint after RequestTimeout", func() {
+			client := NewBearerTokenClient(server.URL, "token")
+			client.RequestTimeout = 100 * time.Millisecond
+			start := time.Now()
+			_, err := client.GetUsers(0, 10)
+			Expect(err).ToNot(BeNil())
+			Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+		})
+
+		It("should honor the caller's deadline", func() {
+			client := NewBearerTokenClient(server.URL, "token")
+			client.RequestTimeout = 0
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			_, err := client.GetUsersContext(ctx, 0, 10)
+			Expect(err).ToNot(BeNil())
+			Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+		})
+
+		It("should not send requests with a cancelled context", func() {
+			client := NewBearerTokenClient(server.URL, "token")
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, err := client.PostJsonContext(ctx, map[string]interface{}{}, nil, "api/auth/role-assignments")
+			Expect(err).ToNot(BeNil())
+		})
+	})
+}
@@ -21,71 +21,155 @@ under the License.
 package api
 
 import (
+	"context"
 	"fmt"
 	"github.com/pkg/errors"
+	"time"
 )
 
-type ProjectRelationshipsLinks struct {
-	Self    string
-	Related string
+type V0Project struct {
+	Type       string
+	Id         string
+	Attributes struct {
+		Name        string
+		Description string
+		// Properties are free-form settings, such as the project's integration settings
+		Properties map[string]string
+	}
+	Relationships struct {
+		Branches          *Relationship
+		Runs              *Relationship
+		ProjectPreference *Relationship `json:"project-preference"`
+		UserDefaultBranch *Relationship `json:"user-default-branch"`
+	}
+	Links *struct {
+		Self *struct {
+			HRef string
+			Meta *struct {
+				Durable string
+			}
+		}
+	}
+	Meta struct {
+		Etag           string
+		OrganizationId string `json:"organization-id"`
+		InTrash        bool   `json:"in-trash"`
+	}
 }
 
-type ProjectRelationshipsData struct {
-	Type string
-	Id   string
+type GetProjectsResponse struct {
+	Data     []*V0Project
+	Included Included
+	// Offset is not returned by this endpoint
+	Meta  PageMeta
+	Links PageLinks
 }
 
-type ProjectRelationshipsJustLinks struct {
-	Links ProjectRelationshipsLinks
+func (client *Client) GetProjects(limit int) (*GetProjectsResponse, error) {
+	return client.GetProjectsContext(context.Background(), limit)
 }
 
-type ProjectRelationshipsLinksAndData struct {
-	Links ProjectRelationshipsLinks
-	Data  *ProjectRelationshipsData
+func (client *Client) GetProjectsContext(ctx context.Context, limit int) (*GetProjectsResponse, error) {
+	return client.getProjects(ctx, map[string]interface{}{"page[limit]": fmt.Sprintf("%d", limit)})
 }
 
-type GetProjectsResponse struct {
-	Data []struct {
-		Type       string
-		Id         string
-		Attributes struct {
-			// TODO properties
-			Name string
-		}
-		Relationships struct {
-			Branches          *ProjectRelationshipsJustLinks
-			Runs              *ProjectRelationshipsJustLinks
-			ProjectPreference *ProjectRelationshipsLinksAndData `json:"project-preference"`
-			UserDefaultBranch *ProjectRelationshipsLinksAndData `json:"user-default-branch"`
-		}
-		Links *struct {
-			Self *struct {
-				HRef string
-				Meta *struct {
-					Durable string
-				}
-			}
-		}
-		Meta struct {
-			Etag           string
-			OrganizationId string `json:"organization-id"`
-			InTrash        bool   `json:"in-trash"`
+func (client *Client) getProjects(ctx context.Context, params map[string]interface{}) (*GetProjectsResponse, error) {
+	result := &GetProjectsResponse{}
+	_, err := client.GetJsonContext(ctx, params, result, "api/common/v0/projects")
+	return result, err
+}
+
+// ProjectsPaginator walks all projects; items are *V0Project.
+func (client *Client) ProjectsPaginator(pageSize int) *Paginator {
+	return NewPaginator(func(ctx context.Context, offset int, limit int) (*Page, error) {
+		projects, err := client.getProjects(ctx, map[string]interface{}{
+			"page[offset]": fmt.Sprintf("%d", offset),
+			"page[limit]":  fmt.Sprintf("%d", limit),
+		})
+		if err != nil {
+			return nil, err
 		}
+		items := boxItems(len(projects.Data), func(i int) interface{} { return projects.Data[i] })
+		meta := projects.Meta
+		meta.Offset = offset
+		return &Page{Items: items, Meta: meta, Links: projects.Links}, nil
+	}, pageSize)
+}
+
+type ProjectResponse struct {
+	Data     *V0Project
+	Included Included
+}
+
+func (client *Client) GetProject(projectId string) (*ProjectResponse, error) {
+	return client.GetProjectContext(context.Background(), projectId)
+}
+
+func (client *Client) GetProjectContext(ctx context.Context, projectId string) (*ProjectResponse, error) {
+	result := &ProjectResponse{}
+	_, err := client.GetJsonContext(ctx, NewQuery(), result, "api/common/v0/projects/%s", projectId)
+	return result, err
+}
+
+// GetProjectsByName finds projects by exact name.
+func (client *Client) GetProjectsByName(name string) (*GetProjectsResponse, error) {
+	return client.GetProjectsByNameContext(context.Background(), name)
+}
+
+func (client *Client) GetProjectsByNameContext(ctx context.Context, name string) (*GetProjectsResponse, error) {
+	return client.getProjects(ctx, NewQuery().Set("filter[project][name][$eq]", name).Page(0, 25))
+}
+
+// CreateProject creates a project; Polaris gives it a main branch.
+func (client *Client) CreateProject(name string, description string) (*ProjectResponse, error) {
+	return client.CreateProjectContext(context.Background(), name, description)
+}
+
+func (client *Client) CreateProjectContext(ctx context.Context, name string, description string) (*ProjectResponse, error) {
+	bodyParams := map[string]interface{}{
+		"data": map[string]interface{}{
+			"attributes": map[string]interface{}{
+				"name":        name,
+				"description": description,
+				"properties":  map[string]string{},
+			},
+			"type": "project",
+		},
 	}
-	// TODO included, links
-	Meta struct {
-		// Offset int
-		Limit int
-		Total int
-	}
+	result := &ProjectResponse{}
+	_, err := client.PostJsonContext(ctx, bodyParams, result, "api/common/v0/projects")
+	return result, err
 }
 
-func (client *Client) GetProjects(limit int) (*GetProjectsResponse, error) {
-	result := &GetProjectsResponse{}
-	_, err := client.GetJson(map[string]interface{}{"page[limit]": fmt.Sprintf("%d", limit)}, result, "api/common/v0/projects")
+func (client *Client) RenameProject(projectId string, name string) (*ProjectResponse, error) {
+	return client.RenameProjectContext(context.Background(), projectId, name)
+}
+
+func (client *Client) RenameProjectContext(ctx context.Context, projectId string, name string) (*ProjectResponse, error) {
+	bodyParams := map[string]interface{}{
+		"data": map[string]interface{}{
+			"attributes": map[string]interface{}{
+				"name": name,
+			},
+			"id":   projectId,
+			"type": "project",
+		},
+	}
+	result := &ProjectResponse{}
+	_, err := client.PatchJsonContext(ctx, bodyParams, result, "api/common/v0/projects/%s", projectId)
 	return result, err
 }
 
+// DeleteProject deletes a project along with its branches and runs.
+func (client *Client) DeleteProject(projectId string) error {
+	return client.DeleteProjectContext(context.Background(), projectId)
+}
+
+func (client *Client) DeleteProjectContext(ctx context.Context, projectId string) error {
+	_, err := client.DeleteJsonContext(ctx, nil, "api/common/v0/projects/%s", projectId)
+	return err
+}
+
 type GetToolsResponse struct {
 	Data []struct {
 		Type       string
@@ -94,32 +178,39 @@ type GetToolsResponse struct {
 			Name    string
 			Version string
 		}
-		// TODO relationships
-		// TODO links
-		// TODO meta
+		Relationships map[string]Relationship
+		Meta          map[string]interface{}
 	}
-	// TODO included
-	Meta struct {
+	Included Included
+	Meta     struct {
 		//Offset int
 		Limit int
 		Total int
 	}
-	// TODO links
+	Links PageLinks
 }
 
 func (client *Client) GetTools(limit int) (*GetToolsResponse, error) {
+	return client.GetToolsContext(context.Background(), limit)
+}
+
+func (client *Client) GetToolsContext(ctx context.Context, limit int) (*GetToolsResponse, error) {
 	if limit <= 0 || limit > 500 {
 		return nil, errors.New(fmt.Sprintf("limit must be between 1 and 500, got %d", limit))
 	}
 	result := &GetToolsResponse{}
 	params := map[string]interface{}{"page[limit]": fmt.Sprintf("%d", limit)}
-	_, err := client.GetJson(params, result, "api/common/v0/tools")
+	_, err := client.GetJsonContext(ctx, params, result, "api/common/v0/tools")
 	return result, err
 }
 
 // PostTools handles
 //   https://sig-gitlab.internal.synopsys.com/clops/polaris-local/-/blob/master/README.md#temporary-workaround-if-needed-1
 func (client *Client) PostTools() (string, error) {
+	return client.PostToolsContext(context.Background())
+}
+
+func (client *Client) PostToolsContext(ctx context.Context) (string, error) {
 	params := map[string]interface{}{
 		"data": map[string]interface{}{
 			"type": "tool",
@@ -129,31 +220,120 @@ func (client *Client) PostTools() (string, error) {
 			},
 		},
 	}
-	return client.PostJson(params, nil, "api/common/v0/tools")
+	return client.PostJsonContext(ctx, params, nil, "api/common/v0/tools")
 }
 
-type GetV0BranchResponse struct {
-	Data struct {
-		Type       string
-		Id         string
-		Attributes struct {
-			Name           string
-			MainForProject bool `json:"main-for-project"`
-		}
-		// TODO Relationships Links Meta
+type V0Branch struct {
+	Type       string
+	Id         string
+	Attributes struct {
+		Name           string
+		MainForProject bool `json:"main-for-project"`
 	}
-	// TODO Included
-	// TODO Meta
-	// TODO Links
+	Relationships map[string]Relationship
+	Meta          map[string]interface{}
+}
+
+type GetV0BranchResponse struct {
+	Data     V0Branch
+	Included Included
 }
 
 func (client *Client) GetV0Branch(branchId string) (*GetV0BranchResponse, error) {
+	return client.GetV0BranchContext(context.Background(), branchId)
+}
+
+func (client *Client) GetV0BranchContext(ctx context.Context, branchId string) (*GetV0BranchResponse, error) {
 	result := &GetV0BranchResponse{}
 	params := map[string]interface{}{}
-	_, err := client.GetJson(params, result, "api/common/v0/branches/%s", branchId)
+	_, err := client.GetJsonContext(ctx, params, result, "api/common/v0/branches/%s", branchId)
 	return result, err
 }
 
+type GetV0BranchesResponse struct {
+	Data     []*V0Branch
+	Included Included
+	Meta     PageMeta
+	Links    PageLinks
+}
+
+// GetV0Branches lists a project's branches.
+func (client *Client) GetV0Branches(projectId string, offset int, limit int) (*GetV0BranchesResponse, error) {
+	return client.GetV0BranchesContext(context.Background(), projectId, offset, limit)
+}
+
+func (client *Client) GetV0BranchesContext(ctx context.Context, projectId string, offset int, limit int) (*GetV0BranchesResponse, error) {
+	result := &GetV0BranchesResponse{}
+	query := NewQuery().
+		Set("filter[branch][project][id][$eq]", projectId).
+		Page(offset, limit)
+	_, err := client.GetJsonContext(ctx, query, result, "api/common/v0/branches")
+	return result, err
+}
+
+// V0BranchesPaginator walks a project's branches; items are *V0Branch.
+func (client *Client) V0BranchesPaginator(projectId string, pageSize int) *Paginator {
+	return NewPaginator(func(ctx context.Context, offset int, limit int) (*Page, error) {
+		branches, err := client.GetV0BranchesContext(ctx, projectId, offset, limit)
+		if err != nil {
+			return nil, err
+		}
+		items := boxItems(len(branches.Data), func(i int) interface{} { return branches.Data[i] })
+		return &Page{Items: items, Meta: branches.Meta, Links: branches.Links}, nil
+	}, pageSize)
+}
+
+func (client *Client) CreateV0Branch(projectId string, name string) (*GetV0BranchResponse, error) {
+	return client.CreateV0BranchContext(context.Background(), projectId, name)
+}
+
+func (client *Client) CreateV0BranchContext(ctx context.Context, projectId string, name string) (*GetV0BranchResponse, error) {
+	bodyParams := map[string]interface{}{
+		"data": map[string]interface{}{
+			"attributes": map[string]interface{}{
+				"name":             name,
+				"main-for-project": false,
+			},
+			"relationships": map[string]interface{}{
+				"project": identifierData("project", projectId),
+			},
+			"type": "branch",
+		},
+	}
+	result := &GetV0BranchResponse{}
+	_, err := client.PostJsonContext(ctx, bodyParams, result, "api/common/v0/branches")
+	return result, err
+}
+
+func (client *Client) RenameV0Branch(branchId string, name string) (*GetV0BranchResponse, error) {
+	return client.RenameV0BranchContext(context.Background(), branchId, name)
+}
+
+func (client *Client) RenameV0BranchContext(ctx context.Context, branchId string, name string) (*GetV0BranchResponse, error) {
+	bodyParams := map[string]interface{}{
+		"data": map[string]interface{}{
+			"attributes": map[string]interface{}{
+				"name": name,
+			},
+			"id":   branchId,
+			"type": "branch",
+		},
+	}
+	result := &GetV0BranchResponse{}
+	_, err := client.PatchJsonContext(ctx, bodyParams, result, "api/common/v0/branches/%s", branchId)
+	return result, err
+}
+
+// DeleteV0Branch deletes a branch and its runs.  A project's main branch can't be deleted.
+func (client *Client) DeleteV0Branch(branchId string) error {
+	return client.DeleteV0BranchContext(context.Background(), branchId)
+}
+
+func (client *Client) DeleteV0BranchContext(ctx context.Context, branchId string) error {
+	_, err := client.DeleteJsonContext(ctx, nil, "api/common/v0/branches/%s", branchId)
+	return err
+}
+
 type GetV0RevisionsResponse struct {
 	Data []struct {
 		Type       string
@@ -163,31 +343,149 @@ type GetV0RevisionsResponse struct {
 			Timestamp            string
 			ModifiedOffTheRecord bool `json:"modified-off-the-record"`
 		}
-		// TODO Relationships, Links, Meta
-	}
-	// TODO Included, Links
-	Meta struct {
-		Offset int
-		Limit  int
-		Total  int
+		Relationships map[string]Relationship
+		Meta          map[string]interface{}
 	}
+	Included Included
+	Meta     PageMeta
+	Links    PageLinks
 }
 
 func (client *Client) GetV0Revisions(limit int) (*GetV0RevisionsResponse, error) {
+	return client.GetV0RevisionsContext(context.Background(), limit)
+}
+
+func (client *Client) GetV0RevisionsContext(ctx context.Context, limit int) (*GetV0RevisionsResponse, error) {
 	result := &GetV0RevisionsResponse{}
 	params := map[string]interface{}{
 		"page[limit]": fmt.Sprintf("%d", limit),
 	}
-	_, err := client.GetJson(params, result, "api/common/v0/revisions")
+	_, err := client.GetJsonContext(ctx, params, result, "api/common/v0/revisions")
 	return result, err
 }
 
 func (client *Client) GetV0RevisionsByBranch(branchId string, limit int) (*GetV0RevisionsResponse, error) {
+	return client.GetV0RevisionsByBranchContext(context.Background(), branchId, limit)
+}
+
+func (client *Client) GetV0RevisionsByBranchContext(ctx context.Context, branchId string, limit int) (*GetV0RevisionsResponse, error) {
 	result := &GetV0RevisionsResponse{}
 	params := map[string]interface{}{
 		"filter[revision][branch][id][$eq]": branchId,
 		"page[limit]":                       fmt.Sprintf("%d", limit),
 	}
-	_, err := client.GetJson(params, result, "api/common/v0/revisions")
+	_, err := client.GetJsonContext(ctx, params, result, "api/common/v0/revisions")
+	return result, err
+}
+
+// Run statuses.
+const (
+	RunStatusRunning   = "RUNNING"
+	RunStatusCompleted = "COMPLETED"
+	RunStatusFailed    = "FAILED"
+)
+
+type V0Run struct {
+	Type       string
+	Id         string
+	Attributes struct {
+		RunType       string `json:"run-type"`
+		Status        string
+		UploadId      string `json:"upload-id"`
+		CreationDate  string `json:"creation-date"`
+		CompletedDate string `json:"completed-date"`
+		Segment       bool
+		Fingerprints  []interface{}
+	}
+	Relationships map[string]Relationship
+	Meta          map[string]interface{}
+}
+
+type GetV0RunsResponse struct {
+	Data     []*V0Run
+	Included Included
+	Meta     PageMeta
+	Links    PageLinks
+}
+
+// RunsFilter selects runs; zero fields don't filter.
+type RunsFilter struct {
+	ProjectId     string
+	BranchId      string
+	Status        string
+	RunType       string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
+
+// query asks for the newest runs first
+func (filter *RunsFilter) query() Query {
+	query := NewQuery().Set("sort", "-creation-date")
+	if filter == nil {
+		return query
+	}
+	if filter.ProjectId != "" {
+		query.Set("filter[run][project][id][$eq]", filter.ProjectId)
+	}
+	if filter.BranchId != "" {
+		query.Set("filter[run][revision][branch][id][$eq]", filter.BranchId)
+	}
+	if filter.Status != "" {
+		query.Set("filter[run][status][$eq]", filter.Status)
+	}
+	if filter.RunType != "" {
+		query.Set("filter[run][run-type][$eq]", filter.RunType)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		query.Set("filter[run][creation-date][$gte]", filter.CreatedAfter.UTC().Format(time.RFC3339))
+	}
+	if !filter.CreatedBefore.IsZero() {
+		query.Set("filter[run][creation-date][$lt]", filter.CreatedBefore.UTC().Format(time.RFC3339))
+	}
+	return query
+}
+
+// GetV0Runs lists runs, newest first.
+func (client *Client) GetV0Runs(filter *RunsFilter, offset int, limit int) (*GetV0RunsResponse, error) {
+	return client.GetV0RunsContext(context.Background(), filter, offset, limit)
+}
+
+func (client *Client) GetV0RunsContext(ctx context.Context, filter *RunsFilter, offset int, limit int) (*GetV0RunsResponse, error) {
+	return client.getRuns(ctx, filter.query().Page(offset, limit))
+}
+
+func (client *Client) getRuns(ctx context.Context, query Query) (*GetV0RunsResponse, error) {
+	result := &GetV0RunsResponse{}
+	_, err := client.GetJsonContext(ctx, query, result, "api/common/v0/runs")
 	return result, err
 }
+
+// V0RunsPaginator walks the runs selected by filter, which may be nil, newest first; items are *V0Run.
+func (client *Client) V0RunsPaginator(filter *RunsFilter, pageSize int) *Paginator {
+	return NewPaginator(func(ctx context.Context, offset int, limit int) (*Page, error) {
+		runs, err := client.GetV0RunsContext(ctx, filter, offset, limit)
+		if err != nil {
+			return nil, err
+		}
+		items := boxItems(len(runs.Data), func(i int) interface{} { return runs.Data[i] })
+		return &Page{Items: items, Meta: runs.Meta, Links: runs.Links}, nil
+	}, pageSize)
+}
+
+// GetLatestCompletedRun returns the branch's most recently completed run, or
+// nil if it has none.
+func (client *Client) GetLatestCompletedRun(branchId string) (*V0Run, error) {
+	return client.GetLatestCompletedRunContext(context.Background(), branchId)
+}
+
+func (client *Client) GetLatestCompletedRunContext(ctx context.Context, branchId string) (*V0Run, error) {
+	filter := &RunsFilter{BranchId: branchId, Status: RunStatusCompleted}
+	runs, err := client.getRuns(ctx, filter.query().Set("sort", "-completed-date").Page(0, 1))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "unable to get latest completed run for branch %s", branchId)
+	}
+	if len(runs.Data) == 0 {
+		return nil, nil
+	}
+	return runs.Data[0], nil
+}
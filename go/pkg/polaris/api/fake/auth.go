@@ -0,0 +1,491 @@
+/*
+Copyright (C) 2018 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package fake
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var rolePermissions = []struct {
+	name         string
+	organization []string
+	project      []string
+}{
+	{"Observer", []string{}, []string{"projects.read"}},
+	{"Contributor", []string{"users.read", "groups.read"}, []string{"projects.read", "projects.write"}},
+	{"Administrator", []string{"administer", "users.read", "users.readPrivate", "users.write", "groups.read", "groups.write", "projects.create"}, []string{"administer", "projects.read", "projects.write"}},
+}
+
+// userAttributes are the user attributes clients may set
+var userAttributes = []string{"name", "email", "username", "owner", "enabled", "automated"}
+
+func (s *Server) orgUrn() string {
+	return fmt.Sprintf("urn:x-swip:organizations:%s", s.OrganizationId)
+}
+
+// seed sets up the organization, its roles and owner, and the tools and taxonomies
+func (s *Server) seed() {
+	org := s.store.add(newResource("organizations", s.store.newId("org"), map[string]interface{}{
+		"organizationname": s.Config.OrganizationName,
+		"description":      fmt.Sprintf("%s organization", s.Config.OrganizationName),
+	}))
+	s.OrganizationId = org.Id
+	for _, role := range rolePermissions {
+		s.store.add(newResource("roles", s.store.newId("role"), map[string]interface{}{
+			"rolename": role.name,
+			"permissions": map[string][]string{
+				"organization": role.organization,
+				"project":      role.project,
+			},
+		}))
+	}
+	owner := s.store.add(newResource("users", s.store.newId("user"), map[string]interface{}{
+		"name":      "Owner",
+		"email":     s.Config.Email,
+		"username":  "owner",
+		"owner":     true,
+		"enabled":   true,
+		"automated": false,
+	}).relate("organization", "organizations", org.Id))
+	s.OwnerId = owner.Id
+	s.passwords[owner.Id] = s.Config.Password
+	s.seedTools()
+	s.seedTaxonomies()
+}
+
+// RoleId returns the id of the role with the given name, such as "Contributor".
+func (s *Server) RoleId(name string) string {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	for _, role := range s.store.list("roles", nil) {
+		if role.attribute("rolename") == name {
+			return role.Id
+		}
+	}
+	return ""
+}
+
+func (s *Server) userByEmail(email string) *resource {
+	for _, user := range s.store.list("users", nil) {
+		if user.attribute("email") == email {
+			return user
+		}
+	}
+	return nil
+}
+
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request, args []string) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if accessToken := r.PostForm.Get("accesstoken"); accessToken != "" {
+		token, ok := s.apiTokens[accessToken]
+		if !ok || token.flag("revoked") {
+			writeError(w, http.StatusUnauthorized, "invalid access token")
+			return
+		}
+		writeJson(w, http.StatusOK, map[string]string{"jwt": s.newSession(token.related("user"))})
+		return
+	}
+	user := s.userByEmail(r.PostForm.Get("email"))
+	if user == nil || !user.flag("enabled") || s.passwords[user.Id] != r.PostForm.Get("password") {
+		writeError(w, http.StatusUnauthorized, "invalid email or password")
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: "access_token", Value: s.newSession(user.Id), Path: "/"})
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) getApiTokens(w http.ResponseWriter, r *http.Request, args []string) {
+	userId := s.authenticatedUser(r)
+	tokens := s.store.list("apitokens", func(token *resource) bool {
+		return token.related("user") == userId
+	})
+	writeJson(w, http.StatusOK, pageOf(r, tokens))
+}
+
+func (s *Server) createApiToken(w http.ResponseWriter, r *http.Request, args []string) {
+	doc, err := readDocument(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	token := s.store.add(newResource("apitokens", s.store.newId("apitoken"), map[string]interface{}{
+		"name":         doc.attribute("name"),
+		"date-created": time.Now().UTC().Format(time.RFC3339),
+		"revoked":      false,
+	}).relate("user", "users", s.authenticatedUser(r)))
+	secret := fmt.Sprintf("fake-access-token-%s", token.Id)
+	s.apiTokens[secret] = token
+
+	// the secret is only ever returned here
+	created := *token
+	created.Attributes = map[string]interface{}{"access-token": secret}
+	for name, value := range token.Attributes {
+		created.Attributes[name] = value
+	}
+	writeJson(w, http.StatusCreated, map[string]interface{}{"data": &created})
+}
+
+func (s *Server) updateApiToken(w http.ResponseWriter, r *http.Request, args []string) {
+	token := s.store.find("apitokens", args[0])
+	if token == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no apitoken %s", args[0]))
+		return
+	}
+	doc, err := readDocument(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if revoked, ok := doc.Attributes["revoked"].(bool); ok {
+		token.Attributes["revoked"] = revoked
+	}
+	writeJson(w, http.StatusOK, map[string]interface{}{"data": token})
+}
+
+func (s *Server) deleteApiToken(w http.ResponseWriter, r *http.Request, args []string) {
+	if !s.store.remove("apitokens", args[0]) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no apitoken %s", args[0]))
+		return
+	}
+	for secret, token := range s.apiTokens {
+		if token.Id == args[0] {
+			delete(s.apiTokens, secret)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddOrganization adds another organization, which users can be created in
+// and taxonomies requested for.  It returns the organization's id.
+func (s *Server) AddOrganization(name string) string {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.store.add(newResource("organizations", s.store.newId("org"), map[string]interface{}{
+		"organizationname": name,
+		"description":      fmt.Sprintf("%s organization", name),
+	})).Id
+}
+
+func (s *Server) getOrganizations(w http.ResponseWriter, r *http.Request, args []string) {
+	writeJson(w, http.StatusOK, pageOf(r, s.store.list("organizations", nil)))
+}
+
+func (s *Server) getOrganization(w http.ResponseWriter, r *http.Request, args []string) {
+	org := s.store.find("organizations", args[0])
+	if org == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no organization %s", args[0]))
+		return
+	}
+	writeJson(w, http.StatusOK, map[string]interface{}{"data": org})
+}
+
+func (s *Server) getUsers(w http.ResponseWriter, r *http.Request, args []string) {
+	query := r.URL.Query()
+	email := query.Get("filter[users][email][$eq]")
+	automated := query.Get("filter[users][automated][$eq]")
+	users := s.store.list("users", func(user *resource) bool {
+		return (email == "" || user.attribute("email") == email) &&
+			(automated == "" || fmt.Sprintf("%t", user.flag("automated")) == automated)
+	})
+	writeJson(w, http.StatusOK, pageOf(r, users))
+}
+
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request, args []string) {
+	user := s.store.find("users", args[0])
+	if user == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no user %s", args[0]))
+		return
+	}
+	writeJson(w, http.StatusOK, map[string]interface{}{"data": user})
+}
+
+func (s *Server) createUser(w http.ResponseWriter, r *http.Request, args []string) {
+	doc, err := readDocument(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	email := doc.attribute("email")
+	if email == "" {
+		writeError(w, http.StatusBadRequest, "email is required")
+		return
+	}
+	if s.userByEmail(email) != nil {
+		writeError(w, http.StatusConflict, fmt.Sprintf("user %s already exists", email))
+		return
+	}
+	user := newResource("users", s.store.newId("user"), map[string]interface{}{
+		"owner":     false,
+		"enabled":   true,
+		"automated": false,
+	})
+	orgId := doc.related("organization")
+	if orgId == "" {
+		orgId = s.OrganizationId
+	}
+	if s.store.find("organizations", orgId) == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no organization %s", orgId))
+		return
+	}
+	s.setUserAttributes(user, doc.Attributes)
+	user.relate("organization", "organizations", orgId)
+	s.store.add(user)
+	writeJson(w, http.StatusCreated, map[string]interface{}{"data": user})
+}
+
+func (s *Server) setUserAttributes(user *resource, attributes map[string]interface{}) {
+	for _, name := range userAttributes {
+		if value, ok := attributes[name]; ok {
+			user.Attributes[name] = value
+		}
+	}
+	if login, ok := attributes["password-login"].(map[string]interface{}); ok {
+		if password, ok := login["password"].(string); ok {
+			s.passwords[user.Id] = password
+		}
+	}
+}
+
+func (s *Server) updateUser(w http.ResponseWriter, r *http.Request, args []string) {
+	user := s.store.find("users", args[0])
+	if user == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no user %s", args[0]))
+		return
+	}
+	doc, err := readDocument(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if email := doc.attribute("email"); email != "" {
+		if other := s.userByEmail(email); other != nil && other.Id != user.Id {
+			writeError(w, http.StatusConflict, fmt.Sprintf("user %s already exists", email))
+			return
+		}
+	}
+	s.setUserAttributes(user, doc.Attributes)
+	writeJson(w, http.StatusOK, map[string]interface{}{"data": user})
+}
+
+// deleteUser also deletes the user's role assignments and api tokens
+func (s *Server) deleteUser(w http.ResponseWriter, r *http.Request, args []string) {
+	userId := args[0]
+	if userId == s.OwnerId {
+		writeError(w, http.StatusForbidden, "the organization owner can't be deleted")
+		return
+	}
+	if !s.store.remove("users", userId) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no user %s", userId))
+		return
+	}
+	delete(s.passwords, userId)
+	for _, ra := range s.store.list("role-assignments", nil) {
+		if ra.related("user") == userId {
+			s.store.remove("role-assignments", ra.Id)
+		}
+	}
+	for secret, token := range s.apiTokens {
+		if token.related("user") == userId {
+			s.store.remove("apitokens", token.Id)
+			delete(s.apiTokens, secret)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) getRoles(w http.ResponseWriter, r *http.Request, args []string) {
+	writeJson(w, http.StatusOK, pageOf(r, s.store.list("roles", nil)))
+}
+
+func (s *Server) getRoleAssignments(w http.ResponseWriter, r *http.Request, args []string) {
+	query := r.URL.Query()
+	object := query.Get("filter[role-assignments][object][$eq]")
+	email := query.Get("filter[role-assignments][user][email][$eq]")
+	automated := query.Get("filter[role-assignments][user][automated]")
+	ras := s.store.list("role-assignments", func(ra *resource) bool {
+		if object != "" && ra.attribute("object") != object {
+			return false
+		}
+		if email == "" && automated == "" {
+			return true
+		}
+		user := s.store.find("users", ra.related("user"))
+		return user != nil &&
+			(email == "" || user.attribute("email") == email) &&
+			(automated == "" || fmt.Sprintf("%t", user.flag("automated")) == automated)
+	})
+	page := pageOf(r, ras)
+	if hasInclude(r) {
+		page["included"] = s.roleAssignmentsIncluded(page["data"].([]*resource))
+	}
+	writeJson(w, http.StatusOK, page)
+}
+
+func (s *Server) getRoleAssignment(w http.ResponseWriter, r *http.Request, args []string) {
+	ra := s.store.find("role-assignments", args[0])
+	if ra == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no role assignment %s", args[0]))
+		return
+	}
+	doc := map[string]interface{}{"data": ra}
+	if hasInclude(r) {
+		doc["included"] = s.roleAssignmentsIncluded([]*resource{ra})
+	}
+	writeJson(w, http.StatusOK, doc)
+}
+
+// roleAssignmentsIncluded returns the roles and users of ras, once each
+func (s *Server) roleAssignmentsIncluded(ras []*resource) []*resource {
+	included := []*resource{}
+	seen := map[string]bool{}
+	for _, ra := range ras {
+		for _, rel := range []struct{ name, resourceType string }{{"role", "roles"}, {"user", "users"}} {
+			id := ra.related(rel.name)
+			if related := s.store.find(rel.resourceType, id); related != nil && !seen[id] {
+				seen[id] = true
+				included = append(included, related)
+			}
+		}
+	}
+	return included
+}
+
+func hasInclude(r *http.Request) bool {
+	for key := range r.URL.Query() {
+		if strings.HasPrefix(key, "include[") {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) createRoleAssignment(w http.ResponseWriter, r *http.Request, args []string) {
+	doc, err := readDocument(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if s.store.find("roles", doc.related("role")) == nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("no role %s", doc.related("role")))
+		return
+	}
+	userId := doc.related("user")
+	if userId == "" && doc.related("group") == "" {
+		writeError(w, http.StatusBadRequest, "one of user and group is required")
+		return
+	}
+	if userId != "" && s.store.find("users", userId) == nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("no user %s", userId))
+		return
+	}
+	ra := newResource("role-assignments", s.store.newId("ra"), map[string]interface{}{
+		"object":     doc.attribute("object"),
+		"expires-by": doc.Attributes["expires-by"],
+	})
+	for name, relationship := range doc.Relationships {
+		ra.Relationships[name] = relationship
+	}
+	s.store.add(ra)
+	writeJson(w, http.StatusCreated, map[string]interface{}{"data": ra})
+}
+
+func (s *Server) updateRoleAssignment(w http.ResponseWriter, r *http.Request, args []string) {
+	ra := s.store.find("role-assignments", args[0])
+	if ra == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no role assignment %s", args[0]))
+		return
+	}
+	doc, err := readDocument(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if expiresBy, ok := doc.Attributes["expires-by"]; ok {
+		ra.Attributes["expires-by"] = expiresBy
+	}
+	if roleId := doc.related("role"); roleId != "" {
+		if s.store.find("roles", roleId) == nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("no role %s", roleId))
+			return
+		}
+		ra.relate("role", "roles", roleId)
+	}
+	writeJson(w, http.StatusOK, map[string]interface{}{"data": ra})
+}
+
+func (s *Server) deleteRoleAssignment(w http.ResponseWriter, r *http.Request, args []string) {
+	if !s.store.remove("role-assignments", args[0]) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no role assignment %s", args[0]))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getEntitlements derives entitlements from role assignments; the owner is
+// entitled to administer the organization.
+func (s *Server) getEntitlements(w http.ResponseWriter, r *http.Request, args []string) {
+	object := r.URL.Query().Get("filter[entitlements][object][eq]")
+	entitlements := []*resource{}
+	if object == "" || object == s.orgUrn() {
+		entitlements = append(entitlements, newResource("entitlements", "ent-"+s.OwnerId, map[string]interface{}{
+			"allowed": rolePermissions[len(rolePermissions)-1].organization,
+			"object":  s.orgUrn(),
+		}).relate("user", "users", s.OwnerId))
+	}
+	for _, ra := range s.store.list("role-assignments", nil) {
+		if object != "" && ra.attribute("object") != object {
+			continue
+		}
+		role := s.store.find("roles", ra.related("role"))
+		if role == nil {
+			continue
+		}
+		kind := "organization"
+		if strings.HasPrefix(ra.attribute("object"), "urn:x-swip:projects:") {
+			kind = "project"
+		}
+		entitlement := newResource("entitlements", "ent-"+ra.Id, map[string]interface{}{
+			"allowed": role.Attributes["permissions"].(map[string][]string)[kind],
+			"object":  ra.attribute("object"),
+		})
+		if userId := ra.related("user"); userId != "" {
+			entitlement.relate("user", "users", userId)
+		} else {
+			entitlement.relate("group", "groups", ra.related("group"))
+		}
+		entitlements = append(entitlements, entitlement)
+	}
+	page := pageOf(r, entitlements)
+	included := []*resource{}
+	for _, entitlement := range page["data"].([]*resource) {
+		if user := s.store.find("users", entitlement.related("user")); user != nil {
+			included = append(included, user)
+		}
+	}
+	page["included"] = included
+	writeJson(w, http.StatusOK, page)
+}
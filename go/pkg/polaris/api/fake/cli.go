@@ -0,0 +1,76 @@
+/*
+Copyright (C) 2018 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package fake
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+var cliArchivePattern = regexp.MustCompile(`^polaris_cli-(macosx|linux64|win64)\.zip$`)
+
+// cliScript stands in for the polaris binary: it accepts any command and succeeds
+const cliScript = "#!/bin/sh\necho \"fake polaris $*\"\n"
+
+// cliArchive zips a polaris_cli-<platform> directory holding a polaris script,
+// laid out so that api.Client.DownloadCli finds the directory first.
+func cliArchive(platform string) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	archive := zip.NewWriter(buffer)
+	dir := fmt.Sprintf("polaris_cli-%s/", platform)
+	if _, err := archive.Create(dir); err != nil {
+		return nil, err
+	}
+	header := &zip.FileHeader{Name: dir + "polaris", Method: zip.Deflate}
+	header.SetMode(0755)
+	script, err := archive.CreateHeader(header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := script.Write([]byte(cliScript)); err != nil {
+		return nil, err
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// getCli serves the Polaris CLI download.  Like the real one, it doesn't need a token.
+func (s *Server) getCli(w http.ResponseWriter, r *http.Request, args []string) {
+	match := cliArchivePattern.FindStringSubmatch(args[0])
+	if match == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no tool %s", args[0]))
+		return
+	}
+	content, err := cliArchive(match[1])
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("ETag", fmt.Sprintf(`"%x"`, sha256.Sum256(content)))
+	w.Write(content)
+}
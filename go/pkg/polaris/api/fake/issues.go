@@ -0,0 +1,305 @@
+/*
+Copyright (C) 2018 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package fake
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var severities = []string{"critical", "high", "medium", "low"}
+
+var issueTypes = []struct{ name, abbreviation string }{
+	{"SQL injection", "SQLI"},
+	{"Cross-site scripting", "XSS"},
+	{"Explicit null dereferenced", "FORWARD_NULL"},
+	{"Resource leak", "RESOURCE_LEAK"},
+}
+
+// facets maps roll-up count group-bys to the issue relationship they count by
+var facets = map[string]string{
+	"[issue][severity]": "severity",
+	"[issue][type]":     "issue-type",
+	"[issue][tool]":     "tool",
+	"[issue][status]":   "status",
+	"[path]":            "path",
+}
+
+// seedTaxonomies adds the severity taxonomy and the issue types issues are spread over
+func (s *Server) seedTaxonomies() {
+	taxa := []map[string]interface{}{}
+	for _, severity := range severities {
+		s.store.add(newResource("taxon", severity, map[string]interface{}{"name": strings.Title(severity)}))
+		taxa = append(taxa, map[string]interface{}{
+			"id":   severity,
+			"name": map[string]string{"en": strings.Title(severity)},
+		})
+	}
+	s.taxonomies = append(s.taxonomies, map[string]interface{}{
+		"taxonomy-type":   "severity",
+		"id":              "severity",
+		"optimistic-lock": "1",
+		"taxonomy": map[string]interface{}{
+			"taxa":         taxa,
+			"name":         map[string]string{"en": "Severity"},
+			"description":  map[string]string{"en": "How bad an issue is"},
+			"abbreviation": map[string]string{"en": "SEV"},
+			"root-taxa":    severities,
+		},
+	})
+	for _, issueType := range issueTypes {
+		s.store.add(newResource("issue-type", s.store.newId("issue-type"), map[string]interface{}{
+			"name":         issueType.name,
+			"abbreviation": issueType.abbreviation,
+		}))
+	}
+	for _, status := range []string{"opened", "closed"} {
+		s.store.add(newResource("status", status, map[string]interface{}{"name": status}))
+	}
+}
+
+// AddIssues adds count issues to the project's main branch and run, spread
+// over severities, issue types, tools and paths.  One in five is closed.
+func (s *Server) AddIssues(project *Project, count int) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	types := s.store.list("issue-type", nil)
+	tools := s.store.list("tool", nil)
+	for i := 0; i < count; i++ {
+		path := s.store.add(newResource("path", s.store.newId("path"), map[string]interface{}{
+			"path": []string{"src", fmt.Sprintf("file%d.go", i%10)},
+		}))
+		issue := newResource("issue", s.store.newId("issue"), map[string]interface{}{
+			"issue-key":   s.store.newId("issue-key"),
+			"finding-key": s.store.newId("finding-key"),
+			"sub-tool":    "sast",
+		}).
+			relate("severity", "taxon", severities[i%len(severities)]).
+			relate("issue-type", "issue-type", types[i%len(types)].Id).
+			relate("tool", "tool", tools[i%len(tools)].Id).
+			relate("path", "path", path.Id)
+		status := "opened"
+		if i%5 == 4 {
+			status = "closed"
+		}
+		issue.relate("status", "status", status)
+		issue.hidden["project"] = project.Id
+		issue.hidden["branch"] = project.BranchId
+		issue.hidden["run"] = project.RunId
+		s.store.add(issue)
+	}
+}
+
+// selectIssues returns the issues of the branch or runs r asks for
+func (s *Server) selectIssues(r *http.Request) ([]*resource, error) {
+	query := r.URL.Query()
+	projectId := query.Get("project-id")
+	branchId := query.Get("branch-id")
+	runIds := query["run-id[]"]
+	if projectId == "" {
+		return nil, fmt.Errorf("project-id is required")
+	}
+	if (branchId == "") == (len(runIds) == 0) {
+		return nil, fmt.Errorf("exactly one of branch-id and run-id[] is required")
+	}
+	status := query.Get("filter[issue][status][$eq]")
+	return s.store.list("issue", func(issue *resource) bool {
+		if issue.hidden["project"] != projectId {
+			return false
+		}
+		if status != "" && issue.related("status") != status {
+			return false
+		}
+		if branchId != "" {
+			return issue.hidden["branch"] == branchId
+		}
+		for _, runId := range runIds {
+			if issue.hidden["run"] == runId {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+// includeRelated returns the resources that the given relationships of items point to, once each
+func (s *Server) includeRelated(items []*resource, relationships ...string) []*resource {
+	included := []*resource{}
+	seen := map[string]bool{}
+	for _, item := range items {
+		for _, name := range relationships {
+			relationship, _ := item.Relationships[name].(map[string]interface{})
+			data, _ := relationship["data"].(map[string]interface{})
+			resourceType, _ := data["type"].(string)
+			id, _ := data["id"].(string)
+			key := resourceType + "/" + id
+			if seen[key] {
+				continue
+			}
+			if related := s.store.find(resourceType, id); related != nil {
+				seen[key] = true
+				included = append(included, related)
+			}
+		}
+	}
+	return included
+}
+
+func (s *Server) getIssues(w http.ResponseWriter, r *http.Request, args []string) {
+	issues, err := s.selectIssues(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	page := pageOf(r, issues)
+	page["included"] = s.includeRelated(page["data"].([]*resource), "severity", "issue-type", "tool", "path")
+	meta := page["meta"].(map[string]interface{})
+	meta["complete"] = true
+	meta["run-count"] = 1
+	writeJson(w, http.StatusOK, page)
+}
+
+func (s *Server) getIssue(w http.ResponseWriter, r *http.Request, args []string) {
+	issue := s.store.find("issue", args[0])
+	if issue == nil || issue.hidden["project"] != r.URL.Query().Get("project-id") {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no issue %s", args[0]))
+		return
+	}
+	writeJson(w, http.StatusOK, map[string]interface{}{
+		"data":     issue,
+		"included": s.includeRelated([]*resource{issue}, "severity", "issue-type", "tool", "path"),
+	})
+}
+
+// rollUpCounts counts issues by the relationships named by groupBy, in the order groups are first seen
+func (s *Server) rollUpCounts(issues []*resource, groupBy []string) ([]*resource, error) {
+	relationships := []string{}
+	for _, facet := range groupBy {
+		relationship, ok := facets[facet]
+		if !ok {
+			return nil, fmt.Errorf("unsupported group-by %s", facet)
+		}
+		relationships = append(relationships, relationship)
+	}
+	counts := []*resource{}
+	byKey := map[string]*resource{}
+	for _, issue := range issues {
+		ids := []string{}
+		for _, relationship := range relationships {
+			ids = append(ids, issue.related(relationship))
+		}
+		key := strings.Join(ids, "/")
+		count, ok := byKey[key]
+		if !ok {
+			count = newResource("rollup-counts", fmt.Sprintf("rollup-%d", len(counts)), map[string]interface{}{"value": 0})
+			for _, relationship := range relationships {
+				count.Relationships[relationship] = issue.Relationships[relationship]
+			}
+			byKey[key] = count
+			counts = append(counts, count)
+		}
+		count.Attributes["value"] = count.Attributes["value"].(int) + 1
+	}
+	return counts, nil
+}
+
+func (s *Server) writeRollUpCounts(w http.ResponseWriter, r *http.Request, groupBy []string, metaGroupBy interface{}) {
+	issues, err := s.selectIssues(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	counts, err := s.rollUpCounts(issues, groupBy)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	relationships := []string{}
+	for _, facet := range groupBy {
+		relationships = append(relationships, facets[facet])
+	}
+	page := pageOf(r, counts)
+	page["included"] = s.includeRelated(page["data"].([]*resource), relationships...)
+	meta := page["meta"].(map[string]interface{})
+	meta["complete"] = true
+	meta["group-by"] = metaGroupBy
+	meta["run-count"] = 1
+	writeJson(w, http.StatusOK, page)
+}
+
+func (s *Server) getV0RollUpCounts(w http.ResponseWriter, r *http.Request, args []string) {
+	s.writeRollUpCounts(w, r, []string{"[issue][severity]"}, "[issue][severity]")
+}
+
+func (s *Server) getV1RollUpCounts(w http.ResponseWriter, r *http.Request, args []string) {
+	groupBy := r.URL.Query()["group-by"]
+	if len(groupBy) == 0 {
+		groupBy = []string{"[issue][severity]"}
+	}
+	s.writeRollUpCounts(w, r, groupBy, groupBy)
+}
+
+func (s *Server) getStatusCounts(w http.ResponseWriter, r *http.Request, args []string) {
+	issues, err := s.selectIssues(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	byStatus := map[string]int{}
+	for _, issue := range issues {
+		byStatus[issue.related("status")]++
+	}
+	counts := []*resource{}
+	for _, status := range []string{"opened", "closed"} {
+		counts = append(counts, newResource("status-counts", status, map[string]interface{}{
+			"status": status,
+			"value":  byStatus[status],
+		}))
+	}
+	writeJson(w, http.StatusOK, map[string]interface{}{
+		"data": counts,
+		"meta": map[string]interface{}{"complete": true, "run-count": 1},
+	})
+}
+
+// getTaxonomies serves plain JSON rather than JSON:API, like the real endpoint
+func (s *Server) getTaxonomies(w http.ResponseWriter, r *http.Request, args []string) {
+	if tenant := r.URL.Query().Get("tenant-id"); tenant != "" && s.store.find("organizations", tenant) == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no organization %s", tenant))
+		return
+	}
+	limit := len(s.taxonomies)
+	if value := r.URL.Query().Get("page[limit]"); value != "" {
+		fmt.Sscanf(value, "%d", &limit)
+	}
+	if limit > len(s.taxonomies) {
+		limit = len(s.taxonomies)
+	}
+	if limit < 0 {
+		limit = 0
+	}
+	w.Header().Set("Content-Type", "application/json")
+	writeJson(w, http.StatusOK, map[string]interface{}{
+		"data": s.taxonomies[:limit],
+		"meta": map[string]interface{}{"total": len(s.taxonomies), "offset": 0, "limit": limit},
+	})
+}
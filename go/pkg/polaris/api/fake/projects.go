@@ -0,0 +1,413 @@
+/*
+Copyright (C) 2018 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package fake
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Project is a project created by AddProject, with its main branch and the
+// run that its issues were found in.
+type Project struct {
+	Id       string
+	Name     string
+	BranchId string
+	RunId    string
+}
+
+func (s *Server) seedTools() {
+	for _, tool := range []struct{ name, version string }{{"Coverity", "2020.06"}, {"Black Duck", "2020.6.0"}} {
+		s.store.add(newResource("tool", s.store.newId("tool"), map[string]interface{}{
+			"name":    tool.name,
+			"version": tool.version,
+		}))
+	}
+}
+
+// AddProject adds a project with a main branch and one completed run.
+func (s *Server) AddProject(name string) *Project {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	project, branch := s.addProject(name, "")
+	run := s.addRun(project.Id, branch.Id, "COMPLETED", time.Now())
+	return &Project{Id: project.Id, Name: name, BranchId: branch.Id, RunId: run.Id}
+}
+
+// AddRun adds a run to a branch, created at the given time and, unless it's
+// RUNNING, completed then too.  It returns the run's id.
+func (s *Server) AddRun(projectId string, branchId string, status string, created time.Time) string {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.addRun(projectId, branchId, status, created).Id
+}
+
+func (s *Server) addRun(projectId string, branchId string, status string, created time.Time) *resource {
+	date := created.UTC().Format(time.RFC3339)
+	run := newResource("run", s.store.newId("run"), map[string]interface{}{
+		"status":        status,
+		"run-type":      "ANALYSIS",
+		"segment":       false,
+		"creation-date": date,
+	}).relate("project", "project", projectId).relate("branch", "branch", branchId)
+	if status != "RUNNING" {
+		run.Attributes["completed-date"] = date
+	}
+	return s.store.add(run)
+}
+
+// addProject adds a project and its main branch
+func (s *Server) addProject(name string, description string) (*resource, *resource) {
+	project := newResource("project", s.store.newId("project"), map[string]interface{}{
+		"name":        name,
+		"description": description,
+		"properties":  map[string]string{},
+	})
+	branch := newResource("branch", s.store.newId("branch"), map[string]interface{}{
+		"name":             "master",
+		"main-for-project": true,
+	}).relate("project", "project", project.Id)
+	project.relate("main-branch", "branch", branch.Id)
+	for _, related := range []string{"branches", "runs"} {
+		project.Relationships[related] = map[string]interface{}{
+			"links": map[string]string{"related": fmt.Sprintf("/api/common/v0/projects/%s/%s", project.Id, related)},
+		}
+	}
+	project.Meta = map[string]interface{}{
+		"etag":            project.Id,
+		"organization-id": s.OrganizationId,
+		"in-trash":        false,
+	}
+	s.store.add(project)
+	s.store.add(branch)
+	return project, branch
+}
+
+func (s *Server) getProjects(w http.ResponseWriter, r *http.Request, args []string) {
+	name := r.URL.Query().Get("filter[project][name][$eq]")
+	projects := s.store.list("project", func(project *resource) bool {
+		return name == "" || project.attribute("name") == name
+	})
+	writeJson(w, http.StatusOK, pageOf(r, projects))
+}
+
+func (s *Server) getProject(w http.ResponseWriter, r *http.Request, args []string) {
+	project := s.store.find("project", args[0])
+	if project == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no project %s", args[0]))
+		return
+	}
+	writeJson(w, http.StatusOK, map[string]interface{}{"data": project})
+}
+
+func (s *Server) createProject(w http.ResponseWriter, r *http.Request, args []string) {
+	doc, err := readDocument(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	name := doc.attribute("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	if len(s.store.list("project", func(project *resource) bool { return project.attribute("name") == name })) > 0 {
+		writeError(w, http.StatusConflict, fmt.Sprintf("project %s already exists", name))
+		return
+	}
+	project, _ := s.addProject(name, doc.attribute("description"))
+	writeJson(w, http.StatusCreated, map[string]interface{}{"data": project})
+}
+
+func (s *Server) updateProject(w http.ResponseWriter, r *http.Request, args []string) {
+	project := s.store.find("project", args[0])
+	if project == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no project %s", args[0]))
+		return
+	}
+	doc, err := readDocument(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	for _, name := range []string{"name", "description", "properties"} {
+		if value, ok := doc.Attributes[name]; ok {
+			project.Attributes[name] = value
+		}
+	}
+	writeJson(w, http.StatusOK, map[string]interface{}{"data": project})
+}
+
+// deleteProject also deletes the project's branches, runs, issues and jobs
+func (s *Server) deleteProject(w http.ResponseWriter, r *http.Request, args []string) {
+	projectId := args[0]
+	if !s.store.remove("project", projectId) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no project %s", projectId))
+		return
+	}
+	for _, resourceType := range []string{"branch", "run", "jobs"} {
+		for _, related := range s.store.list(resourceType, nil) {
+			if related.related("project") == projectId {
+				s.store.remove(resourceType, related.Id)
+			}
+		}
+	}
+	for _, issue := range s.store.list("issue", nil) {
+		if issue.hidden["project"] == projectId {
+			s.store.remove("issue", issue.Id)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) getBranches(w http.ResponseWriter, r *http.Request, args []string) {
+	projectId := r.URL.Query().Get("filter[branch][project][id][$eq]")
+	branches := s.store.list("branch", func(branch *resource) bool {
+		return projectId == "" || branch.related("project") == projectId
+	})
+	writeJson(w, http.StatusOK, pageOf(r, branches))
+}
+
+func (s *Server) getBranch(w http.ResponseWriter, r *http.Request, args []string) {
+	branch := s.store.find("branch", args[0])
+	if branch == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no branch %s", args[0]))
+		return
+	}
+	writeJson(w, http.StatusOK, map[string]interface{}{"data": branch})
+}
+
+func (s *Server) createBranch(w http.ResponseWriter, r *http.Request, args []string) {
+	doc, err := readDocument(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	projectId := doc.related("project")
+	if s.store.find("project", projectId) == nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("no project %s", projectId))
+		return
+	}
+	name := doc.attribute("name")
+	for _, branch := range s.store.list("branch", nil) {
+		if branch.related("project") == projectId && branch.attribute("name") == name {
+			writeError(w, http.StatusConflict, fmt.Sprintf("branch %s already exists", name))
+			return
+		}
+	}
+	branch := s.store.add(newResource("branch", s.store.newId("branch"), map[string]interface{}{
+		"name":             name,
+		"main-for-project": false,
+	}).relate("project", "project", projectId))
+	writeJson(w, http.StatusCreated, map[string]interface{}{"data": branch})
+}
+
+func (s *Server) updateBranch(w http.ResponseWriter, r *http.Request, args []string) {
+	branch := s.store.find("branch", args[0])
+	if branch == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no branch %s", args[0]))
+		return
+	}
+	doc, err := readDocument(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if name := doc.attribute("name"); name != "" {
+		branch.Attributes["name"] = name
+	}
+	writeJson(w, http.StatusOK, map[string]interface{}{"data": branch})
+}
+
+func (s *Server) deleteBranch(w http.ResponseWriter, r *http.Request, args []string) {
+	branch := s.store.find("branch", args[0])
+	if branch == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no branch %s", args[0]))
+		return
+	}
+	if branch.flag("main-for-project") {
+		writeError(w, http.StatusConflict, "the main branch can't be deleted")
+		return
+	}
+	s.store.remove("branch", branch.Id)
+	for _, run := range s.store.list("run", nil) {
+		if run.related("branch") == branch.Id {
+			s.store.remove("run", run.Id)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getRuns supports sorting by a single date attribute, e.g. sort=-completed-date
+func (s *Server) getRuns(w http.ResponseWriter, r *http.Request, args []string) {
+	query := r.URL.Query()
+	projectId := query.Get("filter[run][project][id][$eq]")
+	branchId := query.Get("filter[run][revision][branch][id][$eq]")
+	status := query.Get("filter[run][status][$eq]")
+	runType := query.Get("filter[run][run-type][$eq]")
+	after := query.Get("filter[run][creation-date][$gte]")
+	before := query.Get("filter[run][creation-date][$lt]")
+	runs := s.store.list("run", func(run *resource) bool {
+		created := run.attribute("creation-date")
+		return (projectId == "" || run.related("project") == projectId) &&
+			(branchId == "" || run.related("branch") == branchId) &&
+			(status == "" || run.attribute("status") == status) &&
+			(runType == "" || run.attribute("run-type") == runType) &&
+			(after == "" || created >= after) &&
+			(before == "" || created < before)
+	})
+	if sortBy := query.Get("sort"); sortBy != "" {
+		attribute := strings.TrimPrefix(sortBy, "-")
+		descending := strings.HasPrefix(sortBy, "-")
+		sort.SliceStable(runs, func(i, j int) bool {
+			if descending {
+				return runs[i].attribute(attribute) > runs[j].attribute(attribute)
+			}
+			return runs[i].attribute(attribute) < runs[j].attribute(attribute)
+		})
+	}
+	writeJson(w, http.StatusOK, pageOf(r, runs))
+}
+
+func (s *Server) getVinylProjects(w http.ResponseWriter, r *http.Request, args []string) {
+	page := pageOf(r, s.store.list("project", nil))
+	included := []*resource{}
+	for _, project := range page["data"].([]*resource) {
+		if branch := s.store.find("branch", project.related("main-branch")); branch != nil {
+			included = append(included, branch)
+		}
+	}
+	page["included"] = included
+	writeJson(w, http.StatusOK, page)
+}
+
+func (s *Server) getTools(w http.ResponseWriter, r *http.Request, args []string) {
+	writeJson(w, http.StatusOK, pageOf(r, s.store.list("tool", nil)))
+}
+
+func isTerminal(state string) bool {
+	return state == "COMPLETED" || state == "FAILED" || state == "CANCELLED"
+}
+
+// AddJob adds an analysis job on the project's main branch.  It moves through
+// states, one per GET of the job, and then stays in the last one.
+func (s *Server) AddJob(projectId string, states ...string) string {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	job := newResource("jobs", s.store.newId("job"), map[string]interface{}{
+		"jobType":     "ANALYSIS",
+		"dateCreated": time.Now().UTC().Format(time.RFC3339),
+		"details":     map[string]interface{}{},
+	}).relate("project", "projects", projectId)
+	if project := s.store.find("project", projectId); project != nil {
+		job.relate("branch", "branches", project.related("main-branch"))
+	}
+	// the states still to come, so that repeated states each last one GET
+	job.hidden["states"] = strings.Join(states[1:], ",")
+	setJobState(job, states[0])
+	s.store.add(job)
+	return job.Id
+}
+
+func setJobState(job *resource, state string) {
+	progress := 0
+	switch {
+	case isTerminal(state):
+		progress = 100
+		job.Attributes["dateFinished"] = time.Now().UTC().Format(time.RFC3339)
+	case state == "RUNNING":
+		progress = 50
+	}
+	job.Attributes["status"] = map[string]interface{}{"state": state, "progress": progress}
+	if state == "FAILED" {
+		job.Attributes["failureInfo"] = map[string]string{
+			"userFriendlyFailureReason": "failed by the fake polaris",
+			"exception":                 "",
+		}
+	}
+	job.hidden["state"] = state
+}
+
+// advance moves a job on to its next state
+func advance(job *resource) {
+	if job.hidden["states"] == "" {
+		return
+	}
+	states := strings.SplitN(job.hidden["states"], ",", 2)
+	setJobState(job, states[0])
+	job.hidden["states"] = ""
+	if len(states) > 1 {
+		job.hidden["states"] = states[1]
+	}
+}
+
+func (s *Server) getJobs(w http.ResponseWriter, r *http.Request, args []string) {
+	query := r.URL.Query()
+	projectId := query.Get("filter[jobs][project][id][$eq]")
+	branchId := query.Get("filter[jobs][branch][id][$eq]")
+	states := query.Get("filter[jobs][status][state][$in]")
+	after := query.Get("filter[jobs][dateCreated][$gte]")
+	before := query.Get("filter[jobs][dateCreated][$lt]")
+	jobs := s.store.list("jobs", func(job *resource) bool {
+		// RFC3339 UTC dates compare correctly as strings
+		created := job.attribute("dateCreated")
+		return (projectId == "" || job.related("project") == projectId) &&
+			(branchId == "" || job.related("branch") == branchId) &&
+			(states == "" || strings.Contains(","+states+",", ","+job.hidden["state"]+",")) &&
+			(after == "" || created >= after) &&
+			(before == "" || created < before)
+	})
+	writeJson(w, http.StatusOK, pageOf(r, jobs))
+}
+
+func (s *Server) getJob(w http.ResponseWriter, r *http.Request, args []string) {
+	job := s.store.find("jobs", args[0])
+	if job == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no job %s", args[0]))
+		return
+	}
+	writeJson(w, http.StatusOK, map[string]interface{}{"data": job})
+	advance(job)
+}
+
+func (s *Server) getJobLogs(w http.ResponseWriter, r *http.Request, args []string) {
+	job := s.store.find("jobs", args[0])
+	if job == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no job %s", args[0]))
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	fmt.Fprintf(w, "job %s is %s\n", job.Id, job.hidden["state"])
+}
+
+// Seed adds projects named project-0, project-1, ..., each with issuesPerProject issues.
+func (s *Server) Seed(projects int, issuesPerProject int) []*Project {
+	added := []*Project{}
+	for i := 0; i < projects; i++ {
+		project := s.AddProject(fmt.Sprintf("project-%d", i))
+		s.AddIssues(project, issuesPerProject)
+		added = append(added, project)
+	}
+	return added
+}
@@ -0,0 +1,446 @@
+/*
+Copyright (C) 2018 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package fake
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Config configures a fake Polaris.  Email and Password are the organization
+// owner's credentials.
+type Config struct {
+	Email            string
+	Password         string
+	OrganizationName string
+	// TokenLifetime is how long session tokens are accepted for
+	TokenLifetime time.Duration
+	// Latency is added to every request
+	Latency time.Duration
+	// ErrorRate is the fraction of requests, logins excepted, answered with ErrorStatusCode
+	ErrorRate       float64
+	ErrorStatusCode int
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Email:            "admin@example.com",
+		Password:         "password",
+		OrganizationName: "fake",
+		TokenLifetime:    time.Hour,
+		ErrorStatusCode:  http.StatusServiceUnavailable,
+	}
+}
+
+// Fault delays or fails the requests it matches, on top of Config's Latency and ErrorRate.
+type Fault struct {
+	// Method matches any method if empty
+	Method string
+	// PathPrefix is matched against the request path, for example "/api/auth/users"
+	PathPrefix string
+	// StatusCode is sent instead of the real response; 0 only delays
+	StatusCode int
+	Latency    time.Duration
+	// Count is how many requests to affect; 0 means all of them
+	Count int
+}
+
+func (f *Fault) matches(r *http.Request) bool {
+	return (f.Method == "" || f.Method == r.Method) && strings.HasPrefix(r.URL.Path, f.PathPrefix)
+}
+
+type session struct {
+	userId string
+	expiry time.Time
+}
+
+type handlerFunc func(w http.ResponseWriter, r *http.Request, args []string)
+
+type route struct {
+	method   string
+	segments []string
+	handle   handlerFunc
+	// stateless routes don't touch the server's state, so they run without the lock
+	stateless bool
+}
+
+// Server is an in-process Polaris that keeps its state in memory.  It serves
+// the subset of endpoints api.Client calls, well enough for load generators
+// and tools to run against it end to end.
+type Server struct {
+	*httptest.Server
+	Config         *Config
+	OrganizationId string
+	OwnerId        string
+
+	// mux guards the state that handlers work on
+	mux       *sync.Mutex
+	store     *store
+	passwords map[string]string
+	// apiTokens maps secret access tokens to their apitokens resource
+	apiTokens map[string]*resource
+	sessions  map[string]*session
+	// taxonomies aren't JSON:API resources
+	taxonomies []map[string]interface{}
+	routes     []*route
+
+	// faultsMux guards the faults and request counts, which every request touches
+	faultsMux *sync.Mutex
+	faults    []*Fault
+	requests  map[string]int
+}
+
+// NewServer starts a fake Polaris on a random local port.
+func NewServer(config *Config) *Server {
+	server := NewUnstartedServer(config)
+	server.Start()
+	return server
+}
+
+// NewUnstartedServer lets the caller set the listener, for example to serve on
+// a fixed port, before calling Start.
+func NewUnstartedServer(config *Config) *Server {
+	server := &Server{
+		Config:    config,
+		mux:       &sync.Mutex{},
+		faultsMux: &sync.Mutex{},
+		store:     newStore(),
+		passwords: map[string]string{},
+		apiTokens: map[string]*resource{},
+		sessions:  map[string]*session{},
+		requests:  map[string]int{},
+	}
+	server.addRoutes()
+	server.seed()
+	server.Server = httptest.NewUnstartedServer(server)
+	return server
+}
+
+func (s *Server) addRoutes() {
+	s.handle("POST", "api/auth/authenticate", s.authenticate)
+	s.handle("GET", "api/auth/apitokens", s.getApiTokens)
+	s.handle("POST", "api/auth/apitokens", s.createApiToken)
+	s.handle("PATCH", "api/auth/apitokens/*", s.updateApiToken)
+	s.handle("DELETE", "api/auth/apitokens/*", s.deleteApiToken)
+	s.handle("GET", "api/auth/organizations", s.getOrganizations)
+	s.handle("GET", "api/auth/organizations/*", s.getOrganization)
+	s.handle("GET", "api/auth/users", s.getUsers)
+	s.handle("POST", "api/auth/users", s.createUser)
+	s.handle("GET", "api/auth/users/*", s.getUser)
+	s.handle("PATCH", "api/auth/users/*", s.updateUser)
+	s.handle("DELETE", "api/auth/users/*", s.deleteUser)
+	s.handle("GET", "api/auth/roles", s.getRoles)
+	s.handle("GET", "api/auth/role-assignments", s.getRoleAssignments)
+	s.handle("POST", "api/auth/role-assignments", s.createRoleAssignment)
+	s.handle("GET", "api/auth/role-assignments/*", s.getRoleAssignment)
+	s.handle("PATCH", "api/auth/role-assignments/*", s.updateRoleAssignment)
+	s.handle("DELETE", "api/auth/role-assignments/*", s.deleteRoleAssignment)
+	s.handle("GET", "api/auth/entitlements", s.getEntitlements)
+	s.handle("GET", "api/common/v0/projects", s.getProjects)
+	s.handle("POST", "api/common/v0/projects", s.createProject)
+	s.handle("GET", "api/common/v0/projects/*", s.getProject)
+	s.handle("PATCH", "api/common/v0/projects/*", s.updateProject)
+	s.handle("DELETE", "api/common/v0/projects/*", s.deleteProject)
+	s.handle("GET", "api/common/v0/branches", s.getBranches)
+	s.handle("POST", "api/common/v0/branches", s.createBranch)
+	s.handle("GET", "api/common/v0/branches/*", s.getBranch)
+	s.handle("PATCH", "api/common/v0/branches/*", s.updateBranch)
+	s.handle("DELETE", "api/common/v0/branches/*", s.deleteBranch)
+	s.handle("GET", "api/common/v0/runs", s.getRuns)
+	s.handle("GET", "api/common/v0/tools", s.getTools)
+	s.handleStateless("HEAD", "api/tools/*", s.getCli)
+	s.handleStateless("GET", "api/tools/*", s.getCli)
+	s.handle("GET", "api/vinyl/common/v0/projects", s.getVinylProjects)
+	s.handle("GET", "api/jobs/jobs", s.getJobs)
+	s.handle("GET", "api/jobs/jobs/*", s.getJob)
+	s.handle("GET", "api/jobs/jobs/*/logs", s.getJobLogs)
+	s.handle("GET", "api/query/v1/issues", s.getIssues)
+	s.handle("GET", "api/query/v1/issues/*", s.getIssue)
+	s.handle("GET", "api/query/v0/roll-up-counts", s.getV0RollUpCounts)
+	s.handle("GET", "api/query/v1/roll-up-counts", s.getV1RollUpCounts)
+	s.handle("GET", "api/query/v1/counts/status", s.getStatusCounts)
+	s.handle("GET", "api/taxonomy/v0/taxonomies", s.getTaxonomies)
+}
+
+// handle registers a route; "*" in the pattern matches one path segment, which is passed to h.
+func (s *Server) handle(method string, pattern string, h handlerFunc) {
+	s.routes = append(s.routes, &route{method: method, segments: strings.Split(pattern, "/"), handle: h})
+}
+
+// handleStateless registers a route whose handler doesn't touch the server's state.
+func (s *Server) handleStateless(method string, pattern string, h handlerFunc) {
+	s.routes = append(s.routes, &route{method: method, segments: strings.Split(pattern, "/"), handle: h, stateless: true})
+}
+
+func (s *Server) match(r *http.Request) (*route, []string, int) {
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	status := http.StatusNotFound
+	for _, rt := range s.routes {
+		if len(rt.segments) != len(segments) {
+			continue
+		}
+		args := []string{}
+		matched := true
+		for i, segment := range rt.segments {
+			if segment == "*" {
+				args = append(args, segments[i])
+			} else if segment != segments[i] {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		if rt.method != r.Method {
+			status = http.StatusMethodNotAllowed
+			continue
+		}
+		return rt, args, http.StatusOK
+	}
+	return nil, nil, status
+}
+
+// InjectFault adds a fault; faults are checked in the order they were injected.
+func (s *Server) InjectFault(fault *Fault) {
+	s.faultsMux.Lock()
+	defer s.faultsMux.Unlock()
+	copied := *fault
+	s.faults = append(s.faults, &copied)
+}
+
+func (s *Server) ClearFaults() {
+	s.faultsMux.Lock()
+	defer s.faultsMux.Unlock()
+	s.faults = nil
+}
+
+// RequestCount is the number of requests received for method and path, for
+// example "GET", "/api/auth/users", whether or not they succeeded.
+func (s *Server) RequestCount(method string, path string) int {
+	s.faultsMux.Lock()
+	defer s.faultsMux.Unlock()
+	return s.requests[fmt.Sprintf("%s %s", method, path)]
+}
+
+// fault finds and uses up the first fault matching r
+func (s *Server) fault(r *http.Request) (time.Duration, int) {
+	s.faultsMux.Lock()
+	defer s.faultsMux.Unlock()
+	s.requests[fmt.Sprintf("%s %s", r.Method, r.URL.Path)]++
+	for i, fault := range s.faults {
+		if !fault.matches(r) {
+			continue
+		}
+		if fault.Count > 0 {
+			fault.Count--
+			if fault.Count == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return fault.Latency, fault.StatusCode
+	}
+	return 0, 0
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	latency, statusCode := s.fault(r)
+	if wait := s.Config.Latency + latency; wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	isLogin := r.URL.Path == "/api/auth/authenticate"
+	isPublic := isLogin || strings.HasPrefix(r.URL.Path, "/api/tools/")
+	if statusCode == 0 && !isLogin && s.Config.ErrorRate > 0 && rand.Float64() < s.Config.ErrorRate {
+		statusCode = s.Config.ErrorStatusCode
+	}
+	if statusCode != 0 {
+		log.Debugf("fake polaris: injecting %d for %s %s", statusCode, r.Method, r.URL.Path)
+		writeError(w, statusCode, "injected fault")
+		return
+	}
+
+	rt, args, status := s.match(r)
+	if rt == nil {
+		writeError(w, status, fmt.Sprintf("no route for %s %s", r.Method, r.URL.Path))
+		return
+	}
+	if rt.stateless && isPublic {
+		rt.handle(w, r, args)
+		return
+	}
+
+	// the lock is only held while the handler works on the state: the request
+	// body is read before taking it, and the response is written after releasing it
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	response := httptest.NewRecorder()
+	s.mux.Lock()
+	authenticated := isPublic || s.authenticatedUser(r) != ""
+	if authenticated {
+		rt.handle(response, r, args)
+	}
+	s.mux.Unlock()
+	if !authenticated {
+		writeError(w, http.StatusUnauthorized, "missing or expired token")
+		return
+	}
+	for name, values := range response.Header() {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(response.Code)
+	w.Write(response.Body.Bytes())
+}
+
+// authenticatedUser returns the id of the user whose bearer token r carries, if it's valid
+func (s *Server) authenticatedUser(r *http.Request) string {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	sess, ok := s.sessions[token]
+	if !ok || time.Now().After(sess.expiry) {
+		return ""
+	}
+	return sess.userId
+}
+
+// newSession issues a JWT-shaped token, so that clients can read its expiry
+func (s *Server) newSession(userId string) string {
+	expiry := time.Now().Add(s.Config.TokenLifetime)
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d,"sub":"%s","jti":"%d"}`, expiry.Unix(), userId, len(s.sessions))))
+	token := fmt.Sprintf("%s.%s.fake", header, payload)
+	s.sessions[token] = &session{userId: userId, expiry: expiry}
+	return token
+}
+
+// ExpireSessions invalidates every session token, as if they had all timed out.
+func (s *Server) ExpireSessions() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.sessions = map[string]*session{}
+}
+
+func writeJson(w http.ResponseWriter, statusCode int, body interface{}) {
+	content, err := json.Marshal(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+	}
+	w.WriteHeader(statusCode)
+	w.Write(content)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, detail string) {
+	writeJson(w, statusCode, map[string]interface{}{
+		"errors": []map[string]string{{
+			"status": strconv.Itoa(statusCode),
+			"title":  http.StatusText(statusCode),
+			"detail": detail,
+		}},
+	})
+}
+
+// readDocument decodes a JSON:API request body with a single resource as its data
+func readDocument(r *http.Request) (*resource, error) {
+	content, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	doc := struct {
+		Data *resource `json:"data"`
+	}{}
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Data == nil {
+		return nil, fmt.Errorf("no data in request body")
+	}
+	return doc.Data, nil
+}
+
+// pageOf cuts the requested page out of items and adds the pagination meta and links
+func pageOf(r *http.Request, items []*resource) map[string]interface{} {
+	query := r.URL.Query()
+	offset, _ := strconv.Atoi(query.Get("page[offset]"))
+	limit := 25
+	if value := query.Get("page[limit]"); value != "" {
+		limit, _ = strconv.Atoi(value)
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(items) {
+		offset = len(items)
+	}
+	end := offset + limit
+	if end > len(items) || limit < 0 {
+		end = len(items)
+	}
+	links := map[string]string{"self": pageLink(r, offset, limit)}
+	if end < len(items) && limit > 0 {
+		links["next"] = pageLink(r, end, limit)
+	}
+	return map[string]interface{}{
+		"data": nonNil(items[offset:end]),
+		"meta": map[string]interface{}{
+			"offset": offset,
+			"limit":  limit,
+			"total":  len(items),
+		},
+		"links": links,
+	}
+}
+
+func pageLink(r *http.Request, offset int, limit int) string {
+	query := r.URL.Query()
+	query.Set("page[offset]", strconv.Itoa(offset))
+	query.Set("page[limit]", strconv.Itoa(limit))
+	return fmt.Sprintf("%s?%s", r.URL.Path, query.Encode())
+}
+
+// nonNil makes empty lists marshal as [] rather than null
+func nonNil(items []*resource) []*resource {
+	if items == nil {
+		return []*resource{}
+	}
+	return items
+}
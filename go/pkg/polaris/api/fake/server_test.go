@@ -0,0 +1,314 @@
+/*
+Copyright (C) 2018 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func RunServerTests() {
+	Describe("Fake Polaris", func() {
+		var server *Server
+		var client *api.Client
+
+		BeforeEach(func() {
+			server = NewServer(DefaultConfig())
+			client = api.NewClient(server.URL, server.Config.Email, server.Config.Password)
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should only serve authenticated requests", func() {
+			_, err := api.NewBearerTokenClient(server.URL, "nope").GetUsers(0, 10)
+			Expect(err).ToNot(BeNil())
+
+			users, err := client.GetUsers(0, 10)
+			Expect(err).To(BeNil())
+			Expect(users.Meta.Total).To(Equal(1))
+			Expect(users.Data[0].Attributes.Owner).To(BeTrue())
+
+			// the client logs in again once its session is gone
+			server.ExpireSessions()
+			_, err = client.GetUsers(0, 10)
+			Expect(err).To(BeNil())
+			Expect(server.RequestCount("POST", "/api/auth/authenticate")).To(Equal(2))
+		})
+
+		It("should log in with access tokens until they're revoked", func() {
+			created, err := client.GetAccessToken("ci")
+			Expect(err).To(BeNil())
+			accessToken := created.Data.Attributes.AccessToken
+
+			tokenClient := api.NewClientWithAuthenticator(server.URL, &api.AccessTokenAuthenticator{AccessToken: accessToken})
+			tokens, err := tokenClient.GetAccessTokens(0, 10)
+			Expect(err).To(BeNil())
+			Expect(tokens.Data[0].Attributes.Name).To(Equal("ci"))
+
+			Expect(client.RevokeAccessToken(tokens.Data[0].Id)).To(Succeed())
+			Expect(api.NewClientWithAuthenticator(server.URL, &api.AccessTokenAuthenticator{AccessToken: accessToken}).Authenticate()).ToNot(Succeed())
+		})
+
+		It("should keep users and role assignments", func() {
+			project := server.AddProject("cerebros")
+			orgs, err := client.GetOrganizations()
+			Expect(err).To(BeNil())
+			orgId := orgs.Data[0].Id
+
+			created, err := client.CreateServiceAccount("bot@example.com", "bot", orgId, "secret")
+			Expect(err).To(BeNil())
+			userId := created.Data.Id
+			_, err = client.CreateServiceAccount("bot@example.com", "bot", orgId, "secret")
+			Expect(err).ToNot(BeNil())
+
+			body, err := client.CreateRoleAssignment(userId, server.RoleId("Contributor"), project.Id, orgId)
+			Expect(err).To(BeNil())
+			created2 := struct{ Data api.RoleAssignment }{}
+			Expect(json.Unmarshal([]byte(body), &created2)).To(Succeed())
+
+			ras, err := client.GetRoleAssignmentsForUser("bot@example.com", 0, 10, true)
+			Expect(err).To(BeNil())
+			Expect(len(ras.Data)).To(Equal(1))
+			Expect(ras.Data[0].Id).To(Equal(created2.Data.Id))
+
+			ra, err := client.GetRoleAssignment(created2.Data.Id)
+			Expect(err).To(BeNil())
+			Expect(ra.Included.ResolveOne(ra.Data.Relationships["role"]).Attributes["rolename"]).To(Equal("Contributor"))
+			user, err := client.GetUser(userId)
+			Expect(err).To(BeNil())
+			Expect(user.Data.Attributes.Automated).To(BeTrue())
+
+			entitlements, err := client.GetEntitlementsForProject(project.Id)
+			Expect(err).To(BeNil())
+			Expect(entitlements.Data[0].Attributes.Allowed).To(Equal([]string{"projects.read", "projects.write"}))
+
+			// service accounts can log in with their password
+			Expect(api.NewClient(server.URL, "bot@example.com", "secret").Authenticate()).To(Succeed())
+
+			Expect(client.DeleteUser(userId)).To(Succeed())
+			ras, err = client.GetRoleAssignments(0, 10)
+			Expect(err).To(BeNil())
+			Expect(ras.Meta.Total).To(Equal(0))
+		})
+
+		It("should page through projects and their issues", func() {
+			projects := server.Seed(12, 10)
+
+			all, err := client.VinylV0ProjectsPaginator(5).All(context.Background())
+			Expect(err).To(BeNil())
+			Expect(len(all)).To(Equal(12))
+			first := all[0].(*api.VinylV0Project)
+			Expect(first.Relationships["main-branch"].Data.Id).To(Equal(projects[0].BranchId))
+
+			issues, err := client.GetV1Issues(projects[0].Id, projects[0].BranchId, "", 0, 4)
+			Expect(err).To(BeNil())
+			Expect(issues.Meta.Total).To(Equal(10))
+			Expect(len(issues.Data)).To(Equal(4))
+			Expect(issues.Included.ResolveOne(issues.Data[0].Relationships.Severity).Id).To(Equal("critical"))
+
+			counts, err := client.GetV1RollUpCounts(&api.RollUpCountsQuery{ProjectId: projects[0].Id, RunIds: []string{projects[0].RunId}, GroupBy: []string{api.GroupByIssueSeverity}})
+			Expect(err).To(BeNil())
+			total := 0
+			for _, count := range counts.Data {
+				total += count.Attributes.Value
+			}
+			Expect(total).To(Equal(10))
+			Expect(len(counts.Data)).To(Equal(4))
+
+			statuses, err := client.GetV1StatusCounts(&api.RollUpCountsQuery{ProjectId: projects[0].Id, BranchId: projects[0].BranchId})
+			Expect(err).To(BeNil())
+			Expect(statuses.ByStatus()).To(Equal(map[string]int{"opened": 8, "closed": 2}))
+
+			taxonomies, err := client.GetTaxonomyCount()
+			Expect(err).To(BeNil())
+			Expect(taxonomies.Meta.Total).To(Equal(1))
+		})
+
+		It("should move jobs through their states", func() {
+			project := server.AddProject("cerebros")
+			jobId := server.AddJob(project.Id, api.JobStateQueued, api.JobStateRunning, api.JobStateRunning, api.JobStateCompleted)
+
+			job, err := client.WaitForJobWithPolicy(context.Background(), jobId, &api.JobPollPolicy{InitialInterval: time.Millisecond, MaxInterval: time.Millisecond})
+			Expect(err).To(BeNil())
+			Expect(job.Attributes.Status.State).To(Equal(api.JobStateCompleted))
+			Expect(server.RequestCount("GET", "/api/jobs/jobs/"+jobId)).To(Equal(4))
+
+			jobs, err := client.GetFilteredJobs(&api.JobsFilter{ProjectId: project.Id, States: []string{api.JobStateCompleted}}, 0, 10)
+			Expect(err).To(BeNil())
+			Expect(len(jobs.Data)).To(Equal(1))
+		})
+
+		It("should create, rename and delete projects and branches", func() {
+			created, err := client.CreateProject("fixtures", "set up by a test")
+			Expect(err).To(BeNil())
+			projectId := created.Data.Id
+			Expect(created.Data.Attributes.Description).To(Equal("set up by a test"))
+
+			_, err = client.CreateProject("fixtures", "")
+			Expect(err).ToNot(BeNil())
+
+			_, err = client.RenameProject(projectId, "renamed")
+			Expect(err).To(BeNil())
+			found, err := client.GetProjectsByName("renamed")
+			Expect(err).To(BeNil())
+			Expect(len(found.Data)).To(Equal(1))
+			Expect(found.Data[0].Id).To(Equal(projectId))
+
+			branch, err := client.CreateV0Branch(projectId, "feature")
+			Expect(err).To(BeNil())
+			_, err = client.RenameV0Branch(branch.Data.Id, "feature-2")
+			Expect(err).To(BeNil())
+			branches, err := client.GetV0Branches(projectId, 0, 10)
+			Expect(err).To(BeNil())
+			Expect(len(branches.Data)).To(Equal(2))
+
+			Expect(client.DeleteV0Branch(branch.Data.Id)).To(Succeed())
+			main := branches.Data[0]
+			if !main.Attributes.MainForProject {
+				main = branches.Data[1]
+			}
+			Expect(client.DeleteV0Branch(main.Id)).ToNot(Succeed())
+
+			Expect(client.DeleteProject(projectId)).To(Succeed())
+			_, err = client.GetProject(projectId)
+			Expect(err).ToNot(BeNil())
+			branches, err = client.GetV0Branches(projectId, 0, 10)
+			Expect(err).To(BeNil())
+			Expect(branches.Data).To(BeEmpty())
+		})
+
+		It("should filter runs and find the latest completed one", func() {
+			project := server.AddProject("cerebros")
+			now := time.Now()
+			latest := server.AddRun(project.Id, project.BranchId, api.RunStatusCompleted, now.Add(time.Minute))
+			server.AddRun(project.Id, project.BranchId, api.RunStatusFailed, now.Add(2*time.Minute))
+			server.AddRun(project.Id, project.BranchId, api.RunStatusRunning, now.Add(3*time.Minute))
+
+			run, err := client.GetLatestCompletedRun(project.BranchId)
+			Expect(err).To(BeNil())
+			Expect(run.Id).To(Equal(latest))
+
+			runs, err := client.GetV0Runs(&api.RunsFilter{BranchId: project.BranchId, CreatedAfter: now.Add(time.Second)}, 0, 10)
+			Expect(err).To(BeNil())
+			Expect(len(runs.Data)).To(Equal(3))
+			Expect(runs.Data[0].Attributes.Status).To(Equal(api.RunStatusRunning))
+
+			all, err := client.V0RunsPaginator(&api.RunsFilter{ProjectId: project.Id}, 2).All(context.Background())
+			Expect(err).To(BeNil())
+			Expect(len(all)).To(Equal(4))
+
+			branch, err := client.CreateV0Branch(project.Id, "empty")
+			Expect(err).To(BeNil())
+			run, err = client.GetLatestCompletedRun(branch.Data.Id)
+			Expect(err).To(BeNil())
+			Expect(run).To(BeNil())
+		})
+
+		It("should serve the polaris cli without a token", func() {
+			dir, err := ioutil.TempDir("", "fake-polaris-cli-")
+			Expect(err).To(BeNil())
+			defer os.RemoveAll(dir)
+
+			cliDir, err := api.NewBearerTokenClient(server.URL, "").DownloadCli(dir, api.OSTypeLinux)
+			Expect(err).To(BeNil())
+			info, err := os.Stat(filepath.Join(cliDir, "polaris"))
+			Expect(err).To(BeNil())
+			Expect(info.Mode() & 0100).ToNot(BeZero())
+
+			response, err := http.Get(server.URL + "/api/tools/polaris_cli-os2.zip")
+			Expect(err).To(BeNil())
+			response.Body.Close()
+			Expect(response.StatusCode).To(Equal(http.StatusNotFound))
+		})
+
+		It("should serve the polaris cli while the state is locked", func() {
+			server.mux.Lock()
+			defer server.mux.Unlock()
+			httpClient := &http.Client{Timeout: 5 * time.Second}
+			response, err := httpClient.Get(server.URL + "/api/tools/polaris_cli-linux64.zip")
+			Expect(err).To(BeNil())
+			response.Body.Close()
+			Expect(response.StatusCode).To(Equal(http.StatusOK))
+			Expect(server.RequestCount(http.MethodGet, "/api/tools/polaris_cli-linux64.zip")).To(Equal(1))
+		})
+
+		It("should scope calls to the selected organization", func() {
+			org, err := client.SelectOrganization("")
+			Expect(err).To(BeNil())
+			Expect(org.Id).To(Equal(server.OrganizationId))
+
+			otherId := server.AddOrganization("Other")
+			_, err = api.NewClient(server.URL, server.Config.Email, server.Config.Password).SelectOrganization("")
+			Expect(err).ToNot(BeNil())
+
+			other, err := client.SelectOrganization("other")
+			Expect(err).To(BeNil())
+			Expect(other.Id).To(Equal(otherId))
+			Expect(other.Attributes.Description).To(Equal("Other organization"))
+			_, err = client.SelectOrganization(server.OrganizationId)
+			Expect(err).To(BeNil())
+			Expect(client.OrganizationId()).To(Equal(server.OrganizationId))
+			_, err = client.SelectOrganization("nope")
+			Expect(err).ToNot(BeNil())
+
+			created, err := client.CreateUser("home@example.com", "home", "")
+			Expect(err).To(BeNil())
+			Expect(created.Data.Relationships["organization"].Data.Id).To(Equal(server.OrganizationId))
+			created, err = client.CreateUserContext(api.WithOrganization(context.Background(), otherId), "away@example.com", "away", "")
+			Expect(err).To(BeNil())
+			Expect(created.Data.Relationships["organization"].Data.Id).To(Equal(otherId))
+
+			fetched, err := client.GetOrganization(otherId)
+			Expect(err).To(BeNil())
+			Expect(fetched.Data.Attributes.OrganizationName).To(Equal("Other"))
+
+			_, err = client.GetTaxonomies(10)
+			Expect(err).To(BeNil())
+			_, err = client.GetTaxonomiesContext(api.WithOrganization(context.Background(), "nope"), 10)
+			Expect(err).ToNot(BeNil())
+		})
+
+		It("should inject faults", func() {
+			Expect(client.Authenticate()).To(Succeed())
+			server.InjectFault(&Fault{Method: "GET", PathPrefix: "/api/auth/users", StatusCode: http.StatusServiceUnavailable, Count: 2})
+			client.Retry = &api.RetryPolicy{MaxAttempts: 3}
+
+			_, err := client.GetUsers(0, 10)
+			Expect(err).To(BeNil())
+			Expect(server.RequestCount("GET", "/api/auth/users")).To(Equal(3))
+
+			server.Config.ErrorRate = 1
+			_, err = client.GetRoles()
+			Expect(err).ToNot(BeNil())
+		})
+	})
+}
@@ -0,0 +1,124 @@
+/*
+Copyright (C) 2018 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package fake
+
+import (
+	"fmt"
+)
+
+// resource is a JSON:API resource object as the fake stores and serves it.
+type resource struct {
+	Type          string                 `json:"type"`
+	Id            string                 `json:"id"`
+	Attributes    map[string]interface{} `json:"attributes"`
+	Relationships map[string]interface{} `json:"relationships,omitempty"`
+	Meta          map[string]interface{} `json:"meta,omitempty"`
+	// hidden holds server-side state that isn't served, like an issue's status
+	hidden map[string]string
+}
+
+func newResource(resourceType string, id string, attributes map[string]interface{}) *resource {
+	return &resource{Type: resourceType, Id: id, Attributes: attributes, Relationships: map[string]interface{}{}, hidden: map[string]string{}}
+}
+
+func identifier(resourceType string, id string) map[string]interface{} {
+	return map[string]interface{}{"type": resourceType, "id": id}
+}
+
+func (r *resource) relate(name string, resourceType string, id string) *resource {
+	r.Relationships[name] = map[string]interface{}{"data": identifier(resourceType, id)}
+	return r
+}
+
+// related returns the id in a to-one relationship, or "" if there is none
+func (r *resource) related(name string) string {
+	relationship, ok := r.Relationships[name].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	data, ok := relationship["data"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	id, _ := data["id"].(string)
+	return id
+}
+
+func (r *resource) attribute(name string) string {
+	value, _ := r.Attributes[name].(string)
+	return value
+}
+
+func (r *resource) flag(name string) bool {
+	value, _ := r.Attributes[name].(bool)
+	return value
+}
+
+// store keeps resources by type, in the order they were added.
+type store struct {
+	resources map[string][]*resource
+	nextId    int
+}
+
+func newStore() *store {
+	return &store{resources: map[string][]*resource{}}
+}
+
+func (s *store) newId(prefix string) string {
+	s.nextId++
+	return fmt.Sprintf("%s-%05d", prefix, s.nextId)
+}
+
+func (s *store) add(r *resource) *resource {
+	s.resources[r.Type] = append(s.resources[r.Type], r)
+	return r
+}
+
+func (s *store) find(resourceType string, id string) *resource {
+	for _, r := range s.resources[resourceType] {
+		if r.Id == id {
+			return r
+		}
+	}
+	return nil
+}
+
+func (s *store) remove(resourceType string, id string) bool {
+	resources := s.resources[resourceType]
+	for i, r := range resources {
+		if r.Id == id {
+			s.resources[resourceType] = append(resources[:i:i], resources[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// list returns the resources of a type that pass keep, or all of them if keep is nil
+func (s *store) list(resourceType string, keep func(r *resource) bool) []*resource {
+	kept := []*resource{}
+	for _, r := range s.resources[resourceType] {
+		if keep == nil || keep(r) {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
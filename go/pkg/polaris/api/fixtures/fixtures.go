@@ -0,0 +1,371 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package fixtures
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ModeEnvVar selects the mode of NewTransportFromEnv; set it to "record" to
+// re-record fixtures against a live Polaris.
+const ModeEnvVar = "POLARIS_FIXTURES"
+
+// Redacted replaces secrets in recorded fixtures.
+const Redacted = "REDACTED"
+
+type Mode string
+
+const (
+	ModeRecord Mode = "record"
+	ModeReplay Mode = "replay"
+)
+
+// sensitiveKeys are JSON keys, form fields and query parameters whose values are scrubbed.
+var sensitiveKeys = map[string]bool{
+	"email":         true,
+	"password":      true,
+	"accesstoken":   true,
+	"access-token":  true,
+	"access_token":  true,
+	"jwt":           true,
+	"secret":        true,
+	"token":         true,
+	"refresh_token": true,
+	"authorization": true,
+	"api-key":       true,
+}
+
+// sensitiveHeaders carry credentials or challenges; their values are scrubbed.
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "WWW-Authenticate", "Proxy-Authenticate"}
+
+// unstableHeaders change on every request and are not recorded.
+var unstableHeaders = []string{"Date", "Set-Cookie", "X-Request-Id"}
+
+type RecordedRequest struct {
+	Method string
+	Path   string
+	// Query is canonical: sorted and scrubbed
+	Query string
+	Body  string
+}
+
+func (rr *RecordedRequest) key() string {
+	return fmt.Sprintf("%s %s?%s\n%s", rr.Method, rr.Path, rr.Query, rr.Body)
+}
+
+type RecordedResponse struct {
+	StatusCode int
+	Header     http.Header
+	// Cookies are the names of the cookies set; their values are always Redacted
+	Cookies []string `json:",omitempty"`
+	Body    string
+}
+
+type Interaction struct {
+	Request  *RecordedRequest
+	Response *RecordedResponse
+}
+
+// Cassette is the golden file format: the interactions in the order they happened.
+type Cassette struct {
+	Interactions []*Interaction
+}
+
+func LoadCassette(path string) (*Cassette, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read fixtures %s", path)
+	}
+	cassette := &Cassette{}
+	if err := json.Unmarshal(content, cassette); err != nil {
+		return nil, errors.Wrapf(err, "unable to unmarshal fixtures %s", path)
+	}
+	return cassette, nil
+}
+
+func (c *Cassette) Save(path string) error {
+	content, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "unable to marshal fixtures")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrapf(err, "unable to make dir for fixtures %s", path)
+	}
+	return errors.Wrapf(ioutil.WriteFile(path, append(content, '\n'), 0644), "unable to write fixtures %s", path)
+}
+
+// Transport is an http.RoundTripper that either records the traffic it
+// forwards to Upstream, or replays a cassette without touching the network.
+type Transport struct {
+	Mode     Mode
+	Path     string
+	Upstream http.RoundTripper
+
+	mux      *sync.Mutex
+	cassette *Cassette
+	// served counts the replays of each request; repeats past the recording get the last response
+	served map[string]int
+	byKey  map[string][]*Interaction
+}
+
+func NewRecorder(path string, upstream http.RoundTripper) *Transport {
+	if upstream == nil {
+		upstream = http.DefaultTransport
+	}
+	return &Transport{
+		Mode:     ModeRecord,
+		Path:     path,
+		Upstream: upstream,
+		mux:      &sync.Mutex{},
+		cassette: &Cassette{},
+	}
+}
+
+func NewReplayer(path string) (*Transport, error) {
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	t := &Transport{
+		Mode:     ModeReplay,
+		Path:     path,
+		mux:      &sync.Mutex{},
+		cassette: cassette,
+		served:   map[string]int{},
+		byKey:    map[string][]*Interaction{},
+	}
+	for _, interaction := range cassette.Interactions {
+		key := interaction.Request.key()
+		t.byKey[key] = append(t.byKey[key], interaction)
+	}
+	return t, nil
+}
+
+// NewTransportFromEnv records through http.DefaultTransport if ModeEnvVar is
+// "record", and otherwise replays path.
+func NewTransportFromEnv(path string) (*Transport, error) {
+	if Mode(os.Getenv(ModeEnvVar)) == ModeRecord {
+		log.Infof("recording fixtures to %s", path)
+		return NewRecorder(path, nil), nil
+	}
+	return NewReplayer(path)
+}
+
+// Attach routes all of a resty client's requests through the transport.
+func (t *Transport) Attach(client *resty.Client) {
+	client.SetTransport(t)
+}
+
+// Save writes the recorded cassette; it does nothing when replaying.
+func (t *Transport) Save() error {
+	if t.Mode != ModeRecord {
+		return nil
+	}
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	return t.cassette.Save(t.Path)
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded, err := recordRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if t.Mode == ModeRecord {
+		return t.record(req, recorded)
+	}
+	return t.replay(req, recorded)
+}
+
+func (t *Transport) record(req *http.Request, recorded *RecordedRequest) (*http.Response, error) {
+	resp, err := t.Upstream.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read response to %s %s", req.Method, req.URL.Path)
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	header := scrubHeader(resp.Header)
+	cookies := []string{}
+	for _, cookie := range resp.Cookies() {
+		cookies = append(cookies, cookie.Name)
+	}
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	t.cassette.Interactions = append(t.cassette.Interactions, &Interaction{
+		Request: recorded,
+		Response: &RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     header,
+			Cookies:    cookies,
+			Body:       scrubBody(resp.Header.Get("Content-Type"), body),
+		},
+	})
+	return resp, nil
+}
+
+func (t *Transport) replay(req *http.Request, recorded *RecordedRequest) (*http.Response, error) {
+	key := recorded.key()
+	t.mux.Lock()
+	interactions := t.byKey[key]
+	if len(interactions) == 0 {
+		t.mux.Unlock()
+		return nil, errors.Errorf("no recorded interaction in %s for %s %s?%s", t.Path, recorded.Method, recorded.Path, recorded.Query)
+	}
+	index := t.served[key]
+	if index >= len(interactions) {
+		index = len(interactions) - 1
+	}
+	t.served[key]++
+	interaction := interactions[index]
+	t.mux.Unlock()
+
+	header := http.Header{}
+	for name, values := range interaction.Response.Header {
+		header[name] = append([]string{}, values...)
+	}
+	for _, name := range interaction.Response.Cookies {
+		header.Add("Set-Cookie", (&http.Cookie{Name: name, Value: Redacted}).String())
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+		StatusCode:    interaction.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(strings.NewReader(interaction.Response.Body)),
+		ContentLength: int64(len(interaction.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+func recordRequest(req *http.Request) (*RecordedRequest, error) {
+	body := []byte{}
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read body of %s %s", req.Method, req.URL.Path)
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	return &RecordedRequest{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  scrubValues(req.URL.Query()).Encode(),
+		Body:   scrubBody(req.Header.Get("Content-Type"), body),
+	}, nil
+}
+
+func scrubValues(values url.Values) url.Values {
+	scrubbed := url.Values{}
+	for key, vals := range values {
+		if sensitiveKeys[strings.ToLower(key)] {
+			scrubbed[key] = []string{Redacted}
+		} else {
+			scrubbed[key] = vals
+		}
+	}
+	return scrubbed
+}
+
+// scrubHeader copies a response header without its unstable entries, and with
+// the values of credentials redacted.
+func scrubHeader(header http.Header) http.Header {
+	scrubbed := http.Header{}
+	for name, values := range header {
+		lower := strings.ToLower(name)
+		if sensitiveKeys[lower] || sensitiveKeys[strings.TrimPrefix(lower, "x-")] {
+			scrubbed[name] = []string{Redacted}
+		} else {
+			scrubbed[name] = values
+		}
+	}
+	for _, name := range sensitiveHeaders {
+		if _, ok := scrubbed[http.CanonicalHeaderKey(name)]; ok {
+			scrubbed.Set(name, Redacted)
+		}
+	}
+	for _, name := range unstableHeaders {
+		scrubbed.Del(name)
+	}
+	return scrubbed
+}
+
+// scrubBody redacts secrets from JSON and form bodies.  JSON is re-encoded
+// with sorted keys, so that equivalent bodies compare equal.
+func scrubBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			return scrubValues(values).Encode()
+		}
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err == nil {
+		if encoded, err := json.Marshal(scrubJson(decoded)); err == nil {
+			return string(encoded)
+		}
+	}
+	return string(body)
+}
+
+func scrubJson(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if sensitiveKeys[strings.ToLower(key)] && field != nil {
+				v[key] = Redacted
+			} else {
+				v[key] = scrubJson(field)
+			}
+		}
+		return v
+	case []interface{}:
+		for i := range v {
+			v[i] = scrubJson(v[i])
+		}
+		return v
+	default:
+		return value
+	}
+}
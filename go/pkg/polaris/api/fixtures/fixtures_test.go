@@ -0,0 +1,182 @@
+/*
+Copyright (C) 2018 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package fixtures
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// newCountingServer logs in with a cookie and answers everything else with the number of requests so far
+func newCountingServer() *httptest.Server {
+	count := 0
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/auth/authenticate" {
+			http.SetCookie(w, &http.Cookie{Name: "access_token", Value: "session-secret"})
+			return
+		}
+		count++
+		w.Header().Set("Date", "Mon, 01 Jun 2020 10:00:00 GMT")
+		fmt.Fprintf(w, `{"data":{"count":%d},"jwt":"jwt-secret"}`, count)
+	}))
+}
+
+func RunFixturesTests() {
+	Describe("Fixtures", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "fixtures")
+			Expect(err).To(BeNil())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		record := func(path string) {
+			server := newCountingServer()
+			defer server.Close()
+			recorder := NewRecorder(path, nil)
+			client := resty.New().SetHostURL(server.URL)
+			recorder.Attach(client)
+
+			resp, err := client.R().
+				SetFormData(map[string]string{"email": "user@example.com", "password": "hunter2"}).
+				Post("api/auth/authenticate")
+			Expect(err).To(BeNil())
+			Expect(resp.Cookies()[0].Value).To(Equal("session-secret"))
+			for i := 0; i < 2; i++ {
+				_, err = client.R().
+					SetHeader("Authorization", "Bearer session-secret").
+					SetQueryParam("page[limit]", "10").
+					Get("api/auth/users")
+				Expect(err).To(BeNil())
+			}
+			Expect(recorder.Save()).To(Succeed())
+		}
+
+		It("should scrub secrets from recorded fixtures", func() {
+			path := filepath.Join(dir, "auth.json")
+			record(path)
+
+			content, err := ioutil.ReadFile(path)
+			Expect(err).To(BeNil())
+			for _, secret := range []string{"user@example.com", "hunter2", "session-secret", "jwt-secret", "Authorization", "Mon, 01 Jun"} {
+				Expect(string(content)).ToNot(ContainSubstring(secret))
+			}
+
+			cassette, err := LoadCassette(path)
+			Expect(err).To(BeNil())
+			Expect(len(cassette.Interactions)).To(Equal(3))
+			Expect(cassette.Interactions[0].Request.Body).To(Equal("email=REDACTED&password=REDACTED"))
+			Expect(cassette.Interactions[0].Response.Cookies).To(Equal([]string{"access_token"}))
+			Expect(cassette.Interactions[1].Request.Query).To(Equal("page%5Blimit%5D=10"))
+		})
+
+		It("should scrub credentials from response headers and token fields", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="header-secret"`)
+				w.Header().Set("Authorization", "Bearer header-secret")
+				w.Header().Set("X-Api-Key", "header-secret")
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, `{"token":"body-secret","refresh_token":"body-secret","authorization":"body-secret","api-key":"body-secret","name":"kept"}`)
+			}))
+			defer server.Close()
+			path := filepath.Join(dir, "headers.json")
+			recorder := NewRecorder(path, nil)
+			client := resty.New().SetHostURL(server.URL)
+			recorder.Attach(client)
+			_, err := client.R().SetBody(map[string]string{"refresh_token": "request-secret"}).Post("api/auth/refresh")
+			Expect(err).To(BeNil())
+			Expect(recorder.Save()).To(Succeed())
+
+			content, err := ioutil.ReadFile(path)
+			Expect(err).To(BeNil())
+			for _, secret := range []string{"header-secret", "body-secret", "request-secret"} {
+				Expect(string(content)).ToNot(ContainSubstring(secret))
+			}
+			cassette, err := LoadCassette(path)
+			Expect(err).To(BeNil())
+			response := cassette.Interactions[0].Response
+			for _, name := range []string{"WWW-Authenticate", "Authorization", "X-Api-Key"} {
+				Expect(response.Header.Get(name)).To(Equal(Redacted))
+			}
+			Expect(response.Body).To(ContainSubstring(`"name":"kept"`))
+		})
+
+		It("should replay fixtures in order without a server", func() {
+			path := filepath.Join(dir, "auth.json")
+			record(path)
+
+			replayer, err := NewReplayer(path)
+			Expect(err).To(BeNil())
+			client := resty.New().SetHostURL("http://polaris.invalid")
+			replayer.Attach(client)
+
+			resp, err := client.R().
+				SetFormData(map[string]string{"email": "user@example.com", "password": "another password"}).
+				Post("api/auth/authenticate")
+			Expect(err).To(BeNil())
+			Expect(resp.Cookies()[0].Value).To(Equal(Redacted))
+
+			bodies := []string{}
+			for i := 0; i < 3; i++ {
+				resp, err = client.R().SetQueryParam("page[limit]", "10").Get("api/auth/users")
+				Expect(err).To(BeNil())
+				bodies = append(bodies, resp.String())
+			}
+			// repeats past the end of the recording stick at the last response
+			Expect(bodies).To(Equal([]string{
+				`{"data":{"count":1},"jwt":"REDACTED"}`,
+				`{"data":{"count":2},"jwt":"REDACTED"}`,
+				`{"data":{"count":2},"jwt":"REDACTED"}`,
+			}))
+
+			_, err = client.R().SetQueryParam("page[limit]", "20").Get("api/auth/users")
+			Expect(err).ToNot(BeNil())
+			Expect(strings.Contains(err.Error(), "no recorded interaction")).To(BeTrue())
+		})
+
+		It("should replay unless asked to record", func() {
+			path := filepath.Join(dir, "missing.json")
+			os.Unsetenv(ModeEnvVar)
+			_, err := NewTransportFromEnv(path)
+			Expect(err).ToNot(BeNil())
+
+			os.Setenv(ModeEnvVar, string(ModeRecord))
+			defer os.Unsetenv(ModeEnvVar)
+			transport, err := NewTransportFromEnv(path)
+			Expect(err).To(BeNil())
+			Expect(transport.Mode).To(Equal(ModeRecord))
+		})
+	})
+}
@@ -1,18 +1,15 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
+
 	"github.com/pkg/errors"
 )
 
-type IdAndType struct {
-	Id   string
-	Type string
-}
-
-type Data struct {
-	Data IdAndType
-}
+type IdAndType = ResourceIdentifier
 
 type V1IssueResponse struct {
 	Attributes struct {
@@ -24,22 +21,16 @@ type V1IssueResponse struct {
 	Id            string
 	Type          string
 	Relationships struct {
-		Path                Data
-		ToolDomainService   Data `json:"tool-domain-service-data"`
-		IssueType           Data `json:"issue-type"`
-		Tool                Data
-		LatestObservedOnRun Data `json:"latest-observed-on-run"`
-		Transitions         struct {
-			Data []IdAndType
-		}
-		RelatedTaxa struct {
-			Data []interface{} // TODO type ?
-		} `json:"related-taxa"`
-		RelatedIndicators struct {
-			Data []interface{} // TODO type ?
-		} `json:"related-indicators"`
-		IssueKind interface{} `json:"issue-kind"` // TODO type?
-		Severity  interface{} // TODO type?
+		Path                Relationship
+		ToolDomainService   Relationship `json:"tool-domain-service-data"`
+		IssueType           Relationship `json:"issue-type"`
+		Tool                Relationship
+		LatestObservedOnRun Relationship `json:"latest-observed-on-run"`
+		Transitions         Relationship
+		RelatedTaxa         Relationship `json:"related-taxa"`
+		RelatedIndicators   Relationship `json:"related-indicators"`
+		IssueKind           Relationship `json:"issue-kind"`
+		Severity            Relationship
 	}
 	Links struct {
 		Self struct {
@@ -48,15 +39,9 @@ type V1IssueResponse struct {
 	}
 }
 
-type V1IssueIncluded struct {
-	Id         string
-	Type       string
-	Attributes map[string]interface{}
-}
-
 type GetV1IssuesResponse struct {
 	Data     []V1IssueResponse
-	Included []V1IssueIncluded
+	Included Included
 	Meta     struct {
 		Total    int
 		Offset   int
@@ -64,9 +49,17 @@ type GetV1IssuesResponse struct {
 		Complete bool
 		RunCount int `json:"run-count"`
 	}
+	Links PageLinks
 }
 
+// v1IssueIncludes are the relationships ResolveV1Issue looks up in the included section.
+var v1IssueIncludes = []string{"severity", "related-taxa", "tool", "path", "issue-type"}
+
 func (client *Client) GetV1Issues(projectId string, branchId string, runId string, offset int, limit int) (*GetV1IssuesResponse, error) {
+	return client.GetV1IssuesContext(context.Background(), projectId, branchId, runId, offset, limit)
+}
+
+func (client *Client) GetV1IssuesContext(ctx context.Context, projectId string, branchId string, runId string, offset int, limit int) (*GetV1IssuesResponse, error) {
 	if branchId != "" && runId != "" {
 		return nil, errors.New("only one of branchId and runId may be specified (both were non-empty)")
 	}
@@ -74,41 +67,44 @@ func (client *Client) GetV1Issues(projectId string, branchId string, runId strin
 		return nil, errors.New("one of branchId and runId may be specified (both were empty)")
 	}
 	result := &GetV1IssuesResponse{}
-	params := map[string]interface{}{
-		"project-id":   projectId,
-		"page[offset]": fmt.Sprintf("%d", offset),
-		"page[limit]":  fmt.Sprintf("%d", limit),
-	}
+	query := NewQuery().
+		Set("project-id", projectId).
+		Page(offset, limit).
+		Include("issue", v1IssueIncludes...)
 	if branchId != "" {
-		params["branch-id"] = branchId
+		query.Set("branch-id", branchId)
 	}
 	if runId != "" {
-		params["run-id[]"] = []string{runId}
+		query["run-id[]"] = []string{runId}
 	}
-	_, err := client.GetJson(params, result, "api/query/v1/issues")
+	_, err := client.GetJsonContext(ctx, query, result, "api/query/v1/issues")
 	return result, err
 }
 
+// V1IssuesPaginator walks the issues of a branch or run; items are *V1IssueResponse.
+func (client *Client) V1IssuesPaginator(projectId string, branchId string, runId string, pageSize int) *Paginator {
+	return NewPaginator(func(ctx context.Context, offset int, limit int) (*Page, error) {
+		issues, err := client.GetV1IssuesContext(ctx, projectId, branchId, runId, offset, limit)
+		if err != nil {
+			return nil, err
+		}
+		items := boxItems(len(issues.Data), func(i int) interface{} { return &issues.Data[i] })
+		meta := PageMeta{Offset: issues.Meta.Offset, Limit: issues.Meta.Limit, Total: issues.Meta.Total}
+		return &Page{Items: items, Meta: meta, Links: issues.Links}, nil
+	}, pageSize)
+}
+
 type GetV0RollUpCountsResponse struct {
 	Data []struct {
 		Id            string
 		Type          string
-		Relationships map[string]struct {
-			Data *struct {
-				Id   string
-				Type string
-			}
-		}
-		Attributes struct {
+		Relationships map[string]Relationship
+		Attributes    struct {
 			Value int
 		}
 	}
-	Included []struct {
-		Id         string
-		Type       string
-		Attributes map[string]interface{} // TODO type?
-	}
-	Meta struct {
+	Included Included
+	Meta     struct {
 		Offset   int
 		Limit    int
 		Total    int
@@ -119,40 +115,359 @@ type GetV0RollUpCountsResponse struct {
 }
 
 func (client *Client) GetV0RollUpCounts(projectId string, branchId string, limit int) (*GetV0RollUpCountsResponse, error) {
+	return client.GetV0RollUpCountsContext(context.Background(), projectId, branchId, limit)
+}
+
+func (client *Client) GetV0RollUpCountsContext(ctx context.Context, projectId string, branchId string, limit int) (*GetV0RollUpCountsResponse, error) {
 	result := &GetV0RollUpCountsResponse{}
 	params := map[string]interface{}{
 		"project-id":  projectId,
 		"branch-id":   branchId,
 		"page[limit]": fmt.Sprintf("%d", limit),
 	}
-	_, err := client.GetJson(params, result, "api/query/v0/roll-up-counts")
+	_, err := client.GetJsonContext(ctx, params, result, "api/query/v0/roll-up-counts")
 	return result, err
 }
 
 type GetV1IssueResponse struct {
 	Data     V1IssueResponse
-	Included []V1IssueIncluded
+	Included Included
 }
 
 func (client *Client) GetV1Issue(projectId string, branchId string, issueId string) (*GetV1IssueResponse, error) {
+	return client.GetV1IssueContext(context.Background(), projectId, branchId, issueId)
+}
+
+func (client *Client) GetV1IssueContext(ctx context.Context, projectId string, branchId string, issueId string) (*GetV1IssueResponse, error) {
 	result := &GetV1IssueResponse{}
 	params := map[string]interface{}{
 		"project-id": projectId,
 		"branch-id":  branchId,
 	}
-	_, err := client.GetJson(params, result, "api/query/v1/issues/%s", issueId)
+	_, err := client.GetJsonContext(ctx, params, result, "api/query/v1/issues/%s", issueId)
+	return result, err
+}
+
+// StringList decodes either a single string or a list of strings.
+type StringList []string
+
+func (sl *StringList) UnmarshalJSON(data []byte) error {
+	single := ""
+	if err := json.Unmarshal(data, &single); err == nil {
+		*sl = StringList{single}
+		return nil
+	}
+	many := []string{}
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*sl = many
+	return nil
+}
+
+// Group-by facets for roll-up counts.
+const (
+	GroupByIssueSeverity = "[issue][severity]"
+	GroupByIssueType     = "[issue][type]"
+	GroupByIssueTool     = "[issue][tool]"
+	GroupByIssueStatus   = "[issue][status]"
+	GroupByPath          = "[path]"
+)
+
+// RollUpCountsQuery selects the issues to count.  Exactly one of BranchId and
+// RunIds must be set.  Filters are passed through as is, for example
+// "filter[issue][status][$eq]": "opened".
+type RollUpCountsQuery struct {
+	ProjectId string
+	BranchId  string
+	RunIds    []string
+	GroupBy   []string
+	Filters   map[string]string
+	Offset    int
+	Limit     int
+}
+
+func (q *RollUpCountsQuery) params() (Query, error) {
+	if (q.BranchId == "") == (len(q.RunIds) == 0) {
+		return nil, errors.New("exactly one of BranchId and RunIds must be specified")
+	}
+	query := NewQuery().Set("project-id", q.ProjectId)
+	if q.BranchId != "" {
+		query.Set("branch-id", q.BranchId)
+	} else {
+		query["run-id[]"] = q.RunIds
+	}
+	if len(q.GroupBy) > 0 {
+		query["group-by"] = q.GroupBy
+	}
+	for key, value := range q.Filters {
+		query.Set(key, value)
+	}
+	if q.Limit > 0 {
+		query.Page(q.Offset, q.Limit)
+	}
+	return query, nil
+}
+
+// RollUpCount is the number of issues in one group; the group's members, such
+// as its severity or issue type, are in Relationships.
+type RollUpCount struct {
+	Id         string
+	Type       string
+	Attributes struct {
+		Value int
+	}
+	Relationships map[string]Relationship
+}
+
+type GetV1RollUpCountsResponse struct {
+	Data     []*RollUpCount
+	Included Included
+	Meta     struct {
+		Offset   int
+		Limit    int
+		Total    int
+		Complete bool
+		GroupBy  StringList `json:"group-by"`
+		RunCount int        `json:"run-count"`
+	}
+	Links PageLinks
+}
+
+func (client *Client) GetV1RollUpCounts(query *RollUpCountsQuery) (*GetV1RollUpCountsResponse, error) {
+	return client.GetV1RollUpCountsContext(context.Background(), query)
+}
+
+func (client *Client) GetV1RollUpCountsContext(ctx context.Context, query *RollUpCountsQuery) (*GetV1RollUpCountsResponse, error) {
+	params, err := query.params()
+	if err != nil {
+		return nil, err
+	}
+	result := &GetV1RollUpCountsResponse{}
+	_, err = client.GetJsonContext(ctx, params, result, "api/query/v1/roll-up-counts")
+	return result, err
+}
+
+type StatusCount struct {
+	Id         string
+	Type       string
+	Attributes struct {
+		Status string
+		Value  int
+	}
+}
+
+type GetV1StatusCountsResponse struct {
+	Data []*StatusCount
+	Meta struct {
+		Complete bool
+		RunCount int `json:"run-count"`
+	}
+}
+
+// ByStatus returns the counts keyed by status, e.g. "opened" or "closed".
+func (resp *GetV1StatusCountsResponse) ByStatus() map[string]int {
+	counts := map[string]int{}
+	for _, count := range resp.Data {
+		counts[count.Attributes.Status] += count.Attributes.Value
+	}
+	return counts
+}
+
+// GetV1StatusCounts counts issues by status; only the selection fields of query are used.
+func (client *Client) GetV1StatusCounts(query *RollUpCountsQuery) (*GetV1StatusCountsResponse, error) {
+	return client.GetV1StatusCountsContext(context.Background(), query)
+}
+
+func (client *Client) GetV1StatusCountsContext(ctx context.Context, query *RollUpCountsQuery) (*GetV1StatusCountsResponse, error) {
+	selection := &RollUpCountsQuery{ProjectId: query.ProjectId, BranchId: query.BranchId, RunIds: query.RunIds, Filters: query.Filters}
+	params, err := selection.params()
+	if err != nil {
+		return nil, err
+	}
+	result := &GetV1StatusCountsResponse{}
+	_, err = client.GetJsonContext(ctx, params, result, "api/query/v1/counts/status")
+	return result, err
+}
+
+// Discovery filter keys, the facets issues can be filtered on.
+const (
+	FilterKeyIssueTool     = "issue.tool.id"
+	FilterKeyIssueType     = "issue.type.id"
+	FilterKeyIssueSeverity = "issue.severity"
+	FilterKeyIssueStatus   = "issue.status"
+	FilterKeyPath          = "path"
+)
+
+type FilterKey struct {
+	Id         string
+	Type       string
+	Attributes struct {
+		Name        string
+		DisplayName string `json:"display-name"`
+	}
+}
+
+type GetV0FilterKeysResponse struct {
+	Data  []*FilterKey
+	Meta  PageMeta
+	Links PageLinks
+}
+
+func (client *Client) GetV0DiscoveryFilterKeys(limit int) (*GetV0FilterKeysResponse, error) {
+	return client.GetV0DiscoveryFilterKeysContext(context.Background(), limit)
+}
+
+func (client *Client) GetV0DiscoveryFilterKeysContext(ctx context.Context, limit int) (*GetV0FilterKeysResponse, error) {
+	result := &GetV0FilterKeysResponse{}
+	params := NewQuery().Set("page[limit]", fmt.Sprintf("%d", limit))
+	_, err := client.GetJsonContext(ctx, params, result, "api/query/v0/discovery/filter-keys")
 	return result, err
 }
 
-// TODO:
-// /v1/roll-up-counts
-// /v1/counts/status
+type FilterValue struct {
+	Id         string
+	Type       string
+	Attributes struct {
+		Value        string
+		DisplayValue string `json:"display-value"`
+		Count        int
+	}
+}
+
+type GetV0FilterValuesResponse struct {
+	Data  []*FilterValue
+	Meta  PageMeta
+	Links PageLinks
+}
 
-func (client *Client) QueryV0DiscoveryFilterKeysIssuetoolidValues() (string, error) {
+// GetV0DiscoveryFilterValues lists the values of a filter key, such as FilterKeyIssueSeverity.
+func (client *Client) GetV0DiscoveryFilterValues(filterKey string, limit int) (*GetV0FilterValuesResponse, error) {
+	return client.GetV0DiscoveryFilterValuesContext(context.Background(), filterKey, limit)
+}
+
+func (client *Client) GetV0DiscoveryFilterValuesContext(ctx context.Context, filterKey string, limit int) (*GetV0FilterValuesResponse, error) {
 	// example:
 	//   https://local.dev.polaris.synopsys.com/api/query/v0/discovery/filter-keys/issue.tool.id/values?page%5Blimit%5D=50
-	params := map[string]interface{}{
-		"page[limit]": "50",
+	result := &GetV0FilterValuesResponse{}
+	params := NewQuery().Set("page[limit]", fmt.Sprintf("%d", limit))
+	_, err := client.GetJsonContext(ctx, params, result, "api/query/v0/discovery/filter-keys/%s/values", filterKey)
+	return result, err
+}
+
+func (client *Client) QueryV0DiscoveryFilterKeysIssuetoolidValues() (*GetV0FilterValuesResponse, error) {
+	return client.QueryV0DiscoveryFilterKeysIssuetoolidValuesContext(context.Background())
+}
+
+func (client *Client) QueryV0DiscoveryFilterKeysIssuetoolidValuesContext(ctx context.Context) (*GetV0FilterValuesResponse, error) {
+	return client.GetV0DiscoveryFilterValuesContext(ctx, FilterKeyIssueTool, 50)
+}
+
+// ResolvedV1Issue is a V1 issue with its related resources looked up in the
+// response's included data and flattened, ready for reports.  Fields whose
+// resource wasn't included are empty.
+type ResolvedV1Issue struct {
+	Id                    string           `json:"id"`
+	IssueKey              string           `json:"issue-key"`
+	FindingKey            string           `json:"finding-key"`
+	SubTool               string           `json:"sub-tool,omitempty"`
+	IssueTypeId           string           `json:"issue-type-id"`
+	IssueType             string           `json:"issue-type"`
+	IssueTypeAbbreviation string           `json:"issue-type-abbreviation,omitempty"`
+	Severity              string           `json:"severity"`
+	Tool                  string           `json:"tool"`
+	ToolVersion           string           `json:"tool-version,omitempty"`
+	Path                  string           `json:"path"`
+	RunId                 string           `json:"run-id,omitempty"`
+	Taxa                  []*ResolvedTaxon `json:"taxa,omitempty"`
+	URL                   string           `json:"url,omitempty"`
+}
+
+// ResolvedTaxon is a taxon an issue relates to, such as a CWE weakness.
+type ResolvedTaxon struct {
+	Id   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// ResolveV1Issue flattens issue, looking up its related resources in index.
+func ResolveV1Issue(issue *V1IssueResponse, index ResourceIndex) *ResolvedV1Issue {
+	resolved := &ResolvedV1Issue{
+		Id:          issue.Id,
+		IssueKey:    issue.Attributes.IssueKey,
+		FindingKey:  issue.Attributes.FindingKey,
+		SubTool:     issue.Attributes.SubTool,
+		IssueTypeId: issue.Relationships.IssueType.Data.Id,
+		RunId:       issue.Relationships.LatestObservedOnRun.Data.Id,
+		URL:         issue.Links.Self.HRef,
+	}
+	if issueType := index.ResolveOne(issue.Relationships.IssueType); issueType != nil {
+		resolved.IssueType = stringAttribute(issueType, "name")
+		resolved.IssueTypeAbbreviation = stringAttribute(issueType, "abbreviation")
 	}
-	return client.GetJson(params, nil, "api/query/v0/discovery/filter-keys/issue.tool.id/values")
+	if severity := index.ResolveOne(issue.Relationships.Severity); severity != nil {
+		resolved.Severity = stringAttribute(severity, "name")
+	} else {
+		resolved.Severity = issue.Relationships.Severity.Data.Id
+	}
+	if tool := index.ResolveOne(issue.Relationships.Tool); tool != nil {
+		resolved.Tool = stringAttribute(tool, "name")
+		resolved.ToolVersion = stringAttribute(tool, "version")
+	}
+	if path := index.ResolveOne(issue.Relationships.Path); path != nil {
+		resolved.Path = pathAttribute(path)
+	}
+	for _, identifier := range issue.Relationships.RelatedTaxa.Data.Identifiers() {
+		taxon := &ResolvedTaxon{Id: identifier.Id}
+		if resource, ok := index[identifier]; ok {
+			taxon.Name = stringAttribute(resource, "name")
+		}
+		resolved.Taxa = append(resolved.Taxa, taxon)
+	}
+	return resolved
+}
+
+// Resolve flattens each issue of the response along with its included resources.
+func (resp *GetV1IssuesResponse) Resolve() []*ResolvedV1Issue {
+	index := resp.Included.Index()
+	resolved := make([]*ResolvedV1Issue, len(resp.Data))
+	for i := range resp.Data {
+		resolved[i] = ResolveV1Issue(&resp.Data[i], index)
+	}
+	return resolved
+}
+
+func stringAttribute(resource *Resource, name string) string {
+	value, _ := resource.Attributes[name].(string)
+	return value
+}
+
+// pathAttribute joins the segments of a path resource, which come as a list
+func pathAttribute(resource *Resource) string {
+	switch path := resource.Attributes["path"].(type) {
+	case string:
+		return path
+	case []interface{}:
+		segments := make([]string, len(path))
+		for i, segment := range path {
+			segments[i] = fmt.Sprintf("%v", segment)
+		}
+		return strings.Join(segments, "/")
+	default:
+		return ""
+	}
+}
+
+// ResolvedV1IssuesPaginator walks the issues of a branch or run like
+// V1IssuesPaginator, but its items are *ResolvedV1Issue.
+func (client *Client) ResolvedV1IssuesPaginator(projectId string, branchId string, runId string, pageSize int) *Paginator {
+	return NewPaginator(func(ctx context.Context, offset int, limit int) (*Page, error) {
+		issues, err := client.GetV1IssuesContext(ctx, projectId, branchId, runId, offset, limit)
+		if err != nil {
+			return nil, err
+		}
+		resolved := issues.Resolve()
+		items := boxItems(len(resolved), func(i int) interface{} { return resolved[i] })
+		meta := PageMeta{Offset: issues.Meta.Offset, Limit: issues.Meta.Limit, Total: issues.Meta.Total}
+		return &Page{Items: items, Meta: meta, Links: issues.Links}, nil
+	}, pageSize)
 }
@@ -0,0 +1,179 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func RunIssueTests() {
+	Describe("Issue queries", func() {
+		It("should group v1 roll-up counts by several facets", func() {
+			server := newTestServer(serveBody(`{
+				"data": [{"type": "rollup-counts", "id": "1", "attributes": {"value": 7},
+					"relationships": {"severity": {"data": {"type": "taxon", "id": "high"}}}}],
+				"included": [{"type": "taxon", "id": "high", "attributes": {"name": "High"}}],
+				"meta": {"total": 1, "group-by": ["[issue][severity]", "[issue][type]"]}}`))
+			defer server.Close()
+
+			counts, err := NewBearerTokenClient(server.URL, "token").GetV1RollUpCounts(&RollUpCountsQuery{
+				ProjectId: "project-1",
+				BranchId:  "branch-1",
+				GroupBy:   []string{GroupByIssueSeverity, GroupByIssueType},
+				Filters:   map[string]string{"filter[issue][status][$eq]": "opened"},
+				Limit:     100,
+			})
+			Expect(err).To(BeNil())
+			Expect(server.last().Path).To(Equal("/api/query/v1/roll-up-counts"))
+			Expect(server.last().Query["group-by"]).To(Equal([]string{"[issue][severity]", "[issue][type]"}))
+			Expect(server.last().Query.Get("filter[issue][status][$eq]")).To(Equal("opened"))
+			Expect(server.last().Query.Get("branch-id")).To(Equal("branch-1"))
+
+			Expect(counts.Meta.GroupBy).To(Equal(StringList{"[issue][severity]", "[issue][type]"}))
+			severity := counts.Included.ResolveOne(counts.Data[0].Relationships["severity"])
+			Expect(severity.Attributes["name"]).To(Equal("High"))
+			Expect(counts.Data[0].Attributes.Value).To(Equal(7))
+		})
+
+		It("should require exactly one of branch and runs", func() {
+			client := NewBearerTokenClient("http://localhost:1", "token")
+			_, err := client.GetV1RollUpCounts(&RollUpCountsQuery{ProjectId: "project-1"})
+			Expect(err).ToNot(BeNil())
+			_, err = client.GetV1StatusCounts(&RollUpCountsQuery{ProjectId: "project-1", BranchId: "b", RunIds: []string{"r"}})
+			Expect(err).ToNot(BeNil())
+		})
+
+		It("should decode a single group-by", func() {
+			groupBy := StringList{}
+			Expect(json.Unmarshal([]byte(`"[issue][type]"`), &groupBy)).To(Succeed())
+			Expect(groupBy).To(Equal(StringList{"[issue][type]"}))
+		})
+
+		It("should sum status counts", func() {
+			server := newTestServer(serveBody(`{"data": [
+				{"type": "counts", "id": "1", "attributes": {"status": "opened", "value": 4}},
+				{"type": "counts", "id": "2", "attributes": {"status": "closed", "value": 9}}]}`))
+			defer server.Close()
+
+			counts, err := NewBearerTokenClient(server.URL, "token").GetV1StatusCounts(&RollUpCountsQuery{ProjectId: "project-1", RunIds: []string{"run-1", "run-2"}})
+			Expect(err).To(BeNil())
+			Expect(server.last().Path).To(Equal("/api/query/v1/counts/status"))
+			Expect(server.last().Query["run-id[]"]).To(Equal([]string{"run-1", "run-2"}))
+			Expect(counts.ByStatus()).To(Equal(map[string]int{"opened": 4, "closed": 9}))
+		})
+
+		It("should list the values of any filter key", func() {
+			server := newTestServer(serveBody(`{"data": [{"type": "filter-value", "id": "high", "attributes": {"value": "high", "display-value": "High", "count": 3}}]}`))
+			defer server.Close()
+
+			values, err := NewBearerTokenClient(server.URL, "token").GetV0DiscoveryFilterValues(FilterKeyIssueSeverity, 50)
+			Expect(err).To(BeNil())
+			Expect(server.last().Path).To(Equal("/api/query/v0/discovery/filter-keys/issue.severity/values"))
+			Expect(values.Data[0].Attributes.DisplayValue).To(Equal("High"))
+			Expect(values.Data[0].Attributes.Count).To(Equal(3))
+		})
+
+		It("should resolve issues against included resources", func() {
+			response := &GetV1IssuesResponse{}
+			Expect(json.Unmarshal([]byte(`{
+				"data": [{"type": "issue", "id": "issue-1",
+					"attributes": {"issue-key": "key-1", "finding-key": "finding-1", "sub-tool": "sast"},
+					"relationships": {
+						"issue-type": {"data": {"type": "issue-type", "id": "type-1"}},
+						"severity": {"data": {"type": "taxon", "id": "high"}},
+						"tool": {"data": {"type": "tool", "id": "tool-1"}},
+						"path": {"data": {"type": "path", "id": "path-1"}},
+						"latest-observed-on-run": {"data": {"type": "run", "id": "run-1"}},
+						"related-taxa": {"data": [{"type": "taxon", "id": "cwe-79"}, {"type": "taxon", "id": "cwe-20"}]}},
+					"links": {"self": {"href": "https://polaris/api/query/v1/issues/issue-1"}}}],
+				"included": [
+					{"type": "issue-type", "id": "type-1", "attributes": {"name": "Cross-site scripting", "abbreviation": "XSS"}},
+					{"type": "taxon", "id": "high", "attributes": {"name": "High"}},
+					{"type": "tool", "id": "tool-1", "attributes": {"name": "Coverity", "version": "2020.06"}},
+					{"type": "path", "id": "path-1", "attributes": {"path": ["src", "main.go"]}},
+					{"type": "taxon", "id": "cwe-79", "attributes": {"name": "CWE-79"}}]}`), response)).To(Succeed())
+
+			resolved := response.Resolve()
+			Expect(len(resolved)).To(Equal(1))
+			Expect(*resolved[0]).To(Equal(ResolvedV1Issue{
+				Id:                    "issue-1",
+				IssueKey:              "key-1",
+				FindingKey:            "finding-1",
+				SubTool:               "sast",
+				IssueTypeId:           "type-1",
+				IssueType:             "Cross-site scripting",
+				IssueTypeAbbreviation: "XSS",
+				Severity:              "High",
+				Tool:                  "Coverity",
+				ToolVersion:           "2020.06",
+				Path:                  "src/main.go",
+				RunId:                 "run-1",
+				Taxa:                  []*ResolvedTaxon{{Id: "cwe-79", Name: "CWE-79"}, {Id: "cwe-20"}},
+				URL:                   "https://polaris/api/query/v1/issues/issue-1",
+			}))
+		})
+
+		It("should include the resources issues are resolved against", func() {
+			server := newTestServer(serveBody(`{"data": [], "meta": {"offset": 0, "limit": 25, "total": 0}}`))
+			defer server.Close()
+
+			_, err := NewBearerTokenClient(server.URL, "token").ResolvedV1IssuesPaginator("project-1", "", "run-1", 25).All(context.Background())
+			Expect(err).To(BeNil())
+			Expect(server.last().Path).To(Equal("/api/query/v1/issues"))
+			Expect(server.last().Query["include[issue][]"]).To(ConsistOf("severity", "related-taxa", "tool", "path", "issue-type"))
+			Expect(server.last().Query["run-id[]"]).To(Equal([]string{"run-1"}))
+			Expect(server.last().Query.Get("project-id")).To(Equal("project-1"))
+		})
+
+		It("should read and update triage", func() {
+			server := newTestServer(serveBody(`{"data": {"type": "triage-current", "id": "x", "attributes": {"triage-values": [
+				{"attribute-name": "DISMISS", "value": "DISMISSED_AS_FP", "display-value": "False positive"}]}}}`))
+			defer server.Close()
+			client := NewBearerTokenClient(server.URL, "token")
+
+			current, err := client.GetTriageCurrent("project-1", "key-1")
+			Expect(err).To(BeNil())
+			Expect(server.last().Path).To(Equal("/api/triage-query/v1/triage-current/project-id:project-1:issue-key:key-1"))
+			dismiss, ok := current.Value(TriageDismiss)
+			Expect(ok).To(BeTrue())
+			Expect(dismiss).To(Equal(DismissFalsePositive))
+
+			Expect(client.UpdateTriage(&TriageUpdate{
+				ProjectId: "project-1",
+				IssueKeys: []string{"key-1"},
+				Values:    map[string]interface{}{TriageDismiss: DismissIntentional, TriageCommentary: "by design"},
+			})).To(Succeed())
+			request := server.last()
+			Expect(request.Method).To(Equal(http.MethodPost))
+			Expect(request.Path).To(Equal("/api/triage-command/v1/triage-issues"))
+			attributes := request.Body["data"].(map[string]interface{})["attributes"].(map[string]interface{})
+			Expect(attributes["issue-keys"]).To(Equal([]interface{}{"key-1"}))
+			Expect(attributes["triage-values"]).To(HaveKeyWithValue(TriageDismiss, DismissIntentional))
+
+			Expect(client.UpdateTriage(&TriageUpdate{ProjectId: "project-1"})).ToNot(Succeed())
+		})
+	})
+}
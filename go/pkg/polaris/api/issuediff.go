@@ -0,0 +1,208 @@
+/*
+Copyright (C) 2018 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Ways of matching issues between two sets.
+const (
+	// MatchByIssueKey matches issues within a project: an issue keeps its key from run to run
+	MatchByIssueKey = "issue-key"
+	// MatchByFindingKey matches issues by their fingerprint, which also works across projects
+	MatchByFindingKey = "finding-key"
+)
+
+// severityOrder is how severities are listed, most severe first; others follow alphabetically
+var severityOrder = []string{"critical", "high", "medium", "low", "audit"}
+
+// IssueSelection picks the issues of a branch or of a run; exactly one of
+// BranchId and RunId must be set.
+type IssueSelection struct {
+	ProjectId string
+	BranchId  string
+	RunId     string
+}
+
+// IssueDiff compares a head set of issues against a base set.
+type IssueDiff struct {
+	// New issues are in head but not base
+	New []*ResolvedV1Issue
+	// Fixed issues are in base but not head
+	Fixed []*ResolvedV1Issue
+	// Unchanged issues are in both; the head's copy is kept
+	Unchanged []*ResolvedV1Issue
+}
+
+type SeverityCounts struct {
+	Severity  string
+	New       int
+	Fixed     int
+	Unchanged int
+}
+
+// DiffIssues matches head against base by matchBy, one of the MatchBy
+// constants.  Issues keep the order they had in their set.  Keys that appear
+// more than once are matched pairwise, in order, so if base has two issues
+// with a key and head has three, one of head's is new.  Every issue must have
+// the key being matched by.
+func DiffIssues(base []*ResolvedV1Issue, head []*ResolvedV1Issue, matchBy string) (*IssueDiff, error) {
+	if matchBy == "" {
+		matchBy = MatchByIssueKey
+	}
+	key, err := issueMatchKey(matchBy)
+	if err != nil {
+		return nil, err
+	}
+	baseCounts, err := countIssueKeys(base, key, matchBy)
+	if err != nil {
+		return nil, err
+	}
+	headCounts, err := countIssueKeys(head, key, matchBy)
+	if err != nil {
+		return nil, err
+	}
+	diff := &IssueDiff{New: []*ResolvedV1Issue{}, Fixed: []*ResolvedV1Issue{}, Unchanged: []*ResolvedV1Issue{}}
+	for _, issue := range head {
+		if baseCounts[key(issue)] > 0 {
+			baseCounts[key(issue)]--
+			diff.Unchanged = append(diff.Unchanged, issue)
+		} else {
+			diff.New = append(diff.New, issue)
+		}
+	}
+	for _, issue := range base {
+		if headCounts[key(issue)] > 0 {
+			headCounts[key(issue)]--
+		} else {
+			diff.Fixed = append(diff.Fixed, issue)
+		}
+	}
+	return diff, nil
+}
+
+// countIssueKeys counts the issues with each key, failing on an issue without one.
+func countIssueKeys(issues []*ResolvedV1Issue, key func(issue *ResolvedV1Issue) string, matchBy string) (map[string]int, error) {
+	counts := map[string]int{}
+	for _, issue := range issues {
+		if key(issue) == "" {
+			return nil, errors.Errorf("unable to match issue %s: it has no %s", issue.Id, matchBy)
+		}
+		counts[key(issue)]++
+	}
+	return counts, nil
+}
+
+func issueMatchKey(matchBy string) (func(issue *ResolvedV1Issue) string, error) {
+	switch matchBy {
+	case MatchByIssueKey:
+		return func(issue *ResolvedV1Issue) string { return issue.IssueKey }, nil
+	case MatchByFindingKey:
+		return func(issue *ResolvedV1Issue) string { return issue.FindingKey }, nil
+	default:
+		return nil, errors.Errorf("invalid match %s: expected %s or %s", matchBy, MatchByIssueKey, MatchByFindingKey)
+	}
+}
+
+// HasChanges is true if any issue is new or fixed.
+func (diff *IssueDiff) HasChanges() bool {
+	return len(diff.New) > 0 || len(diff.Fixed) > 0
+}
+
+// CountsBySeverity counts the issues of each severity, most severe first.
+func (diff *IssueDiff) CountsBySeverity() []*SeverityCounts {
+	bySeverity := map[string]*SeverityCounts{}
+	countsFor := func(issue *ResolvedV1Issue) *SeverityCounts {
+		counts, ok := bySeverity[issue.Severity]
+		if !ok {
+			counts = &SeverityCounts{Severity: issue.Severity}
+			bySeverity[issue.Severity] = counts
+		}
+		return counts
+	}
+	for _, issue := range diff.New {
+		countsFor(issue).New++
+	}
+	for _, issue := range diff.Fixed {
+		countsFor(issue).Fixed++
+	}
+	for _, issue := range diff.Unchanged {
+		countsFor(issue).Unchanged++
+	}
+	all := []*SeverityCounts{}
+	for _, counts := range bySeverity {
+		all = append(all, counts)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		rankI, rankJ := severityRank(all[i].Severity), severityRank(all[j].Severity)
+		if rankI != rankJ {
+			return rankI < rankJ
+		}
+		return all[i].Severity < all[j].Severity
+	})
+	return all
+}
+
+func severityRank(severity string) int {
+	for i, known := range severityOrder {
+		if strings.EqualFold(severity, known) {
+			return i
+		}
+	}
+	return len(severityOrder)
+}
+
+// DiffV1Issues fetches the issues of base and head and diffs them; see DiffIssues.
+func (client *Client) DiffV1Issues(base *IssueSelection, head *IssueSelection, matchBy string) (*IssueDiff, error) {
+	return client.DiffV1IssuesContext(context.Background(), base, head, matchBy)
+}
+
+func (client *Client) DiffV1IssuesContext(ctx context.Context, base *IssueSelection, head *IssueSelection, matchBy string) (*IssueDiff, error) {
+	if base.ProjectId != head.ProjectId && matchBy != MatchByFindingKey {
+		return nil, errors.Errorf("issues in different projects can only be matched by %s", MatchByFindingKey)
+	}
+	baseIssues, err := client.selectedIssues(ctx, base)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "unable to get base issues")
+	}
+	headIssues, err := client.selectedIssues(ctx, head)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "unable to get head issues")
+	}
+	return DiffIssues(baseIssues, headIssues, matchBy)
+}
+
+func (client *Client) selectedIssues(ctx context.Context, selection *IssueSelection) ([]*ResolvedV1Issue, error) {
+	all, err := client.ResolvedV1IssuesPaginator(selection.ProjectId, selection.BranchId, selection.RunId, 500).All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	issues := make([]*ResolvedV1Issue, len(all))
+	for i, item := range all {
+		issues[i] = item.(*ResolvedV1Issue)
+	}
+	return issues, nil
+}
@@ -0,0 +1,133 @@
+/*
+Copyright (C) 2018 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func issueKeys(issues []*ResolvedV1Issue) []string {
+	keys := []string{}
+	for _, issue := range issues {
+		keys = append(keys, issue.IssueKey)
+	}
+	return keys
+}
+
+func RunIssueDiffTests() {
+	Describe("Issue diff", func() {
+		issue := func(key string, severity string) *ResolvedV1Issue {
+			return &ResolvedV1Issue{IssueKey: key, FindingKey: "f-" + key, Severity: severity}
+		}
+
+		It("should find new, fixed and unchanged issues", func() {
+			base := []*ResolvedV1Issue{issue("a", "High"), issue("b", "Low"), issue("c", "Critical")}
+			head := []*ResolvedV1Issue{issue("c", "Critical"), issue("d", "Medium"), issue("a", "High"), issue("e", "High")}
+
+			diff, err := DiffIssues(base, head, MatchByIssueKey)
+			Expect(err).To(BeNil())
+			Expect(issueKeys(diff.New)).To(Equal([]string{"d", "e"}))
+			Expect(issueKeys(diff.Fixed)).To(Equal([]string{"b"}))
+			Expect(issueKeys(diff.Unchanged)).To(Equal([]string{"c", "a"}))
+			Expect(diff.HasChanges()).To(BeTrue())
+
+			Expect(diff.CountsBySeverity()).To(Equal([]*SeverityCounts{
+				{Severity: "Critical", Unchanged: 1},
+				{Severity: "High", New: 1, Unchanged: 1},
+				{Severity: "Medium", New: 1},
+				{Severity: "Low", Fixed: 1},
+			}))
+
+			same, err := DiffIssues(base, base, MatchByFindingKey)
+			Expect(err).To(BeNil())
+			Expect(same.HasChanges()).To(BeFalse())
+
+			_, err = DiffIssues(base, head, "nope")
+			Expect(err).ToNot(BeNil())
+		})
+
+		It("should match repeated keys by count", func() {
+			base := []*ResolvedV1Issue{issue("a", "High"), issue("a", "High"), issue("b", "Low")}
+			head := []*ResolvedV1Issue{issue("a", "High"), issue("b", "Low"), issue("b", "Low"), issue("b", "Low")}
+
+			diff, err := DiffIssues(base, head, MatchByIssueKey)
+			Expect(err).To(BeNil())
+			Expect(issueKeys(diff.New)).To(Equal([]string{"b", "b"}))
+			Expect(issueKeys(diff.Fixed)).To(Equal([]string{"a"}))
+			Expect(issueKeys(diff.Unchanged)).To(Equal([]string{"a", "b"}))
+		})
+
+		It("should refuse to match issues without a key", func() {
+			keyless := &ResolvedV1Issue{Id: "issue-1", IssueKey: "k"}
+			_, err := DiffIssues([]*ResolvedV1Issue{issue("a", "High")}, []*ResolvedV1Issue{keyless}, MatchByFindingKey)
+			Expect(err).ToNot(BeNil())
+			Expect(err.Error()).To(ContainSubstring("issue-1"))
+
+			_, err = DiffIssues([]*ResolvedV1Issue{keyless, keyless}, nil, MatchByIssueKey)
+			Expect(err).To(BeNil())
+		})
+
+		It("should only match issues across projects by finding key", func() {
+			client := NewBearerTokenClient("http://localhost:1", "token")
+			_, err := client.DiffV1Issues(&IssueSelection{ProjectId: "p1", RunId: "run-1"}, &IssueSelection{ProjectId: "p2", RunId: "run-2"}, MatchByIssueKey)
+			Expect(err).ToNot(BeNil())
+			Expect(err.Error()).To(ContainSubstring(MatchByFindingKey))
+		})
+
+		It("should fetch and diff two runs", func() {
+			server, client := newFakeServer()
+			defer server.Close()
+			project := server.AddProject("cerebros")
+			server.AddIssues(project, 4)
+			baseRun := project.RunId
+			project.RunId = server.AddRun(project.Id, project.BranchId, "COMPLETED", time.Now())
+			server.AddIssues(project, 2)
+
+			base := &IssueSelection{ProjectId: project.Id, RunId: baseRun}
+			diff, err := client.DiffV1Issues(base, &IssueSelection{ProjectId: project.Id, RunId: project.RunId}, MatchByIssueKey)
+			Expect(err).To(BeNil())
+			Expect(diff.New).To(HaveLen(2))
+			Expect(diff.Fixed).To(HaveLen(4))
+			Expect(diff.Unchanged).To(BeEmpty())
+			Expect(diff.New[0].Severity).To(Equal("Critical"))
+			Expect(diff.New[0].Tool).ToNot(BeEmpty())
+
+			// the branch has the issues of both runs
+			diff, err = client.DiffV1Issues(base, &IssueSelection{ProjectId: project.Id, BranchId: project.BranchId}, MatchByFindingKey)
+			Expect(err).To(BeNil())
+			Expect(diff.New).To(HaveLen(2))
+			Expect(diff.Fixed).To(BeEmpty())
+			Expect(diff.Unchanged).To(HaveLen(4))
+			Expect(diff.CountsBySeverity()).To(Equal([]*SeverityCounts{
+				{Severity: "Critical", New: 1, Unchanged: 1},
+				{Severity: "High", New: 1, Unchanged: 1},
+				{Severity: "Medium", Unchanged: 1},
+				{Severity: "Low", Unchanged: 1},
+			}))
+
+			_, err = client.DiffV1Issues(base, &IssueSelection{ProjectId: project.Id, BranchId: project.BranchId, RunId: baseRun}, MatchByIssueKey)
+			Expect(err).ToNot(BeNil())
+		})
+	})
+}
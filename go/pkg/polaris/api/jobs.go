@@ -20,33 +20,247 @@ under the License.
 */
 package api
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
 
-type GetJobsResponse struct {
-	Data []struct {
-		Type       string
-		Id         string
-		Attributes struct {
-			DateFinished string
-			// TODO lots more stuff
-			Status struct {
-				State    string
-				Progress int
-			}
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Job states.  COMPLETED, FAILED and CANCELLED are terminal.
+const (
+	JobStateQueued     = "QUEUED"
+	JobStateDispatched = "DISPATCHED"
+	JobStateRunning    = "RUNNING"
+	JobStateCompleted  = "COMPLETED"
+	JobStateFailed     = "FAILED"
+	JobStateCancelled  = "CANCELLED"
+)
+
+type JobStatus struct {
+	State    string
+	Progress int
+}
+
+type Job struct {
+	Type       string
+	Id         string
+	Attributes struct {
+		JobType      string
+		DateCreated  string
+		DateQueued   string
+		DateStarted  string
+		DateFinished string
+		Status       JobStatus
+		FailureInfo  *struct {
+			UserFriendlyFailureReason string
+			Exception                 string
 		}
-		// TODO relationships
+		Details map[string]interface{}
+	}
+	Relationships map[string]Relationship
+}
+
+func (job *Job) IsTerminal() bool {
+	switch job.Attributes.Status.State {
+	case JobStateCompleted, JobStateFailed, JobStateCancelled:
+		return true
+	default:
+		return false
 	}
+}
+
+type GetJobsResponse struct {
+	Data []*Job
 	Meta struct {
 		Total   int
 		Offset  int
 		Limit   int
 		Filters map[string]string
 	}
+	Links PageLinks
 }
 
 func (client *Client) GetJobs(limit int) (*GetJobsResponse, error) {
-	params := map[string]interface{}{"page[limit]": fmt.Sprintf("%d", limit)}
+	return client.GetJobsContext(context.Background(), limit)
+}
+
+func (client *Client) GetJobsContext(ctx context.Context, limit int) (*GetJobsResponse, error) {
+	return client.getJobs(ctx, NewQuery().Set("page[limit]", fmt.Sprintf("%d", limit)))
+}
+
+// JobsFilter selects jobs; zero fields don't filter.
+type JobsFilter struct {
+	ProjectId     string
+	BranchId      string
+	States        []string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
+
+func (filter *JobsFilter) query() Query {
+	query := NewQuery()
+	if filter == nil {
+		return query
+	}
+	if filter.ProjectId != "" {
+		query.Set("filter[jobs][project][id][$eq]", filter.ProjectId)
+	}
+	if filter.BranchId != "" {
+		query.Set("filter[jobs][branch][id][$eq]", filter.BranchId)
+	}
+	if len(filter.States) > 0 {
+		query.Set("filter[jobs][status][state][$in]", strings.Join(filter.States, ","))
+	}
+	if !filter.CreatedAfter.IsZero() {
+		query.Set("filter[jobs][dateCreated][$gte]", filter.CreatedAfter.UTC().Format(time.RFC3339))
+	}
+	if !filter.CreatedBefore.IsZero() {
+		query.Set("filter[jobs][dateCreated][$lt]", filter.CreatedBefore.UTC().Format(time.RFC3339))
+	}
+	return query
+}
+
+func (client *Client) GetFilteredJobs(filter *JobsFilter, offset int, limit int) (*GetJobsResponse, error) {
+	return client.GetFilteredJobsContext(context.Background(), filter, offset, limit)
+}
+
+func (client *Client) GetFilteredJobsContext(ctx context.Context, filter *JobsFilter, offset int, limit int) (*GetJobsResponse, error) {
+	return client.getJobs(ctx, filter.query().Page(offset, limit))
+}
+
+func (client *Client) getJobs(ctx context.Context, query Query) (*GetJobsResponse, error) {
 	result := &GetJobsResponse{}
-	_, err := client.GetJson(params, result, "api/jobs/jobs")
+	_, err := client.GetJsonContext(ctx, query, result, "api/jobs/jobs")
 	return result, err
 }
+
+// JobsPaginator walks all jobs; items are *Job.
+func (client *Client) JobsPaginator(pageSize int) *Paginator {
+	return client.FilteredJobsPaginator(nil, pageSize)
+}
+
+// FilteredJobsPaginator walks the jobs selected by filter, which may be nil; items are *Job.
+func (client *Client) FilteredJobsPaginator(filter *JobsFilter, pageSize int) *Paginator {
+	return NewPaginator(func(ctx context.Context, offset int, limit int) (*Page, error) {
+		jobs, err := client.getJobs(ctx, filter.query().Page(offset, limit))
+		if err != nil {
+			return nil, err
+		}
+		items := boxItems(len(jobs.Data), func(i int) interface{} { return jobs.Data[i] })
+		meta := PageMeta{Offset: jobs.Meta.Offset, Limit: jobs.Meta.Limit, Total: jobs.Meta.Total}
+		return &Page{Items: items, Meta: meta, Links: jobs.Links}, nil
+	}, pageSize)
+}
+
+type GetJobResponse struct {
+	Data *Job
+}
+
+func (client *Client) GetJob(jobId string) (*GetJobResponse, error) {
+	return client.GetJobContext(context.Background(), jobId)
+}
+
+func (client *Client) GetJobContext(ctx context.Context, jobId string) (*GetJobResponse, error) {
+	result := &GetJobResponse{}
+	_, err := client.GetJsonContext(ctx, NewQuery(), result, "api/jobs/jobs/%s", jobId)
+	if err == nil && result.Data == nil {
+		return result, errors.Errorf("no data in response for job %s", jobId)
+	}
+	return result, err
+}
+
+// GetJobLogs returns the plain text log of a job.
+func (client *Client) GetJobLogs(jobId string) (string, error) {
+	return client.GetJobLogsContext(context.Background(), jobId)
+}
+
+func (client *Client) GetJobLogsContext(ctx context.Context, jobId string) (string, error) {
+	return client.getJsonWithHeader(ctx, "text/plain", NewQuery(), nil, "api/jobs/jobs/%s/logs", []interface{}{jobId})
+}
+
+// JobPollPolicy controls how often WaitForJob checks on a job.  Fields that
+// are 0 or less take their values from DefaultJobPollPolicy.
+type JobPollPolicy struct {
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	// MaxConsecutiveErrors is how many polls in a row may fail before WaitForJob gives up
+	MaxConsecutiveErrors int
+}
+
+func DefaultJobPollPolicy() *JobPollPolicy {
+	return &JobPollPolicy{
+		InitialInterval:      2 * time.Second,
+		MaxInterval:          30 * time.Second,
+		MaxConsecutiveErrors: 3,
+	}
+}
+
+// withDefaults fills in the fields left unset
+func (policy JobPollPolicy) withDefaults() *JobPollPolicy {
+	defaults := DefaultJobPollPolicy()
+	if policy.InitialInterval <= 0 {
+		policy.InitialInterval = defaults.InitialInterval
+	}
+	if policy.MaxInterval <= 0 {
+		policy.MaxInterval = defaults.MaxInterval
+	}
+	if policy.MaxInterval < policy.InitialInterval {
+		policy.MaxInterval = policy.InitialInterval
+	}
+	if policy.MaxConsecutiveErrors <= 0 {
+		policy.MaxConsecutiveErrors = defaults.MaxConsecutiveErrors
+	}
+	return &policy
+}
+
+// WaitForJob polls a job, backing off between polls, until it reaches a
+// terminal state, and returns it.  A FAILED or CANCELLED job is not an error:
+// check its state.  Use ctx to bound the wait; on error, the last state seen is
+// returned along with it.
+func (client *Client) WaitForJob(ctx context.Context, jobId string) (*Job, error) {
+	return client.WaitForJobWithPolicy(ctx, jobId, DefaultJobPollPolicy())
+}
+
+func (client *Client) WaitForJobWithPolicy(ctx context.Context, jobId string, policy *JobPollPolicy) (*Job, error) {
+	policy = policy.withDefaults()
+	interval := policy.InitialInterval
+	var job *Job
+	errorsInARow := 0
+	for {
+		response, err := client.GetJobContext(ctx, jobId)
+		if err != nil {
+			errorsInARow++
+			recordEvent("poll_job", err)
+			if errorsInARow >= policy.MaxConsecutiveErrors || ctx.Err() != nil {
+				// hand back the last state we saw, if any
+				return job, errors.WithMessagef(err, "unable to check on job %s", jobId)
+			}
+			log.Warnf("unable to check on job %s (%d of %d failures allowed in a row), trying again in %s: %s", jobId, errorsInARow, policy.MaxConsecutiveErrors, interval, err)
+		} else {
+			errorsInARow = 0
+			job = response.Data
+			if job.IsTerminal() {
+				log.Infof("job %s finished: %s", jobId, job.Attributes.Status.State)
+				recordEvent(fmt.Sprintf("job_%s", strings.ToLower(job.Attributes.Status.State)), nil)
+				return job, nil
+			}
+			log.Debugf("job %s is %s (%d%%), checking again in %s", jobId, job.Attributes.Status.State, job.Attributes.Status.Progress, interval)
+		}
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			if job == nil {
+				return nil, errors.Wrapf(ctx.Err(), "gave up waiting for job %s", jobId)
+			}
+			return job, errors.Wrapf(ctx.Err(), "gave up waiting for job %s in state %s", jobId, job.Attributes.Status.State)
+		}
+		interval *= 2
+		if interval > policy.MaxInterval {
+			interval = policy.MaxInterval
+		}
+	}
+}
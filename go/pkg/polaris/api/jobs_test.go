@@ -0,0 +1,147 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api/fake"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var fastJobPolls = &JobPollPolicy{InitialInterval: time.Millisecond, MaxInterval: 5 * time.Millisecond}
+
+func RunJobsTests() {
+	Describe("Jobs", func() {
+		It("should filter jobs by project, branch, state and date", func() {
+			server := newTestServer(serveBody(`{"data": [], "meta": {"total": 0}}`))
+			defer server.Close()
+
+			_, err := NewBearerTokenClient(server.URL, "token").GetFilteredJobs(&JobsFilter{
+				ProjectId:    "project-1",
+				BranchId:     "branch-1",
+				States:       []string{JobStateQueued, JobStateRunning},
+				CreatedAfter: time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC),
+			}, 20, 10)
+			Expect(err).To(BeNil())
+			query := server.last().Query
+			Expect(query.Get("filter[jobs][project][id][$eq]")).To(Equal("project-1"))
+			Expect(query.Get("filter[jobs][branch][id][$eq]")).To(Equal("branch-1"))
+			Expect(query.Get("filter[jobs][status][state][$in]")).To(Equal("QUEUED,RUNNING"))
+			Expect(query.Get("filter[jobs][dateCreated][$gte]")).To(Equal("2020-06-01T00:00:00Z"))
+			Expect(query).ToNot(HaveKey("filter[jobs][dateCreated][$lt]"))
+			Expect(query.Get("page[offset]")).To(Equal("20"))
+		})
+
+		It("should wait until the job finishes", func() {
+			server, client := newFakeServer()
+			defer server.Close()
+			project := server.AddProject("cerebros")
+			jobId := server.AddJob(project.Id, JobStateQueued, JobStateRunning, JobStateRunning, JobStateCompleted)
+
+			job, err := client.WaitForJobWithPolicy(context.Background(), jobId, fastJobPolls)
+			Expect(err).To(BeNil())
+			Expect(job.Attributes.Status.State).To(Equal(JobStateCompleted))
+			Expect(job.Attributes.DateCreated).ToNot(BeEmpty())
+			Expect(server.RequestCount(http.MethodGet, "/api/jobs/jobs/"+jobId)).To(Equal(4))
+		})
+
+		It("should return failed jobs without an error", func() {
+			server, client := newFakeServer()
+			defer server.Close()
+			project := server.AddProject("cerebros")
+			jobId := server.AddJob(project.Id, JobStateRunning, JobStateFailed)
+
+			job, err := client.WaitForJobWithPolicy(context.Background(), jobId, fastJobPolls)
+			Expect(err).To(BeNil())
+			Expect(job.IsTerminal()).To(BeTrue())
+			Expect(job.Attributes.FailureInfo.UserFriendlyFailureReason).To(Equal("failed by the fake polaris"))
+		})
+
+		It("should give up when the context is done", func() {
+			server, client := newFakeServer()
+			defer server.Close()
+			project := server.AddProject("cerebros")
+			jobId := server.AddJob(project.Id, JobStateRunning)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			job, err := client.WaitForJobWithPolicy(ctx, jobId, fastJobPolls)
+			Expect(err).ToNot(BeNil())
+			Expect(job).ToNot(BeNil())
+			Expect(job.Attributes.Status.State).To(Equal(JobStateRunning))
+		})
+
+		It("should keep polling through a few failed polls in a row", func() {
+			server, client := newFakeServer()
+			defer server.Close()
+			project := server.AddProject("cerebros")
+			jobId := server.AddJob(project.Id, JobStateRunning, JobStateCompleted)
+			Expect(client.Authenticate()).To(Succeed())
+			server.InjectFault(&fake.Fault{PathPrefix: "/api/jobs/jobs/" + jobId, StatusCode: http.StatusBadGateway, Count: 2})
+
+			job, err := client.WaitForJobWithPolicy(context.Background(), jobId, fastJobPolls)
+			Expect(err).To(BeNil())
+			Expect(job.Attributes.Status.State).To(Equal(JobStateCompleted))
+			Expect(server.RequestCount(http.MethodGet, "/api/jobs/jobs/"+jobId)).To(Equal(4))
+		})
+
+		It("should give up after too many failed polls in a row", func() {
+			server, client := newFakeServer()
+			defer server.Close()
+			project := server.AddProject("cerebros")
+			jobId := server.AddJob(project.Id, JobStateRunning, JobStateCompleted)
+			Expect(client.Authenticate()).To(Succeed())
+			server.InjectFault(&fake.Fault{PathPrefix: "/api/jobs/jobs/" + jobId, StatusCode: http.StatusBadGateway})
+
+			policy := &JobPollPolicy{InitialInterval: time.Millisecond, MaxInterval: 5 * time.Millisecond, MaxConsecutiveErrors: 2}
+			job, err := client.WaitForJobWithPolicy(context.Background(), jobId, policy)
+			Expect(err).ToNot(BeNil())
+			Expect(job).To(BeNil())
+			Expect(server.RequestCount(http.MethodGet, "/api/jobs/jobs/"+jobId)).To(Equal(2))
+		})
+
+		It("should fill in unset poll policy fields from the defaults", func() {
+			Expect((JobPollPolicy{}).withDefaults()).To(Equal(DefaultJobPollPolicy()))
+			Expect((JobPollPolicy{InitialInterval: -time.Second, MaxInterval: time.Minute}).withDefaults()).To(Equal(&JobPollPolicy{
+				InitialInterval:      2 * time.Second,
+				MaxInterval:          time.Minute,
+				MaxConsecutiveErrors: 3,
+			}))
+			Expect((JobPollPolicy{InitialInterval: time.Minute}).withDefaults().MaxInterval).To(Equal(time.Minute))
+		})
+
+		It("should fetch job logs as text", func() {
+			server, client := newFakeServer()
+			defer server.Close()
+			project := server.AddProject("cerebros")
+			jobId := server.AddJob(project.Id, JobStateCompleted)
+
+			logs, err := client.GetJobLogs(jobId)
+			Expect(err).To(BeNil())
+			Expect(logs).To(Equal(fmt.Sprintf("job %s is %s", jobId, JobStateCompleted)))
+		})
+	})
+}
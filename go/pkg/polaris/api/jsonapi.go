@@ -0,0 +1,286 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ResourceIdentifier identifies a JSON:API resource.
+type ResourceIdentifier struct {
+	Type string
+	Id   string
+}
+
+func (ri ResourceIdentifier) String() string {
+	return fmt.Sprintf("%s/%s", ri.Type, ri.Id)
+}
+
+// ResourceLinkage is the data of a relationship: a single identifier for
+// to-one relationships (promoted, so Data.Id works), a list for to-many.
+type ResourceLinkage struct {
+	ResourceIdentifier
+	Many   []ResourceIdentifier
+	IsMany bool
+}
+
+func (rl *ResourceLinkage) UnmarshalJSON(data []byte) error {
+	*rl = ResourceLinkage{}
+	trimmed := bytes.TrimSpace(data)
+	if bytes.Equal(trimmed, []byte("null")) {
+		return nil
+	}
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		rl.IsMany = true
+		return json.Unmarshal(trimmed, &rl.Many)
+	}
+	return json.Unmarshal(trimmed, &rl.ResourceIdentifier)
+}
+
+// MarshalJSON writes the linkage the way UnmarshalJSON reads it: an array for
+// to-many, an identifier for to-one, or null for an empty to-one.
+func (rl ResourceLinkage) MarshalJSON() ([]byte, error) {
+	if rl.IsMany {
+		if rl.Many == nil {
+			return []byte("[]"), nil
+		}
+		return json.Marshal(rl.Many)
+	}
+	if rl.ResourceIdentifier == (ResourceIdentifier{}) {
+		return []byte("null"), nil
+	}
+	return json.Marshal(rl.ResourceIdentifier)
+}
+
+// Identifiers returns the linked resources, whether to-one or to-many.
+func (rl ResourceLinkage) Identifiers() []ResourceIdentifier {
+	if rl.IsMany {
+		return rl.Many
+	}
+	if rl.Id == "" {
+		return nil
+	}
+	return []ResourceIdentifier{rl.ResourceIdentifier}
+}
+
+type RelationshipLinks struct {
+	Self    string
+	Related string
+}
+
+type Relationship struct {
+	Links RelationshipLinks
+	Data  ResourceLinkage
+	Meta  map[string]interface{}
+}
+
+// Resource is an untyped JSON:API resource object.  It keeps its JSON so that
+// it can later be decoded into a typed struct such as *User with Decode.
+type Resource struct {
+	Type          string
+	Id            string
+	Attributes    map[string]interface{}
+	Relationships map[string]Relationship
+	Meta          map[string]interface{}
+
+	raw json.RawMessage
+}
+
+func (r *Resource) UnmarshalJSON(data []byte) error {
+	// the alias drops this method, avoiding infinite recursion
+	type resource Resource
+	decoded := resource{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*r = Resource(decoded)
+	r.raw = append(json.RawMessage{}, data...)
+	return nil
+}
+
+func (r *Resource) Identifier() ResourceIdentifier {
+	return ResourceIdentifier{Type: r.Type, Id: r.Id}
+}
+
+// Decode unmarshals the whole resource object into target.
+func (r *Resource) Decode(target interface{}) error {
+	if r.raw == nil {
+		return errors.Errorf("resource %s has no JSON to decode", r.Identifier())
+	}
+	return errors.Wrapf(json.Unmarshal(r.raw, target), "unable to decode resource %s", r.Identifier())
+}
+
+// Included is the included section of a compound document.
+type Included []*Resource
+
+// Index builds a lookup table; use it instead of Find when resolving many relationships.
+func (inc Included) Index() ResourceIndex {
+	index := ResourceIndex{}
+	for _, resource := range inc {
+		index[resource.Identifier()] = resource
+	}
+	return index
+}
+
+func (inc Included) Find(resourceType string, id string) *Resource {
+	for _, resource := range inc {
+		if resource.Type == resourceType && resource.Id == id {
+			return resource
+		}
+	}
+	return nil
+}
+
+// Resolve returns the included resources a relationship links to.  Linked
+// resources that weren't included are skipped.
+func (inc Included) Resolve(relationship Relationship) []*Resource {
+	return inc.Index().Resolve(relationship)
+}
+
+// ResolveOne returns the included resource of a to-one relationship, or nil.
+func (inc Included) ResolveOne(relationship Relationship) *Resource {
+	return inc.Index().ResolveOne(relationship)
+}
+
+type ResourceIndex map[ResourceIdentifier]*Resource
+
+func (ri ResourceIndex) Find(resourceType string, id string) *Resource {
+	return ri[ResourceIdentifier{Type: resourceType, Id: id}]
+}
+
+func (ri ResourceIndex) Resolve(relationship Relationship) []*Resource {
+	resources := []*Resource{}
+	for _, identifier := range relationship.Data.Identifiers() {
+		if resource, ok := ri[identifier]; ok {
+			resources = append(resources, resource)
+		}
+	}
+	return resources
+}
+
+func (ri ResourceIndex) ResolveOne(relationship Relationship) *Resource {
+	if relationship.Data.IsMany || relationship.Data.Id == "" {
+		return nil
+	}
+	return ri[relationship.Data.ResourceIdentifier]
+}
+
+// Document is a decoded JSON:API document whose primary data is decoded
+// separately, into whatever typed struct or slice the caller passes to
+// DecodeDocument.
+type Document struct {
+	Data     json.RawMessage
+	Included Included
+	Meta     map[string]interface{}
+	Links    PageLinks
+	Errors   []*DocumentError
+}
+
+type DocumentError struct {
+	Status string
+	Code   string
+	Title  string
+	Detail string
+}
+
+// DecodeDocument decodes body, unmarshalling its primary data into data,
+// which may be nil.
+func DecodeDocument(body []byte, data interface{}) (*Document, error) {
+	doc := &Document{}
+	if err := json.Unmarshal(body, doc); err != nil {
+		return nil, errors.Wrapf(err, "unable to decode JSON:API document")
+	}
+	if len(doc.Errors) > 0 {
+		titles := []string{}
+		for _, docErr := range doc.Errors {
+			titles = append(titles, fmt.Sprintf("%s %s: %s", docErr.Status, docErr.Title, docErr.Detail))
+		}
+		return doc, errors.Errorf("JSON:API errors: %s", strings.Join(titles, "; "))
+	}
+	if data != nil && len(doc.Data) > 0 {
+		if err := json.Unmarshal(doc.Data, data); err != nil {
+			return doc, errors.Wrapf(err, "unable to decode JSON:API primary data into %T", data)
+		}
+	}
+	return doc, nil
+}
+
+// GetDocument fetches a JSON:API document; see DecodeDocument.
+func (client *Client) GetDocument(query Query, data interface{}, pathTemplate string, pathArgs ...interface{}) (*Document, error) {
+	return client.GetDocumentContext(context.Background(), query, data, pathTemplate, pathArgs...)
+}
+
+func (client *Client) GetDocumentContext(ctx context.Context, query Query, data interface{}, pathTemplate string, pathArgs ...interface{}) (*Document, error) {
+	body, err := client.GetJsonContext(ctx, query, nil, pathTemplate, pathArgs...)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeDocument([]byte(body), data)
+}
+
+// Query holds JSON:API query parameters; it can be passed anywhere a params map is expected.
+type Query map[string]interface{}
+
+func NewQuery() Query {
+	return Query{}
+}
+
+// Include asks for related resources in the included section, using the
+// include[type][] form Polaris expects.
+func (q Query) Include(resourceType string, paths ...string) Query {
+	key := fmt.Sprintf("include[%s][]", resourceType)
+	existing, _ := q[key].([]string)
+	q[key] = append(existing, paths...)
+	return q
+}
+
+// Fields limits the attributes and relationships returned for resourceType.
+func (q Query) Fields(resourceType string, fields ...string) Query {
+	q[fmt.Sprintf("fields[%s]", resourceType)] = strings.Join(fields, ",")
+	return q
+}
+
+func (q Query) Page(offset int, limit int) Query {
+	q["page[offset]"] = fmt.Sprintf("%d", offset)
+	q["page[limit]"] = fmt.Sprintf("%d", limit)
+	return q
+}
+
+func (q Query) Set(key string, value string) Query {
+	q[key] = value
+	return q
+}
+
+// identifierData builds the JSON:API resource linkage for a relationship in a request body.
+func identifierData(resourceType string, id string) map[string]interface{} {
+	return map[string]interface{}{
+		"data": map[string]interface{}{
+			"type": resourceType,
+			"id":   id,
+		},
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const compoundDocument = `{
+	"data": [
+		{"type": "issues", "id": "issue-1",
+			"attributes": {"issue-key": "key-1"},
+			"relationships": {
+				"issue-type": {"data": {"type": "issue-type", "id": "type-1"}},
+				"related-taxa": {"data": [{"type": "taxon", "id": "cwe-79"}, {"type": "taxon", "id": "missing"}]},
+				"severity": {"data": null}}},
+		{"type": "issues", "id": "issue-2",
+			"attributes": {"issue-key": "key-2"},
+			"relationships": {"issue-type": {"data": {"type": "issue-type", "id": "type-1"}}}}
+	],
+	"included": [
+		{"type": "issue-type", "id": "type-1", "attributes": {"name": "Cross-site scripting", "abbreviation": "XSS"}},
+		{"type": "taxon", "id": "cwe-79", "attributes": {"name": "CWE-79"}}
+	],
+	"meta": {"total": 2},
+	"links": {"next": "/api/query/v1/issues?page[offset]=2"}
+}`
+
+func RunJsonApiTests() {
+	Describe("JSON:API documents", func() {
+		It("should decode primary data into typed resources", func() {
+			issues := []*V1IssueResponse{}
+			doc, err := DecodeDocument([]byte(compoundDocument), &issues)
+			Expect(err).To(BeNil())
+			Expect(issues).To(HaveLen(2))
+			Expect(issues[1].Attributes.IssueKey).To(Equal("key-2"))
+			Expect(doc.Meta["total"]).To(Equal(float64(2)))
+			Expect(doc.Links.Next).To(Equal("/api/query/v1/issues?page[offset]=2"))
+		})
+
+		It("should resolve relationships against included", func() {
+			issues := []*V1IssueResponse{}
+			doc, err := DecodeDocument([]byte(compoundDocument), &issues)
+			Expect(err).To(BeNil())
+			index := doc.Included.Index()
+
+			issue := issues[0]
+			issueType := index.ResolveOne(issue.Relationships.IssueType)
+			Expect(issueType).ToNot(BeNil())
+			Expect(issueType.Attributes["abbreviation"]).To(Equal("XSS"))
+			Expect(index.ResolveOne(issue.Relationships.Severity)).To(BeNil())
+
+			// linked but not included resources are skipped
+			taxa := doc.Included.Resolve(issue.Relationships.RelatedTaxa)
+			Expect(taxa).To(HaveLen(1))
+			Expect(taxa[0].Id).To(Equal("cwe-79"))
+			Expect(doc.Included.Find("taxon", "missing")).To(BeNil())
+		})
+
+		It("should decode an included resource into a typed struct", func() {
+			doc, err := DecodeDocument([]byte(compoundDocument), nil)
+			Expect(err).To(BeNil())
+			issueType := struct {
+				Id         string
+				Attributes struct {
+					Name string
+				}
+			}{}
+			Expect(doc.Included.Find("issue-type", "type-1").Decode(&issueType)).To(Succeed())
+			Expect(issueType.Id).To(Equal("type-1"))
+			Expect(issueType.Attributes.Name).To(Equal("Cross-site scripting"))
+		})
+
+		It("should encode relationship data the way it was decoded", func() {
+			for _, data := range []string{
+				`{"Type": "issue-type", "Id": "type-1"}`,
+				`[{"Type": "taxon", "Id": "cwe-79"}, {"Type": "taxon", "Id": "cwe-89"}]`,
+				`[]`,
+				`null`,
+			} {
+				relationship := Relationship{}
+				Expect(json.Unmarshal([]byte(`{"data": `+data+`}`), &relationship)).To(Succeed())
+				encoded, err := json.Marshal(relationship.Data)
+				Expect(err).To(BeNil())
+				Expect(encoded).To(MatchJSON(data))
+			}
+		})
+
+		It("should report JSON:API errors", func() {
+			_, err := DecodeDocument([]byte(`{"errors": [{"status": "404", "title": "Not Found", "detail": "no such issue"}]}`), nil)
+			Expect(err).ToNot(BeNil())
+			Expect(err.Error()).To(ContainSubstring("no such issue"))
+		})
+
+		It("should send includes and sparse fieldsets", func() {
+			server := newTestServer(serveBody(compoundDocument))
+			defer server.Close()
+
+			q := NewQuery().
+				Include("issue", "issue-type", "related-taxa").
+				Fields("issue-type", "name", "abbreviation").
+				Page(0, 25)
+			issues := []*V1IssueResponse{}
+			doc, err := NewBearerTokenClient(server.URL, "token").GetDocument(q, &issues, "api/query/v1/issues")
+			Expect(err).To(BeNil())
+			Expect(issues).To(HaveLen(2))
+			Expect(doc.Included).To(HaveLen(2))
+
+			query := server.last().Query
+			Expect(query["include[issue][]"]).To(Equal([]string{"issue-type", "related-taxa"}))
+			Expect(query.Get("fields[issue-type]")).To(Equal("name,abbreviation"))
+			Expect(query.Get("page[limit]")).To(Equal("25"))
+		})
+	})
+}
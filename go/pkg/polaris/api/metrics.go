@@ -29,6 +29,7 @@ import (
 var eventCounter *prometheus.CounterVec
 var statusCodeCounter *prometheus.CounterVec
 var responseTimeHistogram *prometheus.HistogramVec
+var cacheCounter *prometheus.CounterVec
 
 func recordEvent(event string, err error) {
 	eventCounter.With(prometheus.Labels{"event": event, "iserror": fmt.Sprintf("%t", err != nil)}).Inc()
@@ -47,6 +48,10 @@ func recordResponseTime(verb string, apiPath string, duration time.Duration, sta
 	}).Observe(milliseconds)
 }
 
+func recordCacheResult(verb string, apiPath string, result string) {
+	cacheCounter.With(prometheus.Labels{"verb": verb, "apipath": apiPath, "result": result}).Inc()
+}
+
 func init() {
 	eventCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "cerebros",
@@ -72,4 +77,12 @@ func init() {
 		Buckets:   prometheus.ExponentialBuckets(1, 2, 20),
 	}, []string{"verb", "apipath", "code"})
 	prometheus.MustRegister(responseTimeHistogram)
+
+	cacheCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "cerebros",
+		Subsystem: "polaris_api",
+		Name:      "response_cache_counter",
+		Help:      "a counter of response cache hits, misses, revalidations and bypasses",
+	}, []string{"verb", "apipath", "result"})
+	prometheus.MustRegister(cacheCounter)
 }
@@ -32,6 +32,7 @@ func RunMetricsTests() {
 			recordEvent("abc", nil)
 			recordResponseStatusCode("PATCH", "things", 404)
 			recordResponseTime("HEAD", "stuff", time.Now().Sub(time.Now()), 200)
+			recordCacheResult("GET", "things", cacheHit)
 		})
 	})
 }
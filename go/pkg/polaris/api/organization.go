@@ -0,0 +1,196 @@
+/*
+Copyright (C) 2018 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type Organization struct {
+	Type       string
+	Id         string
+	Attributes struct {
+		OrganizationName string
+		Description      string
+	}
+	Relationships map[string]Relationship
+	Links         map[string]interface{}
+	Meta          map[string]interface{}
+}
+
+type GetOrganizationsResponse struct {
+	Data  []*Organization
+	Meta  PageMeta
+	Links PageLinks
+}
+
+func (client *Client) GetOrganizations() (*GetOrganizationsResponse, error) {
+	return client.GetOrganizationsContext(context.Background())
+}
+
+func (client *Client) GetOrganizationsContext(ctx context.Context) (*GetOrganizationsResponse, error) {
+	result := &GetOrganizationsResponse{}
+	_, err := client.GetJsonContext(ctx, map[string]interface{}{}, result, "api/auth/organizations")
+	return result, err
+}
+
+// OrganizationsPaginator walks all organizations the user can see; items are *Organization.
+func (client *Client) OrganizationsPaginator(pageSize int) *Paginator {
+	return NewPaginator(func(ctx context.Context, offset int, limit int) (*Page, error) {
+		result := &GetOrganizationsResponse{}
+		_, err := client.GetJsonContext(ctx, NewQuery().Page(offset, limit), result, "api/auth/organizations")
+		if err != nil {
+			return nil, err
+		}
+		items := boxItems(len(result.Data), func(i int) interface{} { return result.Data[i] })
+		return &Page{Items: items, Meta: result.Meta, Links: result.Links}, nil
+	}, pageSize)
+}
+
+type GetOrganizationResponse struct {
+	Data *Organization
+}
+
+func (client *Client) GetOrganization(orgId string) (*GetOrganizationResponse, error) {
+	return client.GetOrganizationContext(context.Background(), orgId)
+}
+
+func (client *Client) GetOrganizationContext(ctx context.Context, orgId string) (*GetOrganizationResponse, error) {
+	result := &GetOrganizationResponse{}
+	_, err := client.GetJsonContext(ctx, NewQuery(), result, "api/auth/organizations/%s", orgId)
+	if err == nil && result.Data == nil {
+		return result, errors.Errorf("no data in response for organization %s", orgId)
+	}
+	return result, err
+}
+
+// SelectOrganization finds an organization by id or, failing that, by name,
+// ignoring case, and makes it the client's organization: calls that need one
+// and aren't given one, like CreateUser with an empty orgId, use it.  An empty
+// nameOrId selects the only organization, if there's just one.
+func (client *Client) SelectOrganization(nameOrId string) (*Organization, error) {
+	return client.SelectOrganizationContext(context.Background(), nameOrId)
+}
+
+func (client *Client) SelectOrganizationContext(ctx context.Context, nameOrId string) (*Organization, error) {
+	all, err := client.OrganizationsPaginator(100).All(ctx)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "unable to list organizations")
+	}
+	orgs := make([]*Organization, len(all))
+	for i, item := range all {
+		orgs[i] = item.(*Organization)
+	}
+	org, err := findOrganization(orgs, nameOrId)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("selected organization %s (%s)", org.Attributes.OrganizationName, org.Id)
+	client.SetOrganizationId(org.Id)
+	return org, nil
+}
+
+func findOrganization(orgs []*Organization, nameOrId string) (*Organization, error) {
+	names := make([]string, len(orgs))
+	for i, org := range orgs {
+		names[i] = org.Attributes.OrganizationName
+	}
+	if nameOrId == "" {
+		if len(orgs) != 1 {
+			return nil, errors.Errorf("found %d organizations, so one must be chosen: %s", len(orgs), strings.Join(names, ", "))
+		}
+		return orgs[0], nil
+	}
+	for _, org := range orgs {
+		if org.Id == nameOrId {
+			return org, nil
+		}
+	}
+	var found *Organization
+	for _, org := range orgs {
+		if strings.EqualFold(org.Attributes.OrganizationName, nameOrId) {
+			if found != nil {
+				return nil, errors.Errorf("more than one organization is named %s: use its id", nameOrId)
+			}
+			found = org
+		}
+	}
+	if found == nil {
+		return nil, errors.Errorf("no organization %s among %s", nameOrId, strings.Join(names, ", "))
+	}
+	return found, nil
+}
+
+// SetOrganizationId sets the client's organization without looking it up.
+func (client *Client) SetOrganizationId(orgId string) {
+	client.orgMux.Lock()
+	defer client.orgMux.Unlock()
+	client.organizationId = orgId
+}
+
+// OrganizationId returns the organization selected for the client, if any.
+func (client *Client) OrganizationId() string {
+	client.orgMux.RLock()
+	defer client.orgMux.RUnlock()
+	return client.organizationId
+}
+
+type organizationKey struct{}
+
+// WithOrganization makes calls using ctx act in orgId instead of the client's organization.
+func WithOrganization(ctx context.Context, orgId string) context.Context {
+	return context.WithValue(ctx, organizationKey{}, orgId)
+}
+
+// organizationFor picks the organization for a call: orgId if it's given,
+// then the one on ctx, then the client's.  A client without one selects the
+// only organization there is, if there's just one.
+func (client *Client) organizationFor(ctx context.Context, orgId string) (string, error) {
+	if orgId != "" {
+		return orgId, nil
+	}
+	if current := client.currentOrganization(ctx); current != "" {
+		return current, nil
+	}
+	org, err := client.SelectOrganizationContext(ctx, "")
+	if err != nil {
+		return "", errors.WithMessagef(err, "no organization selected")
+	}
+	return org.Id, nil
+}
+
+// currentOrganization returns ctx's organization, or else the client's, without looking one up
+func (client *Client) currentOrganization(ctx context.Context) string {
+	if fromContext, _ := ctx.Value(organizationKey{}).(string); fromContext != "" {
+		return fromContext
+	}
+	return client.OrganizationId()
+}
+
+// organizationURN is how role assignments and entitlements refer to an organization
+func organizationURN(orgId string) string {
+	return fmt.Sprintf("urn:x-swip:organizations:%s", orgId)
+}
@@ -0,0 +1,284 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// PageMeta is the JSON:API paging metadata returned with list responses.
+type PageMeta struct {
+	Offset int
+	Limit  int
+	Total  int
+}
+
+// PageLinks holds the JSON:API pagination links.  Next is empty on the last page.
+type PageLinks struct {
+	Self  string
+	First string
+	Prev  string
+	Next  string
+	Last  string
+}
+
+// Page is one page of a list response, with its items boxed so that a single
+// Paginator can walk any collection.
+type Page struct {
+	Items []interface{}
+	Meta  PageMeta
+	Links PageLinks
+}
+
+// PageFetcher fetches the page of a collection starting at offset.
+type PageFetcher func(ctx context.Context, offset int, limit int) (*Page, error)
+
+// Paginator walks a paginated collection using the JSON:API Meta.Total and
+// links.next conventions.  Once the total is known, up to Prefetch pages are
+// fetched concurrently; pages are always delivered in order.
+type Paginator struct {
+	Fetch       PageFetcher
+	PageSize    int
+	StartOffset int
+	// MaxItems stops iteration after this many items; 0 means no limit
+	MaxItems int
+	// Prefetch is the number of pages requested ahead of the consumer; values below 2 fetch serially
+	Prefetch int
+	// OnPage, if set, is called with each page in order, before its items are delivered
+	OnPage func(page *Page)
+}
+
+func NewPaginator(fetch PageFetcher, pageSize int) *Paginator {
+	return &Paginator{
+		Fetch:    fetch,
+		PageSize: pageSize,
+		Prefetch: 1,
+	}
+}
+
+// Items streams the items of the collection.  The item channel is closed when
+// the collection is exhausted, MaxItems is reached, a fetch fails or ctx is
+// cancelled; the error channel then yields the failure, if any, and is closed.
+func (p *Paginator) Items(ctx context.Context) (<-chan interface{}, <-chan error) {
+	items := make(chan interface{})
+	errs := make(chan error, 1)
+	go func() {
+		err := p.run(ctx, items)
+		close(items)
+		if err != nil {
+			errs <- err
+		}
+		close(errs)
+	}()
+	return items, errs
+}
+
+// All collects every item of the collection.
+func (p *Paginator) All(ctx context.Context) ([]interface{}, error) {
+	all := []interface{}{}
+	it := p.Iterate(ctx)
+	defer it.Close()
+	for it.Next() {
+		all = append(all, it.Item())
+	}
+	return all, it.Err()
+}
+
+// Iterate returns a pull-style iterator over the collection.  Close must be
+// called if iteration is abandoned before Next returns false.
+func (p *Paginator) Iterate(ctx context.Context) *PageIterator {
+	ctx, cancel := context.WithCancel(ctx)
+	items, errs := p.Items(ctx)
+	return &PageIterator{items: items, errs: errs, cancel: cancel}
+}
+
+func (p *Paginator) run(ctx context.Context, items chan<- interface{}) error {
+	if p.PageSize <= 0 {
+		return errors.Errorf("page size must be positive, got %d", p.PageSize)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	delivered := 0
+	deliver := func(page *Page) (bool, error) {
+		if p.OnPage != nil {
+			p.OnPage(page)
+		}
+		for _, item := range page.Items {
+			if p.MaxItems > 0 && delivered >= p.MaxItems {
+				return false, nil
+			}
+			select {
+			case items <- item:
+				delivered++
+			case <-ctx.Done():
+				return false, ctx.Err()
+			}
+		}
+		return p.MaxItems <= 0 || delivered < p.MaxItems, nil
+	}
+
+	offset := p.StartOffset
+	page, err := p.fetch(ctx, offset)
+	if err != nil {
+		return err
+	}
+	if p.Prefetch > 1 && page.Meta.Total > 0 {
+		if more, err := deliver(page); !more || err != nil {
+			return err
+		}
+		return p.runConcurrent(ctx, offset, page, deliver)
+	}
+	for {
+		more, err := deliver(page)
+		if !more || err != nil {
+			return err
+		}
+		if !hasNextPage(page, offset) {
+			return nil
+		}
+		offset += len(page.Items)
+		page, err = p.fetch(ctx, offset)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// runConcurrent fetches the pages after first by offset, keeping up to
+// Prefetch requests in flight and handing the results to deliver in order.
+func (p *Paginator) runConcurrent(ctx context.Context, offset int, first *Page, deliver func(*Page) (bool, error)) error {
+	type result struct {
+		page *Page
+		err  error
+	}
+	step := first.Meta.Limit
+	if step <= 0 {
+		step = p.PageSize
+	}
+	total := first.Meta.Total
+	end := total
+	if p.MaxItems > 0 && offset+p.MaxItems < end {
+		end = offset + p.MaxItems
+	}
+
+	pending := make(chan chan result, p.Prefetch)
+	go func() {
+		defer close(pending)
+		for next := offset + step; next < end; next += step {
+			resultChan := make(chan result, 1)
+			select {
+			case pending <- resultChan:
+			case <-ctx.Done():
+				return
+			}
+			go func(next int) {
+				page, err := p.fetch(ctx, next)
+				resultChan <- result{page: page, err: err}
+			}(next)
+		}
+	}()
+
+	for resultChan := range pending {
+		var r result
+		select {
+		case r = <-resultChan:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if r.err != nil {
+			return r.err
+		}
+		if more, err := deliver(r.page); !more || err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+func (p *Paginator) fetch(ctx context.Context, offset int) (*Page, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	log.Debugf("fetching page at offset %d, limit %d", offset, p.PageSize)
+	page, err := p.Fetch(ctx, offset, p.PageSize)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "unable to fetch page at offset %d, limit %d", offset, p.PageSize)
+	}
+	if page == nil {
+		return nil, errors.Errorf("nil page at offset %d, limit %d", offset, p.PageSize)
+	}
+	return page, nil
+}
+
+// hasNextPage prefers links.next, falling back to Meta.Total for responses that don't carry links.
+func hasNextPage(page *Page, offset int) bool {
+	if len(page.Items) == 0 {
+		return false
+	}
+	return page.Links.Next != "" || offset+len(page.Items) < page.Meta.Total
+}
+
+// PageIterator is a pull-style wrapper around Paginator.Items.
+type PageIterator struct {
+	items  <-chan interface{}
+	errs   <-chan error
+	cancel context.CancelFunc
+	item   interface{}
+	err    error
+}
+
+// Next advances to the next item, returning false once iteration is over.
+func (it *PageIterator) Next() bool {
+	item, ok := <-it.items
+	if !ok {
+		it.item = nil
+		it.err = <-it.errs
+		return false
+	}
+	it.item = item
+	return true
+}
+
+func (it *PageIterator) Item() interface{} {
+	return it.item
+}
+
+// Err returns the error that ended iteration, if any.
+func (it *PageIterator) Err() error {
+	return it.err
+}
+
+// Close stops any outstanding fetches.
+func (it *PageIterator) Close() {
+	it.cancel()
+}
+
+func boxItems(count int, item func(i int) interface{}) []interface{} {
+	items := make([]interface{}, count)
+	for i := range items {
+		items[i] = item(i)
+	}
+	return items
+}
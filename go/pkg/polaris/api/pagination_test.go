@@ -0,0 +1,165 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+// fakeCollection serves the integers [0, total) a page at a time
+type fakeCollection struct {
+	total     int
+	withTotal bool
+	failAt    int
+	mux       sync.Mutex
+	offsets   []int
+}
+
+func (fc *fakeCollection) fetch(ctx context.Context, offset int, limit int) (*Page, error) {
+	fc.mux.Lock()
+	fc.offsets = append(fc.offsets, offset)
+	fc.mux.Unlock()
+	if fc.failAt > 0 && offset >= fc.failAt {
+		return nil, errors.New("boom")
+	}
+	page := &Page{Meta: PageMeta{Offset: offset, Limit: limit}}
+	if fc.withTotal {
+		page.Meta.Total = fc.total
+	}
+	for i := offset; i < offset+limit && i < fc.total; i++ {
+		page.Items = append(page.Items, i)
+	}
+	if !fc.withTotal && offset+limit < fc.total {
+		page.Links.Next = fmt.Sprintf("/things?page[offset]=%d", offset+limit)
+	}
+	return page, nil
+}
+
+func expectSequence(items []interface{}, from int, to int) {
+	expected := []interface{}{}
+	for i := from; i < to; i++ {
+		expected = append(expected, i)
+	}
+	Expect(items).To(Equal(expected))
+}
+
+func RunPaginationTests() {
+	Describe("Paginator", func() {
+		It("should walk every page using Meta.Total", func() {
+			fc := &fakeCollection{total: 23, withTotal: true}
+			items, err := NewPaginator(fc.fetch, 5).All(context.Background())
+			Expect(err).To(BeNil())
+			expectSequence(items, 0, 23)
+			Expect(fc.offsets).To(Equal([]int{0, 5, 10, 15, 20}))
+		})
+
+		It("should follow links.next when there is no total", func() {
+			fc := &fakeCollection{total: 12}
+			items, err := NewPaginator(fc.fetch, 5).All(context.Background())
+			Expect(err).To(BeNil())
+			expectSequence(items, 0, 12)
+			Expect(fc.offsets).To(Equal([]int{0, 5, 10}))
+		})
+
+		It("should start at StartOffset and stop at MaxItems", func() {
+			fc := &fakeCollection{total: 100, withTotal: true}
+			paginator := NewPaginator(fc.fetch, 10)
+			paginator.StartOffset = 30
+			paginator.MaxItems = 15
+			items, err := paginator.All(context.Background())
+			Expect(err).To(BeNil())
+			expectSequence(items, 30, 45)
+			Expect(fc.offsets).To(Equal([]int{30, 40}))
+		})
+
+		It("should prefetch concurrently and still deliver in order", func() {
+			fc := &fakeCollection{total: 97, withTotal: true}
+			paginator := NewPaginator(fc.fetch, 4)
+			paginator.Prefetch = 5
+			pages := []int{}
+			paginator.OnPage = func(page *Page) {
+				pages = append(pages, page.Meta.Offset)
+			}
+			items, err := paginator.All(context.Background())
+			Expect(err).To(BeNil())
+			expectSequence(items, 0, 97)
+			Expect(len(fc.offsets)).To(Equal(25))
+			Expect(pages[len(pages)-1]).To(Equal(96))
+			for i := 1; i < len(pages); i++ {
+				Expect(pages[i]).To(Equal(pages[i-1] + 4))
+			}
+		})
+
+		It("should report fetch errors after the items before them", func() {
+			fc := &fakeCollection{total: 50, withTotal: true, failAt: 20}
+			for _, prefetch := range []int{1, 3} {
+				paginator := NewPaginator(fc.fetch, 10)
+				paginator.Prefetch = prefetch
+				items, err := paginator.All(context.Background())
+				Expect(err).ToNot(BeNil())
+				Expect(err.Error()).To(ContainSubstring("offset 20"))
+				expectSequence(items, 0, 20)
+			}
+		})
+
+		It("should stop when the iterator is closed", func() {
+			fc := &fakeCollection{total: 1000, withTotal: true}
+			paginator := NewPaginator(fc.fetch, 10)
+			paginator.Prefetch = 3
+			it := paginator.Iterate(context.Background())
+			Expect(it.Next()).To(BeTrue())
+			Expect(it.Item()).To(Equal(0))
+			it.Close()
+			for it.Next() {
+			}
+			Expect(it.Err()).To(Equal(context.Canceled))
+			Expect(len(fc.offsets)).To(BeNumerically("<", 100))
+		})
+
+		It("should reject a non-positive page size", func() {
+			fc := &fakeCollection{total: 3, withTotal: true}
+			_, err := NewPaginator(fc.fetch, 0).All(context.Background())
+			Expect(err).ToNot(BeNil())
+		})
+
+		It("should page through users from the API", func() {
+			server, client := newFakeServer()
+			defer server.Close()
+			for i := 0; i < 6; i++ {
+				_, err := client.CreateUser(fmt.Sprintf("user-%d@example.com", i), fmt.Sprintf("user %d", i), server.OrganizationId)
+				Expect(err).To(BeNil())
+			}
+
+			items, err := client.UsersPaginator(3).All(context.Background())
+			Expect(err).To(BeNil())
+			Expect(len(items)).To(Equal(7))
+			Expect(items[6].(*User).Attributes.Email).To(Equal("user-5@example.com"))
+			Expect(server.RequestCount(http.MethodGet, "/api/auth/users")).To(Equal(3))
+		})
+	})
+}
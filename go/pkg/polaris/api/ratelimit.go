@@ -0,0 +1,160 @@
+/*
+Copyright (C) 2018 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+)
+
+// RateLimit throttles requests with a token bucket and caps how many are in
+// flight at once.  Zero values don't limit.
+type RateLimit struct {
+	RequestsPerSecond float64
+	// Burst is how many requests may go at once after a quiet spell; defaults to 1
+	Burst       int
+	MaxInFlight int
+}
+
+// RateLimitConfig configures a client's throttling.  Each attempt, including
+// retries, counts against the Global limits and those of its path template.
+type RateLimitConfig struct {
+	Global RateLimit
+	// Paths is keyed by path template, e.g. "api/auth/role-assignments"
+	Paths map[string]RateLimit
+}
+
+// DefaultRateLimitConfig is gentle enough for tools run against production.
+func DefaultRateLimitConfig() *RateLimitConfig {
+	return &RateLimitConfig{
+		Global: RateLimit{RequestsPerSecond: 10, Burst: 10, MaxInFlight: 8},
+	}
+}
+
+type limiter struct {
+	rate  *rate.Limiter
+	slots chan struct{}
+}
+
+func newLimiter(limit RateLimit) *limiter {
+	l := &limiter{}
+	if limit.RequestsPerSecond > 0 {
+		burst := limit.Burst
+		if burst < 1 {
+			burst = 1
+		}
+		l.rate = rate.NewLimiter(rate.Limit(limit.RequestsPerSecond), burst)
+	}
+	if limit.MaxInFlight > 0 {
+		l.slots = make(chan struct{}, limit.MaxInFlight)
+	}
+	return l
+}
+
+func (l *limiter) wait(ctx context.Context) error {
+	if l == nil || l.rate == nil {
+		return nil
+	}
+	return l.rate.Wait(ctx)
+}
+
+func (l *limiter) acquire(ctx context.Context) error {
+	if l == nil || l.slots == nil {
+		return nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *limiter) release() {
+	if l == nil || l.slots == nil {
+		return
+	}
+	<-l.slots
+}
+
+// limitersFor returns the global limiter and the path template's, either of
+// which may be nil.  Limiters are created on first use, so changes to
+// client.RateLimit after that are ignored.
+func (client *Client) limitersFor(pathTemplate string) (*limiter, *limiter) {
+	if client.RateLimit == nil {
+		return nil, nil
+	}
+	client.limitersMux.Lock()
+	defer client.limitersMux.Unlock()
+	if client.globalLimiter == nil {
+		client.globalLimiter = newLimiter(client.RateLimit.Global)
+	}
+	limit, ok := client.RateLimit.Paths[pathTemplate]
+	if !ok {
+		return client.globalLimiter, nil
+	}
+	pathLimiter, ok := client.limiters[pathTemplate]
+	if !ok {
+		pathLimiter = newLimiter(limit)
+		client.limiters[pathTemplate] = pathLimiter
+	}
+	return client.globalLimiter, pathLimiter
+}
+
+// throttle waits until a request to pathTemplate may be sent.  Call the
+// returned function once the response is in.
+func (client *Client) throttle(ctx context.Context, method string, pathTemplate string) (func(), error) {
+	global, path := client.limitersFor(pathTemplate)
+	if global == nil && path == nil {
+		return func() {}, nil
+	}
+	start := time.Now()
+	if err := path.wait(ctx); err != nil {
+		return nil, errors.Wrapf(err, "gave up waiting for rate limit on %s %s", method, pathTemplate)
+	}
+	if err := global.wait(ctx); err != nil {
+		return nil, errors.Wrapf(err, "gave up waiting for rate limit on %s %s", method, pathTemplate)
+	}
+	// in-flight slots are taken path first, then global, so that no two
+	// requests can each hold a slot the other is waiting on
+	if err := path.acquire(ctx); err != nil {
+		return nil, errors.Wrapf(err, "gave up waiting for a request slot for %s %s", method, pathTemplate)
+	}
+	if err := global.acquire(ctx); err != nil {
+		path.release()
+		return nil, errors.Wrapf(err, "gave up waiting for a request slot for %s %s", method, pathTemplate)
+	}
+	if waited := time.Since(start); waited > time.Millisecond {
+		log.Debugf("throttled %s %s for %s", method, pathTemplate, waited)
+		recordEvent(fmt.Sprintf("%s_%s_throttled", method, pathTemplate), nil)
+		trace.SpanFromContext(ctx).AddEvent("throttled")
+	}
+	return func() {
+		global.release()
+		path.release()
+	}, nil
+}
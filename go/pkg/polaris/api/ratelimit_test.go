@@ -0,0 +1,112 @@
+/*
+Copyright (C) 2018 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func RunRateLimitTests() {
+	Describe("Rate limiting", func() {
+		var server *testServer
+		var inFlight, maxInFlight int32
+
+		BeforeEach(func() {
+			inFlight, maxInFlight = 0, 0
+			server = newTestServer(func(w http.ResponseWriter, r *http.Request) {
+				current := atomic.AddInt32(&inFlight, 1)
+				defer atomic.AddInt32(&inFlight, -1)
+				for {
+					seen := atomic.LoadInt32(&maxInFlight)
+					if current <= seen || atomic.CompareAndSwapInt32(&maxInFlight, seen, current) {
+						break
+					}
+				}
+				time.Sleep(20 * time.Millisecond)
+				w.Header().Set("Content-Type", "application/vnd.api+json")
+				w.Write([]byte(`{"data": []}`))
+			})
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		getConcurrently := func(client *Client, count int, pathTemplate string) {
+			var wg sync.WaitGroup
+			for i := 0; i < count; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer GinkgoRecover()
+					_, err := client.GetJson(NewQuery(), nil, pathTemplate)
+					Expect(err).To(BeNil())
+				}()
+			}
+			wg.Wait()
+		}
+
+		It("should cap requests in flight", func() {
+			client := NewBearerTokenClient(server.URL, "token")
+			client.RateLimit = &RateLimitConfig{Global: RateLimit{MaxInFlight: 2}}
+			getConcurrently(client, 8, "api/auth/users")
+			Expect(atomic.LoadInt32(&maxInFlight)).To(Equal(int32(2)))
+		})
+
+		It("should apply per-path limits on top of the global ones", func() {
+			client := NewBearerTokenClient(server.URL, "token")
+			client.RateLimit = &RateLimitConfig{
+				Global: RateLimit{MaxInFlight: 4},
+				Paths: map[string]RateLimit{
+					"api/auth/role-assignments": {RequestsPerSecond: 20, MaxInFlight: 1},
+				},
+			}
+			start := time.Now()
+			getConcurrently(client, 4, "api/auth/users")
+			Expect(time.Since(start)).To(BeNumerically("<", 150*time.Millisecond))
+
+			start = time.Now()
+			getConcurrently(client, 5, "api/auth/role-assignments")
+			// a burst of 1, then one every 50ms
+			Expect(time.Since(start)).To(BeNumerically(">=", 200*time.Millisecond))
+			Expect(atomic.LoadInt32(&maxInFlight)).To(BeNumerically("<=", 4))
+		})
+
+		It("should give up waiting when the context is done", func() {
+			client := NewBearerTokenClient(server.URL, "token")
+			client.RateLimit = &RateLimitConfig{Global: RateLimit{RequestsPerSecond: 0.1}}
+			_, err := client.GetJson(NewQuery(), nil, "api/auth/users")
+			Expect(err).To(BeNil())
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			_, err = client.GetJsonContext(ctx, NewQuery(), nil, "api/auth/users")
+			Expect(err).ToNot(BeNil())
+		})
+	})
+}
@@ -0,0 +1,146 @@
+/*
+Copyright (C) 2018 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api/fixtures"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// recording is true when the fixtures are being re-recorded against a live
+// Polaris rather than replayed.
+var recording = fixtures.Mode(os.Getenv(fixtures.ModeEnvVar)) == fixtures.ModeRecord
+
+// replayClient serves requests from a golden file in testdata.  To re-record
+// them, set POLARIS_FIXTURES=record and point POLARIS_URL, POLARIS_EMAIL and
+// POLARIS_PASSWORD at a live Polaris; the file is rewritten when the spec
+// finishes.  Ids come from earlier responses rather than being hardcoded, so
+// the calls work against any organization.  The assertions on values only
+// hold for the checked-in fixtures, and are skipped while recording.
+func replayClient(name string, transports *[]*fixtures.Transport) *Client {
+	transport, err := fixtures.NewTransportFromEnv(filepath.Join("testdata", name))
+	Expect(err).To(BeNil())
+	*transports = append(*transports, transport)
+	url, email, password := "https://polaris.example.com", "ada@example.com", "password"
+	if recording {
+		url, email, password = os.Getenv("POLARIS_URL"), os.Getenv("POLARIS_EMAIL"), os.Getenv("POLARIS_PASSWORD")
+		Expect(url).ToNot(BeEmpty(), "POLARIS_URL is required to record fixtures")
+	}
+	client := NewClient(url, email, password)
+	transport.Attach(client.RestyClient)
+	Expect(client.Authenticate()).To(Succeed())
+	return client
+}
+
+func RunReplayTests() {
+	Describe("Recorded responses", func() {
+		var transports []*fixtures.Transport
+
+		AfterEach(func() {
+			for _, transport := range transports {
+				Expect(transport.Save()).To(Succeed())
+			}
+			transports = nil
+		})
+
+		It("should decode auth responses", func() {
+			client := replayClient("auth.json", &transports)
+
+			users, err := client.GetUsers(0, 25)
+			Expect(err).To(BeNil())
+			roles, err := client.GetRoles()
+			Expect(err).To(BeNil())
+			assignments, err := client.GetRoleAssignments(0, 25)
+			Expect(err).To(BeNil())
+			orgs, err := client.GetOrganizations()
+			Expect(err).To(BeNil())
+			Expect(orgs.Data).ToNot(BeEmpty())
+			entitlements, err := client.GetEntitlementsForOrganization(orgs.Data[0].Id)
+			Expect(err).To(BeNil())
+			token, err := client.GetAccessToken("fixtures")
+			Expect(err).To(BeNil())
+			Expect(token.Data.Attributes.AccessToken).ToNot(BeEmpty())
+			if recording {
+				return
+			}
+
+			Expect(users.Meta.Total).To(Equal(2))
+			Expect(users.Data[1].Attributes.Automated).To(BeTrue())
+			Expect(roles.Data[0].Attributes.RoleName).To(Equal("Administrator"))
+			Expect(assignments.Data[0].Relationships["role"].Data.Identifiers()[0].Id).To(Equal("role-1"))
+			Expect(orgs.Data[0].Id).To(Equal("org-1"))
+			Expect(entitlements.Data[0].Attributes.Allowed).To(ContainElement("administer"))
+			Expect(token.Data.Attributes.AccessToken).To(Equal(fixtures.Redacted))
+		})
+
+		It("should decode project and job responses", func() {
+			client := replayClient("projects.json", &transports)
+
+			projects, err := client.GetProjects(25)
+			Expect(err).To(BeNil())
+			vinyl, err := client.GetVinylV0Projects(0, 25)
+			Expect(err).To(BeNil())
+			Expect(vinyl.Data).ToNot(BeEmpty())
+			branch := vinyl.Included.ResolveOne(vinyl.Data[0].Relationships["main-branch"])
+			Expect(branch).ToNot(BeNil())
+			jobs, err := client.GetJobs(1)
+			Expect(err).To(BeNil())
+			Expect(jobs.Data).ToNot(BeEmpty())
+			job, err := client.GetJob(jobs.Data[0].Id)
+			Expect(err).To(BeNil())
+			Expect(job.Data.Id).To(Equal(jobs.Data[0].Id))
+			if recording {
+				return
+			}
+
+			Expect(projects.Data[0].Attributes.Name).To(Equal("cerebros"))
+			Expect(branch.Id).To(Equal("branch-1"))
+			Expect(job.Data.Attributes.Status.State).To(Equal(JobStateCompleted))
+			Expect(job.Data.IsTerminal()).To(BeTrue())
+		})
+
+		It("should decode issue responses", func() {
+			client := replayClient("issues.json", &transports)
+
+			vinyl, err := client.GetVinylV0Projects(0, 25)
+			Expect(err).To(BeNil())
+			Expect(vinyl.Data).ToNot(BeEmpty())
+			projectId := vinyl.Data[0].Id
+			branchId := vinyl.Data[0].Relationships["main-branch"].Data.Identifiers()[0].Id
+
+			issues, err := client.GetV1Issues(projectId, branchId, "", 0, 25)
+			Expect(err).To(BeNil())
+			counts, err := client.GetV1RollUpCounts(&RollUpCountsQuery{ProjectId: projectId, BranchId: branchId, GroupBy: []string{GroupByIssueSeverity}, Limit: 100})
+			Expect(err).To(BeNil())
+			if recording {
+				return
+			}
+
+			Expect(issues.Data[0].Attributes.IssueKey).To(Equal("ik-1"))
+			Expect(len(counts.Data)).To(Equal(2))
+			Expect(counts.Data[0].Attributes.Value).To(Equal(12))
+		})
+	})
+}
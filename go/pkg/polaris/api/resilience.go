@@ -0,0 +1,298 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrCircuitOpen is returned, wrapped, for requests that an open circuit breaker
+// refused to send.  Check for it with errors.Cause.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// RetryPolicy controls retries of failed requests.  GETs are retried on
+// transport errors and 429/502/503/504 responses; POSTs aren't idempotent, so
+// they're only retried on 429, which means the server didn't process them.
+// A Retry-After header replaces the backoff, unless it asks for a longer wait
+// than MaxBackoff, in which case the request isn't retried.
+type RetryPolicy struct {
+	// MaxAttempts includes the first attempt
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+	}
+}
+
+// backoff returns the jittered delay before retrying after the given attempt (1-based).
+func (rp *RetryPolicy) backoff(attempt int) time.Duration {
+	delay := rp.InitialBackoff
+	for i := 1; i < attempt && delay < rp.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > rp.MaxBackoff {
+		delay = rp.MaxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+	// equal jitter: somewhere between half and all of the exponential delay
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+func shouldRetry(method string, resp *resty.Response, err error) bool {
+	if err != nil {
+		return method == resty.MethodGet
+	}
+	switch resp.StatusCode() {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return method == resty.MethodGet
+	default:
+		return false
+	}
+}
+
+// retryAfter parses a Retry-After header, given either in seconds or as an HTTP date.
+func retryAfter(resp *resty.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	header := resp.Header().Get("Retry-After")
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// CircuitBreakerConfig configures the per-endpoint circuit breakers.  After
+// FailureThreshold consecutive failures (transport errors or 5xx responses) an
+// endpoint's breaker opens and requests to it fail fast with ErrCircuitOpen.
+// After OpenDuration a single trial request is let through: success closes
+// the breaker, failure opens it again.
+type CircuitBreakerConfig struct {
+	FailureThreshold int
+	OpenDuration     time.Duration
+}
+
+func DefaultCircuitBreakerConfig() *CircuitBreakerConfig {
+	return &CircuitBreakerConfig{
+		FailureThreshold: 10,
+		OpenDuration:     30 * time.Second,
+	}
+}
+
+// UseDefaultResilience turns on retries and circuit breakers with their default settings.
+// They're off unless asked for, so that load tests see every failure.
+func (client *Client) UseDefaultResilience() {
+	client.Retry = DefaultRetryPolicy()
+	client.CircuitBreaker = DefaultCircuitBreakerConfig()
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (bs breakerState) String() string {
+	switch bs {
+	case breakerClosed:
+		return "closed"
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half_open"
+	}
+	return fmt.Sprintf("unknown(%d)", int(bs))
+}
+
+type breakerOutcome int
+
+const (
+	outcomeSuccess breakerOutcome = iota
+	outcomeFailure
+	// outcomeIgnored is for attempts abandoned by the caller, which say nothing about the endpoint
+	outcomeIgnored
+)
+
+type circuitBreaker struct {
+	name     string
+	config   CircuitBreakerConfig
+	mux      *sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+func newCircuitBreaker(name string, config CircuitBreakerConfig) *circuitBreaker {
+	return &circuitBreaker{name: name, config: config, mux: &sync.Mutex{}, state: breakerClosed}
+}
+
+func (cb *circuitBreaker) setState(state breakerState) {
+	if cb.state == state {
+		return
+	}
+	log.Infof("circuit breaker for %s: %s -> %s", cb.name, cb.state, state)
+	cb.state = state
+	recordEvent(fmt.Sprintf("%s_circuit_%s", cb.name, state), nil)
+}
+
+func (cb *circuitBreaker) allow(now time.Time) bool {
+	cb.mux.Lock()
+	defer cb.mux.Unlock()
+	switch cb.state {
+	case breakerOpen:
+		if now.Sub(cb.openedAt) < cb.config.OpenDuration {
+			return false
+		}
+		cb.setState(breakerHalfOpen)
+		cb.trial = true
+		return true
+	case breakerHalfOpen:
+		// only one trial request at a time
+		if cb.trial {
+			return false
+		}
+		cb.trial = true
+		return true
+	default:
+		return true
+	}
+}
+
+func (cb *circuitBreaker) record(outcome breakerOutcome, now time.Time) {
+	cb.mux.Lock()
+	defer cb.mux.Unlock()
+	if cb.state == breakerHalfOpen {
+		cb.trial = false
+	}
+	switch outcome {
+	case outcomeSuccess:
+		cb.failures = 0
+		cb.setState(breakerClosed)
+	case outcomeFailure:
+		cb.failures++
+		if cb.state == breakerHalfOpen || cb.failures >= cb.config.FailureThreshold {
+			cb.openedAt = now
+			cb.setState(breakerOpen)
+		}
+	}
+}
+
+func breakerOutcomeOf(ctx context.Context, resp *resty.Response, err error) breakerOutcome {
+	if ctx.Err() != nil {
+		return outcomeIgnored
+	}
+	if err != nil || resp.StatusCode() >= 500 {
+		return outcomeFailure
+	}
+	return outcomeSuccess
+}
+
+func (client *Client) breaker(method string, pathTemplate string) *circuitBreaker {
+	if client.CircuitBreaker == nil {
+		return nil
+	}
+	name := fmt.Sprintf("%s_%s", method, pathTemplate)
+	client.breakersMux.Lock()
+	defer client.breakersMux.Unlock()
+	cb, ok := client.breakers[name]
+	if !ok {
+		cb = newCircuitBreaker(name, *client.CircuitBreaker)
+		client.breakers[name] = cb
+	}
+	return cb
+}
+
+// sendWithRetry sends requests built by newRequest through the endpoint's
+// circuit breaker, retrying according to client.Retry.
+func (client *Client) sendWithRetry(ctx context.Context, method string, url string, pathTemplate string, newRequest func() *resty.Request) (*resty.Response, error) {
+	breaker := client.breaker(method, pathTemplate)
+	maxAttempts := 1
+	if client.Retry != nil && client.Retry.MaxAttempts > 1 {
+		maxAttempts = client.Retry.MaxAttempts
+	}
+	for attempt := 1; ; attempt++ {
+		if breaker != nil && !breaker.allow(time.Now()) {
+			recordEvent(fmt.Sprintf("%s_%s_circuit_rejected", method, pathTemplate), ErrCircuitOpen)
+			// 0 marks requests that were never sent
+			recordResponseStatusCode(method, pathTemplate, 0)
+			return nil, errors.WithMessagef(ErrCircuitOpen, "not sending %s %s", method, url)
+		}
+		resp, err := client.send(ctx, method, url, pathTemplate, newRequest())
+		if breaker != nil {
+			breaker.record(breakerOutcomeOf(ctx, resp, err), time.Now())
+		}
+		if attempt >= maxAttempts || ctx.Err() != nil || !shouldRetry(method, resp, err) {
+			return resp, err
+		}
+
+		wait := client.Retry.backoff(attempt)
+		if after, ok := retryAfter(resp); ok {
+			if after > client.Retry.MaxBackoff {
+				log.Debugf("not retrying %s %s: Retry-After of %s is longer than the maximum backoff of %s", method, url, after, client.Retry.MaxBackoff)
+				return resp, err
+			}
+			wait = after
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+			return resp, err
+		}
+		log.Debugf("retrying %s %s in %s (attempt %d of %d)", method, url, wait, attempt+1, maxAttempts)
+		recordEvent(fmt.Sprintf("%s_%s_retry", method, pathTemplate), err)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return resp, err
+		}
+	}
+}
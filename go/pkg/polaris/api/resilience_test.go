@@ -0,0 +1,187 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+// serveFailures answers with status while *failures is positive, counting it
+// down, and with an empty collection after that
+func serveFailures(failures *int32, status int, retryAfter string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(failures, -1) >= 0 {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			return
+		}
+		fmt.Fprint(w, `{"data":[]}`)
+	}
+}
+
+func newResilienceTestClient(url string) *Client {
+	client := NewBearerTokenClient(url, "token")
+	client.Retry = &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+	client.CircuitBreaker = nil
+	return client
+}
+
+func RunResilienceTests() {
+	Describe("Retries", func() {
+		It("should retry GETs on 503", func() {
+			failures := int32(2)
+			server := newTestServer(serveFailures(&failures, http.StatusServiceUnavailable, ""))
+			defer server.Close()
+			_, err := newResilienceTestClient(server.URL).GetUsers(0, 10)
+			Expect(err).To(BeNil())
+			Expect(server.count("", "")).To(Equal(3))
+		})
+
+		It("should give up after MaxAttempts", func() {
+			failures := int32(5)
+			server := newTestServer(serveFailures(&failures, http.StatusBadGateway, ""))
+			defer server.Close()
+			_, err := newResilienceTestClient(server.URL).GetUsers(0, 10)
+			Expect(err).ToNot(BeNil())
+			Expect(server.count("", "")).To(Equal(3))
+		})
+
+		It("should only retry POSTs on 429", func() {
+			failures := int32(1)
+			server := newTestServer(serveFailures(&failures, http.StatusServiceUnavailable, ""))
+			defer server.Close()
+			client := newResilienceTestClient(server.URL)
+			_, err := client.PostTools()
+			Expect(err).ToNot(BeNil())
+			Expect(server.count("", "")).To(Equal(1))
+
+			throttledFailures := int32(1)
+			throttled := newTestServer(serveFailures(&throttledFailures, http.StatusTooManyRequests, ""))
+			defer throttled.Close()
+			_, err = newResilienceTestClient(throttled.URL).PostTools()
+			Expect(err).To(BeNil())
+			Expect(throttled.count("", "")).To(Equal(2))
+		})
+
+		It("should honor Retry-After", func() {
+			failures := int32(1)
+			server := newTestServer(serveFailures(&failures, http.StatusTooManyRequests, "1"))
+			defer server.Close()
+			client := newResilienceTestClient(server.URL)
+			client.Retry.MaxBackoff = 2 * time.Second
+			start := time.Now()
+			_, err := client.GetUsers(0, 10)
+			Expect(err).To(BeNil())
+			Expect(time.Since(start)).To(BeNumerically(">=", time.Second))
+		})
+
+		It("should give up when Retry-After is longer than the maximum backoff", func() {
+			failures := int32(1)
+			server := newTestServer(serveFailures(&failures, http.StatusTooManyRequests, "3600"))
+			defer server.Close()
+			start := time.Now()
+			_, err := newResilienceTestClient(server.URL).GetUsers(0, 10)
+			Expect(err).ToNot(BeNil())
+			Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+			Expect(server.count("", "")).To(Equal(1))
+		})
+
+		It("should be off unless asked for", func() {
+			failures := int32(1)
+			server := newTestServer(serveFailures(&failures, http.StatusServiceUnavailable, ""))
+			defer server.Close()
+			client := NewBearerTokenClient(server.URL, "token")
+			Expect(client.Retry).To(BeNil())
+			Expect(client.CircuitBreaker).To(BeNil())
+			_, err := client.GetUsers(0, 10)
+			Expect(err).ToNot(BeNil())
+			Expect(server.count("", "")).To(Equal(1))
+
+			client.UseDefaultResilience()
+			Expect(client.Retry).To(Equal(DefaultRetryPolicy()))
+			Expect(client.CircuitBreaker).To(Equal(DefaultCircuitBreakerConfig()))
+		})
+
+		It("should keep jittered backoff within bounds", func() {
+			policy := &RetryPolicy{MaxAttempts: 10, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+			for attempt := 1; attempt < 10; attempt++ {
+				backoff := policy.backoff(attempt)
+				Expect(backoff).To(BeNumerically(">=", 50*time.Millisecond))
+				Expect(backoff).To(BeNumerically("<=", time.Second))
+			}
+		})
+	})
+
+	Describe("Circuit breaker", func() {
+		It("should open after sustained failures and recover after OpenDuration", func() {
+			failures := int32(1000)
+			server := newTestServer(serveFailures(&failures, http.StatusInternalServerError, ""))
+			defer server.Close()
+			client := newResilienceTestClient(server.URL)
+			client.Retry = nil
+			client.CircuitBreaker = &CircuitBreakerConfig{FailureThreshold: 3, OpenDuration: 100 * time.Millisecond}
+
+			for i := 0; i < 3; i++ {
+				_, err := client.GetUsers(0, 10)
+				Expect(err).ToNot(BeNil())
+			}
+			_, err := client.GetUsers(0, 10)
+			Expect(errors.Cause(err)).To(Equal(ErrCircuitOpen))
+			Expect(server.count("", "")).To(Equal(3))
+
+			// other endpoints have their own breakers
+			_, err = client.GetRoles()
+			Expect(errors.Cause(err)).ToNot(Equal(ErrCircuitOpen))
+
+			atomic.StoreInt32(&failures, 0)
+			time.Sleep(150 * time.Millisecond)
+			_, err = client.GetUsers(0, 10)
+			Expect(err).To(BeNil())
+			_, err = client.GetUsers(0, 10)
+			Expect(err).To(BeNil())
+		})
+
+		It("should reopen when the half-open trial fails", func() {
+			cb := newCircuitBreaker("test", CircuitBreakerConfig{FailureThreshold: 1, OpenDuration: time.Minute})
+			now := time.Now()
+			Expect(cb.allow(now)).To(BeTrue())
+			cb.record(outcomeFailure, now)
+			Expect(cb.allow(now)).To(BeFalse())
+
+			later := now.Add(2 * time.Minute)
+			Expect(cb.allow(later)).To(BeTrue())
+			// only one trial at a time
+			Expect(cb.allow(later)).To(BeFalse())
+			cb.record(outcomeFailure, later)
+			Expect(cb.state).To(Equal(breakerOpen))
+			Expect(cb.allow(later.Add(time.Second))).To(BeFalse())
+		})
+	})
+}
@@ -20,54 +20,74 @@ under the License.
 */
 package api
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
-type GetTaxonomiesResponse struct {
-	Data []struct {
-		TaxonomyType   string `json:"taxonomy-type"`
-		Id             string
-		OptimisticLock string `json:"optimistic-lock"`
-		Taxonomy       struct {
-			Taxa []struct {
-				Id   string
-				Name struct {
-					En string
-				}
-				// TODO lots more stuff
-			}
-			Name struct {
-				En string
-			}
-			Description struct {
-				En string
-			}
-			Abbreviation struct {
-				En string
-			}
-			// TODO extra
-			// TODO depends-on
-			RootTaxa []string `json:"root-taxa"`
-		}
-	}
-	Meta struct {
-		Total  int
-		Offset int
-		Limit  int
+// LocalizedString is text in each language the taxonomy service has it in.
+type LocalizedString struct {
+	En string
+}
+
+// Taxon is one entry of a taxonomy, such as a CWE weakness or an OWASP Top 10 category.
+type Taxon struct {
+	Id           string
+	Name         LocalizedString
+	Description  LocalizedString
+	Abbreviation LocalizedString
+	// Children are the ids of the taxa directly below this one, in the same taxonomy
+	Children []string
+	// RelatedTaxa are the ids of taxa in other taxonomies that correspond to
+	// this one, such as the CWE weaknesses an OWASP category covers
+	RelatedTaxa []string `json:"related-taxa"`
+	Extra       map[string]interface{}
+}
+
+type Taxonomy struct {
+	TaxonomyType   string `json:"taxonomy-type"`
+	Id             string
+	OptimisticLock string `json:"optimistic-lock"`
+	Taxonomy       struct {
+		Taxa         []*Taxon
+		Name         LocalizedString
+		Description  LocalizedString
+		Abbreviation LocalizedString
+		Extra        map[string]interface{}
+		// DependsOn are the ids of the taxonomies this one's related taxa are in
+		DependsOn []string `json:"depends-on"`
+		RootTaxa  []string `json:"root-taxa"`
 	}
 }
 
-func (client *Client) GetTaxonomies(tenantId *string, pageLimit int) (*GetTaxonomiesResponse, error) {
+type GetTaxonomiesResponse struct {
+	Data  []*Taxonomy
+	Meta  PageMeta
+	Links PageLinks
+}
+
+// GetTaxonomies lists the taxonomies of the organization on ctx or, failing
+// that, the client's.  Without either, only the global taxonomies are listed.
+func (client *Client) GetTaxonomies(pageLimit int) (*GetTaxonomiesResponse, error) {
+	return client.GetTaxonomiesContext(context.Background(), pageLimit)
+}
+
+func (client *Client) GetTaxonomiesContext(ctx context.Context, pageLimit int) (*GetTaxonomiesResponse, error) {
 	result := &GetTaxonomiesResponse{}
 	params := map[string]interface{}{
 		"page[limit]": fmt.Sprintf("%d", pageLimit),
 	}
-	if tenantId != nil {
-		params["tenant-id"] = *tenantId
+	if orgId := client.currentOrganization(ctx); orgId != "" {
+		params["tenant-id"] = orgId
 	}
-	_, err := client.GetRawJson(params, result, "api/taxonomy/v0/taxonomies")
+	_, err := client.GetRawJsonContext(ctx, params, result, "api/taxonomy/v0/taxonomies")
 	return result, err
 }
 
 func (client *Client) GetTaxonomyCount() (*GetTaxonomiesResponse, error) {
-	return client.GetTaxonomies(nil, 0)
+	return client.GetTaxonomyCountContext(context.Background())
+}
+
+func (client *Client) GetTaxonomyCountContext(ctx context.Context) (*GetTaxonomiesResponse, error) {
+	return client.GetTaxonomiesContext(ctx, 0)
 }
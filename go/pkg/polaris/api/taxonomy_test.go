@@ -0,0 +1,133 @@
+/*
+Copyright (C) 2018 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const taxonomiesJson = `{
+	"data": [
+		{"taxonomy-type": "cwe", "id": "cwe", "optimistic-lock": "3", "taxonomy": {
+			"name": {"en": "CWE"},
+			"depends-on": [],
+			"extra": {"version": "4.1"},
+			"root-taxa": ["cwe-707"],
+			"taxa": [
+				{"id": "cwe-707", "name": {"en": "Improper Neutralization"}, "children": ["cwe-74", "cwe-20"]},
+				{"id": "cwe-74", "name": {"en": "Injection"}, "children": ["cwe-79", "cwe-89"]},
+				{"id": "cwe-20", "name": {"en": "Improper Input Validation"}, "children": ["cwe-79"]},
+				{"id": "cwe-79", "name": {"en": "Cross-site Scripting"}, "extra": {"likelihood": "high"}},
+				{"id": "cwe-89", "name": {"en": "SQL Injection"}, "children": ["cwe-missing"]}
+			]}},
+		{"taxonomy-type": "owasp", "id": "owasp-2017", "optimistic-lock": "1", "taxonomy": {
+			"name": {"en": "OWASP Top 10 2017"},
+			"depends-on": ["cwe"],
+			"root-taxa": ["owasp-2017"],
+			"taxa": [
+				{"id": "owasp-2017", "name": {"en": "OWASP Top 10 2017"}, "children": ["a1", "a7"]},
+				{"id": "a1", "abbreviation": {"en": "A1"}, "related-taxa": ["cwe-74"]},
+				{"id": "a7", "abbreviation": {"en": "A7"}, "related-taxa": ["cwe-79"]}
+			]}},
+		{"taxonomy-type": "severity", "id": "severity", "taxonomy": {
+			"taxa": [{"id": "high"}, {"id": "low"}]}}
+	],
+	"meta": {"total": 3, "offset": 0, "limit": 3}}`
+
+func taxonIds(nodes []*TaxonNode) []string {
+	ids := []string{}
+	for _, node := range nodes {
+		ids = append(ids, node.Taxon.Id)
+	}
+	return ids
+}
+
+func RunTaxonomyTests() {
+	Describe("Taxonomy tree", func() {
+		var tree *TaxonomyTree
+
+		BeforeEach(func() {
+			response := &GetTaxonomiesResponse{}
+			Expect(json.Unmarshal([]byte(taxonomiesJson), response)).To(Succeed())
+			tree = NewTaxonomyTree(response.Data)
+		})
+
+		It("should decode taxa", func() {
+			xss := tree.Taxon("cwe-79")
+			Expect(xss.Taxon.Name.En).To(Equal("Cross-site Scripting"))
+			Expect(xss.Taxon.Extra["likelihood"]).To(Equal("high"))
+			Expect(xss.Taxonomy.Id).To(Equal("cwe"))
+			Expect(tree.TaxonomyOfType("owasp").Taxonomy.DependsOn).To(Equal([]string{"cwe"}))
+			Expect(tree.Taxonomy("cwe").Taxonomy.Extra["version"]).To(Equal("4.1"))
+			Expect(tree.Taxon("cwe-missing")).To(BeNil())
+		})
+
+		It("should find roots, ancestors and descendants", func() {
+			Expect(taxonIds(tree.Roots("cwe"))).To(Equal([]string{"cwe-707"}))
+			Expect(taxonIds(tree.Roots("severity"))).To(Equal([]string{"high", "low"}))
+			Expect(taxonIds(tree.Ancestors("cwe-79"))).To(Equal([]string{"cwe-74", "cwe-20", "cwe-707"}))
+			Expect(taxonIds(tree.Descendants("cwe-707"))).To(Equal([]string{"cwe-74", "cwe-20", "cwe-79", "cwe-89"}))
+			Expect(tree.Ancestors("nope")).To(BeEmpty())
+		})
+
+		It("should map taxa to categories of another taxonomy", func() {
+			Expect(taxonIds(tree.Taxon("cwe-79").Related)).To(Equal([]string{"a7"}))
+			Expect(taxonIds(tree.Categorize("cwe-79", "owasp-2017"))).To(Equal([]string{"a7", "owasp-2017", "a1"}))
+			Expect(taxonIds(tree.Categorize("cwe-89", "owasp-2017"))).To(Equal([]string{"a1", "owasp-2017"}))
+			Expect(taxonIds(tree.Categorize("a7", "owasp-2017"))).To(Equal([]string{"a7", "owasp-2017"}))
+			Expect(tree.Categorize("cwe-707", "owasp-2017")).To(BeEmpty())
+		})
+
+		It("should load each organization's taxonomies once", func() {
+			server, client := newFakeServer()
+			defer server.Close()
+			loads := func() int { return server.RequestCount(http.MethodGet, "/api/taxonomy/v0/taxonomies") }
+
+			first, err := client.LoadTaxonomyTree()
+			Expect(err).To(BeNil())
+			Expect(first.Taxonomy("severity")).ToNot(BeNil())
+			requestsPerLoad := loads()
+			again, err := client.LoadTaxonomyTree()
+			Expect(err).To(BeNil())
+			Expect(again).To(BeIdenticalTo(first))
+			Expect(loads()).To(Equal(requestsPerLoad))
+
+			client.SetOrganizationId(server.OrganizationId)
+			_, err = client.LoadTaxonomyTree()
+			Expect(err).To(BeNil())
+			Expect(loads()).To(Equal(2 * requestsPerLoad))
+			// the fake rejects unknown tenants, so this shows the organization is sent
+			client.SetOrganizationId("no-such-org")
+			_, err = client.LoadTaxonomyTree()
+			Expect(err).ToNot(BeNil())
+
+			client.SetOrganizationId("")
+			client.ForgetTaxonomyTrees()
+			reloaded, err := client.LoadTaxonomyTree()
+			Expect(err).To(BeNil())
+			Expect(reloaded).ToNot(BeIdenticalTo(first))
+		})
+	})
+}
@@ -0,0 +1,257 @@
+/*
+Copyright (C) 2018 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// TaxonNode is a taxon in a TaxonomyTree, linked to its neighbors.  Most
+// taxonomies are trees, but some, like CWE, let a taxon have several parents.
+type TaxonNode struct {
+	Taxon    *Taxon
+	Taxonomy *Taxonomy
+	Parents  []*TaxonNode
+	Children []*TaxonNode
+	// Related are the taxa in other taxonomies that correspond to this one,
+	// whichever side of the relation listed it
+	Related []*TaxonNode
+}
+
+// TaxonomyTree indexes a set of taxonomies for lookups and navigation.  It's
+// read-only once built, so it's safe to share between goroutines.
+type TaxonomyTree struct {
+	taxonomies []*Taxonomy
+	nodes      map[string]*TaxonNode
+	roots      map[string][]*TaxonNode
+}
+
+// NewTaxonomyTree links up the taxa of taxonomies.  Taxon ids are expected to
+// be unique across taxonomies; only the first of any duplicates is kept.
+// References to taxa that aren't there are skipped.
+func NewTaxonomyTree(taxonomies []*Taxonomy) *TaxonomyTree {
+	tree := &TaxonomyTree{
+		taxonomies: taxonomies,
+		nodes:      map[string]*TaxonNode{},
+		roots:      map[string][]*TaxonNode{},
+	}
+	for _, taxonomy := range taxonomies {
+		for _, taxon := range taxonomy.Taxonomy.Taxa {
+			if existing, ok := tree.nodes[taxon.Id]; ok {
+				log.Warnf("taxon %s is in taxonomies %s and %s; ignoring the second", taxon.Id, existing.Taxonomy.Id, taxonomy.Id)
+				continue
+			}
+			tree.nodes[taxon.Id] = &TaxonNode{Taxon: taxon, Taxonomy: taxonomy}
+		}
+	}
+	for _, taxonomy := range taxonomies {
+		for _, taxon := range taxonomy.Taxonomy.Taxa {
+			node := tree.nodes[taxon.Id]
+			if node.Taxon != taxon {
+				continue
+			}
+			for _, childId := range taxon.Children {
+				if child, ok := tree.nodes[childId]; ok {
+					node.Children = append(node.Children, child)
+					child.Parents = append(child.Parents, node)
+				} else {
+					log.Debugf("taxon %s has unknown child %s", taxon.Id, childId)
+				}
+			}
+			for _, relatedId := range taxon.RelatedTaxa {
+				if related, ok := tree.nodes[relatedId]; ok {
+					node.Related = appendNode(node.Related, related)
+					related.Related = appendNode(related.Related, node)
+				} else {
+					log.Debugf("taxon %s has unknown related taxon %s", taxon.Id, relatedId)
+				}
+			}
+		}
+		roots := []*TaxonNode{}
+		for _, rootId := range taxonomy.Taxonomy.RootTaxa {
+			if root, ok := tree.nodes[rootId]; ok {
+				roots = append(roots, root)
+			}
+		}
+		tree.roots[taxonomy.Id] = roots
+	}
+	// taxonomies that don't list their roots get the taxa without parents
+	for _, taxonomy := range taxonomies {
+		if len(taxonomy.Taxonomy.RootTaxa) > 0 {
+			continue
+		}
+		for _, taxon := range taxonomy.Taxonomy.Taxa {
+			if node := tree.nodes[taxon.Id]; node.Taxon == taxon && len(node.Parents) == 0 {
+				tree.roots[taxonomy.Id] = append(tree.roots[taxonomy.Id], node)
+			}
+		}
+	}
+	return tree
+}
+
+func appendNode(nodes []*TaxonNode, node *TaxonNode) []*TaxonNode {
+	for _, existing := range nodes {
+		if existing == node {
+			return nodes
+		}
+	}
+	return append(nodes, node)
+}
+
+func (tree *TaxonomyTree) Taxonomies() []*Taxonomy {
+	return tree.taxonomies
+}
+
+// Taxonomy finds a taxonomy by id, returning nil if there's no such taxonomy.
+func (tree *TaxonomyTree) Taxonomy(taxonomyId string) *Taxonomy {
+	for _, taxonomy := range tree.taxonomies {
+		if taxonomy.Id == taxonomyId {
+			return taxonomy
+		}
+	}
+	return nil
+}
+
+// TaxonomyOfType finds the first taxonomy of a type, such as "cwe", returning nil if there's none.
+func (tree *TaxonomyTree) TaxonomyOfType(taxonomyType string) *Taxonomy {
+	for _, taxonomy := range tree.taxonomies {
+		if taxonomy.TaxonomyType == taxonomyType {
+			return taxonomy
+		}
+	}
+	return nil
+}
+
+// Roots returns the top level taxa of a taxonomy.
+func (tree *TaxonomyTree) Roots(taxonomyId string) []*TaxonNode {
+	return tree.roots[taxonomyId]
+}
+
+// Taxon finds a taxon by id, returning nil if there's no such taxon.
+func (tree *TaxonomyTree) Taxon(taxonId string) *TaxonNode {
+	return tree.nodes[taxonId]
+}
+
+// Ancestors returns the taxa above a taxon in its taxonomy, nearest first.
+func (tree *TaxonomyTree) Ancestors(taxonId string) []*TaxonNode {
+	return walk(tree.nodes[taxonId], func(node *TaxonNode) []*TaxonNode { return node.Parents })
+}
+
+// Descendants returns the taxa below a taxon in its taxonomy, nearest first.
+func (tree *TaxonomyTree) Descendants(taxonId string) []*TaxonNode {
+	return walk(tree.nodes[taxonId], func(node *TaxonNode) []*TaxonNode { return node.Children })
+}
+
+// walk visits the nodes reachable from start breadth first, each once, leaving out start
+func walk(start *TaxonNode, next func(node *TaxonNode) []*TaxonNode) []*TaxonNode {
+	if start == nil {
+		return nil
+	}
+	seen := map[*TaxonNode]bool{start: true}
+	found := []*TaxonNode{}
+	queue := []*TaxonNode{start}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, neighbor := range next(node) {
+			if !seen[neighbor] {
+				seen[neighbor] = true
+				found = append(found, neighbor)
+				queue = append(queue, neighbor)
+			}
+		}
+	}
+	return found
+}
+
+// Categorize finds the taxa of a taxonomy that a taxon falls under, such as
+// the OWASP categories of a CWE weakness: those related to the taxon or to any
+// of its ancestors, along with their own ancestors.  Taxa of the taxonomy
+// itself categorize as themselves and their ancestors.
+func (tree *TaxonomyTree) Categorize(taxonId string, taxonomyId string) []*TaxonNode {
+	start := tree.nodes[taxonId]
+	if start == nil {
+		return nil
+	}
+	seen := map[*TaxonNode]bool{}
+	categories := []*TaxonNode{}
+	add := func(node *TaxonNode) {
+		if node.Taxonomy.Id == taxonomyId && !seen[node] {
+			seen[node] = true
+			categories = append(categories, node)
+		}
+	}
+	lineage := append([]*TaxonNode{start}, tree.Ancestors(taxonId)...)
+	for _, node := range lineage {
+		add(node)
+		for _, related := range node.Related {
+			add(related)
+			for _, ancestor := range tree.Ancestors(related.Taxon.Id) {
+				add(ancestor)
+			}
+		}
+	}
+	return categories
+}
+
+// LoadTaxonomyTree fetches all the taxonomies of the client's organization, or
+// the global ones without one, and builds a tree of them.  Trees are cached
+// per organization until ForgetTaxonomyTrees is called.
+func (client *Client) LoadTaxonomyTree() (*TaxonomyTree, error) {
+	return client.LoadTaxonomyTreeContext(context.Background())
+}
+
+func (client *Client) LoadTaxonomyTreeContext(ctx context.Context) (*TaxonomyTree, error) {
+	orgId := client.currentOrganization(ctx)
+	// holding the lock while loading keeps concurrent callers from all fetching the same taxonomies
+	client.taxonomyMux.Lock()
+	defer client.taxonomyMux.Unlock()
+	if tree, ok := client.taxonomyTrees[orgId]; ok {
+		return tree, nil
+	}
+	count, err := client.GetTaxonomyCountContext(ctx)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "unable to count taxonomies")
+	}
+	taxonomies := []*Taxonomy{}
+	if count.Meta.Total > 0 {
+		all, err := client.GetTaxonomiesContext(ctx, count.Meta.Total)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "unable to get %d taxonomies", count.Meta.Total)
+		}
+		taxonomies = all.Data
+	}
+	log.Debugf("loaded %d taxonomies for organization '%s'", len(taxonomies), orgId)
+	tree := NewTaxonomyTree(taxonomies)
+	client.taxonomyTrees[orgId] = tree
+	return tree, nil
+}
+
+// ForgetTaxonomyTrees drops the cached taxonomy trees, so the next
+// LoadTaxonomyTree fetches the taxonomies again.
+func (client *Client) ForgetTaxonomyTrees() {
+	client.taxonomyMux.Lock()
+	defer client.taxonomyMux.Unlock()
+	client.taxonomyTrees = map[string]*TaxonomyTree{}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api/fake"
+)
+
+type recordedRequest struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	// Body is the request's JSON body, if it had one
+	Body map[string]interface{}
+}
+
+// testServer records every request and answers it with respond.  It's for
+// tests that check exactly what the client sends, or that need responses
+// Polaris wouldn't give; everything else should run against fake.Server.
+type testServer struct {
+	*httptest.Server
+	mux      sync.Mutex
+	requests []*recordedRequest
+}
+
+// newTestServer answers with respond, or with an empty collection if respond is nil.
+func newTestServer(respond http.HandlerFunc) *testServer {
+	if respond == nil {
+		respond = serveBody(`{"data": []}`)
+	}
+	ts := &testServer{}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &recordedRequest{Method: r.Method, Path: r.URL.Path, Query: r.URL.Query(), Header: r.Header.Clone()}
+		if body, err := ioutil.ReadAll(r.Body); err == nil && len(body) > 0 {
+			json.Unmarshal(body, &request.Body)
+			// let respond read the body too
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+		ts.mux.Lock()
+		ts.requests = append(ts.requests, request)
+		ts.mux.Unlock()
+		respond(w, r)
+	}))
+	return ts
+}
+
+// serveBody answers DELETEs with 204 and everything else with body.
+func serveBody(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		fmt.Fprint(w, body)
+	}
+}
+
+func (ts *testServer) last() *recordedRequest {
+	ts.mux.Lock()
+	defer ts.mux.Unlock()
+	return ts.requests[len(ts.requests)-1]
+}
+
+// count counts the requests to path with method, or all requests if method is empty.
+func (ts *testServer) count(method string, path string) int {
+	ts.mux.Lock()
+	defer ts.mux.Unlock()
+	count := 0
+	for _, request := range ts.requests {
+		if method == "" || (request.Method == method && request.Path == path) {
+			count++
+		}
+	}
+	return count
+}
+
+// newFakeServer starts a fake Polaris and a client logged in as its owner.
+func newFakeServer() (*fake.Server, *Client) {
+	server := fake.NewServer(fake.DefaultConfig())
+	return server, NewClient(server.URL, server.Config.Email, server.Config.Password)
+}
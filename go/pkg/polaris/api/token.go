@@ -0,0 +1,164 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultRefreshBefore is how long before its expiry a token is proactively refreshed.
+const DefaultRefreshBefore = 5 * time.Minute
+
+// refreshTimeout bounds a token refresh, which doesn't run on any one caller's context.
+const refreshTimeout = 2 * time.Minute
+
+// tokenExpiry reads the exp claim out of a JWT.  The signature is not verified:
+// the server does that, we only want to know when to refresh.
+func tokenExpiry(token string) (time.Time, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, errors.Errorf("expected 3 JWT segments, found %d", len(parts))
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "unable to decode JWT payload")
+	}
+	claims := struct {
+		Exp *int64 `json:"exp"`
+	}{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}, errors.Wrapf(err, "unable to unmarshal JWT claims")
+	}
+	if claims.Exp == nil {
+		return time.Time{}, errors.New("JWT has no exp claim")
+	}
+	return time.Unix(*claims.Exp, 0), nil
+}
+
+// authCall is an in-flight token refresh that concurrent callers wait on.
+type authCall struct {
+	done chan struct{}
+	err  error
+}
+
+// detachedContext keeps the values of the context it wraps, such as the selected
+// organization, but not its deadline or cancellation.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (client *Client) setToken(token string) {
+	expiry, err := tokenExpiry(token)
+	if err != nil {
+		// opaque tokens still work, we just can't refresh them ahead of time
+		log.Debugf("unable to determine token expiry: %s", err)
+	}
+	client.authMux.Lock()
+	defer client.authMux.Unlock()
+	client.AuthToken = token
+	client.tokenExpiry = expiry
+}
+
+func (client *Client) currentToken() (string, time.Time) {
+	client.authMux.RLock()
+	defer client.authMux.RUnlock()
+	return client.AuthToken, client.tokenExpiry
+}
+
+func (client *Client) canRefresh() bool {
+	return client.Authenticator != nil && client.Authenticator.CanRefresh()
+}
+
+// freshToken returns a token to send, first authenticating if there is none
+// yet or refreshing it if it expires within RefreshBefore.
+func (client *Client) freshToken(ctx context.Context) string {
+	token, expiry := client.currentToken()
+	if !client.canRefresh() {
+		return token
+	}
+	if token != "" && (expiry.IsZero() || time.Now().Add(client.RefreshBefore).Before(expiry)) {
+		return token
+	}
+	if err := client.refreshToken(ctx, token); err != nil {
+		// carry on with whatever we have; the request will fail if it really is unusable
+		log.Errorf("unable to refresh polaris token: %+v", err)
+	}
+	token, _ = client.currentToken()
+	return token
+}
+
+// refreshToken re-authenticates unless someone else already replaced staleToken.
+// Concurrent callers share a single call to api/auth/authenticate, which runs
+// detached from all of them, so that one caller giving up doesn't fail the rest.
+func (client *Client) refreshToken(ctx context.Context, staleToken string) error {
+	client.refreshMux.Lock()
+	if token, _ := client.currentToken(); token != staleToken {
+		client.refreshMux.Unlock()
+		return nil
+	}
+	call := client.refreshCall
+	if call == nil {
+		call = &authCall{done: make(chan struct{})}
+		client.refreshCall = call
+		go client.runRefresh(ctx, call)
+	}
+	client.refreshMux.Unlock()
+
+	select {
+	case <-call.done:
+		return call.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (client *Client) runRefresh(ctx context.Context, call *authCall) {
+	ctx, cancel := context.WithTimeout(detachedContext{ctx}, refreshTimeout)
+	defer cancel()
+
+	log.Infof("refreshing polaris token for %s", client.URL)
+	call.err = client.AuthenticateContext(ctx)
+	recordEvent("refresh_token", call.err)
+
+	client.refreshMux.Lock()
+	client.refreshCall = nil
+	client.refreshMux.Unlock()
+	close(call.done)
+}
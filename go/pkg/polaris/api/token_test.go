@@ -0,0 +1,188 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func fakeJWT(expiry time.Time, id int) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d,"jti":"%d"}`, expiry.Unix(), id)))
+	return fmt.Sprintf("%s.%s.sig", header, payload)
+}
+
+// tokenIssuer hands out a new token on every login and only accepts the latest one
+type tokenIssuer struct {
+	logins   int32
+	lifetime time.Duration
+	mux      sync.Mutex
+	valid    string
+}
+
+func (ti *tokenIssuer) serve(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/api/auth/authenticate" {
+		id := atomic.AddInt32(&ti.logins, 1)
+		// make concurrent callers overlap with the login
+		time.Sleep(20 * time.Millisecond)
+		token := fakeJWT(time.Now().Add(ti.lifetime), int(id))
+		ti.mux.Lock()
+		ti.valid = token
+		ti.mux.Unlock()
+		if r.FormValue("accesstoken") != "" {
+			// access token logins get the session token in the body
+			fmt.Fprintf(w, `{"jwt":"%s"}`, token)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "access_token", Value: token})
+		return
+	}
+	ti.mux.Lock()
+	valid := ti.valid
+	ti.mux.Unlock()
+	if r.Header.Get("Authorization") != "Bearer "+valid {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	fmt.Fprint(w, `{"data":[]}`)
+}
+
+func (ti *tokenIssuer) expireTokens() {
+	ti.mux.Lock()
+	defer ti.mux.Unlock()
+	ti.valid = ""
+}
+
+// newAuthServer serves logins with a tokenIssuer
+func newAuthServer(lifetime time.Duration) (*testServer, *tokenIssuer) {
+	issuer := &tokenIssuer{lifetime: lifetime}
+	return newTestServer(issuer.serve), issuer
+}
+
+func RunTokenTests() {
+	Describe("Token lifecycle", func() {
+		It("should decode the JWT expiry", func() {
+			expiry := time.Unix(1600000000, 0)
+			decoded, err := tokenExpiry(fakeJWT(expiry, 1))
+			Expect(err).To(BeNil())
+			Expect(decoded).To(Equal(expiry))
+
+			_, err = tokenExpiry("not-a-jwt")
+			Expect(err).ToNot(BeNil())
+		})
+
+		It("should authenticate lazily and retry once on 401", func() {
+			server, issuer := newAuthServer(time.Hour)
+			defer server.Close()
+			client := NewClient(server.URL, "user@example.com", "password")
+
+			_, err := client.GetUsers(0, 10)
+			Expect(err).To(BeNil())
+			Expect(server.count(http.MethodPost, "/api/auth/authenticate")).To(Equal(1))
+
+			issuer.expireTokens()
+			_, err = client.GetUsers(0, 10)
+			Expect(err).To(BeNil())
+			Expect(server.count(http.MethodPost, "/api/auth/authenticate")).To(Equal(2))
+		})
+
+		It("should refresh proactively before the token expires", func() {
+			server, _ := newAuthServer(time.Minute)
+			defer server.Close()
+			client := NewClient(server.URL, "user@example.com", "password")
+			Expect(client.Authenticate()).To(Succeed())
+
+			// the token is already inside the refresh window
+			_, err := client.GetUsers(0, 10)
+			Expect(err).To(BeNil())
+			Expect(server.count(http.MethodPost, "/api/auth/authenticate")).To(Equal(2))
+		})
+
+		It("should share a single refresh between concurrent requests", func() {
+			server, issuer := newAuthServer(time.Hour)
+			defer server.Close()
+			client := NewClient(server.URL, "user@example.com", "password")
+			Expect(client.Authenticate()).To(Succeed())
+			issuer.expireTokens()
+
+			wg := &sync.WaitGroup{}
+			errs := make(chan error, 20)
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := client.GetUsers(0, 10)
+					errs <- err
+				}()
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				Expect(err).To(BeNil())
+			}
+			Expect(server.count(http.MethodPost, "/api/auth/authenticate")).To(Equal(2))
+		})
+
+		It("should finish a shared refresh after the caller that started it gives up", func() {
+			server, issuer := newAuthServer(time.Hour)
+			defer server.Close()
+			client := NewClient(server.URL, "user@example.com", "password")
+			Expect(client.Authenticate()).To(Succeed())
+			stale, _ := client.currentToken()
+			issuer.expireTokens()
+
+			// the login takes 20ms; the first caller gives up before it's done
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+			defer cancel()
+			first := make(chan error, 1)
+			go func() { first <- client.refreshToken(ctx, stale) }()
+			Eventually(func() int {
+				return server.count(http.MethodPost, "/api/auth/authenticate")
+			}).Should(Equal(2))
+			Expect(client.refreshToken(context.Background(), stale)).To(Succeed())
+			Expect(<-first).To(Equal(context.DeadlineExceeded))
+
+			_, err := client.GetUsers(0, 10)
+			Expect(err).To(BeNil())
+			Expect(server.count(http.MethodPost, "/api/auth/authenticate")).To(Equal(2))
+		})
+
+		It("should not retry without credentials", func() {
+			server, _ := newAuthServer(time.Hour)
+			defer server.Close()
+			client := NewClient(server.URL, "", "")
+			client.AuthToken = "abc"
+
+			_, err := client.GetUsers(0, 10)
+			Expect(err).ToNot(BeNil())
+			Expect(server.count(http.MethodPost, "/api/auth/authenticate")).To(Equal(0))
+		})
+	})
+}
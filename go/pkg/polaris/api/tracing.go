@@ -0,0 +1,78 @@
+/*
+Copyright (C) 2018 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api
+
+import (
+	"context"
+
+	"github.com/go-resty/resty/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
+
+const (
+	attributePathTemplate = attribute.Key("polaris.path_template")
+	attributeCacheResult  = attribute.Key("polaris.cache")
+)
+
+// startSpan starts a client span covering one API call, including its retries
+// and token refreshes.  Spans are named by path template, not path, so that
+// calls to the same endpoint group together.
+func startSpan(ctx context.Context, method string, pathTemplate string, url string) (context.Context, trace.Span) {
+	// the tracer is looked up each time so that a provider installed later, e.g.
+	// by tracing.Setup, is used; until then it's a no-op
+	return otel.Tracer(instrumentationName).Start(ctx, method+" "+pathTemplate,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPMethodKey.String(method),
+			semconv.HTTPURLKey.String(url),
+			attributePathTemplate.String(pathTemplate)))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// traceAttempt injects a W3C traceparent header into request, so the server can
+// join the trace, and notes the attempt on the call's span
+func traceAttempt(ctx context.Context, request *resty.Request) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(request.Header))
+	trace.SpanFromContext(ctx).AddEvent("attempt")
+}
+
+func traceResponse(ctx context.Context, statusCode int, err error) {
+	span := trace.SpanFromContext(ctx)
+	if err != nil {
+		span.AddEvent("attempt failed", trace.WithAttributes(attribute.String("error", err.Error())))
+		return
+	}
+	span.SetAttributes(semconv.HTTPStatusCodeKey.Int(statusCode))
+}
@@ -0,0 +1,113 @@
+/*
+Copyright (C) 2018 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func RunTracingTests() {
+	Describe("Tracing", func() {
+		var recorder *tracetest.SpanRecorder
+		var server *testServer
+		var mux sync.Mutex
+		var status int
+
+		BeforeEach(func() {
+			recorder = tracetest.NewSpanRecorder()
+			otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+			otel.SetTextMapPropagator(propagation.TraceContext{})
+			status = http.StatusOK
+			server = newTestServer(func(w http.ResponseWriter, r *http.Request) {
+				mux.Lock()
+				code := status
+				mux.Unlock()
+				w.Header().Set("Content-Type", "application/vnd.api+json")
+				w.WriteHeader(code)
+				w.Write([]byte(`{"data": []}`))
+			})
+		})
+
+		AfterEach(func() {
+			server.Close()
+			otel.SetTracerProvider(sdktrace.NewTracerProvider())
+			otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+		})
+
+		It("should trace calls and propagate their context to the server", func() {
+			client := NewBearerTokenClient(server.URL, "token")
+			_, err := client.GetJsonContext(context.Background(), NewQuery(), nil, "api/auth/users/%s", "user-1")
+			Expect(err).To(BeNil())
+			_, err = client.PostJsonContext(context.Background(), map[string]interface{}{}, nil, "api/auth/users")
+			Expect(err).To(BeNil())
+
+			spans := recorder.Ended()
+			Expect(spans).To(HaveLen(2))
+			Expect(spans[0].Name()).To(Equal("GET api/auth/users/%s"))
+			Expect(spans[1].Name()).To(Equal("POST api/auth/users"))
+			Expect(server.requests).To(HaveLen(2))
+			for i, span := range spans {
+				Expect(server.requests[i].Header.Get("traceparent")).To(ContainSubstring(span.SpanContext().TraceID().String()))
+				Expect(span.Status().Code).To(Equal(codes.Unset))
+			}
+		})
+
+		It("should join the caller's trace and mark failures", func() {
+			mux.Lock()
+			status = http.StatusBadRequest
+			mux.Unlock()
+			ctx, parent := otel.Tracer("test").Start(context.Background(), "load test")
+			client := NewBearerTokenClient(server.URL, "token")
+			_, err := client.GetJsonContext(ctx, NewQuery(), nil, "api/auth/roles")
+			Expect(err).ToNot(BeNil())
+			parent.End()
+
+			spans := recorder.Ended()
+			Expect(spans).To(HaveLen(2))
+			Expect(spans[0].Parent().SpanID()).To(Equal(parent.SpanContext().SpanID()))
+			Expect(spans[0].Status().Code).To(Equal(codes.Error))
+		})
+
+		It("should note cache hits", func() {
+			client := NewBearerTokenClient(server.URL, "token")
+			client.Cache = NewResponseCache(&CacheConfig{MaxEntries: 10, PathTTLs: map[string]time.Duration{"api/auth/roles": time.Minute}})
+			for i := 0; i < 2; i++ {
+				_, err := client.GetJsonContext(context.Background(), NewQuery(), nil, "api/auth/roles")
+				Expect(err).To(BeNil())
+			}
+			spans := recorder.Ended()
+			Expect(spans).To(HaveLen(2))
+			Expect(spans[1].Attributes()).To(ContainElement(attributeCacheResult.String(cacheHit)))
+			Expect(server.count("", "")).To(Equal(1))
+		})
+	})
+}
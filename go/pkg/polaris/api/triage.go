@@ -0,0 +1,140 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package api
+
+import (
+	"context"
+	"github.com/pkg/errors"
+)
+
+// Triage attributes, as named by the triage service.
+const (
+	TriageDismiss    = "DISMISS"
+	TriageCommentary = "COMMENTARY"
+	TriageOwner      = "OWNER"
+)
+
+// Values of the DISMISS triage attribute.
+const (
+	DismissNotDismissed  = "NOT_DISMISSED"
+	DismissFalsePositive = "DISMISSED_AS_FP"
+	DismissIntentional   = "DISMISSED_BY_DESIGN"
+	DismissOther         = "DISMISSED_OTHER"
+)
+
+type TriageValue struct {
+	AttributeName string `json:"attribute-name"`
+	AttributeId   string `json:"attribute-id"`
+	Value         interface{}
+	DisplayValue  string `json:"display-value"`
+}
+
+type TriageHistoryItem struct {
+	Id         string
+	Type       string
+	Attributes struct {
+		DateModified string        `json:"date-modified"`
+		TriageValues []TriageValue `json:"triage-values"`
+	}
+	Relationships map[string]Relationship
+}
+
+type GetTriageHistoryResponse struct {
+	Data     []*TriageHistoryItem
+	Included Included
+	Meta     PageMeta
+	Links    PageLinks
+}
+
+// GetTriageHistory lists the triage changes of an issue, newest first.
+func (client *Client) GetTriageHistory(projectId string, issueKey string, offset int, limit int) (*GetTriageHistoryResponse, error) {
+	return client.GetTriageHistoryContext(context.Background(), projectId, issueKey, offset, limit)
+}
+
+func (client *Client) GetTriageHistoryContext(ctx context.Context, projectId string, issueKey string, offset int, limit int) (*GetTriageHistoryResponse, error) {
+	result := &GetTriageHistoryResponse{}
+	query := NewQuery().
+		Set("filter[triage-history-items][project-id][$eq]", projectId).
+		Set("filter[triage-history-items][issue-key][$eq]", issueKey).
+		Page(offset, limit)
+	_, err := client.GetJsonContext(ctx, query, result, "api/triage-query/v1/triage-history-items")
+	return result, err
+}
+
+type GetTriageCurrentResponse struct {
+	Data struct {
+		Id         string
+		Type       string
+		Attributes struct {
+			TriageValues []TriageValue `json:"triage-values"`
+		}
+	}
+}
+
+// Value returns the current value of a triage attribute such as TriageDismiss.
+func (resp *GetTriageCurrentResponse) Value(attributeName string) (interface{}, bool) {
+	for _, value := range resp.Data.Attributes.TriageValues {
+		if value.AttributeName == attributeName {
+			return value.Value, true
+		}
+	}
+	return nil, false
+}
+
+func (client *Client) GetTriageCurrent(projectId string, issueKey string) (*GetTriageCurrentResponse, error) {
+	return client.GetTriageCurrentContext(context.Background(), projectId, issueKey)
+}
+
+func (client *Client) GetTriageCurrentContext(ctx context.Context, projectId string, issueKey string) (*GetTriageCurrentResponse, error) {
+	result := &GetTriageCurrentResponse{}
+	_, err := client.GetJsonContext(ctx, NewQuery(), result, "api/triage-query/v1/triage-current/project-id:%s:issue-key:%s", projectId, issueKey)
+	return result, err
+}
+
+// TriageUpdate sets triage attributes, such as TriageDismiss to DismissFalsePositive,
+// on one or more issues of a project.
+type TriageUpdate struct {
+	ProjectId string
+	IssueKeys []string
+	Values    map[string]interface{}
+}
+
+func (client *Client) UpdateTriage(update *TriageUpdate) error {
+	return client.UpdateTriageContext(context.Background(), update)
+}
+
+func (client *Client) UpdateTriageContext(ctx context.Context, update *TriageUpdate) error {
+	if len(update.IssueKeys) == 0 {
+		return errors.Errorf("no issue keys to triage in project %s", update.ProjectId)
+	}
+	bodyParams := map[string]interface{}{
+		"data": map[string]interface{}{
+			"attributes": map[string]interface{}{
+				"project-id":    update.ProjectId,
+				"issue-keys":    update.IssueKeys,
+				"triage-values": update.Values,
+			},
+			"type": "triage-issues",
+		},
+	}
+	_, err := client.PostJsonContext(ctx, bodyParams, nil, "api/triage-command/v1/triage-issues")
+	return err
+}
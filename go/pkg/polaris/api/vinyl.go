@@ -1,7 +1,7 @@
 package api
 
 import (
-	"fmt"
+	"context"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -11,70 +11,60 @@ type VinylV0Project struct {
 	Attributes struct {
 		Name string
 	}
-	Relationships map[string]struct {
-		Links struct {
-			Self    string
-			Related string
-		}
-		Data *struct {
-			Type string
-			Id   string
-		}
-	}
+	Relationships map[string]Relationship
 }
 
+// GetVinylV0ProjectsResponse includes branches and entitlements, which have
+// different attributes: find them with Included.ResolveOne and Decode them.
 type GetVinylV0ProjectsResponse struct {
 	Data     []*VinylV0Project
-	Included []struct {
-		// TODO Type="branch" has different stuff than Type="entitlements"
-		Type          string
-		Id            string
-		Attributes    map[string]interface{}
-		Relationships map[string]interface{}
-		Links         struct {
-			Self struct {
-				HRef string
-			}
-		}
-		Meta *struct {
-			ETag    string
-			InTrash bool `json:"in-trash"`
-		}
-	}
-	Meta struct {
-		Offset int
-		Limit  int
-		Total  int
-	}
+	Included Included
+	Meta     PageMeta
+	Links    PageLinks
 }
 
 func (client *Client) GetVinylV0Projects(offset int, limit int) (*GetVinylV0ProjectsResponse, error) {
+	return client.GetVinylV0ProjectsContext(context.Background(), offset, limit)
+}
+
+func (client *Client) GetVinylV0ProjectsContext(ctx context.Context, offset int, limit int) (*GetVinylV0ProjectsResponse, error) {
 	result := &GetVinylV0ProjectsResponse{}
-	params := map[string]interface{}{
-		"include[project][]": []string{"entitlements", "main-branch", "project-preference", "user-default-branch"},
-		"page[offset]":       []string{fmt.Sprintf("%d", offset)},
-		"page[limit]":        []string{fmt.Sprintf("%d", limit)},
-	}
-	json, err := client.GetJson(params, result, "api/vinyl/common/v0/projects")
+	query := NewQuery().
+		Include("project", "entitlements", "main-branch", "project-preference", "user-default-branch").
+		Page(offset, limit)
+	json, err := client.GetJsonContext(ctx, query, result, "api/vinyl/common/v0/projects")
 	log.Tracef("vinyl json:\n%s\n\n", json)
 	return result, err
 }
 
+// VinylV0ProjectsPaginator walks all vinyl projects; items are *VinylV0Project.
+func (client *Client) VinylV0ProjectsPaginator(pageSize int) *Paginator {
+	return NewPaginator(func(ctx context.Context, offset int, limit int) (*Page, error) {
+		projects, err := client.GetVinylV0ProjectsContext(ctx, offset, limit)
+		if err != nil {
+			return nil, err
+		}
+		items := boxItems(len(projects.Data), func(i int) interface{} { return projects.Data[i] })
+		return &Page{Items: items, Meta: projects.Meta, Links: projects.Links}, nil
+	}, pageSize)
+}
+
 type GetVinylV0ProjectsRelationshipsRunsResponse struct {
-	Data []struct {
-		Type string
-		Id   string
-	}
+	Data []ResourceIdentifier
 }
 
 func (client *Client) GetVinylV0ProjectsRelationshipsRuns(projectId string) (*GetVinylV0ProjectsRelationshipsRunsResponse, error) {
+	return client.GetVinylV0ProjectsRelationshipsRunsContext(context.Background(), projectId)
+}
+
+func (client *Client) GetVinylV0ProjectsRelationshipsRunsContext(ctx context.Context, projectId string) (*GetVinylV0ProjectsRelationshipsRunsResponse, error) {
 	result := &GetVinylV0ProjectsRelationshipsRunsResponse{}
 	params := map[string]interface{}{}
 	//	"include[project][]": []string{"entitlements", "main-branch", "project-preference", "user-default-branch"},
 	//	"page[offset]":       []string{fmt.Sprintf("%d", offset)},
 	//	"page[limit]":        []string{fmt.Sprintf("%d", limit)},
 	//}
-	json, err := client.GetJson(params, result, "api/vinyl/common/v0/projects/%s/relationships/runs", projectId)
+	json, err := client.GetJsonContext(ctx, params, result, "api/vinyl/common/v0/projects/%s/relationships/runs", projectId)
 	log.Tracef("vinyl json:\n%s\n\n", json)
 	return result, err
 }
@@ -96,9 +86,13 @@ type GetVinylV0ProjectsRelatedRunsResponse struct {
 }
 
 func (client *Client) GetVinylV0ProjectsRelatedRuns(projectId string) (*GetVinylV0ProjectsRelatedRunsResponse, error) {
+	return client.GetVinylV0ProjectsRelatedRunsContext(context.Background(), projectId)
+}
+
+func (client *Client) GetVinylV0ProjectsRelatedRunsContext(ctx context.Context, projectId string) (*GetVinylV0ProjectsRelatedRunsResponse, error) {
 	result := &GetVinylV0ProjectsRelatedRunsResponse{}
 	params := map[string]interface{}{}
-	json, err := client.GetJson(params, result, "api/vinyl/common/v0/projects/%s/related/runs", projectId)
+	json, err := client.GetJsonContext(ctx, params, result, "api/vinyl/common/v0/projects/%s/related/runs", projectId)
 	log.Tracef("vinyl json:\n%s\n\n", json)
 	return result, err
 }
@@ -0,0 +1,75 @@
+/*
+Copyright (C) 2018 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
+	"github.com/pkg/errors"
+)
+
+var csvHeader = []string{"id", "issue-key", "finding-key", "issue-type", "severity", "tool", "path", "taxa", "run-id", "url"}
+
+// CSVWriter writes a header row, then a row per issue.  Taxa are joined with
+// semicolons, each as its name if it has one, or else its id.
+type CSVWriter struct {
+	writer      *csv.Writer
+	wroteHeader bool
+}
+
+func NewCSVWriter(out io.Writer) *CSVWriter {
+	return &CSVWriter{writer: csv.NewWriter(out)}
+}
+
+func (w *CSVWriter) Write(issue *api.ResolvedV1Issue) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	taxa := make([]string, len(issue.Taxa))
+	for i, taxon := range issue.Taxa {
+		taxa[i] = taxon.Id
+		if taxon.Name != "" {
+			taxa[i] = taxon.Name
+		}
+	}
+	row := []string{issue.Id, issue.IssueKey, issue.FindingKey, issue.IssueType, issue.Severity, issue.Tool, issue.Path, strings.Join(taxa, ";"), issue.RunId, issue.URL}
+	return errors.Wrapf(w.writer.Write(row), "unable to write issue %s", issue.Id)
+}
+
+func (w *CSVWriter) writeHeader() error {
+	if w.wroteHeader {
+		return nil
+	}
+	w.wroteHeader = true
+	return errors.Wrapf(w.writer.Write(csvHeader), "unable to write CSV header")
+}
+
+// Close writes the header if there were no issues, and flushes.
+func (w *CSVWriter) Close() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.writer.Flush()
+	return errors.Wrapf(w.writer.Error(), "unable to flush CSV")
+}
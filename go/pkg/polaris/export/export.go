@@ -0,0 +1,93 @@
+/*
+Copyright (C) 2018 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
+	"github.com/pkg/errors"
+)
+
+// Export formats.
+const (
+	FormatSARIF     = "sarif"
+	FormatCSV       = "csv"
+	FormatJSONLines = "jsonl"
+)
+
+// IssueWriter writes issues in some format.  Close must be called once all
+// issues are written: some formats can't be finished before then.
+type IssueWriter interface {
+	Write(issue *api.ResolvedV1Issue) error
+	Close() error
+}
+
+// NewIssueWriter returns a writer for format, one of the Format constants.
+func NewIssueWriter(format string, out io.Writer) (IssueWriter, error) {
+	switch format {
+	case FormatSARIF:
+		return NewSARIFWriter(out), nil
+	case FormatCSV:
+		return NewCSVWriter(out), nil
+	case FormatJSONLines, "jsonlines", "json":
+		return NewJSONLinesWriter(out), nil
+	default:
+		return nil, errors.Errorf("invalid export format %s: expected one of %s, %s or %s", format, FormatSARIF, FormatCSV, FormatJSONLines)
+	}
+}
+
+// JSONLinesWriter writes each issue as a JSON object on its own line.
+type JSONLinesWriter struct {
+	encoder *json.Encoder
+}
+
+func NewJSONLinesWriter(out io.Writer) *JSONLinesWriter {
+	return &JSONLinesWriter{encoder: json.NewEncoder(out)}
+}
+
+func (w *JSONLinesWriter) Write(issue *api.ResolvedV1Issue) error {
+	return errors.Wrapf(w.encoder.Encode(issue), "unable to write issue %s", issue.Id)
+}
+
+func (w *JSONLinesWriter) Close() error {
+	return nil
+}
+
+// WriteIssues writes every issue of paginator, whose items must be
+// *api.ResolvedV1Issue, then closes writer.  It returns how many issues it wrote.
+func WriteIssues(ctx context.Context, paginator *api.Paginator, writer IssueWriter) (int, error) {
+	it := paginator.Iterate(ctx)
+	defer it.Close()
+	count := 0
+	for it.Next() {
+		if err := writer.Write(it.Item().(*api.ResolvedV1Issue)); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := it.Err(); err != nil {
+		return count, errors.WithMessagef(err, "unable to fetch issues after %d", count)
+	}
+	return count, writer.Close()
+}
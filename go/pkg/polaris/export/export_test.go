@@ -0,0 +1,110 @@
+/*
+Copyright (C) 2018 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api/fake"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func RunExportTests() {
+	Describe("Issue export", func() {
+		var server *fake.Server
+		var paginator *api.Paginator
+
+		BeforeEach(func() {
+			server = fake.NewServer(fake.DefaultConfig())
+			project := server.Seed(1, 7)[0]
+			client := api.NewClient(server.URL, server.Config.Email, server.Config.Password)
+			paginator = client.ResolvedV1IssuesPaginator(project.Id, project.BranchId, "", 3)
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		export := func(format string) string {
+			out := &bytes.Buffer{}
+			writer, err := NewIssueWriter(format, out)
+			Expect(err).To(BeNil())
+			count, err := WriteIssues(context.Background(), paginator, writer)
+			Expect(err).To(BeNil())
+			Expect(count).To(Equal(7))
+			return out.String()
+		}
+
+		It("should write JSON lines", func() {
+			lines := strings.Split(strings.TrimSpace(export(FormatJSONLines)), "\n")
+			Expect(len(lines)).To(Equal(7))
+			issue := &api.ResolvedV1Issue{}
+			Expect(json.Unmarshal([]byte(lines[0]), issue)).To(Succeed())
+			Expect(issue.Severity).To(Equal("Critical"))
+			Expect(issue.Path).To(Equal("src/file0.go"))
+			Expect(issue.Tool).ToNot(BeEmpty())
+		})
+
+		It("should write CSV with a header", func() {
+			rows, err := csv.NewReader(strings.NewReader(export(FormatCSV))).ReadAll()
+			Expect(err).To(BeNil())
+			Expect(len(rows)).To(Equal(8))
+			Expect(rows[0]).To(Equal(csvHeader))
+			Expect(rows[1][4]).To(Equal("Critical"))
+		})
+
+		It("should write a SARIF log with a run per tool", func() {
+			log := &sarifLog{}
+			Expect(json.Unmarshal([]byte(export(FormatSARIF)), log)).To(Succeed())
+			Expect(log.Version).To(Equal("2.1.0"))
+			Expect(len(log.Runs)).To(Equal(2))
+			results := 0
+			for _, run := range log.Runs {
+				results += len(run.Results)
+				for _, result := range run.Results {
+					Expect(run.Tool.Driver.Rules[result.RuleIndex].Id).To(Equal(result.RuleId))
+					Expect(result.PartialFingerprints).To(HaveKey(findingKeyFingerprint))
+					Expect(result.Locations[0].PhysicalLocation.ArtifactLocation.URI).To(HavePrefix("src/"))
+				}
+			}
+			Expect(results).To(Equal(7))
+			Expect(log.Runs[0].Results[0].Level).To(Equal("error"))
+		})
+
+		It("should reject unknown formats and close empty exports", func() {
+			_, err := NewIssueWriter("xml", &bytes.Buffer{})
+			Expect(err).ToNot(BeNil())
+
+			out := &bytes.Buffer{}
+			Expect(NewCSVWriter(out).Close()).To(Succeed())
+			Expect(out.String()).To(HavePrefix("id,issue-key"))
+			out.Reset()
+			Expect(NewSARIFWriter(out).Close()).To(Succeed())
+			Expect(out.String()).To(ContainSubstring(`"runs": []`))
+		})
+	})
+}
@@ -0,0 +1,202 @@
+/*
+Copyright (C) 2018 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
+	"github.com/pkg/errors"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	// findingKeyFingerprint identifies a finding across runs, for tools that track results over time
+	findingKeyFingerprint = "polarisFindingKey/v1"
+)
+
+type sarifLog struct {
+	Schema  string      `json:"$schema"`
+	Version string      `json:"version"`
+	Runs    []*sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool      `json:"tool"`
+	Results []*sarifResult `json:"results"`
+	// ruleIndexes finds a rule's index in Tool.Driver.Rules by its id
+	ruleIndexes map[string]int
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name    string       `json:"name"`
+	Version string       `json:"version,omitempty"`
+	Rules   []*sarifRule `json:"rules"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifRule struct {
+	Id               string                 `json:"id"`
+	Name             string                 `json:"name,omitempty"`
+	ShortDescription sarifMessage           `json:"shortDescription"`
+	Properties       map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifResult struct {
+	RuleId              string                 `json:"ruleId"`
+	RuleIndex           int                    `json:"ruleIndex"`
+	Level               string                 `json:"level"`
+	Message             sarifMessage           `json:"message"`
+	Locations           []*sarifLocation       `json:"locations,omitempty"`
+	PartialFingerprints map[string]string      `json:"partialFingerprints,omitempty"`
+	Properties          map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+	} `json:"physicalLocation"`
+}
+
+// SARIFWriter collects issues into a SARIF 2.1.0 log, with a run per tool and
+// a rule per issue type.  Nothing is written until Close.
+type SARIFWriter struct {
+	out  io.Writer
+	log  *sarifLog
+	runs map[string]*sarifRun
+}
+
+func NewSARIFWriter(out io.Writer) *SARIFWriter {
+	return &SARIFWriter{
+		out:  out,
+		log:  &sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []*sarifRun{}},
+		runs: map[string]*sarifRun{},
+	}
+}
+
+func (w *SARIFWriter) Write(issue *api.ResolvedV1Issue) error {
+	run := w.run(issue)
+	ruleId := issue.IssueTypeId
+	if ruleId == "" {
+		ruleId = issue.IssueType
+	}
+	ruleIndex, ok := run.ruleIndexes[ruleId]
+	if !ok {
+		rule := &sarifRule{
+			Id:               ruleId,
+			Name:             issue.IssueTypeAbbreviation,
+			ShortDescription: sarifMessage{Text: issue.IssueType},
+		}
+		if tags := taxonTags(issue.Taxa); len(tags) > 0 {
+			rule.Properties = map[string]interface{}{"tags": tags}
+		}
+		ruleIndex = len(run.Tool.Driver.Rules)
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+		run.ruleIndexes[ruleId] = ruleIndex
+	}
+	result := &sarifResult{
+		RuleId:    ruleId,
+		RuleIndex: ruleIndex,
+		Level:     sarifLevel(issue.Severity),
+		Message:   sarifMessage{Text: issue.IssueType},
+		Properties: map[string]interface{}{
+			"issueKey": issue.IssueKey,
+			"severity": issue.Severity,
+		},
+	}
+	if issue.Path != "" {
+		location := &sarifLocation{}
+		location.PhysicalLocation.ArtifactLocation.URI = issue.Path
+		result.Locations = []*sarifLocation{location}
+	}
+	if issue.FindingKey != "" {
+		result.PartialFingerprints = map[string]string{findingKeyFingerprint: issue.FindingKey}
+	}
+	if issue.URL != "" {
+		result.Properties["url"] = issue.URL
+	}
+	run.Results = append(run.Results, result)
+	return nil
+}
+
+// run finds or starts the run of the issue's tool
+func (w *SARIFWriter) run(issue *api.ResolvedV1Issue) *sarifRun {
+	name := issue.Tool
+	if name == "" {
+		name = "Polaris"
+	}
+	if run, ok := w.runs[name]; ok {
+		return run
+	}
+	run := &sarifRun{
+		Tool:        sarifTool{Driver: sarifDriver{Name: name, Version: issue.ToolVersion, Rules: []*sarifRule{}}},
+		Results:     []*sarifResult{},
+		ruleIndexes: map[string]int{},
+	}
+	w.runs[name] = run
+	w.log.Runs = append(w.log.Runs, run)
+	return run
+}
+
+func (w *SARIFWriter) Close() error {
+	encoder := json.NewEncoder(w.out)
+	encoder.SetIndent("", "  ")
+	return errors.Wrapf(encoder.Encode(w.log), "unable to write SARIF log")
+}
+
+// sarifLevel maps Polaris severities onto SARIF levels
+func sarifLevel(severity string) string {
+	switch strings.ToLower(severity) {
+	case "critical", "high":
+		return "error"
+	case "medium":
+		return "warning"
+	case "low", "audit":
+		return "note"
+	default:
+		return "none"
+	}
+}
+
+// taxonTags turns taxa into rule tags, which code scanning dashboards show and filter on
+func taxonTags(taxa []*api.ResolvedTaxon) []string {
+	tags := []string{}
+	for _, taxon := range taxa {
+		tag := taxon.Id
+		if taxon.Name != "" {
+			tag = taxon.Name
+		}
+		tags = append(tags, tag)
+	}
+	return tags
+}
@@ -22,6 +22,8 @@ package polaris
 
 import (
 	"context"
+	"encoding/json"
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
 	"github.com/blackducksoftware/cerebros/go/pkg/util"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -38,6 +40,7 @@ var polarisServerURL = "POLARIS_SERVER_URL"
 var polarisAccessToken = "POLARIS_ACCESS_TOKEN"
 
 var analyzeTimeout = 15 * time.Minute
+var centralAnalysisTimeout = 2 * time.Hour
 var captureTimeout = 15 * time.Minute
 var configureTimeout = 30 * time.Second
 var setupTimeout = 15 * time.Minute
@@ -47,6 +50,8 @@ type Scanner struct {
 	Token    string
 	CLIPath  string
 	JavaHome string
+	// JobsClient, if set, is used to wait for central analysis jobs to finish
+	JobsClient *api.Client
 }
 
 func NewScanner(cliPath string, url string, token string, javaHome string) (*Scanner, error) {
@@ -67,9 +72,25 @@ func NewScanner(cliPath string, url string, token string, javaHome string) (*Sca
 }
 
 func (ps *Scanner) Capture(capturePath string) (string, error) {
+	return ps.CaptureContext(context.Background(), capturePath)
+}
+
+// CaptureContext is Capture within ctx, which also carries the trace that the
+// capture's span joins.
+func (ps *Scanner) CaptureContext(ctx context.Context, capturePath string) (string, error) {
+	idirPath := ""
+	err := inPhase(ctx, "polaris capture", capturePath, func(ctx context.Context) error {
+		var err error
+		idirPath, err = ps.capture(ctx, capturePath)
+		return err
+	})
+	return idirPath, err
+}
+
+func (ps *Scanner) capture(ctx context.Context, capturePath string) (string, error) {
 	log.Infof("Running Polaris Capture on path %s", capturePath)
 
-	ctx, cancel := context.WithTimeout(context.Background(), captureTimeout)
+	ctx, cancel := context.WithTimeout(ctx, captureTimeout)
 	defer cancel()
 	command := exec.CommandContext(ctx, ps.polarisBinaryPath(), "capture")
 	util.CommandSetDirectory(command, capturePath)
@@ -104,29 +125,45 @@ func (ps *Scanner) polarisBinaryPath() string {
 }
 
 func (ps *Scanner) CaptureAndScan(capturePath string, useLocalAnalysis bool) error {
-	idirPath, err := ps.Capture(capturePath)
-	if err != nil {
-		return errors.WithMessagef(err, "unable to capture path %s", capturePath)
-	}
-	return ps.Scan(capturePath, idirPath, useLocalAnalysis)
+	return ps.CaptureAndScanContext(context.Background(), capturePath, useLocalAnalysis)
+}
+
+func (ps *Scanner) CaptureAndScanContext(ctx context.Context, capturePath string, useLocalAnalysis bool) error {
+	return inPhase(ctx, "polaris capture and scan", capturePath, func(ctx context.Context) error {
+		idirPath, err := ps.CaptureContext(ctx, capturePath)
+		if err != nil {
+			return errors.WithMessagef(err, "unable to capture path %s", capturePath)
+		}
+		return ps.ScanContext(ctx, capturePath, idirPath, useLocalAnalysis)
+	})
 }
 
 func (ps *Scanner) Scan(repoPath string, idirPath string, useLocalAnalysis bool) error {
-	err := ps.setup(repoPath)
-	if err != nil {
-		return err
-	}
+	return ps.ScanContext(context.Background(), repoPath, idirPath, useLocalAnalysis)
+}
 
-	err = ps.addCoverityParamsToPolarisYaml(repoPath, idirPath, useLocalAnalysis)
-	if err != nil {
-		return err
-	}
+func (ps *Scanner) ScanContext(ctx context.Context, repoPath string, idirPath string, useLocalAnalysis bool) error {
+	return inPhase(ctx, "polaris scan", repoPath, func(ctx context.Context) error {
+		err := inPhase(ctx, "polaris setup", repoPath, func(ctx context.Context) error {
+			return ps.setup(ctx, repoPath)
+		})
+		if err != nil {
+			return err
+		}
+
+		err = ps.addCoverityParamsToPolarisYaml(repoPath, idirPath, useLocalAnalysis)
+		if err != nil {
+			return err
+		}
 
-	return ps.analyze(repoPath, useLocalAnalysis)
+		return inPhase(ctx, "polaris analyze", repoPath, func(ctx context.Context) error {
+			return ps.analyze(ctx, repoPath, useLocalAnalysis)
+		})
+	})
 }
 
-func (ps *Scanner) setup(path string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), setupTimeout)
+func (ps *Scanner) setup(ctx context.Context, path string) error {
+	ctx, cancel := context.WithTimeout(ctx, setupTimeout)
 	defer cancel()
 	start := time.Now()
 	setupCommand := exec.CommandContext(ctx, ps.polarisBinaryPath(), "setup")
@@ -176,17 +213,17 @@ func (ps *Scanner) addCoverityParamsToPolarisYaml(repoPath string, idirPath stri
 	return errors.Wrapf(err, "unable to write file %s", polarisYmlPath)
 }
 
-func (ps *Scanner) analyze(path string, useLocalAnalysis bool) error {
+func (ps *Scanner) analyze(ctx context.Context, path string, useLocalAnalysis bool) error {
 	var command *exec.Cmd
-	ctx, cancel := context.WithTimeout(context.Background(), analyzeTimeout)
+	analyzeCtx, cancel := context.WithTimeout(ctx, analyzeTimeout)
 	defer cancel()
 
 	if useLocalAnalysis {
 		log.Debugf("using local analysis mode")
-		command = exec.CommandContext(ctx, ps.polarisBinaryPath(), "analyze", "-w")
+		command = exec.CommandContext(analyzeCtx, ps.polarisBinaryPath(), "analyze", "-w")
 	} else {
 		log.Debugf("using central analysis mode")
-		command = exec.CommandContext(ctx, ps.polarisBinaryPath(), "analyze")
+		command = exec.CommandContext(analyzeCtx, ps.polarisBinaryPath(), "analyze")
 	}
 	javaHome := ps.JavaHome
 	if javaHome != "" {
@@ -198,8 +235,66 @@ func (ps *Scanner) analyze(path string, useLocalAnalysis bool) error {
 	err := util.CommandRunAndPrint(command)
 	recordEventTime("polaris_analyze", time.Now().Sub(analyzeStart))
 	recordEvent("polaris_analyze", err)
+	if err != nil || useLocalAnalysis || ps.JobsClient == nil {
+		return err
+	}
 
-	return err
+	return ps.waitForJobs(ctx, path)
+}
+
+// cliScanSummary is the part of .synopsys/polaris/cli-scan.json, written by
+// polaris analyze, that we need to follow central analysis.
+type cliScanSummary struct {
+	Tools []struct {
+		ToolName  string
+		JobId     string
+		JobStatus string
+	}
+}
+
+func readCliScanSummary(repoPath string) (*cliScanSummary, error) {
+	summaryPath := path.Join(repoPath, ".synopsys/polaris/cli-scan.json")
+	content, err := ioutil.ReadFile(summaryPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read file %s", summaryPath)
+	}
+	summary := &cliScanSummary{}
+	if err := json.Unmarshal(content, summary); err != nil {
+		return nil, errors.Wrapf(err, "unable to unmarshal %s", summaryPath)
+	}
+	return summary, nil
+}
+
+func (ps *Scanner) waitForJobs(ctx context.Context, repoPath string) error {
+	summary, err := readCliScanSummary(repoPath)
+	recordEvent("read_cli_scan_summary", err)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, centralAnalysisTimeout)
+	defer cancel()
+	for _, tool := range summary.Tools {
+		if tool.JobId == "" {
+			continue
+		}
+		log.Infof("waiting for %s job %s", tool.ToolName, tool.JobId)
+		start := time.Now()
+		job, err := ps.JobsClient.WaitForJob(ctx, tool.JobId)
+		recordEventTime("polaris_central_analysis", time.Now().Sub(start))
+		recordEvent("polaris_central_analysis", err)
+		if err != nil {
+			return err
+		}
+		if state := job.Attributes.Status.State; state != api.JobStateCompleted {
+			reason := ""
+			if job.Attributes.FailureInfo != nil {
+				reason = job.Attributes.FailureInfo.UserFriendlyFailureReason
+			}
+			return errors.Errorf("%s job %s finished in state %s: %s", tool.ToolName, tool.JobId, state, reason)
+		}
+	}
+	return nil
 }
 
 func (ps *Scanner) configurePolarisCliWithAccessToken() error {
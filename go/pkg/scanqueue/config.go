@@ -22,9 +22,8 @@ under the License.
 package scanqueue
 
 import (
-	"github.com/pkg/errors"
+	utilconfig "github.com/blackducksoftware/cerebros/go/pkg/util/config"
 	log "github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
 )
 
 // Config ...
@@ -43,21 +42,10 @@ func (config *Config) GetLogLevel() (log.Level, error) {
 
 // GetConfig ...
 func GetConfig(configPath string) (*Config, error) {
-	var config *Config
-
-	// avoid viper serialization problems caused by '.'s in keys
-	v := viper.NewWithOptions(viper.KeyDelimiter("*"))
-
-	v.SetConfigFile(configPath)
-	err := v.ReadInConfig()
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to ReadInConfig")
-	}
-
-	err = v.Unmarshal(&config)
+	config := &Config{}
+	err := utilconfig.Load(configPath, config, &utilconfig.Options{EnvPrefix: "SCAN_QUEUE"})
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to unmarshal config")
+		return nil, err
 	}
-
 	return config, nil
 }
@@ -25,6 +25,7 @@ import (
 	"fmt"
 	"net/http"
 
+	utilconfig "github.com/blackducksoftware/cerebros/go/pkg/util/config"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
@@ -33,10 +34,10 @@ import (
 // RunScanQueue ...
 func RunScanQueue(configPath string, stop <-chan struct{}) {
 	config, err := GetConfig(configPath)
-	log.Warnf("unserialized config: %+v", config)
 	if err != nil {
 		panic(fmt.Errorf("Failed to load configuration: %v", err))
 	}
+	log.Warnf("unserialized config: %s", utilconfig.Redact(config))
 
 	level, err := config.GetLogLevel()
 	if err != nil {
@@ -21,23 +21,22 @@ under the License.
 package synopsys_scancli
 
 import (
-	"encoding/json"
 	"github.com/blackducksoftware/cerebros/go/pkg/blackduck/docker"
 	"github.com/blackducksoftware/cerebros/go/pkg/blackduck/hubcli"
 	polarisapi "github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
-	"github.com/pkg/errors"
+	utilconfig "github.com/blackducksoftware/cerebros/go/pkg/util/config"
+	"github.com/blackducksoftware/cerebros/go/pkg/util/tracing"
 	log "github.com/sirupsen/logrus"
-	"io/ioutil"
 )
 
 type ScanQueueConfig struct {
-	Host string
-	Port int
+	Host string `config:"required"`
+	Port int    `config:"required"`
 }
 
 type BlackduckConfig struct {
-	Host                 string
-	Username             string
+	Host                 string `config:"required"`
+	Username             string `config:"required"`
 	Password             string
 	Port                 int
 	OSType               hubcli.OSType
@@ -52,23 +51,38 @@ type ImageFacadeConfig struct {
 }
 
 type PolarisConfig struct {
-	CLIPath  string
-	URL      string
-	Email    string
-	Password string
-	OSType   polarisapi.OSType
-	JavaHome string
+	CLIPath string
+	// CLICacheDir, if set, keeps downloaded copies of the Polaris CLI so that restarts don't re-download it
+	CLICacheDir string
+	URL         string `config:"required"`
+	Email       string
+	Password    string
+	// AccessToken, if set, is used instead of Email and Password, both to log in and to run the CLI
+	AccessToken string `config:"secret"`
+	OSType      polarisapi.OSType
+	JavaHome    string
+	// WaitForCentralAnalysis makes scans wait for the server-side analysis jobs to finish
+	WaitForCentralAnalysis bool
+}
+
+// WorkspaceConfig controls where per-scan scratch directories go; see util.WorkspaceManager
+type WorkspaceConfig struct {
+	Root    string
+	QuotaMB int64
 }
 
 type Config struct {
 	Blackduck   *BlackduckConfig
 	ImageFacade *ImageFacadeConfig
 	ScanQueue   *ScanQueueConfig
+	Workspace   *WorkspaceConfig
 	//Scanner     *ScannerConfig // TODO do we need this for anything?
 	Polaris *PolarisConfig
 
 	LogLevel string
 	Port     int
+	// Tracing, if set, exports spans for scan phases and Polaris API calls
+	Tracing *tracing.Config
 }
 
 func (config *Config) getLogLevel() (log.Level, error) {
@@ -76,32 +90,12 @@ func (config *Config) getLogLevel() (log.Level, error) {
 }
 
 func GetConfig(configPath string) (*Config, error) {
-	bytes, err := ioutil.ReadFile(configPath)
+	// viper can't be used here: it ignores UnmarshalJSON hooks, which some of these types depend on
+	// (see: https://github.com/spf13/viper/issues/338); utilconfig decodes with encoding/json
+	config := &Config{}
+	err := utilconfig.Load(configPath, config, &utilconfig.Options{EnvPrefix: "SCANCLI"})
 	if err != nil {
-		return nil, errors.Wrapf(err, "unable to read in config file %s", configPath)
+		return nil, err
 	}
-
-	var config Config
-	err = json.Unmarshal(bytes, &config)
-	if err != nil {
-		return nil, errors.Wrapf(err, "unable to unmarshal json from file %s", configPath)
-	}
-
-	// can't use viper if the config contains anything depending on UnmarshalJSON for its deserialization:
-	// viper ignores those hooks
-	// see: https://github.com/spf13/viper/issues/338
-	//var config *Config
-	//
-	//viper.SetConfigFile(configPath)
-	//err := viper.ReadInConfig()
-	//if err != nil {
-	//	return nil, errors.Wrap(err, "failed to ReadInConfig")
-	//}
-	//
-	//err = viper.Unmarshal(&config)
-	//if err != nil {
-	//	return nil, errors.Wrap(err, "failed to unmarshal config")
-	//}
-
-	return &config, nil
+	return config, nil
 }
@@ -21,9 +21,10 @@ under the License.
 package synopsys_scancli
 
 import (
-	"encoding/json"
 	"fmt"
 	"github.com/blackducksoftware/cerebros/go/pkg/scanqueue"
+	utilconfig "github.com/blackducksoftware/cerebros/go/pkg/util/config"
+	"github.com/blackducksoftware/cerebros/go/pkg/util/tracing"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
@@ -40,15 +41,31 @@ func RunContainerizedCLI(configPath string) {
 	config, err := GetConfig(configPath)
 	doOrDie(err)
 
-	configSerialized, err := json.MarshalIndent(config, "", "  ")
-	doOrDie(err)
-	log.Infof("got config: \n%+v\n%s\n", config, string(configSerialized))
+	log.Infof("got config: \n%s\n", utilconfig.Redact(config))
 
 	level, err := config.getLogLevel()
 	doOrDie(err)
 	log.SetLevel(level)
 	log.Warnf("set log level to %s", level)
 
+	if config.Tracing != nil {
+		// this runs until it's killed, so spans are flushed by the batcher
+		// rather than on shutdown
+		_, err = tracing.Setup(config.Tracing)
+		doOrDie(err)
+	}
+
+	// only the log level can be changed without a restart
+	go utilconfig.Watch(configPath, &utilconfig.Options{EnvPrefix: "SCANCLI"}, func() interface{} { return &Config{} }, func(changed interface{}) {
+		level, err := changed.(*Config).getLogLevel()
+		if err != nil {
+			log.Errorf("ignoring invalid log level in reloaded config: %s", err.Error())
+			return
+		}
+		log.SetLevel(level)
+		log.Warnf("set log level to %s", level)
+	}, nil)
+
 	prometheus.Unregister(prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
 	prometheus.Unregister(prometheus.NewGoCollector())
 
@@ -60,7 +77,7 @@ func RunContainerizedCLI(configPath string) {
 		http.ListenAndServe(addr, nil)
 	}()
 
-	scanner, err := NewScannerFromConfig(config.Blackduck, config.Polaris, config.ImageFacade)
+	scanner, err := NewScannerFromConfig(config.Blackduck, config.Polaris, config.ImageFacade, config.Workspace)
 	doOrDie(err)
 
 	stop := make(chan struct{})
@@ -68,4 +85,7 @@ func RunContainerizedCLI(configPath string) {
 	log.Infof("instantiated containerized cli: %+v", cc)
 
 	<-stop
+	if err = scanner.Close(); err != nil {
+		log.Errorf("unable to clean up workspaces: %s", err.Error())
+	}
 }
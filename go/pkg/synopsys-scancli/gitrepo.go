@@ -21,45 +21,20 @@ under the License.
 package synopsys_scancli
 
 import (
-	"context"
 	"fmt"
 	"github.com/blackducksoftware/cerebros/go/pkg/util"
 	resty "github.com/go-resty/resty/v2"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"io/ioutil"
-	"os/exec"
 	"path"
 	"strings"
 	"time"
 )
 
-func GitClone(repo string) (string, error) {
-	cloneDirectory, err := ioutil.TempDir("/tmp", repo)
-	if err != nil {
-		return "", errors.Wrapf(err, "unable to create tmp dir")
-	}
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
-	defer cancel()
-	command := exec.CommandContext(ctx,
-		"git",
-		"clone",
-		fmt.Sprintf("git@github.com:%s", repo),
-		cloneDirectory)
-	// TODO can we set the command's directory to .?  or is that unnecessary?
-	err = util.CommandRunAndPrint(command)
-
-	if err != nil {
-		return "", errors.WithMessagef(err, "unable to clone repo %s to %s", repo, cloneDirectory)
-	}
-	return cloneDirectory, nil
-}
-
 // FetchGithubArchive returns tempDir, unzippedDir, error
 // the caller is responsible for removing tempDir
 func FetchGithubArchive(repo string) (string, string, error) {
-	cleanedName := strings.ReplaceAll(repo, "/", "-")
-
 	// 1. create tmp dir to put files in
 	tempDir, err := ioutil.TempDir("", "gh-archive")
 	recordEvent("create_temp_dir", err)
@@ -67,9 +42,18 @@ func FetchGithubArchive(repo string) (string, string, error) {
 		return tempDir, "", errors.Wrapf(err, "unable to create temp dir")
 	}
 
-	// 2. download archive
+	unzipPath, err := FetchGithubArchiveTo(repo, tempDir)
+	return tempDir, unzipPath, err
+}
+
+// FetchGithubArchiveTo downloads and unzips repo's master branch under directory, returning the
+// unzipped path.
+func FetchGithubArchiveTo(repo string, directory string) (string, error) {
+	cleanedName := strings.ReplaceAll(repo, "/", "-")
+
+	// 1. download archive
 	url := fmt.Sprintf("https://codeload.github.com/%s/zip/master", repo)
-	downloadPath := path.Join(tempDir, strings.ReplaceAll(repo, "/", "-")) + ".zip"
+	downloadPath := path.Join(directory, strings.ReplaceAll(repo, "/", "-")) + ".zip"
 	log.Infof("fetching github archive with url %s to file %s", url, downloadPath)
 	restyClient := resty.New()
 	restyClient.SetTimeout(1 * time.Minute)
@@ -79,23 +63,23 @@ func FetchGithubArchive(repo string) (string, string, error) {
 	recordEvent("fetch_github_archive", err)
 	log.Debugf("response from resty: %s", resp.String())
 	if err != nil {
-		return tempDir, "", errors.Wrapf(err, "http GET request to %s failed", url)
+		return "", errors.Wrapf(err, "http GET request to %s failed", url)
 	}
 
 	if resp.IsError() {
-		return tempDir, "", errors.Errorf("http GET request to %s failed with code %d", url, resp.StatusCode())
+		return "", errors.Errorf("http GET request to %s failed with code %d", url, resp.StatusCode())
 	}
 
-	// 3. unzip archive
-	unzipPath := path.Join(tempDir, cleanedName)
+	// 2. unzip archive
+	unzipPath := path.Join(directory, cleanedName)
 	unzipStart := time.Now()
 	_, err = util.Unzip(downloadPath, unzipPath)
 	recordEventTime("unzip_github_archive", time.Now().Sub(unzipStart))
 	recordEvent("unzip_github_archive", err)
 	if err != nil {
-		return tempDir, "", errors.WithMessagef(err, "unable to unzip %s for repo %s", downloadPath, repo)
+		return "", errors.WithMessagef(err, "unable to unzip %s for repo %s", downloadPath, repo)
 	}
 
-	// 4. done
-	return tempDir, unzipPath, nil
+	// 3. done
+	return unzipPath, nil
 }
@@ -37,6 +37,8 @@ type GitRepo struct {
 
 type FileSystem struct {
 	Path string
+	// Extract, if set and Path is a zip, tar, tar.gz or tar.zst archive, unpacks it to a temp dir before scanning
+	Extract bool
 }
 
 type DockerImage struct {
@@ -27,20 +27,29 @@ import (
 	"github.com/blackducksoftware/cerebros/go/pkg/polaris"
 	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
 	"github.com/blackducksoftware/cerebros/go/pkg/util"
+	utilconfig "github.com/blackducksoftware/cerebros/go/pkg/util/config"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"math/rand"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
 type Scanner struct {
 	Polaris     polaris.ScannerInterface
 	Blackduck   hubcli.ScanClientInterface
 	ImagePuller docker.ImagePullerInterface
+	Workspaces  *util.WorkspaceManager
+	// DeleteImageAfterScan removes pulled image tarballs once they've been scanned, instead of
+	// keeping them in a workspace to be reused until the quota needs the room
+	DeleteImageAfterScan bool
 }
 
 func initPolaris(config *PolarisConfig) (*polaris.Scanner, error) {
-	polarisClient := api.NewClient(config.URL, config.Email, config.Password)
+	polarisClient := api.NewClientFromCredentials(config.URL, config.Email, config.Password, config.AccessToken)
+	polarisClient.UseDefaultResilience()
+	polarisClient.Downloader.CacheDir = config.CLICacheDir
 
 	err := polarisClient.Authenticate()
 	if err != nil {
@@ -63,17 +72,28 @@ func initPolaris(config *PolarisConfig) (*polaris.Scanner, error) {
 	// TODO or should this be:
 	// cliPath := fmt.Sprintf("%s/bin", unzippedCLIPath)
 
-	tokenName := fmt.Sprintf("containerized-cli-%d", rand.Int())
-	scanToken, err := polarisClient.GetAccessToken(tokenName)
-	if err != nil {
-		return nil, errors.WithMessagef(err, "unable to get scan token")
+	accessToken := config.AccessToken
+	if accessToken == "" {
+		tokenName := fmt.Sprintf("containerized-cli-%d", rand.Int())
+		scanToken, err := polarisClient.GetAccessToken(tokenName)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "unable to get scan token")
+		}
+		accessToken = scanToken.Data.Attributes.AccessToken
 	}
 
-	return polaris.NewScanner(cliPath, config.URL, scanToken.Data.Attributes.AccessToken, config.JavaHome)
+	scanner, err := polaris.NewScanner(cliPath, config.URL, accessToken, config.JavaHome)
+	if err != nil {
+		return nil, err
+	}
+	if config.WaitForCentralAnalysis {
+		scanner.JobsClient = polarisClient
+	}
+	return scanner, nil
 }
 
 func initBlackduck(config *BlackduckConfig) (*hubcli.ScanClient, error) {
-	log.Infof("instantiating Blackduck CLI with config %+v", config)
+	log.Infof("instantiating Blackduck CLI with config %s", utilconfig.Redact(config))
 	return hubcli.NewScanClient(
 		config.Host,
 		config.Username,
@@ -104,7 +124,17 @@ func initImagePuller(config *ImageFacadeConfig) (docker.ImagePullerInterface, er
 	return imagePuller, nil
 }
 
-func NewScannerFromConfig(blackduckCfg *BlackduckConfig, polarisCfg *PolarisConfig, imagePuller *ImageFacadeConfig) (*Scanner, error) {
+func initWorkspaces(config *WorkspaceConfig) (*util.WorkspaceManager, error) {
+	if config == nil {
+		// processes sharing the default root only clean up each other's workspaces once the
+		// owner has exited
+		config = &WorkspaceConfig{Root: filepath.Join(os.TempDir(), "synopsys-scancli-workspaces")}
+	}
+	log.Infof("instantiating workspace manager with config %+v", config)
+	return util.NewWorkspaceManager(config.Root, config.QuotaMB*1024*1024)
+}
+
+func NewScannerFromConfig(blackduckCfg *BlackduckConfig, polarisCfg *PolarisConfig, imagePuller *ImageFacadeConfig, workspaceCfg *WorkspaceConfig) (*Scanner, error) {
 	var polarisScanner *polaris.Scanner
 	var err error
 	if polarisCfg != nil {
@@ -130,22 +160,38 @@ func NewScannerFromConfig(blackduckCfg *BlackduckConfig, polarisCfg *PolarisConf
 		}
 	}
 
-	return NewScanner(polarisScanner, blackduckScanner, imageFacade), nil
+	workspaces, err := initWorkspaces(workspaceCfg)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "unable to instantiate workspace manager")
+	}
+
+	scanner := NewScanner(polarisScanner, blackduckScanner, imageFacade, workspaces)
+	if imagePuller != nil {
+		scanner.DeleteImageAfterScan = imagePuller.DeleteImageAfterScan
+	}
+	return scanner, nil
 }
 
-func NewScanner(polaris polaris.ScannerInterface, blackduck hubcli.ScanClientInterface, imagePuller docker.ImagePullerInterface) *Scanner {
+func NewScanner(polaris polaris.ScannerInterface, blackduck hubcli.ScanClientInterface, imagePuller docker.ImagePullerInterface, workspaces *util.WorkspaceManager) *Scanner {
 	return &Scanner{
 		Polaris:     polaris,
 		Blackduck:   blackduck,
 		ImagePuller: imagePuller,
+		Workspaces:  workspaces,
 	}
 }
 
+// Close removes the scanner's workspaces.  It should be called on shutdown.
+func (sc *Scanner) Close() error {
+	return sc.Workspaces.Close()
+}
+
 func (sc *Scanner) Scan(scanConfig *ScanConfig) error {
-	path, err := sc.obtainScanFiles(scanConfig.CodeLocation)
+	path, cleanUp, err := sc.obtainScanFiles(scanConfig.CodeLocation)
 	if err != nil {
 		return errors.WithMessagef(err, "unable to obtain files for scanning")
 	}
+	defer cleanUp()
 
 	scanType := scanConfig.ScanType
 	if scanType.Polaris != nil {
@@ -159,8 +205,7 @@ func (sc *Scanner) Scan(scanConfig *ScanConfig) error {
 
 func cleanUpFile(path string) {
 	err := os.Remove(path)
-	// TODO
-	//recordCleanUpFile(err == nil)
+	recordEvent("clean_up_file", err)
 	if err != nil {
 		log.Errorf("unable to remove file %s: %s", path, err.Error())
 	} else {
@@ -168,33 +213,99 @@ func cleanUpFile(path string) {
 	}
 }
 
-func (sc *Scanner) obtainScanFiles(cl *CodeLocation) (string, error) {
+func releaseWorkspace(ws *util.Workspace) {
+	err := ws.Release()
+	recordEvent("release_workspace", err)
+	if err != nil {
+		log.Errorf("unable to release workspace %s: %s", ws.Path, err.Error())
+	}
+}
+
+// extractScanArchive unpacks path into a new workspace if it's a recognized archive; otherwise,
+// path is scanned as is and no workspace is allocated.
+func (sc *Scanner) extractScanArchive(path string) (string, func(), error) {
+	format, err := util.DetectArchiveFormat(path)
+	if err != nil {
+		return "", nil, errors.WithMessagef(err, "unable to detect archive format of %s", path)
+	}
+	if format == util.ArchiveFormatUnknown {
+		log.Debugf("%s is not a recognized archive, scanning as is", path)
+		return path, func() {}, nil
+	}
+	ws, err := sc.Workspaces.Allocate("scan-archive")
+	if err != nil {
+		return "", nil, errors.WithMessagef(err, "unable to allocate workspace")
+	}
+	if _, err = util.ExtractArchive(path, ws.Path, nil); err != nil {
+		releaseWorkspace(ws)
+		return "", nil, errors.WithMessagef(err, "unable to extract %s", path)
+	}
+	return ws.Path, func() { releaseWorkspace(ws) }, nil
+}
+
+// obtainImage pulls pullSpec into a workspace.  Images pinned by digest never change, so they're
+// kept after the scan and reused by later scans of the same image, unless DeleteImageAfterScan
+// is set; the workspace manager evicts them when it needs the room.
+func (sc *Scanner) obtainImage(pullSpec string) (string, func(), error) {
+	key := fmt.Sprintf("image-%s", pullSpec)
+	reusable := strings.Contains(pullSpec, "@") && !sc.DeleteImageAfterScan
+	if reusable {
+		if ws, ok := sc.Workspaces.Reuse(key); ok {
+			log.Infof("reusing image %s from workspace %s", pullSpec, ws.Path)
+			recordEvent("reuse_image", nil)
+			return docker.TarFilePath(ws.Path, pullSpec), ws.Keep, nil
+		}
+	}
+	ws, err := sc.Workspaces.Allocate(key)
+	if err != nil {
+		return "", nil, errors.WithMessagef(err, "unable to allocate workspace")
+	}
+	pullResult, err := sc.ImagePuller.PullImageTo(pullSpec, ws.Path)
+	if err != nil {
+		releaseWorkspace(ws)
+		return "", nil, errors.WithMessagef(err, "unable to get docker image %s for scanning", pullSpec)
+	}
+	// TODO do something with repo, digest, and tag?  like maybe use them to name the project/version/scan in blackduck?
+	log.Infof("image pull result: %+v", pullResult)
+	if reusable {
+		return pullResult.Path, ws.Keep, nil
+	}
+	return pullResult.Path, func() { releaseWorkspace(ws) }, nil
+}
+
+// obtainScanFiles returns the path to scan, and a function to call once the scan is done to clean up
+// anything that was created along the way.
+func (sc *Scanner) obtainScanFiles(cl *CodeLocation) (string, func(), error) {
+	noop := func() {}
 	if cl.GitRepo != nil {
-		tmpDir, dir, err := FetchGithubArchive(cl.GitRepo.Repo)
-		log.Warnf("TODO at some point, we'll probably want to delete temp dir %s", tmpDir)
-		//recordEvent("removeTempDir", os.RemoveAll(tmpDir))
-		return dir, err
+		ws, err := sc.Workspaces.Allocate("gh-archive")
+		if err != nil {
+			return "", nil, errors.WithMessagef(err, "unable to allocate workspace")
+		}
+		dir, err := FetchGithubArchiveTo(cl.GitRepo.Repo, ws.Path)
+		if err != nil {
+			releaseWorkspace(ws)
+			return "", nil, err
+		}
+		return dir, func() { releaseWorkspace(ws) }, nil
 	} else if cl.FileSystem != nil {
 		// blow up if files aren't there
 		path := cl.FileSystem.Path
 		exists, err := util.FileExists(path)
 		if err != nil {
-			return "", errors.WithMessagef(err, "unable to check if file %s exists", path)
+			return "", nil, errors.WithMessagef(err, "unable to check if file %s exists", path)
 		} else if !exists {
-			return "", errors.New(fmt.Sprintf("file %s not found", path))
+			return "", nil, errors.New(fmt.Sprintf("file %s not found", path))
 		}
-		return path, nil
-	} else if cl.DockerImage != nil {
-		pullResult, err := sc.ImagePuller.PullImage(cl.DockerImage.PullSpec)
-		if err != nil {
-			return "", errors.WithMessagef(err, "unable to get docker image %s for scanning", cl.DockerImage.PullSpec)
+		if cl.FileSystem.Extract {
+			return sc.extractScanArchive(path)
 		}
-		// TODO do something with repo, digest, and tag?  like maybe use them to name the project/version/scan in blackduck?
-		log.Infof("image pull result: %+v", pullResult)
-		return pullResult.Path, nil
+		return path, noop, nil
+	} else if cl.DockerImage != nil {
+		return sc.obtainImage(cl.DockerImage.PullSpec)
 	} else if cl.None {
-		return "", nil
+		return "", noop, nil
 	} else {
-		return "", errors.New(fmt.Sprintf("invalid codelocation config: %+v", cl))
+		return "", nil, errors.New(fmt.Sprintf("invalid codelocation config: %+v", cl))
 	}
 }
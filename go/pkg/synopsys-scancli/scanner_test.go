@@ -0,0 +1,132 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package synopsys_scancli
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/blackducksoftware/cerebros/go/pkg/blackduck/docker"
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api"
+	"github.com/blackducksoftware/cerebros/go/pkg/polaris/api/fake"
+	"github.com/blackducksoftware/cerebros/go/pkg/util"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+type fakeImagePuller struct {
+	pulls int
+}
+
+func (ip *fakeImagePuller) PullImage(pullSpec string) (*docker.PullResult, error) {
+	return nil, errors.New("images should be pulled into workspaces")
+}
+
+func (ip *fakeImagePuller) PullImageTo(pullSpec string, directory string) (*docker.PullResult, error) {
+	ip.pulls++
+	path := docker.TarFilePath(directory, pullSpec)
+	if err := ioutil.WriteFile(path, []byte("image"), 0644); err != nil {
+		return nil, err
+	}
+	return &docker.PullResult{Path: path}, nil
+}
+
+func RunScannerTests() {
+	Describe("obtainScanFiles", func() {
+		var root string
+		var puller *fakeImagePuller
+		var scanner *Scanner
+
+		BeforeEach(func() {
+			dir, err := ioutil.TempDir("", "scancli-workspaces-")
+			Expect(err).To(BeNil())
+			root = filepath.Join(dir, "root")
+			workspaces, err := util.NewWorkspaceManager(root, 0)
+			Expect(err).To(BeNil())
+			puller = &fakeImagePuller{}
+			scanner = NewScanner(nil, nil, puller, workspaces)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(filepath.Dir(root))
+		})
+
+		obtain := func(pullSpec string) string {
+			path, cleanUp, err := scanner.obtainScanFiles(&CodeLocation{DockerImage: &DockerImage{PullSpec: pullSpec}})
+			Expect(err).To(BeNil())
+			Expect(path).To(BeAnExistingFile())
+			cleanUp()
+			return path
+		}
+
+		It("should reuse images pinned by digest", func() {
+			pullSpec := "docker.io/library/alpine@sha256:abc123"
+			first := obtain(pullSpec)
+			Expect(first).To(BeAnExistingFile())
+			Expect(obtain(pullSpec)).To(Equal(first))
+			Expect(puller.pulls).To(Equal(1))
+		})
+
+		It("should pull images by tag every time, and remove them after the scan", func() {
+			first := obtain("docker.io/library/alpine:3.12")
+			Expect(first).ToNot(BeAnExistingFile())
+			obtain("docker.io/library/alpine:3.12")
+			Expect(puller.pulls).To(Equal(2))
+		})
+
+		It("should remove pinned images after the scan when DeleteImageAfterScan is set", func() {
+			scanner.DeleteImageAfterScan = true
+			Expect(obtain("docker.io/library/alpine@sha256:abc123")).ToNot(BeAnExistingFile())
+		})
+
+		It("should remove the workspace root when closed", func() {
+			obtain("docker.io/library/alpine@sha256:abc123")
+			Expect(scanner.Close()).To(Succeed())
+			Expect(root).ToNot(BeADirectory())
+		})
+	})
+
+	Describe("initPolaris", func() {
+		It("should download the CLI and make a scan token on a fake Polaris", func() {
+			server := fake.NewServer(fake.DefaultConfig())
+			defer server.Close()
+			dir, err := ioutil.TempDir("", "scancli-polaris-")
+			Expect(err).To(BeNil())
+			defer os.RemoveAll(dir)
+
+			scanner, err := initPolaris(&PolarisConfig{
+				CLIPath:  filepath.Join(dir, "cli"),
+				URL:      server.URL,
+				Email:    server.Config.Email,
+				Password: server.Config.Password,
+				OSType:   api.OSTypeLinux,
+			})
+			Expect(err).To(BeNil())
+			Expect(filepath.Join(scanner.CLIPath, "polaris")).To(BeAnExistingFile())
+			Expect(scanner.Token).ToNot(BeEmpty())
+			Expect(server.RequestCount(http.MethodPost, "/api/auth/apitokens")).To(Equal(1))
+			Expect(scanner.JobsClient).To(BeNil())
+		})
+	})
+}
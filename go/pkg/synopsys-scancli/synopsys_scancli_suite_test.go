@@ -31,5 +31,6 @@ import (
 func TestModel(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunConfigTests()
+	RunScannerTests()
 	RunSpecs(t, "synopsys scancli suite")
 }
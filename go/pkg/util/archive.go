@@ -0,0 +1,542 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package util
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ArchiveFormat identifies the container format of an archive file.
+type ArchiveFormat int
+
+const (
+	ArchiveFormatUnknown ArchiveFormat = iota
+	ArchiveFormatZip
+	ArchiveFormatTar
+	ArchiveFormatTarGz
+	ArchiveFormatTarZst
+)
+
+func (f ArchiveFormat) String() string {
+	switch f {
+	case ArchiveFormatZip:
+		return "zip"
+	case ArchiveFormatTar:
+		return "tar"
+	case ArchiveFormatTarGz:
+		return "tar.gz"
+	case ArchiveFormatTarZst:
+		return "tar.zst"
+	}
+	return "unknown"
+}
+
+var (
+	zipMagic      = []byte("PK\x03\x04")
+	zipEmptyMagic = []byte("PK\x05\x06")
+	gzipMagic     = []byte{0x1f, 0x8b}
+	zstdMagic     = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	tarMagic      = []byte("ustar")
+)
+
+// tarMagicOffset is where the "ustar" magic lives in a POSIX tar header.
+const tarMagicOffset = 257
+
+// ArchiveLimits bounds what an extraction is willing to write to disk, to protect against
+// zip bombs and similar.  A zero value for any field means "no limit".
+type ArchiveLimits struct {
+	MaxFiles     int
+	MaxFileSize  int64
+	MaxTotalSize int64
+}
+
+// DefaultArchiveLimits are used by Unzip and by ExtractArchive when no limits are given.
+var DefaultArchiveLimits = &ArchiveLimits{
+	MaxFiles:     1000000,
+	MaxFileSize:  10 * 1024 * 1024 * 1024,
+	MaxTotalSize: 50 * 1024 * 1024 * 1024,
+}
+
+// DetectArchiveFormat sniffs the magic bytes at the start of a file.
+func DetectArchiveFormat(path string) (ArchiveFormat, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ArchiveFormatUnknown, errors.Wrapf(err, "unable to open %s", path)
+	}
+	defer f.Close()
+
+	header := make([]byte, tarMagicOffset+len(tarMagic))
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return ArchiveFormatUnknown, errors.Wrapf(err, "unable to read header of %s", path)
+	}
+	return detectArchiveFormat(header[:n]), nil
+}
+
+func detectArchiveFormat(header []byte) ArchiveFormat {
+	switch {
+	case bytes.HasPrefix(header, zipMagic), bytes.HasPrefix(header, zipEmptyMagic):
+		return ArchiveFormatZip
+	case bytes.HasPrefix(header, gzipMagic):
+		return ArchiveFormatTarGz
+	case bytes.HasPrefix(header, zstdMagic):
+		return ArchiveFormatTarZst
+	case len(header) >= tarMagicOffset+len(tarMagic) && bytes.Equal(header[tarMagicOffset:tarMagicOffset+len(tarMagic)], tarMagic):
+		return ArchiveFormatTar
+	}
+	return ArchiveFormatUnknown
+}
+
+// ArchiveFormatFromName picks a format based on a file name's extension; used when creating archives.
+func ArchiveFormatFromName(name string) ArchiveFormat {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return ArchiveFormatZip
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return ArchiveFormatTarGz
+	case strings.HasSuffix(lower, ".tar.zst"), strings.HasSuffix(lower, ".tzst"):
+		return ArchiveFormatTarZst
+	case strings.HasSuffix(lower, ".tar"):
+		return ArchiveFormatTar
+	}
+	return ArchiveFormatUnknown
+}
+
+// ExtractArchive detects the format of source and extracts it into destination, returning the
+// paths that were written.  If limits is nil, DefaultArchiveLimits is used.
+func ExtractArchive(source string, destination string, limits *ArchiveLimits) ([]string, error) {
+	if limits == nil {
+		limits = DefaultArchiveLimits
+	}
+	format, err := DetectArchiveFormat(source)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("extracting %s archive %s to %s", format, source, destination)
+	switch format {
+	case ArchiveFormatZip:
+		return extractZip(source, destination, limits)
+	case ArchiveFormatTar, ArchiveFormatTarGz, ArchiveFormatTarZst:
+		return extractTar(source, destination, format, limits)
+	}
+	return nil, errors.Errorf("unrecognized archive format for %s", source)
+}
+
+// CreateArchive writes the file or directory at source into a new archive at target.
+func CreateArchive(source string, target string, format ArchiveFormat) error {
+	log.Infof("creating %s archive %s from %s", format, target, source)
+	switch format {
+	case ArchiveFormatZip:
+		return createZip(source, target)
+	case ArchiveFormatTar, ArchiveFormatTarGz, ArchiveFormatTarZst:
+		return createTar(source, target, format)
+	}
+	return errors.Errorf("unable to create archive %s: unsupported format %s", target, format)
+}
+
+// extractor enforces ArchiveLimits and keeps every written path inside the destination directory.
+type extractor struct {
+	destination string
+	absolute    string
+	// real is absolute with its symlinks resolved
+	real      string
+	limits    *ArchiveLimits
+	fileCount int
+	totalSize int64
+	filenames []string
+}
+
+func newExtractor(destination string, limits *ArchiveLimits) (*extractor, error) {
+	abs, err := filepath.Abs(destination)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to resolve destination %s", destination)
+	}
+	if err = os.MkdirAll(abs, os.ModePerm); err != nil {
+		return nil, errors.Wrapf(err, "unable to make directory %s", abs)
+	}
+	real, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to resolve destination %s", destination)
+	}
+	return &extractor{destination: destination, absolute: abs, real: real, limits: limits, filenames: []string{}}, nil
+}
+
+// resolve joins name onto the destination, rejecting anything that would escape it, either
+// by name ("zip slip") or through symlinks extracted earlier.
+func (e *extractor) resolve(name string) (string, error) {
+	fpath := filepath.Join(e.destination, name)
+	if !e.contains(e.absolute, filepath.Join(e.absolute, name)) {
+		return "", errors.Errorf("illegal path in archive: %s", name)
+	}
+	real, err := realPath(filepath.Join(e.absolute, name))
+	if err != nil {
+		return "", err
+	}
+	if !e.contains(e.real, real) {
+		return "", errors.Errorf("illegal path in archive: %s leads outside the destination through a symlink", name)
+	}
+	return fpath, nil
+}
+
+func (e *extractor) contains(root string, path string) bool {
+	return path == root || strings.HasPrefix(path, root+string(os.PathSeparator))
+}
+
+// realPath resolves the symlinks in the longest existing prefix of path.  path isn't cleaned
+// first, so that ".." after a symlink goes up from where the link points, as the OS would.
+func realPath(path string) (string, error) {
+	parts := strings.Split(path, string(os.PathSeparator))
+	for i := len(parts); i > 0; i-- {
+		prefix := strings.Join(parts[:i], string(os.PathSeparator))
+		if prefix == "" {
+			prefix = string(os.PathSeparator)
+		}
+		real, err := filepath.EvalSymlinks(prefix)
+		if err == nil {
+			return filepath.Join(append([]string{real}, parts[i:]...)...), nil
+		} else if !os.IsNotExist(err) {
+			return "", errors.Wrapf(err, "unable to resolve %s", prefix)
+		}
+	}
+	return filepath.Clean(path), nil
+}
+
+func (e *extractor) countFile() error {
+	e.fileCount++
+	if e.limits.MaxFiles > 0 && e.fileCount > e.limits.MaxFiles {
+		return errors.Errorf("archive contains more than %d files", e.limits.MaxFiles)
+	}
+	return nil
+}
+
+func (e *extractor) mkdir(name string) error {
+	fpath, err := e.resolve(name)
+	if err != nil {
+		return err
+	}
+	if err = e.countFile(); err != nil {
+		return err
+	}
+	e.filenames = append(e.filenames, fpath)
+	return errors.Wrapf(os.MkdirAll(fpath, os.ModePerm), "unable to make directory %s", fpath)
+}
+
+func (e *extractor) writeFile(name string, mode os.FileMode, r io.Reader) error {
+	fpath, err := e.resolve(name)
+	if err != nil {
+		return err
+	}
+	if err = e.countFile(); err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
+		return errors.Wrapf(err, "unable to make directory")
+	}
+	log.Tracef("writing %s", fpath)
+	f, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return errors.Wrapf(err, "unable to open file")
+	}
+	defer f.Close()
+	e.filenames = append(e.filenames, fpath)
+
+	max := e.remaining()
+	written, err := io.Copy(f, io.LimitReader(r, max+1))
+	e.totalSize += written
+	if err != nil {
+		return errors.Wrapf(err, "unable to copy file")
+	}
+	if written > max {
+		return errors.Errorf("extracting %s exceeded archive size limits", name)
+	}
+	return nil
+}
+
+// remaining is the largest number of bytes the next file may have without breaking a limit.
+func (e *extractor) remaining() int64 {
+	max := int64(1<<63 - 2)
+	if e.limits.MaxFileSize > 0 && e.limits.MaxFileSize < max {
+		max = e.limits.MaxFileSize
+	}
+	if e.limits.MaxTotalSize > 0 && e.limits.MaxTotalSize-e.totalSize < max {
+		max = e.limits.MaxTotalSize - e.totalSize
+	}
+	return max
+}
+
+// symlink only creates links whose targets stay inside the destination, following any links
+// already extracted.
+func (e *extractor) symlink(name string, target string) error {
+	fpath, err := e.resolve(name)
+	if err != nil {
+		return err
+	}
+	resolved := target
+	if !filepath.IsAbs(target) {
+		resolved = filepath.Join(e.absolute, filepath.Dir(name)) + string(os.PathSeparator) + target
+	}
+	real, err := realPath(resolved)
+	if err != nil {
+		return err
+	}
+	if !e.contains(e.real, real) {
+		return errors.Errorf("illegal symlink in archive: %s -> %s", name, target)
+	}
+	if err = e.countFile(); err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
+		return errors.Wrapf(err, "unable to make directory")
+	}
+	e.filenames = append(e.filenames, fpath)
+	return errors.Wrapf(os.Symlink(target, fpath), "unable to create symlink %s", fpath)
+}
+
+func extractZip(source string, destination string, limits *ArchiveLimits) ([]string, error) {
+	r, err := zip.OpenReader(source)
+	if err != nil {
+		return []string{}, errors.Wrapf(err, "unable to open reader")
+	}
+	defer r.Close()
+
+	e, err := newExtractor(destination, limits)
+	if err != nil {
+		return []string{}, err
+	}
+
+	for _, f := range r.File {
+		log.Tracef("looking at %s", f.Name)
+		if f.FileInfo().IsDir() {
+			err = e.mkdir(f.Name)
+		} else {
+			err = extractZipFile(e, f)
+		}
+		if err != nil {
+			return e.filenames, err
+		}
+	}
+	return e.filenames, nil
+}
+
+func extractZipFile(e *extractor, f *zip.File) error {
+	rc, err := f.Open()
+	if err != nil {
+		return errors.Wrapf(err, "unable to open file")
+	}
+	defer rc.Close()
+	return e.writeFile(f.Name, f.Mode(), rc)
+}
+
+func extractTar(source string, destination string, format ArchiveFormat, limits *ArchiveLimits) ([]string, error) {
+	file, err := os.Open(source)
+	if err != nil {
+		return []string{}, errors.Wrapf(err, "unable to open %s", source)
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	switch format {
+	case ArchiveFormatTarGz:
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return []string{}, errors.Wrapf(err, "unable to open gzip reader")
+		}
+		defer gz.Close()
+		r = gz
+	case ArchiveFormatTarZst:
+		zr, err := zstd.NewReader(file)
+		if err != nil {
+			return []string{}, errors.Wrapf(err, "unable to open zstd reader")
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	e, err := newExtractor(destination, limits)
+	if err != nil {
+		return []string{}, err
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return e.filenames, nil
+		} else if err != nil {
+			return e.filenames, errors.Wrapf(err, "unable to read tar header")
+		}
+		log.Tracef("looking at %s", header.Name)
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = e.mkdir(header.Name)
+		case tar.TypeReg, tar.TypeRegA:
+			err = e.writeFile(header.Name, os.FileMode(header.Mode), tr)
+		case tar.TypeSymlink:
+			err = e.symlink(header.Name, header.Linkname)
+		default:
+			log.Warnf("skipping unsupported tar entry %s of type %c", header.Name, header.Typeflag)
+		}
+		if err != nil {
+			return e.filenames, err
+		}
+	}
+}
+
+// walkArchiveSource calls visit for source and everything under it, with the name each entry
+// should have inside the archive.
+func walkArchiveSource(source string, visit func(path string, name string, info os.FileInfo) error) error {
+	info, err := os.Stat(source)
+	if err != nil {
+		return errors.Wrapf(err, "unable to stat %s", source)
+	}
+
+	var baseDir string
+	if info.IsDir() {
+		baseDir = filepath.Base(source)
+	}
+
+	return filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		name := filepath.Base(path)
+		if baseDir != "" {
+			name = filepath.Join(baseDir, strings.TrimPrefix(path, source))
+		}
+		return visit(path, filepath.ToSlash(name), info)
+	})
+}
+
+func copyFileTo(w io.Writer, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(w, file)
+	return err
+}
+
+func createZip(source string, target string) error {
+	zipfile, err := os.Create(target)
+	if err != nil {
+		return errors.Wrapf(err, "unable to create %s", target)
+	}
+	defer zipfile.Close()
+
+	archive := zip.NewWriter(zipfile)
+	err = walkArchiveSource(source, func(path string, name string, info os.FileInfo) error {
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+		} else {
+			header.Method = zip.Deflate
+		}
+
+		writer, err := archive.CreateHeader(header)
+		if err != nil || info.IsDir() {
+			return err
+		}
+		return copyFileTo(writer, path)
+	})
+	if err != nil {
+		archive.Close()
+		return errors.Wrapf(err, "unable to zip %s", source)
+	}
+	return errors.Wrapf(archive.Close(), "unable to finish writing %s", target)
+}
+
+func createTar(source string, target string, format ArchiveFormat) error {
+	file, err := os.Create(target)
+	if err != nil {
+		return errors.Wrapf(err, "unable to create %s", target)
+	}
+	defer file.Close()
+
+	var w io.WriteCloser
+	switch format {
+	case ArchiveFormatTarGz:
+		w = gzip.NewWriter(file)
+	case ArchiveFormatTarZst:
+		w, err = zstd.NewWriter(file)
+		if err != nil {
+			return errors.Wrapf(err, "unable to open zstd writer")
+		}
+	default:
+		w = nopWriteCloser{file}
+	}
+
+	tw := tar.NewWriter(w)
+	err = walkArchiveSource(source, func(path string, name string, info os.FileInfo) error {
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err = tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		return copyFileTo(tw, path)
+	})
+	if err != nil {
+		tw.Close()
+		w.Close()
+		return errors.Wrapf(err, "unable to tar %s", source)
+	}
+	if err = tw.Close(); err != nil {
+		return errors.Wrapf(err, "unable to finish writing %s", target)
+	}
+	return errors.Wrapf(w.Close(), "unable to finish writing %s", target)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
@@ -0,0 +1,157 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package util
+
+import (
+	"archive/tar"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func makeArchiveSource() string {
+	dir, err := ioutil.TempDir("", "archive-test")
+	Expect(err).To(Succeed())
+	src := filepath.Join(dir, "src")
+	Expect(os.MkdirAll(filepath.Join(src, "nested"), 0755)).To(Succeed())
+	Expect(ioutil.WriteFile(filepath.Join(src, "a.txt"), []byte("hello"), 0644)).To(Succeed())
+	Expect(ioutil.WriteFile(filepath.Join(src, "nested", "b.txt"), []byte("world"), 0644)).To(Succeed())
+	return dir
+}
+
+// writeTar writes headers, with contents for the regular files, to a new tar at target
+func writeTar(target string, headers []*tar.Header, contents map[string]string) {
+	file, err := os.Create(target)
+	Expect(err).To(Succeed())
+	tw := tar.NewWriter(file)
+	for _, header := range headers {
+		header.Size = int64(len(contents[header.Name]))
+		Expect(tw.WriteHeader(header)).To(Succeed())
+		_, err = tw.Write([]byte(contents[header.Name]))
+		Expect(err).To(Succeed())
+	}
+	Expect(tw.Close()).To(Succeed())
+	Expect(file.Close()).To(Succeed())
+}
+
+func RunArchiveTests() {
+	Describe("Archive", func() {
+		for _, format := range []ArchiveFormat{ArchiveFormatZip, ArchiveFormatTar, ArchiveFormatTarGz, ArchiveFormatTarZst} {
+			format := format
+			It("should round trip "+format.String(), func() {
+				dir := makeArchiveSource()
+				defer os.RemoveAll(dir)
+
+				target := filepath.Join(dir, "out."+format.String())
+				Expect(ArchiveFormatFromName(target)).To(Equal(format))
+				Expect(CreateArchive(filepath.Join(dir, "src"), target, format)).To(Succeed())
+
+				detected, err := DetectArchiveFormat(target)
+				Expect(err).To(Succeed())
+				Expect(detected).To(Equal(format))
+
+				dest := filepath.Join(dir, "dest")
+				_, err = ExtractArchive(target, dest, nil)
+				Expect(err).To(Succeed())
+				contents, err := ioutil.ReadFile(filepath.Join(dest, "src", "nested", "b.txt"))
+				Expect(err).To(Succeed())
+				Expect(string(contents)).To(Equal("world"))
+			})
+		}
+
+		It("should enforce size limits", func() {
+			dir := makeArchiveSource()
+			defer os.RemoveAll(dir)
+
+			target := filepath.Join(dir, "out.zip")
+			Expect(Zipit(filepath.Join(dir, "src"), target)).To(Succeed())
+			_, err := ExtractArchive(target, filepath.Join(dir, "dest"), &ArchiveLimits{MaxTotalSize: 7})
+			Expect(err).To(HaveOccurred())
+			_, err = ExtractArchive(target, filepath.Join(dir, "dest"), &ArchiveLimits{MaxFiles: 2})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should reject entries escaping the destination", func() {
+			dir, err := ioutil.TempDir("", "archive-test")
+			Expect(err).To(Succeed())
+			defer os.RemoveAll(dir)
+
+			target := filepath.Join(dir, "evil.tar")
+			file, err := os.Create(target)
+			Expect(err).To(Succeed())
+			tw := tar.NewWriter(file)
+			Expect(tw.WriteHeader(&tar.Header{Name: "../evil.txt", Mode: 0644, Size: 4, Typeflag: tar.TypeReg})).To(Succeed())
+			_, err = tw.Write([]byte("evil"))
+			Expect(err).To(Succeed())
+			Expect(tw.Close()).To(Succeed())
+			Expect(file.Close()).To(Succeed())
+
+			_, err = ExtractArchive(target, filepath.Join(dir, "dest"), nil)
+			Expect(err).To(HaveOccurred())
+			exists, err := FileExists(filepath.Join(dir, "evil.txt"))
+			Expect(err).To(Succeed())
+			Expect(exists).To(BeFalse())
+		})
+
+		It("should reject symlink chains escaping the destination", func() {
+			dir, err := ioutil.TempDir("", "archive-test")
+			Expect(err).To(Succeed())
+			defer os.RemoveAll(dir)
+
+			target := filepath.Join(dir, "evil.tar")
+			writeTar(target, []*tar.Header{
+				{Name: "t", Linkname: ".", Typeflag: tar.TypeSymlink},
+				{Name: "u", Linkname: "t/..", Typeflag: tar.TypeSymlink},
+				{Name: "u/evil.txt", Mode: 0644, Typeflag: tar.TypeReg},
+			}, map[string]string{"u/evil.txt": "evil"})
+
+			_, err = ExtractArchive(target, filepath.Join(dir, "dest"), nil)
+			Expect(err).To(HaveOccurred())
+			exists, err := FileExists(filepath.Join(dir, "evil.txt"))
+			Expect(err).To(Succeed())
+			Expect(exists).To(BeFalse())
+		})
+
+		It("should write through symlinks that stay inside the destination", func() {
+			dir, err := ioutil.TempDir("", "archive-test")
+			Expect(err).To(Succeed())
+			defer os.RemoveAll(dir)
+
+			target := filepath.Join(dir, "links.tar")
+			writeTar(target, []*tar.Header{
+				{Name: "nested/", Mode: 0755, Typeflag: tar.TypeDir},
+				{Name: "t", Linkname: "nested", Typeflag: tar.TypeSymlink},
+				{Name: "t/ok.txt", Mode: 0644, Typeflag: tar.TypeReg},
+			}, map[string]string{"t/ok.txt": "ok"})
+
+			dest := filepath.Join(dir, "dest")
+			_, err = ExtractArchive(target, dest, nil)
+			Expect(err).To(Succeed())
+			contents, err := ioutil.ReadFile(filepath.Join(dest, "nested", "ok.txt"))
+			Expect(err).To(Succeed())
+			Expect(string(contents)).To(Equal("ok"))
+		})
+	})
+}
@@ -0,0 +1,132 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type testServerConfig struct {
+	Host     string `config:"required"`
+	Port     int
+	Password string
+	Token    string `config:"secret"`
+}
+
+type testConfig struct {
+	Server   *testServerConfig
+	Other    *testServerConfig
+	Timeout  time.Duration
+	Tags     []string
+	LogLevel string `config:"required"`
+}
+
+func writeTestFile(dir string, name string, contents string) string {
+	path := filepath.Join(dir, name)
+	Expect(ioutil.WriteFile(path, []byte(contents), 0644)).To(Succeed())
+	return path
+}
+
+func RunConfigTests() {
+	Describe("Config", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "config-test")
+			Expect(err).To(Succeed())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+			for _, kv := range os.Environ() {
+				if strings.HasPrefix(kv, "CONFIGTEST_") {
+					os.Unsetenv(strings.SplitN(kv, "=", 2)[0])
+				}
+			}
+		})
+
+		It("should load json and yaml identically", func() {
+			jsonPath := writeTestFile(dir, "conf.json", `{"LogLevel": "debug", "Server": {"Host": "a", "Port": 1}}`)
+			yamlPath := writeTestFile(dir, "conf.yaml", "LogLevel: debug\nServer:\n  Host: a\n  Port: 1\n")
+			fromJSON, fromYAML := &testConfig{}, &testConfig{}
+			Expect(Load(jsonPath, fromJSON, nil)).To(Succeed())
+			Expect(Load(yamlPath, fromYAML, nil)).To(Succeed())
+			Expect(fromJSON).To(Equal(fromYAML))
+			Expect(fromJSON.Server.Port).To(Equal(1))
+		})
+
+		It("should apply env overrides, including from files", func() {
+			path := writeTestFile(dir, "conf.json", `{"LogLevel": "debug", "Server": {"Host": "a"}}`)
+			secretPath := writeTestFile(dir, "secret", "hunter2\n")
+			os.Setenv("CONFIGTEST_SERVER_PORT", "8080")
+			os.Setenv("CONFIGTEST_SERVER_PASSWORD_FILE", secretPath)
+			os.Setenv("CONFIGTEST_OTHER_HOST", "b")
+			os.Setenv("CONFIGTEST_TIMEOUT", "90s")
+			os.Setenv("CONFIGTEST_TAGS", `["x", "y"]`)
+
+			config := &testConfig{}
+			Expect(Load(path, config, &Options{EnvPrefix: "configtest"})).To(Succeed())
+			Expect(config.Server.Port).To(Equal(8080))
+			Expect(config.Server.Password).To(Equal("hunter2"))
+			Expect(config.Other.Host).To(Equal("b"))
+			Expect(config.Timeout).To(Equal(90 * time.Second))
+			Expect(config.Tags).To(Equal([]string{"x", "y"}))
+		})
+
+		It("should report all missing required fields", func() {
+			path := writeTestFile(dir, "conf.json", `{"Server": {"Port": 1}}`)
+			err := Load(path, &testConfig{}, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Server.Host"))
+			Expect(err.Error()).To(ContainSubstring("LogLevel"))
+		})
+
+		It("should redact secrets", func() {
+			redacted := Redact(&testConfig{Server: &testServerConfig{Host: "a", Password: "hunter2", Token: "abc"}})
+			Expect(redacted).To(ContainSubstring(`"Host": "a"`))
+			Expect(redacted).NotTo(ContainSubstring("hunter2"))
+			Expect(redacted).NotTo(ContainSubstring("abc"))
+		})
+
+		It("should reload on change", func() {
+			path := writeTestFile(dir, "conf.json", `{"LogLevel": "debug"}`)
+			stop := make(chan struct{})
+			defer close(stop)
+			changes := make(chan *testConfig, 1)
+			go Watch(path, &Options{ReloadInterval: 10 * time.Millisecond}, func() interface{} { return &testConfig{} }, func(c interface{}) {
+				changes <- c.(*testConfig)
+			}, stop)
+
+			time.Sleep(50 * time.Millisecond)
+			writeTestFile(dir, "conf.json", `{"LogLevel": "info"}`)
+			Eventually(changes).Should(Receive(WithTransform(func(c *testConfig) string { return c.LogLevel }, Equal("info"))))
+		})
+	})
+}
@@ -0,0 +1,175 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package config
+
+import (
+	"encoding"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// ApplyEnv overrides fields of target, a pointer to a struct, from environment variables.
+//
+// Variable names are the prefix and the path of upper-cased field names joined by underscores:
+// with prefix SCANCLI, Blackduck.Password is SCANCLI_BLACKDUCK_PASSWORD.  Appending _FILE
+// (SCANCLI_BLACKDUCK_PASSWORD_FILE) instead reads the value from that file, which is how
+// mounted secrets should be passed in.  Nil struct pointers are allocated if any of their fields
+// are set.  Slices and maps are parsed as JSON.
+func ApplyEnv(prefix string, target interface{}) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return errors.Errorf("expected pointer to struct, found %T", target)
+	}
+	_, err := applyEnvToStruct(strings.ToUpper(prefix), v.Elem())
+	return err
+}
+
+// applyEnvToStruct returns whether any field was set.
+func applyEnvToStruct(prefix string, v reflect.Value) (bool, error) {
+	set := false
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		fieldSet, err := applyEnvToValue(prefix+"_"+strings.ToUpper(field.Name), v.Field(i))
+		if err != nil {
+			return set, err
+		}
+		set = set || fieldSet
+	}
+	return set, nil
+}
+
+func applyEnvToValue(name string, v reflect.Value) (bool, error) {
+	value, ok, err := lookupEnv(name)
+	if err != nil {
+		return false, err
+	}
+	if ok {
+		return true, errors.WithMessagef(setFromString(v, value), "unable to set %s", name)
+	}
+
+	if v.Kind() == reflect.Struct && !v.Addr().Type().Implements(textUnmarshalerType) {
+		return applyEnvToStruct(name, v)
+	}
+	if v.Kind() == reflect.Ptr && v.Type().Elem().Kind() == reflect.Struct && !v.Type().Implements(textUnmarshalerType) {
+		if !v.IsNil() {
+			return applyEnvToStruct(name, v.Elem())
+		}
+		if !anyEnvWithPrefix(name + "_") {
+			return false, nil
+		}
+		elem := reflect.New(v.Type().Elem())
+		set, err := applyEnvToStruct(name, elem.Elem())
+		if set {
+			v.Set(elem)
+		}
+		return set, err
+	}
+	return false, nil
+}
+
+func lookupEnv(name string) (string, bool, error) {
+	if path, ok := os.LookupEnv(name + "_FILE"); ok {
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", false, errors.Wrapf(err, "unable to read %s_FILE %s", name, path)
+		}
+		return strings.TrimRight(string(contents), "\r\n"), true, nil
+	}
+	value, ok := os.LookupEnv(name)
+	return value, ok, nil
+}
+
+func anyEnvWithPrefix(prefix string) bool {
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func setFromString(v reflect.Value, value string) error {
+	if v.Kind() == reflect.Ptr {
+		elem := reflect.New(v.Type().Elem())
+		if err := setFromString(elem.Elem(), value); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+	if v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return errors.Wrapf(err, "invalid duration %s", value)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.Wrapf(err, "invalid bool %s", value)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return errors.Wrapf(err, "invalid int %s", value)
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return errors.Wrapf(err, "invalid uint %s", value)
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return errors.Wrapf(err, "invalid float %s", value)
+		}
+		v.SetFloat(f)
+	case reflect.Slice, reflect.Map, reflect.Struct, reflect.Interface:
+		return errors.Wrapf(json.Unmarshal([]byte(value), v.Addr().Interface()), "invalid json %s", value)
+	default:
+		return errors.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// Options controls how a config file is loaded.
+type Options struct {
+	// EnvPrefix enables environment variable overrides; see ApplyEnv.  Empty means no overrides.
+	EnvPrefix string
+	// ReloadInterval is how often Watch checks the file for changes; defaults to 10 seconds.
+	ReloadInterval time.Duration
+}
+
+// Load reads a JSON or YAML file (by extension) into target, which must be a pointer to a struct,
+// then applies environment variable overrides and validates the result.
+//
+// Decoding always goes through encoding/json -- YAML is converted first -- so that field names
+// match case-insensitively and types' UnmarshalJSON and UnmarshalText hooks are honored.
+func Load(path string, target interface{}, options *Options) error {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "unable to read config file %s", path)
+	}
+	return loadBytes(path, bytes, target, options)
+}
+
+func loadBytes(path string, contents []byte, target interface{}, options *Options) error {
+	if isYAML(path) {
+		var err error
+		contents, err = yamlToJSON(contents)
+		if err != nil {
+			return errors.WithMessagef(err, "unable to parse yaml config file %s", path)
+		}
+	}
+	if err := json.Unmarshal(contents, target); err != nil {
+		return errors.Wrapf(err, "unable to unmarshal config file %s", path)
+	}
+	if options != nil && options.EnvPrefix != "" {
+		if err := ApplyEnv(options.EnvPrefix, target); err != nil {
+			return errors.WithMessagef(err, "unable to apply environment overrides to config file %s", path)
+		}
+	}
+	return errors.WithMessagef(Validate(target), "invalid config file %s", path)
+}
+
+func isYAML(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+func yamlToJSON(contents []byte) ([]byte, error) {
+	var obj interface{}
+	if err := yaml.Unmarshal(contents, &obj); err != nil {
+		return nil, errors.Wrapf(err, "unable to unmarshal yaml")
+	}
+	return json.Marshal(obj)
+}
+
+// Watch polls path for changes, and calls onChange with a freshly loaded config each time its
+// contents change.  newTarget must return a new pointer to load into, each time it's called.
+// Changes which fail to load or validate are logged and skipped.  Watch returns once stop is closed.
+func Watch(path string, options *Options, newTarget func() interface{}, onChange func(config interface{}), stop <-chan struct{}) {
+	interval := 10 * time.Second
+	if options != nil && options.ReloadInterval > 0 {
+		interval = options.ReloadInterval
+	}
+	var lastHash []byte
+	if contents, err := ioutil.ReadFile(path); err == nil {
+		sum := sha256.Sum256(contents)
+		lastHash = sum[:]
+	}
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Errorf("unable to read config file %s for reload: %s", path, err.Error())
+			continue
+		}
+		sum := sha256.Sum256(contents)
+		if bytes.Equal(sum[:], lastHash) {
+			continue
+		}
+		lastHash = sum[:]
+		target := newTarget()
+		if err = loadBytes(path, contents, target, options); err != nil {
+			log.Errorf("unable to reload config file %s, keeping previous config: %s", path, err.Error())
+			continue
+		}
+		log.Infof("reloaded config file %s: %s", path, Redact(target))
+		onChange(target)
+	}
+}
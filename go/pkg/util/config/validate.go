@@ -0,0 +1,151 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Struct tag options, set with `config:"..."`, comma-separated.
+const (
+	// TagRequired makes Validate reject the zero value for a field
+	TagRequired = "required"
+	// TagSecret makes Redact hide a field's value.  Fields whose names end in "Password" are
+	// always treated as secret.
+	TagSecret = "secret"
+)
+
+const redacted = "[REDACTED]"
+
+func hasTag(field reflect.StructField, option string) bool {
+	for _, o := range strings.Split(field.Tag.Get("config"), ",") {
+		if strings.TrimSpace(o) == option {
+			return true
+		}
+	}
+	return false
+}
+
+func isSecret(field reflect.StructField) bool {
+	return hasTag(field, TagSecret) || strings.HasSuffix(field.Name, "Password")
+}
+
+// Validate checks `config:"required"` fields of target, and of any non-nil nested structs,
+// reporting every missing field at once.
+func Validate(target interface{}) error {
+	missing := []string{}
+	validateValue("", reflect.ValueOf(target), &missing)
+	if len(missing) > 0 {
+		return errors.Errorf("missing required config fields: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func validateValue(path string, v reflect.Value, missing *[]string) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		fieldPath := field.Name
+		if path != "" {
+			fieldPath = fmt.Sprintf("%s.%s", path, field.Name)
+		}
+		if hasTag(field, TagRequired) && v.Field(i).IsZero() {
+			*missing = append(*missing, fieldPath)
+			continue
+		}
+		validateValue(fieldPath, v.Field(i), missing)
+	}
+}
+
+// Redact renders target as indented JSON, with secret fields replaced by "[REDACTED]".  Use this
+// rather than %+v whenever a config is logged.
+func Redact(target interface{}) string {
+	bytes, err := json.MarshalIndent(redactValue(reflect.ValueOf(target)), "", "  ")
+	if err != nil {
+		return fmt.Sprintf("<unable to serialize config: %s>", err.Error())
+	}
+	return string(bytes)
+}
+
+func redactValue(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if _, ok := v.Interface().(json.Marshaler); ok {
+		return v.Interface()
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return redactValue(v.Elem())
+	case reflect.Struct:
+		out := map[string]interface{}{}
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			if isSecret(field) && !v.Field(i).IsZero() {
+				out[field.Name] = redacted
+			} else {
+				out[field.Name] = redactValue(v.Field(i))
+			}
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		out := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out[i] = redactValue(v.Index(i))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		out := map[string]interface{}{}
+		for _, key := range v.MapKeys() {
+			out[fmt.Sprintf("%v", key.Interface())] = redactValue(v.MapIndex(key))
+		}
+		return out
+	}
+	return v.Interface()
+}
@@ -22,38 +22,291 @@ under the License.
 package util
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 )
 
+// Downloader fetches large files over http, resuming interrupted transfers with Range requests,
+// retrying with jittered exponential backoff, optionally verifying a SHA-256 digest, and optionally
+// caching completed downloads on disk keyed by URL and ETag.
+type Downloader struct {
+	Client *http.Client
+	// CacheDir, if non-empty, holds completed and partial downloads across calls and process restarts.
+	CacheDir     string
+	MaxRetries   int
+	RetryBackoff time.Duration
+	// ProgressInterval controls how often progress is logged; 0 disables progress logging.
+	ProgressInterval time.Duration
+}
+
+// NewDownloader creates a Downloader with sensible retry defaults.  cacheDir may be empty.
+func NewDownloader(cacheDir string) *Downloader {
+	return &Downloader{
+		Client:           &http.Client{Timeout: 30 * time.Minute},
+		CacheDir:         cacheDir,
+		MaxRetries:       5,
+		RetryBackoff:     2 * time.Second,
+		ProgressInterval: 10 * time.Second,
+	}
+}
+
 func DownloadFile(filepath string, url string) (err error) {
+	return NewDownloader("").Download(url, filepath, "")
+}
 
-	// Create the file
-	out, err := os.Create(filepath)
+// Download writes the contents of url to path.  If expectedSHA256 is non-empty, the download is
+// rejected (and discarded) unless its hex-encoded SHA-256 digest matches.
+func (d *Downloader) Download(url string, path string, expectedSHA256 string) error {
+	expectedSHA256 = strings.ToLower(expectedSHA256)
+	start := time.Now()
+
+	etag := d.fetchETag(url)
+	partialPath := path + ".partial"
+	var cachePath string
+	if d.CacheDir != "" {
+		if err := os.MkdirAll(d.CacheDir, 0755); err != nil {
+			return errors.Wrapf(err, "unable to create download cache dir %s", d.CacheDir)
+		}
+		cachePath = filepath.Join(d.CacheDir, downloadCacheKey(url, etag))
+		partialPath = cachePath + ".partial"
+		if etag != "" {
+			hit, err := d.useCached(cachePath, path, expectedSHA256)
+			if err != nil {
+				return err
+			}
+			if hit {
+				recordDownloadEvent("cache_hit", nil)
+				log.Infof("using cached download of %s from %s", url, cachePath)
+				return nil
+			}
+		}
+		recordDownloadEvent("cache_miss", nil)
+	}
+
+	var err error
+	for attempt := 0; attempt <= d.MaxRetries; attempt++ {
+		if attempt > 0 {
+			backoff := d.backoff(attempt)
+			log.Warnf("download of %s failed (attempt %d of %d), retrying in %s: %s", url, attempt, d.MaxRetries+1, backoff, err.Error())
+			time.Sleep(backoff)
+		}
+		err = d.downloadOnce(url, partialPath, etag)
+		recordDownloadEvent("attempt", err)
+		if err == nil {
+			break
+		}
+		if _, ok := err.(permanentDownloadError); ok {
+			break
+		}
+	}
 	if err != nil {
-		return err
+		return errors.WithMessagef(err, "unable to download %s", url)
 	}
-	defer out.Close()
 
-	// Get the data
-	resp, err := http.Get(url)
+	if expectedSHA256 != "" {
+		digest, err := sha256File(partialPath)
+		if err != nil {
+			return err
+		}
+		if digest != expectedSHA256 {
+			os.Remove(partialPath)
+			err = errors.Errorf("checksum mismatch for %s: expected sha256 %s, got %s", url, expectedSHA256, digest)
+			recordDownloadEvent("verify", err)
+			return err
+		}
+		recordDownloadEvent("verify", nil)
+	}
+
+	if cachePath == "" {
+		err = os.Rename(partialPath, path)
+	} else {
+		if err = os.Rename(partialPath, cachePath); err == nil {
+			err = copyFile(cachePath, path)
+		}
+	}
 	if err != nil {
-		return err
+		return errors.Wrapf(err, "unable to move completed download of %s to %s", url, path)
+	}
+	recordDownloadDuration(time.Now().Sub(start))
+	log.Infof("downloaded %s to %s in %s", url, path, time.Now().Sub(start))
+	return nil
+}
+
+// backoff returns the jittered delay before retrying after the given attempt (1-based), so that
+// clients which failed together don't all retry together.
+func (d *Downloader) backoff(attempt int) time.Duration {
+	delay := d.RetryBackoff * time.Duration(1<<uint(attempt-1))
+	if delay <= 0 {
+		return 0
+	}
+	// equal jitter: somewhere between half and all of the exponential delay
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+// permanentDownloadError marks failures which retrying won't fix, such as a 404.
+type permanentDownloadError struct {
+	error
+}
+
+func (d *Downloader) fetchETag(url string) string {
+	resp, err := d.Client.Head(url)
+	if err != nil {
+		log.Debugf("HEAD %s failed, proceeding without etag: %s", url, err.Error())
+		return ""
+	}
+	resp.Body.Close()
+	return resp.Header.Get("ETag")
+}
+
+func (d *Downloader) useCached(cachePath string, path string, expectedSHA256 string) (bool, error) {
+	exists, err := FileExists(cachePath)
+	if err != nil || !exists {
+		return false, err
+	}
+	if expectedSHA256 != "" {
+		digest, err := sha256File(cachePath)
+		if err != nil {
+			return false, err
+		}
+		if digest != expectedSHA256 {
+			log.Warnf("cached download %s failed verification, discarding", cachePath)
+			os.Remove(cachePath)
+			return false, nil
+		}
+	}
+	return true, errors.Wrapf(copyFile(cachePath, path), "unable to copy cached download %s", cachePath)
+}
+
+// downloadOnce fetches url into partialPath, continuing from the end of partialPath if the server
+// honors Range requests.  Without an ETag there's no way to tell whether partialPath came from the
+// same version of the file, so it's discarded rather than resumed.
+func (d *Downloader) downloadOnce(url string, partialPath string, etag string) error {
+	var offset int64
+	if info, err := os.Stat(partialPath); err == nil {
+		offset = info.Size()
+	}
+	if offset > 0 && etag == "" {
+		log.Infof("no etag for %s, discarding %d bytes of partial download and starting over", url, offset)
+		if err := os.Remove(partialPath); err != nil {
+			return permanentDownloadError{errors.Wrapf(err, "unable to remove %s", partialPath)}
+		}
+		offset = 0
 	}
-	defer resp.Body.Close()
 
-	// Check server response
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("bad status: %s", resp.Status)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return permanentDownloadError{errors.Wrapf(err, "unable to create request for %s", url)}
+	}
+	if offset > 0 {
+		log.Infof("resuming download of %s at byte %d", url, offset)
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", etag)
 	}
 
-	// Writer the body to file
-	_, err = io.Copy(out, resp.Body)
+	resp, err := d.Client.Do(req)
 	if err != nil {
+		return errors.Wrapf(err, "GET %s failed", url)
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		flags |= os.O_APPEND
+		recordDownloadEvent("resume", nil)
+	case http.StatusOK:
+		// server ignored or rejected the range: start over
+		flags |= os.O_TRUNC
+		offset = 0
+	case http.StatusRequestedRangeNotSatisfiable:
+		// most likely the partial file is already complete, or is stale; start over next time
+		os.Remove(partialPath)
+		return errors.Errorf("bad status for GET %s: %s", url, resp.Status)
+	default:
+		err = errors.Errorf("bad status for GET %s: %s", url, resp.Status)
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return permanentDownloadError{err}
+		}
 		return err
 	}
 
-	return nil
+	out, err := os.OpenFile(partialPath, flags, 0644)
+	if err != nil {
+		return permanentDownloadError{errors.Wrapf(err, "unable to open %s", partialPath)}
+	}
+	defer out.Close()
+
+	progress := &progressWriter{url: url, total: offset + resp.ContentLength, written: offset, interval: d.ProgressInterval, last: time.Now()}
+	_, err = io.Copy(out, io.TeeReader(resp.Body, progress))
+	return errors.Wrapf(err, "unable to write body of %s to %s", url, partialPath)
+}
+
+// progressWriter counts bytes as they stream past, and periodically logs how far along a download is.
+type progressWriter struct {
+	url      string
+	total    int64
+	written  int64
+	interval time.Duration
+	last     time.Time
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	p.written += int64(len(b))
+	recordDownloadBytes(len(b))
+	if p.interval > 0 && time.Now().Sub(p.last) >= p.interval {
+		p.last = time.Now()
+		if p.total > 0 {
+			log.Infof("downloading %s: %d of %d bytes (%.1f%%)", p.url, p.written, p.total, 100*float64(p.written)/float64(p.total))
+		} else {
+			log.Infof("downloading %s: %d bytes", p.url, p.written)
+		}
+	}
+	return len(b), nil
+}
+
+func downloadCacheKey(url string, etag string) string {
+	sum := sha256.Sum256([]byte(url + "\n" + etag))
+	return hex.EncodeToString(sum[:])
+}
+
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to open %s", path)
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err = io.Copy(hash, f); err != nil {
+		return "", errors.Wrapf(err, "unable to read %s", path)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func copyFile(from string, to string) error {
+	in, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(to)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
 }
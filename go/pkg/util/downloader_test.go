@@ -0,0 +1,137 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package util
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func RunDownloaderTests() {
+	Describe("Downloader", func() {
+		content := bytes.Repeat([]byte("0123456789"), 1000)
+		sum := sha256.Sum256(content)
+		digest := hex.EncodeToString(sum[:])
+
+		var server *httptest.Server
+		var gets, ranges int
+		var etag string
+		var dir string
+
+		BeforeEach(func() {
+			gets, ranges = 0, 0
+			etag = `"v1"`
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet {
+					gets++
+					if r.Header.Get("Range") != "" {
+						ranges++
+					}
+				}
+				if etag != "" {
+					w.Header().Set("ETag", etag)
+				}
+				http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(content))
+			}))
+			var err error
+			dir, err = ioutil.TempDir("", "downloader-test")
+			Expect(err).To(Succeed())
+		})
+
+		AfterEach(func() {
+			server.Close()
+			os.RemoveAll(dir)
+		})
+
+		It("should download and verify", func() {
+			path := filepath.Join(dir, "out")
+			Expect(NewDownloader("").Download(server.URL, path, digest)).To(Succeed())
+			written, err := ioutil.ReadFile(path)
+			Expect(err).To(Succeed())
+			Expect(written).To(Equal(content))
+		})
+
+		It("should reject a bad checksum", func() {
+			d := NewDownloader("")
+			d.MaxRetries = 0
+			path := filepath.Join(dir, "out")
+			Expect(d.Download(server.URL, path, "abcd")).NotTo(Succeed())
+			exists, err := FileExists(path)
+			Expect(err).To(Succeed())
+			Expect(exists).To(BeFalse())
+		})
+
+		It("should resume a partial download", func() {
+			path := filepath.Join(dir, "out")
+			Expect(ioutil.WriteFile(path+".partial", content[:1234], 0644)).To(Succeed())
+			Expect(NewDownloader("").Download(server.URL, path, digest)).To(Succeed())
+			written, err := ioutil.ReadFile(path)
+			Expect(err).To(Succeed())
+			Expect(written).To(Equal(content))
+			Expect(ranges).To(Equal(1))
+		})
+
+		It("should start over instead of resuming when there's no etag", func() {
+			etag = ""
+			path := filepath.Join(dir, "out")
+			Expect(ioutil.WriteFile(path+".partial", []byte("stale bytes from an older version"), 0644)).To(Succeed())
+			Expect(NewDownloader("").Download(server.URL, path, digest)).To(Succeed())
+			written, err := ioutil.ReadFile(path)
+			Expect(err).To(Succeed())
+			Expect(written).To(Equal(content))
+			Expect(ranges).To(Equal(0))
+		})
+
+		It("should jitter the retry backoff", func() {
+			d := NewDownloader("")
+			d.RetryBackoff = time.Second
+			delays := map[time.Duration]bool{}
+			for i := 0; i < 20; i++ {
+				delay := d.backoff(3)
+				Expect(delay).To(BeNumerically(">=", 2*time.Second))
+				Expect(delay).To(BeNumerically("<=", 4*time.Second))
+				delays[delay] = true
+			}
+			Expect(len(delays)).To(BeNumerically(">", 1))
+		})
+
+		It("should serve repeat downloads from the cache", func() {
+			d := NewDownloader(filepath.Join(dir, "cache"))
+			Expect(d.Download(server.URL, filepath.Join(dir, "first"), digest)).To(Succeed())
+			Expect(d.Download(server.URL, filepath.Join(dir, "second"), digest)).To(Succeed())
+			Expect(gets).To(Equal(1))
+			written, err := ioutil.ReadFile(filepath.Join(dir, "second"))
+			Expect(err).To(Succeed())
+			Expect(written).To(Equal(content))
+		})
+	})
+}
@@ -0,0 +1,110 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var downloadEventCounter *prometheus.CounterVec
+var downloadBytesCounter prometheus.Counter
+var downloadDurationHistogram prometheus.Histogram
+var workspaceEventCounter *prometheus.CounterVec
+var workspaceBytesGauge *prometheus.GaugeVec
+var workspaceCountGauge *prometheus.GaugeVec
+
+func recordDownloadEvent(event string, err error) {
+	downloadEventCounter.With(prometheus.Labels{"event": event, "iserror": fmt.Sprintf("%t", err != nil)}).Inc()
+}
+
+func recordDownloadBytes(count int) {
+	downloadBytesCounter.Add(float64(count))
+}
+
+func recordDownloadDuration(duration time.Duration) {
+	downloadDurationHistogram.Observe(duration.Seconds())
+}
+
+func recordWorkspaceEvent(event string, err error) {
+	workspaceEventCounter.With(prometheus.Labels{"event": event, "iserror": fmt.Sprintf("%t", err != nil)}).Inc()
+}
+
+func recordWorkspaceUsage(root string, usage int64, quota int64, active int, kept int) {
+	workspaceBytesGauge.With(prometheus.Labels{"root": root, "type": "usage"}).Set(float64(usage))
+	workspaceBytesGauge.With(prometheus.Labels{"root": root, "type": "quota"}).Set(float64(quota))
+	workspaceCountGauge.With(prometheus.Labels{"root": root, "state": "active"}).Set(float64(active))
+	workspaceCountGauge.With(prometheus.Labels{"root": root, "state": "kept"}).Set(float64(kept))
+}
+
+func init() {
+	downloadEventCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "cerebros",
+		Subsystem: "util",
+		Name:      "download_event_counter",
+		Help:      "a count of download events: attempts, resumes, cache hits and checksum verifications",
+	}, []string{"event", "iserror"})
+	prometheus.MustRegister(downloadEventCounter)
+
+	downloadBytesCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "cerebros",
+		Subsystem: "util",
+		Name:      "download_bytes_counter",
+		Help:      "a count of bytes downloaded",
+	})
+	prometheus.MustRegister(downloadBytesCounter)
+
+	downloadDurationHistogram = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "cerebros",
+		Subsystem: "util",
+		Name:      "download_duration_histogram",
+		Help:      "a histogram of completed download durations in seconds",
+		Buckets:   prometheus.ExponentialBuckets(0.25, 2, 16),
+	})
+	prometheus.MustRegister(downloadDurationHistogram)
+
+	workspaceEventCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "cerebros",
+		Subsystem: "util",
+		Name:      "workspace_event_counter",
+		Help:      "a count of workspace events: allocations, releases and evictions",
+	}, []string{"event", "iserror"})
+	prometheus.MustRegister(workspaceEventCounter)
+
+	workspaceBytesGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "cerebros",
+		Subsystem: "util",
+		Name:      "workspace_bytes_gauge",
+		Help:      "disk usage and quota of workspace roots, in bytes",
+	}, []string{"root", "type"})
+	prometheus.MustRegister(workspaceBytesGauge)
+
+	workspaceCountGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "cerebros",
+		Subsystem: "util",
+		Name:      "workspace_count_gauge",
+		Help:      "the number of active and kept workspaces",
+	}, []string{"root", "state"})
+	prometheus.MustRegister(workspaceCountGauge)
+}
@@ -0,0 +1,148 @@
+/*
+Copyright (C) 2018 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package tracing
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+// Exporters
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+// Config turns on OpenTelemetry tracing.  Leave it out to keep the no-op
+// tracer, which costs next to nothing.
+type Config struct {
+	// Exporter is one of otlp, stdout or file
+	Exporter string `config:"required"`
+	// Endpoint is the base URL of an OTLP/HTTP collector, e.g. http://localhost:4318
+	Endpoint string
+	// Headers are sent with every OTLP export, e.g. for an API key
+	Headers map[string]string `config:"secret"`
+	// Path is the file the file exporter appends spans to, one JSON object per span
+	Path string
+	// ServiceName defaults to the name of the executable
+	ServiceName string
+	// SampleRatio is the fraction of traces kept; 0 means all of them
+	SampleRatio float64
+}
+
+// Setup installs a global tracer provider and W3C trace context propagation.
+// Call the returned function on shutdown to flush buffered spans.
+func Setup(config *Config) (func(context.Context) error, error) {
+	exporter, closeOutput, err := newExporter(config)
+	if err != nil {
+		return nil, err
+	}
+	serviceName := config.ServiceName
+	if serviceName == "" {
+		serviceName = os.Args[0]
+	}
+	sampler := sdktrace.AlwaysSample()
+	if config.SampleRatio > 0 && config.SampleRatio < 1 {
+		sampler = sdktrace.TraceIDRatioBased(config.SampleRatio)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(serviceName))))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	log.Infof("tracing with the %s exporter as service %s", config.Exporter, serviceName)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closeOutput != nil {
+			if closeErr := closeOutput(); err == nil {
+				err = closeErr
+			}
+		}
+		return errors.Wrapf(err, "unable to shut down tracing")
+	}, nil
+}
+
+// newExporter also returns a function to close the exporter's output, if it has one
+func newExporter(config *Config) (sdktrace.SpanExporter, func() error, error) {
+	switch config.Exporter {
+	case ExporterOTLP:
+		if config.Endpoint == "" {
+			return nil, nil, errors.New("the otlp exporter needs an Endpoint")
+		}
+		options, err := otlpOptions(config.Endpoint, config.Headers)
+		if err != nil {
+			return nil, nil, err
+		}
+		exporter, err := otlptracehttp.New(context.Background(), options...)
+		return exporter, nil, errors.Wrapf(err, "unable to create otlp exporter")
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		return exporter, nil, errors.Wrapf(err, "unable to create stdout exporter")
+	case ExporterFile:
+		if config.Path == "" {
+			return nil, nil, errors.New("the file exporter needs a Path")
+		}
+		file, err := os.OpenFile(config.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "unable to open trace file %s", config.Path)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, nil, errors.Wrapf(err, "unable to create file exporter")
+		}
+		return exporter, file.Close, nil
+	default:
+		return nil, nil, errors.Errorf("unknown trace exporter %s: expected %s, %s or %s", config.Exporter, ExporterOTLP, ExporterStdout, ExporterFile)
+	}
+}
+
+// otlpOptions points the OTLP/HTTP exporter at endpoint's /v1/traces.
+func otlpOptions(endpoint string, headers map[string]string) ([]otlptracehttp.Option, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Host == "" {
+		return nil, errors.Errorf("invalid otlp Endpoint %s: expected a URL like http://localhost:4318", endpoint)
+	}
+	options := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(parsed.Host),
+		otlptracehttp.WithURLPath(strings.TrimSuffix(parsed.Path, "/") + "/v1/traces"),
+		otlptracehttp.WithHeaders(headers),
+	}
+	if parsed.Scheme == "http" {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	return options, nil
+}
@@ -0,0 +1,140 @@
+/*
+Copyright (C) 2018 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package tracing
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func RunTracingTests() {
+	Describe("OTLP exporter", func() {
+		It("should post spans as OTLP protobuf", func() {
+			requests := make(chan *coltracepb.ExportTraceServiceRequest, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				Expect(r.URL.Path).To(Equal("/v1/traces"))
+				Expect(r.Header.Get("X-Api-Key")).To(Equal("secret"))
+				body, err := ioutil.ReadAll(r.Body)
+				Expect(err).To(BeNil())
+				request := &coltracepb.ExportTraceServiceRequest{}
+				Expect(proto.Unmarshal(body, request)).To(Succeed())
+				requests <- request
+			}))
+			defer server.Close()
+
+			recorder := tracetest.NewSpanRecorder()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+			ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+			_, child := provider.Tracer("test").Start(ctx, "child")
+			child.SetAttributes(attribute.Int("count", 3), attribute.Bool("cached", true))
+			child.SetStatus(codes.Error, "boom")
+			child.End()
+			parent.End()
+
+			exporter, _, err := newExporter(&Config{Exporter: ExporterOTLP, Endpoint: server.URL + "/", Headers: map[string]string{"X-Api-Key": "secret"}})
+			Expect(err).To(BeNil())
+			Expect(exporter.ExportSpans(context.Background(), recorder.Ended())).To(Succeed())
+			Expect(exporter.Shutdown(context.Background())).To(Succeed())
+
+			request := <-requests
+			Expect(request.ResourceSpans).To(HaveLen(1))
+			librarySpans := request.ResourceSpans[0].InstrumentationLibrarySpans
+			Expect(librarySpans).To(HaveLen(1))
+			spans := librarySpans[0].Spans
+			Expect(spans).To(HaveLen(2))
+
+			first := spans[0]
+			traceId := child.SpanContext().TraceID()
+			parentSpanId := parent.SpanContext().SpanID()
+			Expect(first.Name).To(Equal("child"))
+			Expect(first.TraceId).To(Equal(traceId[:]))
+			Expect(first.ParentSpanId).To(Equal(parentSpanId[:]))
+			Expect(first.Status.Code).To(Equal(tracepb.Status_STATUS_CODE_ERROR))
+			Expect(first.Status.Message).To(Equal("boom"))
+			Expect(first.Attributes[0].Key).To(Equal("count"))
+			Expect(first.Attributes[0].Value.GetIntValue()).To(Equal(int64(3)))
+		})
+
+		It("should report a collector's errors", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+			}))
+			defer server.Close()
+
+			recorder := tracetest.NewSpanRecorder()
+			_, span := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test").Start(context.Background(), "span")
+			span.End()
+			exporter, _, err := newExporter(&Config{Exporter: ExporterOTLP, Endpoint: server.URL})
+			Expect(err).To(BeNil())
+			Expect(exporter.ExportSpans(context.Background(), recorder.Ended())).ToNot(Succeed())
+		})
+	})
+
+	Describe("Setup", func() {
+		It("should reject incomplete configs", func() {
+			_, err := Setup(&Config{Exporter: "jaeger"})
+			Expect(err).ToNot(BeNil())
+			_, err = Setup(&Config{Exporter: ExporterOTLP})
+			Expect(err).ToNot(BeNil())
+			_, err = Setup(&Config{Exporter: ExporterOTLP, Endpoint: "localhost:4318"})
+			Expect(err).ToNot(BeNil())
+			_, err = Setup(&Config{Exporter: ExporterFile})
+			Expect(err).ToNot(BeNil())
+		})
+
+		It("should write spans to a file and propagate trace context", func() {
+			dir, err := ioutil.TempDir("", "tracing")
+			Expect(err).To(BeNil())
+			defer os.RemoveAll(dir)
+			path := filepath.Join(dir, "spans.json")
+
+			shutdown, err := Setup(&Config{Exporter: ExporterFile, Path: path, ServiceName: "test"})
+			Expect(err).To(BeNil())
+			ctx, span := otel.Tracer("test").Start(context.Background(), "offline")
+			header := http.Header{}
+			otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+			span.End()
+			Expect(shutdown(context.Background())).To(Succeed())
+
+			Expect(header.Get("traceparent")).To(ContainSubstring(span.SpanContext().TraceID().String()))
+			contents, err := ioutil.ReadFile(path)
+			Expect(err).To(BeNil())
+			Expect(string(contents)).To(ContainSubstring(`"Name":"offline"`))
+		})
+	})
+}
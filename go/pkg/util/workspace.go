@@ -0,0 +1,386 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+package util
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// workspaceOwnerFile is written into every workspace, and holds the pid and start time of the
+// process that allocated it.
+const workspaceOwnerFile = ".workspace-owner"
+
+// WorkspaceManager hands out per-job scratch directories under a single root directory.
+//
+// A workspace is either active (a job is using it), or kept (its job finished, but its contents
+// may be reused -- for example, a downloaded image tarball).  Released workspaces are deleted
+// immediately.  When allocating would exceed the disk quota, kept workspaces are evicted in
+// least-recently-used order; active workspaces are never evicted.
+//
+// Several processes may share a root.  When the manager is created, it only deletes workspaces
+// whose owning process has exited; anything it didn't create, and workspaces belonging to live
+// processes, are left alone.  Since pids are reused -- after a container restart, we're pid 1
+// again -- an owner is identified by its pid together with its start time.
+type WorkspaceManager struct {
+	root       string
+	quotaBytes int64
+	workspaces map[string]*Workspace
+	mux        *sync.Mutex
+}
+
+// Workspace is a directory owned by one job.  Key is the name it was allocated with, which is
+// how a kept workspace is found again by Reuse.
+type Workspace struct {
+	Name     string
+	Key      string
+	Path     string
+	manager  *WorkspaceManager
+	active   bool
+	lastUsed time.Time
+}
+
+// NewWorkspaceManager creates root if necessary and removes workspaces left over by processes
+// that have exited.  A quotaBytes of 0 means unlimited.
+func NewWorkspaceManager(root string, quotaBytes int64) (*WorkspaceManager, error) {
+	if err := CreateIfNotExists(root); err != nil {
+		return nil, err
+	}
+	if err := removeLeftoverWorkspaces(root); err != nil {
+		return nil, err
+	}
+	manager := &WorkspaceManager{
+		root:       root,
+		quotaBytes: quotaBytes,
+		workspaces: map[string]*Workspace{},
+		mux:        &sync.Mutex{},
+	}
+	manager.recordUsage()
+	return manager, nil
+}
+
+// removeLeftoverWorkspaces deletes the workspaces under root whose owner is no longer running.
+func removeLeftoverWorkspaces(root string) error {
+	entries, err := ioutil.ReadDir(root)
+	if err != nil {
+		return errors.Wrapf(err, "unable to read workspace root %s", root)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(root, entry.Name())
+		owner, ok := readWorkspaceOwner(path)
+		if !ok || owner.alive() {
+			continue
+		}
+		log.Infof("removing workspace %s left over by process %d", path, owner.pid)
+		if err = os.RemoveAll(path); err != nil {
+			return errors.Wrapf(err, "unable to remove leftover workspace %s", path)
+		}
+	}
+	return nil
+}
+
+// workspaceOwner identifies the process that allocated a workspace.  startTime is empty if it
+// couldn't be determined, in which case only the pid is checked.
+type workspaceOwner struct {
+	pid       int
+	startTime string
+}
+
+// currentWorkspaceOwner identifies this process.
+func currentWorkspaceOwner() workspaceOwner {
+	pid := os.Getpid()
+	return workspaceOwner{pid: pid, startTime: processStartTime(pid)}
+}
+
+func (o workspaceOwner) String() string {
+	if o.startTime == "" {
+		return fmt.Sprintf("%d\n", o.pid)
+	}
+	return fmt.Sprintf("%d %s\n", o.pid, o.startTime)
+}
+
+// alive checks whether the owner is still running.  A live process with the owner's pid, but a
+// different start time, is a different process that happened to get the same pid.
+func (o workspaceOwner) alive() bool {
+	if o.startTime != "" {
+		if startTime := processStartTime(o.pid); startTime != "" {
+			return startTime == o.startTime
+		}
+	}
+	return o.pid == os.Getpid() || processAlive(o.pid)
+}
+
+// readWorkspaceOwner reads a workspace's owner file.  It returns false if path isn't a workspace
+// created by a WorkspaceManager.
+func readWorkspaceOwner(path string) (workspaceOwner, bool) {
+	contents, err := ioutil.ReadFile(filepath.Join(path, workspaceOwnerFile))
+	if err != nil {
+		return workspaceOwner{}, false
+	}
+	fields := strings.Fields(string(contents))
+	if len(fields) == 0 || len(fields) > 2 {
+		return workspaceOwner{}, false
+	}
+	pid, err := strconv.Atoi(fields[0])
+	if err != nil || pid <= 0 {
+		return workspaceOwner{}, false
+	}
+	owner := workspaceOwner{pid: pid}
+	if len(fields) == 2 {
+		owner.startTime = fields[1]
+	}
+	return owner, true
+}
+
+// processStartTime returns the start time of pid, in clock ticks since boot, from /proc.  It
+// returns an empty string if pid isn't running or /proc isn't available.
+func processStartTime(pid int) string {
+	contents, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return ""
+	}
+	// the command name is in parentheses and may contain spaces, so skip past it; starttime is
+	// the 22nd field, and the fields after the command name start at the 3rd
+	stat := string(contents)
+	fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+	if len(fields) < 20 {
+		return ""
+	}
+	return fields[19]
+}
+
+// processAlive checks whether pid is running, by sending it signal 0.
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || err == syscall.EPERM
+}
+
+// Allocate creates a new, empty, active workspace.  key doesn't need to be unique; it's used to
+// find the workspace again with Reuse once it's kept, and as a prefix to make the directory
+// recognizable.
+func (m *WorkspaceManager) Allocate(key string) (*Workspace, error) {
+	if err := m.enforceQuota(); err != nil {
+		return nil, err
+	}
+	path, err := ioutil.TempDir(m.root, workspacePrefix(key))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to create workspace %s in %s", key, m.root)
+	}
+	if err = ioutil.WriteFile(filepath.Join(path, workspaceOwnerFile), []byte(currentWorkspaceOwner().String()), 0644); err != nil {
+		os.RemoveAll(path)
+		return nil, errors.Wrapf(err, "unable to mark workspace %s", path)
+	}
+	ws := &Workspace{Name: filepath.Base(path), Key: key, Path: path, manager: m, active: true, lastUsed: time.Now()}
+	m.mux.Lock()
+	m.workspaces[ws.Name] = ws
+	m.mux.Unlock()
+	recordWorkspaceEvent("allocate", nil)
+	m.recordUsage()
+	log.Debugf("allocated workspace %s", path)
+	return ws, nil
+}
+
+// workspacePrefix turns key into something usable as a directory name.
+func workspacePrefix(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == os.PathSeparator || r == ':' || r == '@' || r == '*' {
+			return '_'
+		}
+		return r
+	}, key) + "-"
+}
+
+// Reuse looks for the most recently used kept workspace allocated with key, and if found, marks
+// it active again.
+func (m *WorkspaceManager) Reuse(key string) (*Workspace, bool) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	var ws *Workspace
+	for _, candidate := range m.workspaces {
+		if candidate.Key == key && !candidate.active && (ws == nil || candidate.lastUsed.After(ws.lastUsed)) {
+			ws = candidate
+		}
+	}
+	if ws == nil {
+		return nil, false
+	}
+	ws.active = true
+	ws.lastUsed = time.Now()
+	recordWorkspaceEvent("reuse", nil)
+	return ws, true
+}
+
+// Usage returns the number of bytes currently used under the root.
+func (m *WorkspaceManager) Usage() (int64, error) {
+	return DiskUsage(m.root)
+}
+
+// Close deletes all of the manager's workspaces, active or kept, and then the root, unless
+// another process is still using it.
+func (m *WorkspaceManager) Close() error {
+	m.mux.Lock()
+	workspaces := make([]*Workspace, 0, len(m.workspaces))
+	for _, ws := range m.workspaces {
+		workspaces = append(workspaces, ws)
+	}
+	m.mux.Unlock()
+	for _, ws := range workspaces {
+		if err := ws.Release(); err != nil {
+			return err
+		}
+	}
+	// os.Remove refuses to delete a directory that isn't empty, so a root shared with a running
+	// process stays put
+	if err := os.Remove(m.root); err != nil && !os.IsNotExist(err) {
+		log.Debugf("leaving workspace root %s in place: %s", m.root, err.Error())
+	}
+	return nil
+}
+
+// Release deletes the workspace: its job is done and nothing in it is needed any more.
+func (ws *Workspace) Release() error {
+	m := ws.manager
+	m.mux.Lock()
+	delete(m.workspaces, ws.Name)
+	m.mux.Unlock()
+	err := os.RemoveAll(ws.Path)
+	recordWorkspaceEvent("release", err)
+	m.recordUsage()
+	log.Debugf("released workspace %s", ws.Path)
+	return errors.Wrapf(err, "unable to remove workspace %s", ws.Path)
+}
+
+// Keep marks the workspace inactive but leaves it on disk, to be picked up with Reuse, until it's
+// evicted to make room.
+func (ws *Workspace) Keep() {
+	m := ws.manager
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	ws.active = false
+	ws.lastUsed = time.Now()
+	recordWorkspaceEvent("keep", nil)
+}
+
+// enforceQuota evicts kept workspaces until usage is under the quota.  The lock is only held
+// while choosing a workspace to evict, not while walking or deleting it.
+func (m *WorkspaceManager) enforceQuota() error {
+	if m.quotaBytes <= 0 {
+		return nil
+	}
+	usage, err := m.Usage()
+	if err != nil {
+		return err
+	}
+	for usage >= m.quotaBytes {
+		ws := m.takeLeastRecentlyUsed()
+		if ws == nil {
+			err = errors.Errorf("workspace root %s is using %d bytes of its %d byte quota, and nothing can be evicted", m.root, usage, m.quotaBytes)
+			recordWorkspaceEvent("quota_exceeded", err)
+			return err
+		}
+		size, err := DiskUsage(ws.Path)
+		if err != nil {
+			return err
+		}
+		log.Infof("evicting workspace %s (%d bytes) to stay under quota of %d bytes", ws.Path, size, m.quotaBytes)
+		err = os.RemoveAll(ws.Path)
+		recordWorkspaceEvent("evict", err)
+		if err != nil {
+			return errors.Wrapf(err, "unable to evict workspace %s", ws.Path)
+		}
+		usage -= size
+	}
+	return nil
+}
+
+// takeLeastRecentlyUsed removes the least recently used kept workspace from the manager, so that
+// it can't be reused while it's being evicted.  It returns nil if there are no kept workspaces.
+func (m *WorkspaceManager) takeLeastRecentlyUsed() *Workspace {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	kept := []*Workspace{}
+	for _, ws := range m.workspaces {
+		if !ws.active {
+			kept = append(kept, ws)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	sort.Slice(kept, func(i, j int) bool { return kept[i].lastUsed.Before(kept[j].lastUsed) })
+	delete(m.workspaces, kept[0].Name)
+	return kept[0]
+}
+
+// recordUsage counts workspaces with the lock held, then walks the root without it.
+func (m *WorkspaceManager) recordUsage() {
+	active, kept := 0, 0
+	m.mux.Lock()
+	for _, ws := range m.workspaces {
+		if ws.active {
+			active++
+		} else {
+			kept++
+		}
+	}
+	m.mux.Unlock()
+	usage, err := m.Usage()
+	if err != nil {
+		log.Errorf("unable to compute disk usage of %s: %s", m.root, err.Error())
+	}
+	recordWorkspaceUsage(m.root, usage, m.quotaBytes, active, kept)
+}
+
+// DiskUsage adds up the sizes of all the files under path.
+func DiskUsage(path string) (int64, error) {
+	var total int64
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		// workspaces may be released while they're being walked
+		if os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total, errors.Wrapf(err, "unable to walk %s", path)
+}
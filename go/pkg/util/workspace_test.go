@@ -0,0 +1,195 @@
+/*
+Copyright (C) 2020 Synopsys, Inc.
+
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements. See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership. The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License. You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied. See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func RunWorkspaceTests() {
+	Describe("WorkspaceManager", func() {
+		var root string
+
+		BeforeEach(func() {
+			dir, err := ioutil.TempDir("", "workspace-test")
+			Expect(err).To(Succeed())
+			root = filepath.Join(dir, "root")
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(filepath.Dir(root))
+		})
+
+		It("should only clean up workspaces whose owner has exited", func() {
+			exited := exec.Command("true")
+			Expect(exited.Run()).To(Succeed())
+			mark := func(name string, owner string) string {
+				path := filepath.Join(root, name)
+				Expect(os.MkdirAll(path, 0755)).To(Succeed())
+				Expect(ioutil.WriteFile(filepath.Join(path, workspaceOwnerFile), []byte(owner), 0644)).To(Succeed())
+				return path
+			}
+			dead := mark("dead-job", fmt.Sprintf("%d\n", exited.Process.Pid))
+			live := mark("live-job", fmt.Sprintf("%d\n", os.Getppid()))
+			current := mark("current-job", currentWorkspaceOwner().String())
+			unmarked := filepath.Join(root, "not-a-workspace")
+			Expect(os.MkdirAll(unmarked, 0755)).To(Succeed())
+
+			_, err := NewWorkspaceManager(root, 0)
+			Expect(err).To(Succeed())
+			for path, expected := range map[string]bool{dead: false, live: true, current: true, unmarked: true} {
+				exists, err := FileExists(path)
+				Expect(err).To(Succeed())
+				Expect(exists).To(Equal(expected), path)
+			}
+		})
+
+		It("should clean up workspaces left by an earlier process with the same pid", func() {
+			if processStartTime(os.Getpid()) == "" {
+				Skip("process start times aren't available")
+			}
+			path := filepath.Join(root, "restarted-job")
+			Expect(os.MkdirAll(path, 0755)).To(Succeed())
+			previous := workspaceOwner{pid: os.Getpid(), startTime: "1"}
+			Expect(ioutil.WriteFile(filepath.Join(path, workspaceOwnerFile), []byte(previous.String()), 0644)).To(Succeed())
+
+			_, err := NewWorkspaceManager(root, 0)
+			Expect(err).To(Succeed())
+			exists, err := FileExists(path)
+			Expect(err).To(Succeed())
+			Expect(exists).To(BeFalse())
+		})
+
+		It("should not remove another manager's workspaces", func() {
+			first, err := NewWorkspaceManager(root, 0)
+			Expect(err).To(Succeed())
+			ws, err := first.Allocate("job")
+			Expect(err).To(Succeed())
+			_, err = NewWorkspaceManager(root, 0)
+			Expect(err).To(Succeed())
+			exists, err := FileExists(ws.Path)
+			Expect(err).To(Succeed())
+			Expect(exists).To(BeTrue())
+		})
+
+		It("should remove released workspaces", func() {
+			manager, err := NewWorkspaceManager(root, 0)
+			Expect(err).To(Succeed())
+			ws, err := manager.Allocate("job")
+			Expect(err).To(Succeed())
+			Expect(ws.Release()).To(Succeed())
+			exists, err := FileExists(ws.Path)
+			Expect(err).To(Succeed())
+			Expect(exists).To(BeFalse())
+		})
+
+		It("should evict the least recently used kept workspace when over quota", func() {
+			manager, err := NewWorkspaceManager(root, 150)
+			Expect(err).To(Succeed())
+
+			older, err := manager.Allocate("older")
+			Expect(err).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(older.Path, "data"), make([]byte, 100), 0644)).To(Succeed())
+			older.Keep()
+
+			newer, err := manager.Allocate("newer")
+			Expect(err).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(newer.Path, "data"), make([]byte, 100), 0644)).To(Succeed())
+			newer.Keep()
+
+			_, err = manager.Allocate("third")
+			Expect(err).To(Succeed())
+
+			_, ok := manager.Reuse("older")
+			Expect(ok).To(BeFalse())
+			reused, ok := manager.Reuse("newer")
+			Expect(ok).To(BeTrue())
+			Expect(reused.Path).To(Equal(newer.Path))
+			_, ok = manager.Reuse("newer")
+			Expect(ok).To(BeFalse())
+		})
+
+		It("should allocate workspaces for keys that aren't valid directory names", func() {
+			manager, err := NewWorkspaceManager(root, 0)
+			Expect(err).To(Succeed())
+			ws, err := manager.Allocate("image-docker.io/library/alpine:3.12")
+			Expect(err).To(Succeed())
+			Expect(filepath.Dir(ws.Path)).To(Equal(root))
+			ws.Keep()
+			reused, ok := manager.Reuse("image-docker.io/library/alpine:3.12")
+			Expect(ok).To(BeTrue())
+			Expect(reused.Path).To(Equal(ws.Path))
+		})
+
+		It("should remove its workspaces and the root when closed", func() {
+			manager, err := NewWorkspaceManager(root, 0)
+			Expect(err).To(Succeed())
+			active, err := manager.Allocate("active")
+			Expect(err).To(Succeed())
+			kept, err := manager.Allocate("kept")
+			Expect(err).To(Succeed())
+			kept.Keep()
+			Expect(manager.Close()).To(Succeed())
+			for _, path := range []string{active.Path, kept.Path, root} {
+				exists, err := FileExists(path)
+				Expect(err).To(Succeed())
+				Expect(exists).To(BeFalse(), path)
+			}
+		})
+
+		It("should leave a shared root in place when closed", func() {
+			first, err := NewWorkspaceManager(root, 0)
+			Expect(err).To(Succeed())
+			second, err := NewWorkspaceManager(root, 0)
+			Expect(err).To(Succeed())
+			ws, err := second.Allocate("job")
+			Expect(err).To(Succeed())
+			Expect(first.Close()).To(Succeed())
+			exists, err := FileExists(ws.Path)
+			Expect(err).To(Succeed())
+			Expect(exists).To(BeTrue())
+		})
+
+		It("should ignore files removed while computing disk usage", func() {
+			usage, err := DiskUsage(filepath.Join(root, "missing"))
+			Expect(err).To(Succeed())
+			Expect(usage).To(Equal(int64(0)))
+		})
+
+		It("should fail when active workspaces exceed the quota", func() {
+			manager, err := NewWorkspaceManager(root, 50)
+			Expect(err).To(Succeed())
+			ws, err := manager.Allocate("big")
+			Expect(err).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(ws.Path, "data"), make([]byte, 100), 0644)).To(Succeed())
+			_, err = manager.Allocate("another")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+}